@@ -0,0 +1,76 @@
+package main
+
+import (
+	"flag"
+	"fmt"
+	"io"
+	"log"
+	"net"
+	"os"
+	"time"
+
+	"github.com/hypebeast/go-osc/osc"
+)
+
+// The scheduler demo sends an arpeggio in a single bundle, in which every
+// note has its own timetag, to a server that executes the notes at their
+// time. The notes are printed with the time they were executed at, relative
+// to the time of the bundle.
+func main() {
+	step := flag.Duration("step", 250*time.Millisecond, "time between the notes")
+	flag.Parse()
+
+	conn, _, err := osc.Listen("127.0.0.1:0")
+	if err != nil {
+		log.Fatal(err)
+	}
+	if _, err := run(conn, []int32{60, 64, 67, 72}, *step, os.Stdout); err != nil {
+		log.Fatal(err)
+	}
+}
+
+// run sends a bundle with the notes, one every step, to a server that serves
+// conn and prints the notes to out as the server executes them. It returns
+// the times the notes were executed at relative to the time of the bundle
+// after the last note was executed, and closes conn.
+func run(conn net.PacketConn, notes []int32, step time.Duration, out io.Writer) ([]time.Duration, error) {
+	defer conn.Close()
+	type note struct {
+		msg *osc.Message
+		at  time.Time
+	}
+	played := make(chan note, len(notes))
+	d := osc.NewStandardDispatcher()
+	d.AddMsgHandler("/note", func(msg *osc.Message) {
+		played <- note{msg, time.Now()}
+	})
+	go (&osc.Server{Dispatcher: d}).Serve(conn)
+
+	// The first note is scheduled a step ahead, so that the bundle arrives
+	// in time
+	start := time.Now().Add(step)
+	bundle := osc.NewBundle(start)
+	for i, key := range notes {
+		if err := bundle.AppendAfter(osc.NewMessage("/note", key), time.Duration(i)*step); err != nil {
+			return nil, err
+		}
+	}
+	client := osc.NewClient("127.0.0.1", conn.LocalAddr().(*net.UDPAddr).Port)
+	if _, err := client.Send(bundle); err != nil {
+		return nil, err
+	}
+
+	var offsets []time.Duration
+	timeout := time.After(time.Duration(len(notes)+1)*step + time.Second)
+	for range notes {
+		select {
+		case n := <-played:
+			offset := n.at.Sub(start)
+			fmt.Fprintf(out, "%6v %v\n", offset.Round(time.Millisecond), n.msg)
+			offsets = append(offsets, offset)
+		case <-timeout:
+			return offsets, fmt.Errorf("only %d of %d notes were played", len(offsets), len(notes))
+		}
+	}
+	return offsets, nil
+}
@@ -0,0 +1,41 @@
+package main
+
+import (
+	"bytes"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/hypebeast/go-osc/osc"
+)
+
+func TestRun(t *testing.T) {
+	conn, _, err := osc.Listen("127.0.0.1:0")
+	if err != nil {
+		t.Fatal(err)
+	}
+	step := 50 * time.Millisecond
+	var out bytes.Buffer
+	offsets, err := run(conn, []int32{60, 64, 67}, step, &out)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	// Timers may fire a little early on some platforms
+	const tolerance = 5 * time.Millisecond
+	for i, offset := range offsets {
+		if want := time.Duration(i) * step; offset < want-tolerance {
+			t.Errorf("note %d played after %v, want >= %v", i, offset, want)
+		}
+	}
+	lines := strings.Split(strings.TrimSuffix(out.String(), "\n"), "\n")
+	want := []string{"/note ,i 60", "/note ,i 64", "/note ,i 67"}
+	if len(lines) != len(want) {
+		t.Fatalf("run() printed %q, want %d lines", out.String(), len(want))
+	}
+	for i, line := range lines {
+		if !strings.HasSuffix(line, want[i]) {
+			t.Errorf("line %d = %q, want suffix = %q", i, line, want[i])
+		}
+	}
+}
@@ -0,0 +1,60 @@
+package main
+
+import (
+	"fmt"
+	"log"
+
+	"github.com/hypebeast/go-osc/osc"
+)
+
+// The bridge receives OSC 'm' MIDI messages on "/midi" and forwards them as
+// plain OSC messages, e.g. "/ch/1/note 60 100" and "/ch/1/cc/7 127", which
+// can be used by applications that don't support the 'm' type. Messages sent
+// to "/note" with the channel, key and velocity as int32 arguments are
+// forwarded as 'm' message in the other direction.
+func main() {
+	addr := "127.0.0.1:8765"
+	target := osc.NewClient("127.0.0.1", 9000)
+
+	d := osc.NewStandardDispatcher()
+	d.AddMsgHandler("/midi", func(msg *osc.Message) {
+		events, err := msg.MIDIEvents()
+		if err != nil {
+			log.Println(err)
+			return
+		}
+		for _, e := range events {
+			var out *osc.Message
+			switch e := e.(type) {
+			case osc.NoteOn:
+				out = osc.NewMessage(fmt.Sprintf("/ch/%d/note", e.Channel+1), int32(e.Key), int32(e.Velocity))
+			case osc.NoteOff:
+				out = osc.NewMessage(fmt.Sprintf("/ch/%d/note", e.Channel+1), int32(e.Key), int32(0))
+			case osc.ControlChange:
+				out = osc.NewMessage(fmt.Sprintf("/ch/%d/cc/%d", e.Channel+1, e.Controller), int32(e.Value))
+			}
+			if _, err := target.Send(out); err != nil {
+				log.Println(err)
+			}
+		}
+	})
+	d.AddMsgHandler("/note", func(msg *osc.Message) {
+		if len(msg.Arguments) != 3 {
+			return
+		}
+		channel, _ := msg.Arguments[0].(int32)
+		key, _ := msg.Arguments[1].(int32)
+		velocity, _ := msg.Arguments[2].(int32)
+
+		e := osc.NoteOn{Channel: uint8(channel - 1), Key: uint8(key), Velocity: uint8(velocity)}
+		if _, err := target.Send(osc.NewMIDIMessage("/midi", 0, e)); err != nil {
+			log.Println(err)
+		}
+	})
+
+	server := &osc.Server{
+		Addr:       addr,
+		Dispatcher: d,
+	}
+	log.Fatal(server.ListenAndServe())
+}
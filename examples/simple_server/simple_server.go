@@ -0,0 +1,60 @@
+package main
+
+import (
+	"context"
+	"flag"
+	"fmt"
+	"io"
+	"log"
+	"net"
+	"os"
+	"os/signal"
+	"sync"
+
+	"github.com/hypebeast/go-osc/osc"
+)
+
+// The simple server prints every received message until it is interrupted.
+func main() {
+	addr := flag.String("addr", "127.0.0.1:8765", "address to listen on")
+	flag.Parse()
+
+	conn, _, err := osc.Listen(*addr)
+	if err != nil {
+		log.Fatal(err)
+	}
+	ctx, cancel := context.WithCancel(context.Background())
+	go func() {
+		sig := make(chan os.Signal, 1)
+		signal.Notify(sig, os.Interrupt)
+		<-sig
+		cancel()
+	}()
+
+	fmt.Println("Listening on", conn.LocalAddr())
+	if err := run(ctx, conn, os.Stdout); err != nil {
+		log.Fatal(err)
+	}
+}
+
+// run prints the messages received on conn to out until ctx is done.
+func run(ctx context.Context, conn net.PacketConn, out io.Writer) error {
+	var mu sync.Mutex
+	d := osc.NewStandardDispatcher()
+	d.SetDefaultHandler(osc.HandlerFunc(func(msg *osc.Message) {
+		mu.Lock()
+		defer mu.Unlock()
+		fmt.Fprintln(out, msg)
+	}))
+
+	server := &osc.Server{Dispatcher: d}
+	go func() {
+		<-ctx.Done()
+		conn.Close()
+	}()
+	err := server.Serve(conn)
+	if ctx.Err() != nil {
+		return nil
+	}
+	return err
+}
@@ -0,0 +1,49 @@
+package main
+
+import (
+	"context"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/hypebeast/go-osc/osc"
+)
+
+// lineWriter sends every written line to a channel.
+type lineWriter chan string
+
+func (w lineWriter) Write(p []byte) (int, error) {
+	w <- strings.TrimSuffix(string(p), "\n")
+	return len(p), nil
+}
+
+func TestRun(t *testing.T) {
+	conn, addr, err := osc.Listen("127.0.0.1:0")
+	if err != nil {
+		t.Fatal(err)
+	}
+	out := make(lineWriter, 1)
+	ctx, cancel := context.WithCancel(context.Background())
+	done := make(chan error, 1)
+	go func() {
+		done <- run(ctx, conn, out)
+	}()
+
+	msg := osc.NewMessage("/filter/cutoff", float32(0.5), "lowpass")
+	if _, err := osc.NewClient("127.0.0.1", addr.Port).Send(msg); err != nil {
+		t.Fatal(err)
+	}
+	select {
+	case line := <-out:
+		if want := msg.String(); line != want {
+			t.Errorf("run() printed %q, want = %q", line, want)
+		}
+	case <-time.After(time.Second):
+		t.Error("run() printed nothing")
+	}
+
+	cancel()
+	if err := <-done; err != nil {
+		t.Errorf("run() = %v, want = nil", err)
+	}
+}
@@ -0,0 +1,74 @@
+package main
+
+import (
+	"context"
+	"flag"
+	"fmt"
+	"log"
+	"net"
+	"os"
+	"os/signal"
+
+	"github.com/hypebeast/go-osc/osc"
+)
+
+// The echo sends every message that is received from TouchOSC back to the
+// device, e.g. to keep the controls of several layouts in sync or to update
+// the controls of a layout from a second device. TouchOSC listens on the
+// incoming port configured in its OSC settings, which is passed as -target.
+func main() {
+	addr := flag.String("addr", "0.0.0.0:8000", "address to listen on")
+	target := flag.String("target", "192.168.1.100:9000", "address of the TouchOSC device")
+	flag.Parse()
+
+	host, port, err := splitHostPort(*target)
+	if err != nil {
+		log.Fatal(err)
+	}
+	conn, _, err := osc.Listen(*addr)
+	if err != nil {
+		log.Fatal(err)
+	}
+	ctx, cancel := context.WithCancel(context.Background())
+	go func() {
+		sig := make(chan os.Signal, 1)
+		signal.Notify(sig, os.Interrupt)
+		<-sig
+		cancel()
+	}()
+
+	fmt.Printf("Echoing messages from %s to %s\n", conn.LocalAddr(), *target)
+	if err := run(ctx, conn, osc.NewClient(host, port)); err != nil {
+		log.Fatal(err)
+	}
+}
+
+// run sends the messages received on conn to target until ctx is done.
+func run(ctx context.Context, conn net.PacketConn, target osc.Sender) error {
+	d := osc.NewStandardDispatcher()
+	d.SetDefaultHandler(osc.HandlerFunc(func(msg *osc.Message) {
+		if _, err := target.Send(msg); err != nil {
+			log.Println(err)
+		}
+	}))
+
+	server := &osc.Server{Dispatcher: d}
+	go func() {
+		<-ctx.Done()
+		conn.Close()
+	}()
+	err := server.Serve(conn)
+	if ctx.Err() != nil {
+		return nil
+	}
+	return err
+}
+
+// splitHostPort splits an address of the form "host:port".
+func splitHostPort(addr string) (string, int, error) {
+	raddr, err := net.ResolveUDPAddr("udp", addr)
+	if err != nil {
+		return "", 0, err
+	}
+	return raddr.IP.String(), raddr.Port, nil
+}
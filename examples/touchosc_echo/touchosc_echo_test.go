@@ -0,0 +1,63 @@
+package main
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/hypebeast/go-osc/osc"
+	"github.com/hypebeast/go-osc/osc/osctest"
+)
+
+func TestRun(t *testing.T) {
+	// The device that receives the echoed messages
+	rec := osctest.NewRecorder()
+	d := osc.NewStandardDispatcher()
+	d.SetDefaultHandler(rec)
+	deviceConn, deviceAddr, err := osc.Listen("127.0.0.1:0")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer deviceConn.Close()
+	go (&osc.Server{Dispatcher: d}).Serve(deviceConn)
+
+	conn, addr, err := osc.Listen("127.0.0.1:0")
+	if err != nil {
+		t.Fatal(err)
+	}
+	ctx, cancel := context.WithCancel(context.Background())
+	done := make(chan error, 1)
+	go func() {
+		done <- run(ctx, conn, osc.NewClient("127.0.0.1", deviceAddr.Port))
+	}()
+
+	want := []*osc.Message{
+		osc.NewMessage("/1/fader1", float32(0.75)),
+		osc.NewMessage("/1/toggle1", float32(1)),
+	}
+	client := osc.NewClient("127.0.0.1", addr.Port)
+	for _, msg := range want {
+		if _, err := client.Send(msg); err != nil {
+			t.Fatal(err)
+		}
+	}
+	rec.Wait(len(want), time.Second)
+	for _, msg := range want {
+		rec.AssertReceived(t, msg)
+	}
+
+	cancel()
+	if err := <-done; err != nil {
+		t.Errorf("run() = %v, want = nil", err)
+	}
+}
+
+func TestSplitHostPort(t *testing.T) {
+	host, port, err := splitHostPort("127.0.0.1:9000")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if host != "127.0.0.1" || port != 9000 {
+		t.Errorf("splitHostPort() = %s, %d, want = 127.0.0.1, 9000", host, port)
+	}
+}
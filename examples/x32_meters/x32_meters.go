@@ -0,0 +1,112 @@
+package main
+
+import (
+	"context"
+	"encoding/binary"
+	"errors"
+	"flag"
+	"fmt"
+	"log"
+	"math"
+	"os"
+	"os/signal"
+	"time"
+
+	"github.com/hypebeast/go-osc/osc"
+)
+
+// The meter bridge subscribes to the input meters of a Behringer X32 console
+// and forwards the levels as float arguments of "/levels" messages, which
+// most OSC applications can display, unlike the blobs of the console. The
+// console only sends updates to clients that renew their subscriptions at
+// least every 10 seconds.
+func main() {
+	addr := flag.String("addr", "0.0.0.0:0", "address to send and receive on")
+	console := flag.String("console", "192.168.1.10:10023", "address of the console")
+	target := flag.String("target", "127.0.0.1:9000", "address the levels are sent to")
+	flag.Parse()
+
+	peer, err := osc.NewPeer(*addr, nil)
+	if err != nil {
+		log.Fatal(err)
+	}
+	out, err := peer.Target(*target)
+	if err != nil {
+		log.Fatal(err)
+	}
+	ctx, cancel := context.WithCancel(context.Background())
+	go func() {
+		sig := make(chan os.Signal, 1)
+		signal.Notify(sig, os.Interrupt)
+		<-sig
+		cancel()
+	}()
+
+	fmt.Printf("Bridging the meters of %s to %s\n", *console, *target)
+	if err := run(ctx, peer, *console, out, 9*time.Second); err != nil {
+		log.Fatal(err)
+	}
+}
+
+// run subscribes to the meters of the console and sends the levels to target
+// until ctx is done. The subscriptions are renewed every interval. The peer
+// is closed when run returns.
+func run(ctx context.Context, peer *osc.Peer, console string, target osc.Sender, interval time.Duration) error {
+	d, ok := peer.Server.Dispatcher.(*osc.StandardDispatcher)
+	if !ok {
+		return errors.New("peer must use a StandardDispatcher")
+	}
+	d.AddMsgHandler("/meters/1", func(msg *osc.Message) {
+		levels, err := meterLevels(msg)
+		if err != nil {
+			log.Println(err)
+			return
+		}
+		if _, err := target.Send(osc.NewMessage("/levels", levels...)); err != nil {
+			log.Println(err)
+		}
+	})
+	x32, err := peer.Target(console)
+	if err != nil {
+		peer.Close()
+		return err
+	}
+
+	for _, msg := range []*osc.Message{
+		osc.NewMessage("/xremote"),
+		osc.NewMessage("/meters", "/meters/1"),
+	} {
+		h := osc.NewHeartbeat(x32, msg, interval)
+		h.OnError = func(err error) { log.Println(err) }
+		h.Start()
+		defer h.Stop()
+	}
+
+	go func() {
+		<-ctx.Done()
+		peer.Close()
+	}()
+	return peer.Serve()
+}
+
+// meterLevels returns the levels of a meter message as float32 arguments. The
+// console sends the levels as blob that starts with their number, both the
+// number and the levels are little-endian.
+func meterLevels(msg *osc.Message) ([]interface{}, error) {
+	if len(msg.Arguments) != 1 {
+		return nil, fmt.Errorf("%s: want 1 argument, got %d", msg.Address, len(msg.Arguments))
+	}
+	blob, ok := msg.Arguments[0].([]byte)
+	if !ok || len(blob) < 4 {
+		return nil, fmt.Errorf("%s: invalid meter blob", msg.Address)
+	}
+	n := int(binary.LittleEndian.Uint32(blob))
+	if n < 0 || len(blob) < 4+4*n {
+		return nil, fmt.Errorf("%s: meter blob too short for %d levels", msg.Address, n)
+	}
+	levels := make([]interface{}, n)
+	for i := range levels {
+		levels[i] = math.Float32frombits(binary.LittleEndian.Uint32(blob[4+4*i:]))
+	}
+	return levels, nil
+}
@@ -0,0 +1,94 @@
+package main
+
+import (
+	"context"
+	"encoding/binary"
+	"math"
+	"testing"
+	"time"
+
+	"github.com/hypebeast/go-osc/osc"
+	"github.com/hypebeast/go-osc/osc/osctest"
+)
+
+// meterBlob returns the blob that the console sends for the given levels.
+func meterBlob(levels ...float32) []byte {
+	blob := make([]byte, 4+4*len(levels))
+	binary.LittleEndian.PutUint32(blob, uint32(len(levels)))
+	for i, l := range levels {
+		binary.LittleEndian.PutUint32(blob[4+4*i:], math.Float32bits(l))
+	}
+	return blob
+}
+
+func TestRun(t *testing.T) {
+	// The console answers meter subscriptions with a single update, which is
+	// sent to the port the subscription came from.
+	console, consoleAddr, err := osc.Listen("127.0.0.1:0")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer console.Close()
+	go func() {
+		buf := make([]byte, 1024)
+		for {
+			n, addr, err := console.ReadFrom(buf)
+			if err != nil {
+				return
+			}
+			p, err := osc.ParsePacket(string(buf[:n]))
+			if msg, ok := p.(*osc.Message); err != nil || !ok || msg.Address != "/meters" {
+				continue
+			}
+			data, _ := osc.NewMessage("/meters/1", meterBlob(0.5, 0.25)).MarshalBinary()
+			console.WriteTo(data, addr)
+		}
+	}()
+
+	rec := osctest.NewRecorder()
+	d := osc.NewStandardDispatcher()
+	d.SetDefaultHandler(rec)
+	targetConn, targetAddr, err := osc.Listen("127.0.0.1:0")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer targetConn.Close()
+	go (&osc.Server{Dispatcher: d}).Serve(targetConn)
+
+	peer, err := osc.NewPeer("127.0.0.1:0", nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	ctx, cancel := context.WithCancel(context.Background())
+	done := make(chan error, 1)
+	go func() {
+		done <- run(ctx, peer, consoleAddr.String(), osc.NewClient("127.0.0.1", targetAddr.Port), time.Minute)
+	}()
+
+	rec.Wait(1, time.Second)
+	rec.AssertMessages(t, osc.NewMessage("/levels", float32(0.5), float32(0.25)))
+
+	cancel()
+	if err := <-done; err != nil {
+		t.Errorf("run() = %v, want = nil", err)
+	}
+}
+
+func TestMeterLevels(t *testing.T) {
+	for _, tt := range []struct {
+		args    []interface{}
+		want    int
+		wantErr bool
+	}{
+		{[]interface{}{meterBlob(1, 0.5, 0)}, 3, false},
+		{[]interface{}{meterBlob()}, 0, false},
+		{nil, 0, true},
+		{[]interface{}{"levels"}, 0, true},
+		{[]interface{}{meterBlob(1, 0.5)[:8]}, 0, true},
+	} {
+		levels, err := meterLevels(osc.NewMessage("/meters/1", tt.args...))
+		if (err != nil) != tt.wantErr || len(levels) != tt.want {
+			t.Errorf("meterLevels(%v) = %d levels, %v, want = %d levels, error %t", tt.args, len(levels), err, tt.want, tt.wantErr)
+		}
+	}
+}
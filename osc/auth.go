@@ -0,0 +1,117 @@
+package osc
+
+import (
+	"crypto/hmac"
+	"crypto/sha256"
+	"errors"
+)
+
+// ErrUnauthenticated is returned by Verify for packets without a valid
+// signature.
+var ErrUnauthenticated = errors.New("osc: packet is not authenticated")
+
+// Sign returns a copy of packet in which every message is signed with key:
+// an HMAC-SHA256 of the encoded message is appended as a 32 byte blob
+// argument. Messages in bundles are signed individually, the time tags of
+// bundles aren't covered by the signatures.
+//
+// Signing is a lightweight protection against spoofed packets where DTLS is
+// not available, e.g. on embedded senders. It doesn't encrypt the packets
+// and doesn't prevent replays.
+func Sign(packet Packet, key []byte) (Packet, error) {
+	switch p := packet.(type) {
+	case *Message:
+		return signMessage(p, key)
+
+	case *Bundle:
+		signed := &Bundle{Timetag: p.Timetag}
+		for _, msg := range p.Messages {
+			m, err := signMessage(msg, key)
+			if err != nil {
+				return nil, err
+			}
+			signed.Messages = append(signed.Messages, m)
+		}
+		for _, b := range p.Bundles {
+			s, err := Sign(b, key)
+			if err != nil {
+				return nil, err
+			}
+			signed.Bundles = append(signed.Bundles, s.(*Bundle))
+		}
+		return signed, nil
+	}
+	return nil, ErrInvalidPacket
+}
+
+// SignHook returns a SendHook that signs every packet with key, see Sign.
+func SignHook(key []byte) SendHook {
+	return func(packet Packet) (Packet, error) {
+		return Sign(packet, key)
+	}
+}
+
+// Verify checks the signatures of all messages of packet, see Sign, and
+// removes them from the messages. Returns ErrUnauthenticated if any message
+// has no valid signature, in which case packet should be discarded.
+func Verify(packet Packet, key []byte) error {
+	switch p := packet.(type) {
+	case *Message:
+		return verifyMessage(p, key)
+
+	case *Bundle:
+		for _, msg := range p.Messages {
+			if err := verifyMessage(msg, key); err != nil {
+				return err
+			}
+		}
+		for _, b := range p.Bundles {
+			if err := Verify(b, key); err != nil {
+				return err
+			}
+		}
+		return nil
+	}
+	return ErrUnauthenticated
+}
+
+// messageMAC returns the HMAC-SHA256 of the encoded message.
+func messageMAC(msg *Message, key []byte) ([]byte, error) {
+	data, err := msg.MarshalBinary()
+	if err != nil {
+		return nil, err
+	}
+	mac := hmac.New(sha256.New, key)
+	mac.Write(data)
+	return mac.Sum(nil), nil
+}
+
+func signMessage(msg *Message, key []byte) (*Message, error) {
+	sum, err := messageMAC(msg, key)
+	if err != nil {
+		return nil, err
+	}
+	signed := msg.Clone()
+	signed.Arguments = append(signed.Arguments, sum)
+	return signed, nil
+}
+
+func verifyMessage(msg *Message, key []byte) error {
+	n := len(msg.Arguments)
+	if n == 0 {
+		return ErrUnauthenticated
+	}
+	sig, ok := msg.Arguments[n-1].([]byte)
+	if !ok || len(sig) != sha256.Size {
+		return ErrUnauthenticated
+	}
+
+	args := msg.Arguments
+	msg.Arguments = args[:n-1]
+	sum, err := messageMAC(msg, key)
+	if err != nil || !hmac.Equal(sig, sum) {
+		msg.Arguments = args
+		return ErrUnauthenticated
+	}
+	return nil
+}
@@ -0,0 +1,99 @@
+package osc
+
+import (
+	"net"
+	"testing"
+	"time"
+)
+
+func TestSignVerify(t *testing.T) {
+	key := []byte("secret")
+	bundle := NewBundle(time.Unix(0, 0))
+	bundle.Messages = []*Message{NewMessage("/a", int32(1))}
+	bundle.Bundles = []*Bundle{{Messages: []*Message{NewMessage("/b", "x")}}}
+
+	for _, packet := range []Packet{NewMessage("/fader", float32(0.5)), bundle} {
+		signed, err := Sign(packet, key)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if msg, ok := packet.(*Message); ok && len(msg.Arguments) != 1 {
+			t.Errorf("Sign() modified the original message: %v", msg)
+		}
+		data, err := signed.MarshalBinary()
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		received, err := ParsePacket(string(data))
+		if err != nil {
+			t.Fatal(err)
+		}
+		if err := Verify(received, []byte("wrong")); err != ErrUnauthenticated {
+			t.Errorf("Verify() with wrong key = %v, want = %v", err, ErrUnauthenticated)
+		}
+		if err := Verify(received, key); err != nil {
+			t.Fatalf("Verify() = %v", err)
+		}
+		want, _ := packet.MarshalBinary()
+		got, _ := received.MarshalBinary()
+		if string(got) != string(want) {
+			t.Errorf("verified packet = %v, want = %v", received, packet)
+		}
+	}
+
+	for _, msg := range []*Message{
+		NewMessage("/unsigned"),
+		NewMessage("/short", []byte{1, 2, 3}),
+		NewMessage("/blob", make([]byte, 32)),
+	} {
+		if err := Verify(msg, key); err != ErrUnauthenticated {
+			t.Errorf("Verify(%v) = %v, want = %v", msg, err, ErrUnauthenticated)
+		}
+		if msg.Address != "/unsigned" && len(msg.Arguments) != 1 {
+			t.Errorf("Verify() removed the argument of a rejected message %v", msg)
+		}
+	}
+}
+
+func TestServer_AuthKey(t *testing.T) {
+	conn, err := net.ListenPacket("udp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer conn.Close()
+	addr := conn.LocalAddr().(*net.UDPAddr)
+
+	var rejected error
+	server := &Server{
+		ReadTimeout: 5 * time.Second,
+		AuthKey:     func(net.Addr) []byte { return []byte("secret") },
+		Trace: &ServerTrace{OnDecodeError: func(data []byte, addr net.Addr, err error) {
+			rejected = err
+		}},
+	}
+
+	spoofer := NewClient(addr.IP.String(), addr.Port)
+	if _, err := spoofer.Send(NewMessage("/fader", float32(1))); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := server.ReceivePacket(conn); err != ErrUnauthenticated {
+		t.Errorf("ReceivePacket() of unsigned packet = %v, want = %v", err, ErrUnauthenticated)
+	}
+	if rejected != ErrUnauthenticated {
+		t.Errorf("OnDecodeError() got %v, want = %v", rejected, ErrUnauthenticated)
+	}
+
+	client := NewClient(addr.IP.String(), addr.Port)
+	client.AddSendHook(SignHook([]byte("secret")))
+	if _, err := client.Send(NewMessage("/fader", float32(0.5))); err != nil {
+		t.Fatal(err)
+	}
+	p, err := server.ReceivePacket(conn)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if want := NewMessage("/fader", float32(0.5)); !p.(*Message).Equals(want) {
+		t.Errorf("received %v, want = %v", p, want)
+	}
+}
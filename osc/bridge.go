@@ -0,0 +1,192 @@
+package osc
+
+import (
+	"net"
+	"sync"
+)
+
+// BridgeDirection is the direction in which a Bridge relays a packet.
+type BridgeDirection int
+
+const (
+	// ToTarget is the direction from the source, e.g. a controller, to the
+	// target of the bridge.
+	ToTarget BridgeDirection = iota
+
+	// ToSource is the direction from the target back to the source.
+	ToSource
+)
+
+// String returns "to target" or "to source".
+func (d BridgeDirection) String() string {
+	if d == ToSource {
+		return "to source"
+	}
+	return "to target"
+}
+
+// BridgeOptions are the options of a Bridge. The zero value relays all
+// packets unchanged.
+type BridgeOptions struct {
+	// Rewrites are applied to the packets that are relayed to the target.
+	Rewrites []Rewrite
+
+	// ReplyRewrites are applied to the packets that are relayed back to the
+	// source.
+	ReplyRewrites []Rewrite
+
+	// OnPacket is called with every decoded packet before it is relayed,
+	// e.g. to log the traffic. from is the address the packet was received
+	// from. The packet must not be modified.
+	OnPacket func(dir BridgeDirection, from net.Addr, packet Packet)
+
+	// OnError is called if a packet couldn't be relayed. Errors are ignored
+	// if OnError is nil.
+	OnError func(dir BridgeDirection, err error)
+}
+
+// Bridge relays all packets between a source and a target, e.g. to monitor
+// the traffic between a controller and a device. The source sends to the
+// listen address of the bridge, the bridge forwards its packets to the target
+// and relays the replies of the target back to the address the last packet
+// was received from. Packets are relayed byte-exact, unless they are
+// rewritten. Packets that can't be decoded are relayed unchanged.
+type Bridge struct {
+	opts  BridgeOptions
+	front net.PacketConn // Bound to the listen address
+	back  *net.UDPConn   // Connected to the target
+
+	mu     sync.Mutex
+	source net.Addr // Address of the last packet received on front
+	closed bool
+}
+
+// NewBridge binds a UDP socket to listenAddr and returns a Bridge that relays
+// the received packets to targetAddr, which has the form "host:port".
+func NewBridge(listenAddr, targetAddr string, opts BridgeOptions) (*Bridge, error) {
+	raddr, err := net.ResolveUDPAddr("udp", targetAddr)
+	if err != nil {
+		return nil, err
+	}
+	front, _, err := Listen(listenAddr)
+	if err != nil {
+		return nil, err
+	}
+	back, err := net.DialUDP("udp", nil, raddr)
+	if err != nil {
+		front.Close()
+		return nil, err
+	}
+	return &Bridge{opts: opts, front: front, back: back}, nil
+}
+
+// LocalAddr returns the address the bridge listens on.
+func (b *Bridge) LocalAddr() net.Addr {
+	return b.front.LocalAddr()
+}
+
+// Serve relays packets in both directions until the bridge is closed. It
+// returns nil if the bridge was closed, otherwise the error that stopped it.
+func (b *Bridge) Serve() error {
+	errc := make(chan error, 2)
+	go func() { errc <- b.relay(ToTarget) }()
+	go func() { errc <- b.relay(ToSource) }()
+	err := <-errc // nil if the bridge was closed
+	b.Close()
+	<-errc
+	return err
+}
+
+// Close closes the sockets of the bridge, which stops Serve.
+func (b *Bridge) Close() error {
+	b.mu.Lock()
+	if b.closed {
+		b.mu.Unlock()
+		return nil
+	}
+	b.closed = true
+	b.mu.Unlock()
+
+	err := b.front.Close()
+	if berr := b.back.Close(); err == nil {
+		err = berr
+	}
+	return err
+}
+
+// relay receives the packets of one direction and forwards them until the
+// bridge is closed.
+func (b *Bridge) relay(dir BridgeDirection) error {
+	conn, rules := b.front, b.opts.Rewrites
+	if dir == ToSource {
+		conn, rules = net.PacketConn(b.back), b.opts.ReplyRewrites
+	}
+
+	buf := make([]byte, receiveBufferSize)
+	for {
+		n, addr, err := conn.ReadFrom(buf)
+		if err != nil {
+			b.mu.Lock()
+			closed := b.closed
+			b.mu.Unlock()
+			if closed {
+				return nil
+			}
+			if dir == ToSource {
+				// E.g. an ICMP port unreachable of a previous packet
+				continue
+			}
+			return err
+		}
+
+		data, err := b.process(dir, addr, buf[:n], rules)
+		if err == nil {
+			err = b.forward(dir, addr, data)
+		}
+		if err != nil && b.opts.OnError != nil {
+			b.opts.OnError(dir, err)
+		}
+	}
+}
+
+// process decodes the data if the packet must be reported or rewritten and
+// returns the data to forward.
+func (b *Bridge) process(dir BridgeDirection, from net.Addr, data []byte, rules []Rewrite) ([]byte, error) {
+	if b.opts.OnPacket == nil && len(rules) == 0 {
+		return data, nil
+	}
+	var d Decoder
+	p, err := d.DecodeBytes(data)
+	if err != nil || p == nil {
+		return data, nil
+	}
+	if b.opts.OnPacket != nil {
+		b.opts.OnPacket(dir, from, p)
+	}
+	if len(rules) == 0 {
+		return data, nil
+	}
+	rewritePacket(rules, p)
+	return p.MarshalBinary()
+}
+
+// forward sends the data to the target or to the last source.
+func (b *Bridge) forward(dir BridgeDirection, from net.Addr, data []byte) error {
+	if dir == ToTarget {
+		b.mu.Lock()
+		b.source = from
+		b.mu.Unlock()
+		_, err := b.back.Write(data)
+		return err
+	}
+
+	b.mu.Lock()
+	source := b.source
+	b.mu.Unlock()
+	if source == nil {
+		// The source is unknown until it sent a packet
+		return nil
+	}
+	_, err := b.front.WriteTo(data, source)
+	return err
+}
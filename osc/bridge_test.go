@@ -0,0 +1,87 @@
+package osc
+
+import (
+	"net"
+	"sync"
+	"testing"
+	"time"
+)
+
+func TestBridge(t *testing.T) {
+	// The device echoes every packet with the address prefix "/reply"
+	device, err := net.ListenPacket("udp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer device.Close()
+	go func() {
+		buf := make([]byte, 1024)
+		for {
+			n, addr, err := device.ReadFrom(buf)
+			if err != nil {
+				return
+			}
+			p, err := ParsePacket(string(buf[:n]))
+			if err != nil {
+				continue
+			}
+			msg := p.(*Message)
+			msg.Address = "/reply" + msg.Address
+			data, _ := msg.MarshalBinary()
+			device.WriteTo(data, addr)
+		}
+	}()
+
+	var mu sync.Mutex
+	var logged []string
+	bridge, err := NewBridge("127.0.0.1:0", device.LocalAddr().String(), BridgeOptions{
+		Rewrites:      []Rewrite{{Prefix: "/ctrl", Replacement: "/dev"}},
+		ReplyRewrites: []Rewrite{{Prefix: "/reply/dev", Replacement: "/ctrl"}},
+		OnPacket: func(dir BridgeDirection, from net.Addr, packet Packet) {
+			mu.Lock()
+			logged = append(logged, dir.String()+" "+packet.(*Message).Address)
+			mu.Unlock()
+		},
+		OnError: func(dir BridgeDirection, err error) {
+			t.Errorf("relaying %s failed: %s", dir, err)
+		},
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	served := make(chan error)
+	go func() { served <- bridge.Serve() }()
+
+	controller, err := net.ListenPacket("udp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer controller.Close()
+	data, _ := NewMessage("/ctrl/fader", float32(0.5)).MarshalBinary()
+	if _, err := controller.WriteTo(data, bridge.LocalAddr()); err != nil {
+		t.Fatal(err)
+	}
+
+	server := &Server{ReadTimeout: 5 * time.Second}
+	packet, err := server.ReceivePacket(controller)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if want := NewMessage("/ctrl/fader", float32(0.5)); !packet.(*Message).Equals(want) {
+		t.Errorf("reply = %v, want = %v", packet, want)
+	}
+
+	if err := bridge.Close(); err != nil {
+		t.Fatal(err)
+	}
+	if err := <-served; err != nil {
+		t.Errorf("Serve() = %v, want = nil", err)
+	}
+
+	mu.Lock()
+	defer mu.Unlock()
+	want := []string{"to target /ctrl/fader", "to source /reply/dev/fader"}
+	if len(logged) != len(want) || logged[0] != want[0] || logged[1] != want[1] {
+		t.Errorf("logged packets = %q, want = %q", logged, want)
+	}
+}
@@ -0,0 +1,99 @@
+package osc
+
+import (
+	"net"
+	"sort"
+	"time"
+
+	"github.com/hypebeast/go-osc/osc/codec"
+)
+
+// Addresses of the built-in services, see Server.EnableBuiltins. Every
+// request is answered with a message to the reply address.
+const (
+	PingAddress           = "/ping"
+	PingReplyAddress      = "/ping/reply"
+	EchoAddress           = "/echo"
+	EchoReplyAddress      = "/echo/reply"
+	NamespaceAddress      = "/namespace"
+	NamespaceReplyAddress = "/namespace/reply"
+)
+
+// EnableBuiltins makes the server answer the requests of the built-in
+// services and consume them, i.e. they aren't dispatched:
+//
+//	/ping       replies to /ping/reply with the arguments of the request
+//	            followed by the time the request was received
+//	/echo       replies to /echo/reply with the arguments of the request
+//	/namespace  replies to /namespace/reply with one string argument per
+//	            address, sorted
+//
+// The addresses listed by /namespace are the methods of the namespace of a
+// StandardDispatcher, see SetNamespace, or the addresses, templates and
+// patterns of its handlers if it has no namespace. Other dispatchers list no
+// addresses. Replies are sent from the connection the request was received
+// on to its sender. Requests inside bundles are dispatched as usual. It must
+// be called before Serve.
+func (s *Server) EnableBuiltins() {
+	s.builtins = true
+}
+
+// serveBuiltin answers the request of a built-in service. Returns true if the
+// packet was a request and was consumed.
+func (s *Server) serveBuiltin(c net.PacketConn, packet Packet, addr net.Addr, received time.Time) bool {
+	msg, ok := packet.(*Message)
+	if !ok {
+		return false
+	}
+	var reply *Message
+	switch msg.Address {
+	case PingAddress:
+		reply = NewMessage(PingReplyAddress, msg.Arguments...)
+		reply.Append(received)
+	case EchoAddress:
+		reply = NewMessage(EchoReplyAddress, msg.Arguments...)
+	case NamespaceAddress:
+		reply = NewMessage(NamespaceReplyAddress)
+		for _, a := range s.namespaceAddresses() {
+			reply.Append(a)
+		}
+	default:
+		return false
+	}
+	// Errors are ignored, the requester has to repeat the request
+	if data, err := reply.MarshalBinary(); err == nil {
+		c.WriteTo(data, addr)
+	}
+	if s.ReuseMessages {
+		codec.ReleasePacket(msg)
+	}
+	return true
+}
+
+// namespaceAddresses returns the sorted addresses that the dispatcher of the
+// server handles.
+func (s *Server) namespaceAddresses() []string {
+	d, ok := s.Dispatcher.(*StandardDispatcher)
+	if !ok {
+		return nil
+	}
+	var addrs []string
+	if d.namespace != nil {
+		for _, m := range d.namespace.Methods() {
+			addrs = append(addrs, m.Address)
+		}
+		return addrs
+	}
+
+	d.handlers.addresses(nil, func(addr string) {
+		addrs = append(addrs, addr)
+	})
+	for _, ph := range d.paramHandlers {
+		addrs = append(addrs, ph.template.template)
+	}
+	for _, ph := range d.patternHandlers {
+		addrs = append(addrs, ph.pattern.String())
+	}
+	sort.Strings(addrs)
+	return addrs
+}
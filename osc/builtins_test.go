@@ -0,0 +1,107 @@
+package osc
+
+import (
+	"net"
+	"reflect"
+	"sync"
+	"testing"
+	"time"
+)
+
+func TestServer_EnableBuiltins(t *testing.T) {
+	d := NewStandardDispatcher()
+	d.SetMatchMode(MatchBoth)
+	var mu sync.Mutex
+	var dispatched []string
+	record := func(msg *Message) {
+		mu.Lock()
+		dispatched = append(dispatched, msg.Address)
+		mu.Unlock()
+	}
+	sub := NewStandardDispatcher()
+	for _, addr := range []string{"/synth/freq", "/mixer/*/mute", "/echo/target"} {
+		if err := d.AddMsgHandler(addr, record); err != nil {
+			t.Fatal(err)
+		}
+	}
+	if err := d.AddParamHandler("/track/{n}/volume", func(*Message, Params) {}); err != nil {
+		t.Fatal(err)
+	}
+	if err := sub.AddMsgHandler("/gain", record); err != nil {
+		t.Fatal(err)
+	}
+	if err := d.Route("/fx/1", sub); err != nil {
+		t.Fatal(err)
+	}
+
+	conn, err := net.ListenPacket("udp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer conn.Close()
+	server := &Server{Dispatcher: d}
+	server.EnableBuiltins()
+	go server.Serve(conn)
+
+	client, err := net.ListenPacket("udp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer client.Close()
+	request := func(msg *Message) *Message {
+		data, err := msg.MarshalBinary()
+		if err != nil {
+			t.Fatal(err)
+		}
+		if _, err := client.WriteTo(data, conn.LocalAddr()); err != nil {
+			t.Fatal(err)
+		}
+		client.SetReadDeadline(time.Now().Add(5 * time.Second))
+		buf := make([]byte, 65535)
+		n, _, err := client.ReadFrom(buf)
+		if err != nil {
+			t.Fatalf("no reply to %s: %s", msg.Address, err)
+		}
+		p, err := ParsePacket(string(buf[:n]))
+		if err != nil {
+			t.Fatal(err)
+		}
+		return p.(*Message)
+	}
+
+	before := time.Now().Add(-time.Second)
+	reply := request(NewMessage(PingAddress, int32(7)))
+	if reply.Address != PingReplyAddress || len(reply.Arguments) != 2 || reply.Arguments[0] != int32(7) {
+		t.Fatalf("ping reply = %v, want %s with 2 arguments", reply, PingReplyAddress)
+	}
+	if received, ok := timeArgument(reply.Arguments[1]); !ok || received.Before(before) || received.After(time.Now().Add(time.Second)) {
+		t.Errorf("ping reply time = %v, want the current time", reply.Arguments[1])
+	}
+
+	reply = request(NewMessage(EchoAddress, "hello", float32(0.5)))
+	if want := NewMessage(EchoReplyAddress, "hello", float32(0.5)); !reflect.DeepEqual(reply, want) {
+		t.Errorf("echo reply = %v, want = %v", reply, want)
+	}
+
+	reply = request(NewMessage(NamespaceAddress))
+	want := []interface{}{"/echo/target", "/fx/1/gain", "/mixer/*/mute", "/synth/freq", "/track/{n}/volume"}
+	if reply.Address != NamespaceReplyAddress || !reflect.DeepEqual(reply.Arguments, want) {
+		t.Errorf("namespace reply = %v, want arguments %v", reply, want)
+	}
+
+	ns := NewNamespace()
+	if err := ns.Add(Method{Address: "/synth/freq"}); err != nil {
+		t.Fatal(err)
+	}
+	nsDispatcher := NewStandardDispatcher()
+	nsDispatcher.SetNamespace(ns)
+	if got, want := (&Server{Dispatcher: nsDispatcher}).namespaceAddresses(), []string{"/synth/freq"}; !reflect.DeepEqual(got, want) {
+		t.Errorf("namespaceAddresses() with namespace = %v, want = %v", got, want)
+	}
+
+	mu.Lock()
+	defer mu.Unlock()
+	if len(dispatched) != 0 {
+		t.Errorf("dispatched %v, want none", dispatched)
+	}
+}
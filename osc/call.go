@@ -0,0 +1,113 @@
+package osc
+
+import (
+	"context"
+	"errors"
+	"net"
+	"time"
+)
+
+// Call sends msg and waits for a reply message with the address replyAddr,
+// e.g. for "/info" queries of digital mixers. If replyAddr is empty, the reply
+// must have the address of msg. The reply is received on the socket that msg
+// was sent from, so the remote side must reply to the source address of the
+// request. Other packets received in the meantime, and messages of bundles
+// with other addresses, are discarded.
+//
+// Call returns the error of ctx if it is done before a reply was received.
+func (c *Client) Call(ctx context.Context, msg *Message, replyAddr string) (*Message, error) {
+	if replyAddr == "" {
+		replyAddr = msg.Address
+	}
+	_, data, err := c.encode(msg)
+	if err != nil {
+		return nil, err
+	}
+	if data == nil {
+		return nil, errors.New("osc: the request was dropped by a send hook")
+	}
+
+	conn := c.conn
+	if conn == nil {
+		udpConn, err := c.dial()
+		if err != nil {
+			return nil, err
+		}
+		defer udpConn.Close()
+		conn = udpConn
+	}
+
+	if c.writeTimeout != 0 {
+		if err = conn.SetWriteDeadline(time.Now().Add(c.writeTimeout)); err != nil {
+			return nil, err
+		}
+	}
+	if _, err = conn.Write(data); err != nil {
+		return nil, err
+	}
+
+	// Interrupt the read when ctx is done
+	deadline, _ := ctx.Deadline()
+	if err = conn.SetReadDeadline(deadline); err != nil {
+		return nil, err
+	}
+	defer conn.SetReadDeadline(time.Time{})
+	stop, done := make(chan struct{}), make(chan struct{})
+	defer func() {
+		close(stop)
+		<-done
+	}()
+	go func() {
+		defer close(done)
+		select {
+		case <-ctx.Done():
+			conn.SetReadDeadline(time.Now())
+		case <-stop:
+		}
+	}()
+
+	buf := make([]byte, receiveBufferSize)
+	for {
+		n, err := conn.Read(buf)
+		if err != nil {
+			if ctx.Err() != nil {
+				return nil, ctx.Err()
+			}
+			if ne, ok := err.(net.Error); ok && ne.Timeout() {
+				return nil, context.DeadlineExceeded
+			}
+			return nil, err
+		}
+
+		p, err := ParsePacket(string(buf[:n]))
+		if err != nil {
+			continue
+		}
+		if reply := findMessage(p, replyAddr); reply != nil {
+			return reply, nil
+		}
+	}
+}
+
+// findMessage returns the first message of the packet with the given address,
+// or nil if there is none.
+func findMessage(packet Packet, addr string) *Message {
+	switch p := packet.(type) {
+	case *Message:
+		if p.Address == addr {
+			return p
+		}
+	case *Bundle:
+		for _, msg := range p.Messages {
+			if msg.Address == addr {
+				return msg
+			}
+		}
+		for _, b := range p.Bundles {
+			if msg := findMessage(b, addr); msg != nil {
+				return msg
+			}
+		}
+	}
+	return nil
+}
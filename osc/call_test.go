@@ -0,0 +1,89 @@
+package osc
+
+import (
+	"context"
+	"net"
+	"testing"
+	"time"
+)
+
+// replyServer starts a UDP server that answers "/info" with an unrelated
+// message followed by "/info/reply" and ignores all other messages.
+func replyServer(t *testing.T) net.PacketConn {
+	conn, err := net.ListenPacket("udp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatal(err)
+	}
+	go func() {
+		buf := make([]byte, 1024)
+		for {
+			n, addr, err := conn.ReadFrom(buf)
+			if err != nil {
+				return
+			}
+			p, err := ParsePacket(string(buf[:n]))
+			if err != nil || p.(*Message).Address != "/info" {
+				continue
+			}
+			for _, reply := range []*Message{
+				NewMessage("/meters", int32(0)),
+				NewMessage("/info/reply", "X32", p.(*Message).Arguments[0]),
+			} {
+				data, _ := reply.MarshalBinary()
+				conn.WriteTo(data, addr)
+			}
+		}
+	}()
+	return conn
+}
+
+func TestClient_Call(t *testing.T) {
+	server := replyServer(t)
+	defer server.Close()
+	addr := server.LocalAddr().(*net.UDPAddr)
+	client := NewClient(addr.IP.String(), addr.Port)
+
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+	reply, err := client.Call(ctx, NewMessage("/info", int32(7)), "/info/reply")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if want := NewMessage("/info/reply", "X32", int32(7)); !reply.Equals(want) {
+		t.Errorf("Call() = %v, want = %v", reply, want)
+	}
+
+	// The server ignores the request, the call times out
+	ctx, cancel = context.WithTimeout(context.Background(), 50*time.Millisecond)
+	defer cancel()
+	if _, err := client.Call(ctx, NewMessage("/status"), ""); err != context.DeadlineExceeded {
+		t.Errorf("Call() error = %v, want = %v", err, context.DeadlineExceeded)
+	}
+
+	// Cancelling the context stops waiting
+	ctx, cancel = context.WithCancel(context.Background())
+	time.AfterFunc(50*time.Millisecond, cancel)
+	if _, err := client.Call(ctx, NewMessage("/status"), ""); err != context.Canceled {
+		t.Errorf("Call() error = %v, want = %v", err, context.Canceled)
+	}
+}
+
+func TestClient_Call_Conn(t *testing.T) {
+	server := replyServer(t)
+	defer server.Close()
+	conn, err := net.Dial("udp", server.LocalAddr().String())
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer conn.Close()
+
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+	reply, err := NewClientFromConn(conn).Call(ctx, NewMessage("/info", int32(1)), "/info/reply")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if want := NewMessage("/info/reply", "X32", int32(1)); !reply.Equals(want) {
+		t.Errorf("Call() = %v, want = %v", reply, want)
+	}
+}
@@ -0,0 +1,164 @@
+// Package capture reads and writes the OSC traffic of packet capture files,
+// e.g. recorded with Wireshark or tcpdump. Reader extracts the UDP payloads
+// of .pcap and .pcapng files and decodes them as OSC packets. Writer writes
+// OSC packets as UDP datagrams to a .pcap file, which can be inspected with
+// the OSC dissector of Wireshark.
+//
+// Only UDP over IPv4 and IPv6 is supported. Fragmented IP packets aren't
+// reassembled and OSC streams over TCP aren't extracted.
+package capture
+
+import (
+	"encoding/binary"
+	"net"
+	"time"
+
+	"github.com/hypebeast/go-osc/osc"
+)
+
+// Record is an OSC packet of a capture file.
+type Record struct {
+	Time     time.Time
+	Src, Dst *net.UDPAddr
+	Data     []byte     // UDP payload
+	Packet   osc.Packet // Data decoded as OSC packet
+}
+
+// Link types of the supported link layers, see
+// https://www.tcpdump.org/linktypes.html.
+const (
+	linkTypeNull      = 0
+	linkTypeEthernet  = 1
+	linkTypeRaw       = 101
+	linkTypeLinuxSLL  = 113
+	linkTypeIPv4      = 228
+	linkTypeIPv6      = 229
+	linkTypeLinuxSLL2 = 276
+)
+
+// EtherTypes of IPv4 and IPv6, and of VLAN tags.
+const (
+	etherTypeIPv4 = 0x0800
+	etherTypeIPv6 = 0x86dd
+	etherTypeVLAN = 0x8100
+	etherTypeQinQ = 0x88a8
+)
+
+// protocolUDP is the IP protocol number of UDP.
+const protocolUDP = 17
+
+// udpDatagram returns the source and destination address and the payload of
+// the UDP datagram in a frame of the given link type. Returns false if the
+// frame doesn't contain a complete UDP datagram.
+func udpDatagram(linkType uint32, frame []byte) (src, dst *net.UDPAddr, payload []byte, ok bool) {
+	ip, ok := ipPacket(linkType, frame)
+	if !ok {
+		return nil, nil, nil, false
+	}
+	srcIP, dstIP, udp, ok := udpSegment(ip)
+	if !ok || len(udp) < 8 {
+		return nil, nil, nil, false
+	}
+	length := int(binary.BigEndian.Uint16(udp[4:]))
+	if length < 8 || length > len(udp) {
+		return nil, nil, nil, false
+	}
+	src = &net.UDPAddr{IP: append(net.IP(nil), srcIP...), Port: int(binary.BigEndian.Uint16(udp))}
+	dst = &net.UDPAddr{IP: append(net.IP(nil), dstIP...), Port: int(binary.BigEndian.Uint16(udp[2:]))}
+	return src, dst, udp[8:length], true
+}
+
+// ipPacket returns the IP packet in a frame of the given link type.
+func ipPacket(linkType uint32, frame []byte) ([]byte, bool) {
+	var etherType uint16
+	switch linkType {
+	case linkTypeRaw, linkTypeIPv4, linkTypeIPv6:
+		return frame, true
+
+	case linkTypeNull:
+		// The address family is in the byte order of the capturing host,
+		// the IP version is checked instead
+		if len(frame) < 4 {
+			return nil, false
+		}
+		return frame[4:], true
+
+	case linkTypeEthernet:
+		if len(frame) < 14 {
+			return nil, false
+		}
+		etherType, frame = binary.BigEndian.Uint16(frame[12:]), frame[14:]
+		for etherType == etherTypeVLAN || etherType == etherTypeQinQ {
+			if len(frame) < 4 {
+				return nil, false
+			}
+			etherType, frame = binary.BigEndian.Uint16(frame[2:]), frame[4:]
+		}
+
+	case linkTypeLinuxSLL:
+		if len(frame) < 16 {
+			return nil, false
+		}
+		etherType, frame = binary.BigEndian.Uint16(frame[14:]), frame[16:]
+
+	case linkTypeLinuxSLL2:
+		if len(frame) < 20 {
+			return nil, false
+		}
+		etherType, frame = binary.BigEndian.Uint16(frame), frame[20:]
+
+	default:
+		return nil, false
+	}
+	return frame, etherType == etherTypeIPv4 || etherType == etherTypeIPv6
+}
+
+// udpSegment returns the addresses and the UDP segment of an IP packet.
+// Returns false if the packet isn't a complete, unfragmented UDP packet.
+func udpSegment(ip []byte) (src, dst net.IP, udp []byte, ok bool) {
+	if len(ip) < 1 {
+		return nil, nil, nil, false
+	}
+	switch ip[0] >> 4 {
+	case 4:
+		if len(ip) < 20 {
+			return nil, nil, nil, false
+		}
+		headerLen, total := int(ip[0]&0x0f)*4, int(binary.BigEndian.Uint16(ip[2:]))
+		if headerLen < 20 || total < headerLen || total > len(ip) {
+			return nil, nil, nil, false
+		}
+		// The more fragments flag and the fragment offset
+		if ip[9] != protocolUDP || binary.BigEndian.Uint16(ip[6:])&0x3fff != 0 {
+			return nil, nil, nil, false
+		}
+		return ip[12:16], ip[16:20], ip[headerLen:total], true
+
+	case 6:
+		if len(ip) < 40 {
+			return nil, nil, nil, false
+		}
+		total := 40 + int(binary.BigEndian.Uint16(ip[4:]))
+		if total > len(ip) {
+			return nil, nil, nil, false
+		}
+		next, payload := ip[6], ip[40:total]
+		for next != protocolUDP {
+			switch next {
+			case 0, 43, 60: // Hop-by-hop, routing and destination options
+				if len(payload) < 8 {
+					return nil, nil, nil, false
+				}
+				n := (int(payload[1]) + 1) * 8
+				if n > len(payload) {
+					return nil, nil, nil, false
+				}
+				next, payload = payload[0], payload[n:]
+			default: // Including fragments
+				return nil, nil, nil, false
+			}
+		}
+		return ip[8:24], ip[24:40], payload, true
+	}
+	return nil, nil, nil, false
+}
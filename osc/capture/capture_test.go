@@ -0,0 +1,202 @@
+package capture
+
+import (
+	"bytes"
+	"encoding/binary"
+	"io"
+	"net"
+	"testing"
+	"time"
+
+	"github.com/hypebeast/go-osc/osc"
+)
+
+func TestWriterReader(t *testing.T) {
+	var buf bytes.Buffer
+	w, err := NewWriter(&buf)
+	if err != nil {
+		t.Fatal(err)
+	}
+	start := time.Unix(1600000000, 123456789)
+	bundle := osc.NewBundle(start)
+	bundle.Append(osc.NewMessage("/b", "x"))
+	records := []Record{
+		{
+			Time:   start,
+			Src:    &net.UDPAddr{IP: net.ParseIP("192.168.1.2"), Port: 50000},
+			Dst:    &net.UDPAddr{IP: net.ParseIP("192.168.1.10"), Port: 10023},
+			Packet: osc.NewMessage("/a", int32(1), float32(0.5)),
+		},
+		{
+			Time:   start.Add(time.Second),
+			Src:    &net.UDPAddr{IP: net.ParseIP("fe80::1"), Port: 10023},
+			Dst:    &net.UDPAddr{IP: net.ParseIP("fe80::2"), Port: 50000},
+			Packet: bundle,
+		},
+	}
+	for _, rec := range records {
+		if err := w.WritePacket(rec.Time, rec.Src, rec.Dst, rec.Packet); err != nil {
+			t.Fatal(err)
+		}
+	}
+	// Skipped by the reader, because of the port or because they aren't OSC
+	other := &net.UDPAddr{IP: net.ParseIP("192.168.1.2"), Port: 53}
+	w.WritePacket(start, other, other, osc.NewMessage("/dns"))
+	w.WriteDatagram(start, records[0].Src, records[0].Dst, []byte("no osc"))
+
+	r, err := NewReader(bytes.NewReader(buf.Bytes()))
+	if err != nil {
+		t.Fatal(err)
+	}
+	r.Port = 10023
+	for i := 0; r.Next(); i++ {
+		got := r.Record()
+		if i >= len(records) {
+			t.Fatalf("Next() returned record %d, want %d records", i, len(records))
+		}
+		want := records[i]
+		if !got.Time.Equal(want.Time) || got.Src.String() != want.Src.String() || got.Dst.String() != want.Dst.String() {
+			t.Errorf("record %d = %v %v -> %v, want = %v %v -> %v", i, got.Time, got.Src, got.Dst, want.Time, want.Src, want.Dst)
+		}
+		data, _ := want.Packet.MarshalBinary()
+		if !bytes.Equal(got.Data, data) {
+			t.Errorf("record %d has data %x, want = %x", i, got.Data, data)
+		}
+		if msg, ok := want.Packet.(*osc.Message); ok && !msg.Equals(got.Packet.(*osc.Message)) {
+			t.Errorf("record %d has packet %v, want = %v", i, got.Packet, msg)
+		}
+	}
+	if err := r.Err(); err != nil {
+		t.Errorf("Err() = %v", err)
+	}
+}
+
+func TestWriter_checksums(t *testing.T) {
+	var buf bytes.Buffer
+	w, _ := NewWriter(&buf)
+	src := &net.UDPAddr{IP: net.ParseIP("10.0.0.1"), Port: 9000}
+	dst := &net.UDPAddr{IP: net.ParseIP("10.0.0.2"), Port: 8000}
+	if err := w.WriteDatagram(time.Now(), src, dst, []byte("odd")); err != nil {
+		t.Fatal(err)
+	}
+	ip := buf.Bytes()[24+16+ethernetHeaderLen:]
+	if sum := checksum(0, ip[:ipv4HeaderLen]); sum != 0xffff {
+		t.Errorf("IPv4 header sum = %#x, want = 0xffff", sum)
+	}
+	pseudo := append(append([]byte{}, ip[12:20]...), 0, protocolUDP, 0, byte(len(ip)-ipv4HeaderLen))
+	if sum := checksum(checksum(0, pseudo), ip[ipv4HeaderLen:]); sum != 0xffff {
+		t.Errorf("UDP sum = %#x, want = 0xffff", sum)
+	}
+
+	mixed := &net.UDPAddr{IP: net.ParseIP("::1"), Port: 8000}
+	if err := w.WriteDatagram(time.Now(), src, mixed, nil); err != ErrAddressFamily {
+		t.Errorf("WriteDatagram() = %v, want = %v", err, ErrAddressFamily)
+	}
+	if err := w.WriteDatagram(time.Now(), src, dst, make([]byte, 0x10000)); err != ErrDatagramSize {
+		t.Errorf("WriteDatagram() = %v, want = %v", err, ErrDatagramSize)
+	}
+}
+
+// pcapng returns a pcapng file with one raw IP interface with nanosecond
+// resolution and an enhanced packet block for every frame.
+func pcapng(order binary.ByteOrder, t time.Time, frames ...[]byte) []byte {
+	var b bytes.Buffer
+	block := func(blockType uint32, body []byte) {
+		for len(body)%4 != 0 {
+			body = append(body, 0)
+		}
+		binary.Write(&b, order, blockType)
+		binary.Write(&b, order, uint32(12+len(body)))
+		b.Write(body)
+		binary.Write(&b, order, uint32(12+len(body)))
+	}
+	put := func(values ...interface{}) []byte {
+		var body bytes.Buffer
+		for _, v := range values {
+			binary.Write(&body, order, v)
+		}
+		return body.Bytes()
+	}
+
+	block(pcapngSectionType, put(uint32(pcapngByteOrder), uint16(1), uint16(0), int64(-1)))
+	block(pcapngInterfaceType, put(uint16(linkTypeRaw), uint16(0), uint32(snapLen),
+		uint16(pcapngTimestampResolution), uint16(1), uint8(9), [3]byte{}, uint32(0)))
+	block(2, nil) // Unknown blocks are skipped
+	ts := uint64(t.UnixNano())
+	for _, frame := range frames {
+		block(pcapngEnhancedPacketType, append(put(uint32(0), uint32(ts>>32), uint32(ts), uint32(len(frame)), uint32(len(frame))), frame...))
+	}
+	return b.Bytes()
+}
+
+func TestReader_pcapng(t *testing.T) {
+	// The IP packet of a written pcap file
+	var buf bytes.Buffer
+	w, _ := NewWriter(&buf)
+	src := &net.UDPAddr{IP: net.ParseIP("10.0.0.1"), Port: 9000}
+	dst := &net.UDPAddr{IP: net.ParseIP("10.0.0.2"), Port: 8000}
+	msg := osc.NewMessage("/a", "x")
+	w.WritePacket(time.Now(), src, dst, msg)
+	ip := buf.Bytes()[24+16+ethernetHeaderLen:]
+
+	at := time.Unix(1600000000, 5)
+	for _, order := range []binary.ByteOrder{binary.LittleEndian, binary.BigEndian} {
+		r, err := NewReader(bytes.NewReader(pcapng(order, at, ip, ip)))
+		if err != nil {
+			t.Fatal(err)
+		}
+		n := 0
+		for ; r.Next(); n++ {
+			rec := r.Record()
+			if !rec.Time.Equal(at) || rec.Src.String() != src.String() || !rec.Packet.(*osc.Message).Equals(msg) {
+				t.Errorf("%v: Record() = %v %v %v, want = %v %v %v", order, rec.Time, rec.Src, rec.Packet, at, src, msg)
+			}
+		}
+		if err := r.Err(); err != nil || n != 2 {
+			t.Errorf("%v: read %d records, %v, want = 2 records, nil", order, n, err)
+		}
+	}
+}
+
+func TestReader_errors(t *testing.T) {
+	if _, err := NewReader(bytes.NewReader([]byte("not a capture file......"))); err != ErrInvalidFile {
+		t.Errorf("NewReader() = %v, want = %v", err, ErrInvalidFile)
+	}
+
+	var buf bytes.Buffer
+	w, _ := NewWriter(&buf)
+	addr := &net.UDPAddr{IP: net.ParseIP("10.0.0.1"), Port: 9000}
+	w.WritePacket(time.Now(), addr, addr, osc.NewMessage("/a"))
+	r, err := NewReader(bytes.NewReader(buf.Bytes()[:buf.Len()-1]))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if r.Next() || r.Err() != io.ErrUnexpectedEOF {
+		t.Errorf("Err() = %v, want = %v", r.Err(), io.ErrUnexpectedEOF)
+	}
+}
+
+func TestIPPacket(t *testing.T) {
+	ip := []byte{0x45}
+	for _, tt := range []struct {
+		name     string
+		linkType uint32
+		frame    []byte
+		ok       bool
+	}{
+		{"raw", linkTypeRaw, ip, true},
+		{"null", linkTypeNull, append([]byte{2, 0, 0, 0}, ip...), true},
+		{"ethernet", linkTypeEthernet, append(make([]byte, 12), 0x08, 0x00, 0x45), true},
+		{"vlan", linkTypeEthernet, append(make([]byte, 12), 0x81, 0x00, 0, 1, 0x86, 0xdd, 0x60), true},
+		{"arp", linkTypeEthernet, append(make([]byte, 12), 0x08, 0x06, 0), false},
+		{"sll", linkTypeLinuxSLL, append(make([]byte, 14), 0x08, 0x00, 0x45), true},
+		{"sll2", linkTypeLinuxSLL2, append([]byte{0x08, 0x00}, append(make([]byte, 18), 0x45)...), true},
+		{"short", linkTypeEthernet, make([]byte, 10), false},
+		{"unsupported", 147, ip, false},
+	} {
+		got, ok := ipPacket(tt.linkType, tt.frame)
+		if ok != tt.ok || ok && got[0]>>4 != tt.frame[len(tt.frame)-1]>>4 {
+			t.Errorf("%s: ipPacket() = %x, %t, want ok = %t", tt.name, got, ok, tt.ok)
+		}
+	}
+}
@@ -0,0 +1,323 @@
+package capture
+
+import (
+	"bufio"
+	"encoding/binary"
+	"errors"
+	"io"
+	"math"
+	"os"
+	"time"
+
+	"github.com/hypebeast/go-osc/osc"
+)
+
+// ErrInvalidFile is returned if a file is neither a pcap nor a pcapng file,
+// or if it is corrupt.
+var ErrInvalidFile = errors.New("capture: invalid capture file")
+
+// maxBlockSize limits the size of the records and blocks that are read, to
+// detect corrupt files before allocating their size.
+const maxBlockSize = 16 << 20
+
+// Magic numbers of the file formats.
+const (
+	pcapMagicMicro    = 0xa1b2c3d4
+	pcapMagicNano     = 0xa1b23c4d
+	pcapngSectionType = 0x0a0d0d0a
+	pcapngByteOrder   = 0x1a2b3c4d
+)
+
+// Block types of pcapng that contain packets or their interfaces.
+const (
+	pcapngInterfaceType      = 1
+	pcapngSimplePacketType   = 3
+	pcapngEnhancedPacketType = 6
+)
+
+// pcapngTimestampResolution is the option code of the timestamp resolution
+// of an interface.
+const pcapngTimestampResolution = 9
+
+// Reader reads the OSC packets of a .pcap or .pcapng file. UDP datagrams that
+// aren't OSC packets are skipped, as are frames of unsupported link layers.
+// Its usage is similar to bufio.Scanner:
+//
+//	for r.Next() {
+//	    rec := r.Record()
+//	    ...
+//	}
+//	if err := r.Err(); err != nil {
+//	    ...
+//	}
+type Reader struct {
+	// Port restricts the records to UDP datagrams from or to this port. Zero
+	// reads the datagrams of all ports.
+	Port int
+	// Options control how the datagrams are decoded.
+	Options osc.DecodeOptions
+
+	br     *bufio.Reader
+	closer io.Closer
+	order  binary.ByteOrder
+	record Record
+	err    error
+
+	// pcap
+	linkType uint32
+	nano     bool
+
+	// pcapng, nil for pcap files
+	interfaces []pcapngInterface
+}
+
+// pcapngInterface is an interface of a pcapng section.
+type pcapngInterface struct {
+	linkType       uint32
+	unitsPerSecond uint64 // Zero if the resolution is unsupported
+}
+
+// NewReader returns a new Reader that reads a pcap or pcapng file from r.
+// Returns ErrInvalidFile if r doesn't start with the header of either format.
+func NewReader(r io.Reader) (*Reader, error) {
+	cr := &Reader{br: bufio.NewReader(r)}
+	magic, err := cr.br.Peek(4)
+	if err != nil {
+		return nil, ErrInvalidFile
+	}
+	if binary.BigEndian.Uint32(magic) == pcapngSectionType {
+		// Interfaces are read with the first section
+		cr.interfaces = []pcapngInterface{}
+		return cr, nil
+	}
+
+	var header [24]byte
+	if _, err := io.ReadFull(cr.br, header[:]); err != nil {
+		return nil, ErrInvalidFile
+	}
+	for _, order := range []binary.ByteOrder{binary.LittleEndian, binary.BigEndian} {
+		switch order.Uint32(header[:]) {
+		case pcapMagicMicro:
+			cr.order = order
+		case pcapMagicNano:
+			cr.order, cr.nano = order, true
+		}
+	}
+	if cr.order == nil {
+		return nil, ErrInvalidFile
+	}
+	// The upper bits contain the FCS length
+	cr.linkType = cr.order.Uint32(header[20:]) & 0x0fffffff
+	return cr, nil
+}
+
+// Open opens the capture file with the given name.
+func Open(name string) (*Reader, error) {
+	f, err := os.Open(name)
+	if err != nil {
+		return nil, err
+	}
+	r, err := NewReader(f)
+	if err != nil {
+		f.Close()
+		return nil, err
+	}
+	r.closer = f
+	return r, nil
+}
+
+// Next advances to the next OSC packet, which is then available through
+// Record. It returns false at the end of the file or if an error occurred.
+func (r *Reader) Next() bool {
+	if r.err != nil {
+		return false
+	}
+	d := osc.Decoder{Options: r.Options}
+	for {
+		t, linkType, frame, err := r.readFrame()
+		if err != nil {
+			if err != io.EOF {
+				r.err = err
+			}
+			return false
+		}
+		src, dst, data, ok := udpDatagram(linkType, frame)
+		if !ok || r.Port != 0 && src.Port != r.Port && dst.Port != r.Port {
+			continue
+		}
+		p, err := d.DecodeBytes(data)
+		if err != nil || p == nil {
+			continue
+		}
+		r.record = Record{Time: t, Src: src, Dst: dst, Data: data, Packet: p}
+		return true
+	}
+}
+
+// Record returns the packet that was read by the last call of Next.
+func (r *Reader) Record() Record {
+	return r.record
+}
+
+// Err returns the first error that occurred while reading the file. The end
+// of the file isn't an error.
+func (r *Reader) Err() error {
+	return r.err
+}
+
+// Close closes the file if the reader was opened with Open.
+func (r *Reader) Close() error {
+	if r.closer == nil {
+		return nil
+	}
+	return r.closer.Close()
+}
+
+// readFrame reads the next frame and returns its capture time and link type.
+// Returns io.EOF at the end of the file.
+func (r *Reader) readFrame() (time.Time, uint32, []byte, error) {
+	if r.interfaces == nil {
+		return r.readPcapRecord()
+	}
+	for {
+		t, linkType, frame, ok, err := r.readPcapngBlock()
+		if err != nil || ok {
+			return t, linkType, frame, err
+		}
+	}
+}
+
+// readPcapRecord reads the next record of a pcap file.
+func (r *Reader) readPcapRecord() (time.Time, uint32, []byte, error) {
+	var header [16]byte
+	if _, err := io.ReadFull(r.br, header[:]); err != nil {
+		return time.Time{}, 0, nil, err
+	}
+	size := r.order.Uint32(header[8:])
+	if size > maxBlockSize {
+		return time.Time{}, 0, nil, ErrInvalidFile
+	}
+	frame := make([]byte, size)
+	if _, err := io.ReadFull(r.br, frame); err != nil {
+		return time.Time{}, 0, nil, noEOF(err)
+	}
+
+	sec, frac := int64(r.order.Uint32(header[:])), int64(r.order.Uint32(header[4:]))
+	if !r.nano {
+		frac *= int64(time.Microsecond)
+	}
+	return time.Unix(sec, frac), r.linkType, frame, nil
+}
+
+// readPcapngBlock reads the next block of a pcapng file. ok is false for
+// blocks that don't contain a frame.
+func (r *Reader) readPcapngBlock() (t time.Time, linkType uint32, frame []byte, ok bool, err error) {
+	var header [8]byte
+	if _, err := io.ReadFull(r.br, header[:]); err != nil {
+		return time.Time{}, 0, nil, false, err
+	}
+	blockType := binary.BigEndian.Uint32(header[:])
+	if blockType == pcapngSectionType {
+		// The byte order of the section follows the block length
+		magic, err := r.br.Peek(4)
+		if err != nil {
+			return time.Time{}, 0, nil, false, io.ErrUnexpectedEOF
+		}
+		switch {
+		case binary.LittleEndian.Uint32(magic) == pcapngByteOrder:
+			r.order = binary.LittleEndian
+		case binary.BigEndian.Uint32(magic) == pcapngByteOrder:
+			r.order = binary.BigEndian
+		default:
+			return time.Time{}, 0, nil, false, ErrInvalidFile
+		}
+		r.interfaces = r.interfaces[:0]
+	} else if r.order == nil {
+		return time.Time{}, 0, nil, false, ErrInvalidFile
+	}
+	blockType = r.order.Uint32(header[:])
+
+	size := r.order.Uint32(header[4:])
+	if size < 12 || size%4 != 0 || size > maxBlockSize {
+		return time.Time{}, 0, nil, false, ErrInvalidFile
+	}
+	block := make([]byte, size-8)
+	if _, err := io.ReadFull(r.br, block); err != nil {
+		return time.Time{}, 0, nil, false, noEOF(err)
+	}
+	body := block[:len(block)-4]
+
+	switch blockType {
+	case pcapngInterfaceType:
+		if len(body) < 8 {
+			return time.Time{}, 0, nil, false, ErrInvalidFile
+		}
+		r.interfaces = append(r.interfaces, pcapngInterface{
+			linkType:       uint32(r.order.Uint16(body)),
+			unitsPerSecond: r.timestampResolution(body[8:]),
+		})
+
+	case pcapngEnhancedPacketType:
+		if len(body) < 20 {
+			return time.Time{}, 0, nil, false, ErrInvalidFile
+		}
+		id, size := r.order.Uint32(body), r.order.Uint32(body[12:])
+		if int(id) >= len(r.interfaces) || size > uint32(len(body)-20) {
+			return time.Time{}, 0, nil, false, ErrInvalidFile
+		}
+		iface := r.interfaces[id]
+		if iface.unitsPerSecond == 0 {
+			return time.Time{}, 0, nil, false, nil
+		}
+		ts := uint64(r.order.Uint32(body[4:]))<<32 | uint64(r.order.Uint32(body[8:]))
+		sec, rem := ts/iface.unitsPerSecond, ts%iface.unitsPerSecond
+		nsec := int64(float64(rem) * 1e9 / float64(iface.unitsPerSecond))
+		return time.Unix(int64(sec), nsec), iface.linkType, body[20 : 20+size], true, nil
+
+	case pcapngSimplePacketType:
+		// Simple packets have no time and belong to the first interface
+		if len(body) < 4 || len(r.interfaces) == 0 {
+			return time.Time{}, 0, nil, false, ErrInvalidFile
+		}
+		frame := body[4:]
+		if size := r.order.Uint32(body); size < uint32(len(frame)) {
+			frame = frame[:size]
+		}
+		return time.Time{}, r.interfaces[0].linkType, frame, true, nil
+	}
+	return time.Time{}, 0, nil, false, nil
+}
+
+// timestampResolution returns the timestamp units per second of an interface
+// with the given options, or zero if the resolution is unsupported. The
+// default resolution is microseconds.
+func (r *Reader) timestampResolution(options []byte) uint64 {
+	for len(options) >= 4 {
+		code, size := r.order.Uint16(options), int(r.order.Uint16(options[2:]))
+		if code == 0 || 4+size > len(options) {
+			break
+		}
+		if code == pcapngTimestampResolution && size >= 1 {
+			exp, base := options[4]&0x7f, 10.0
+			if options[4]&0x80 != 0 {
+				base = 2
+			}
+			units := math.Pow(base, float64(exp))
+			if units >= math.MaxUint64 {
+				return 0
+			}
+			return uint64(units)
+		}
+		options = options[4+(size+3)/4*4:]
+	}
+	return 1e6
+}
+
+// noEOF returns io.ErrUnexpectedEOF instead of io.EOF, for errors that
+// occurred after the header of a record was read.
+func noEOF(err error) error {
+	if err == io.EOF {
+		return io.ErrUnexpectedEOF
+	}
+	return err
+}
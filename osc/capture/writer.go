@@ -0,0 +1,174 @@
+package capture
+
+import (
+	"encoding/binary"
+	"errors"
+	"io"
+	"net"
+	"os"
+	"time"
+
+	"github.com/hypebeast/go-osc/osc"
+)
+
+// ErrAddressFamily is returned by Writer if the source and destination
+// address aren't both IPv4 or both IPv6 addresses.
+var ErrAddressFamily = errors.New("capture: source and destination must have the same IP version")
+
+// ErrDatagramSize is returned by Writer for payloads that don't fit into a
+// UDP datagram.
+var ErrDatagramSize = errors.New("capture: payload too large for a UDP datagram")
+
+// snapLen is the maximum frame size in the header of written files.
+const snapLen = 262144
+
+// Sizes of the headers of written frames.
+const (
+	ethernetHeaderLen = 14
+	ipv4HeaderLen     = 20
+	ipv6HeaderLen     = 40
+	udpHeaderLen      = 8
+)
+
+// Writer writes OSC packets as UDP datagrams to a pcap file. The frames have
+// an Ethernet header with zero MAC addresses, the timestamps have nanosecond
+// resolution.
+type Writer struct {
+	w      io.Writer
+	closer io.Closer
+}
+
+// NewWriter returns a new Writer that writes a pcap file to w. The file
+// header is written immediately.
+func NewWriter(w io.Writer) (*Writer, error) {
+	header := make([]byte, 24)
+	binary.LittleEndian.PutUint32(header, pcapMagicNano)
+	binary.LittleEndian.PutUint16(header[4:], 2) // Version 2.4
+	binary.LittleEndian.PutUint16(header[6:], 4)
+	binary.LittleEndian.PutUint32(header[16:], snapLen)
+	binary.LittleEndian.PutUint32(header[20:], linkTypeEthernet)
+	if _, err := w.Write(header); err != nil {
+		return nil, err
+	}
+	return &Writer{w: w}, nil
+}
+
+// Create creates the capture file with the given name. If the file already
+// exists, it is truncated.
+func Create(name string) (*Writer, error) {
+	f, err := os.Create(name)
+	if err != nil {
+		return nil, err
+	}
+	w, err := NewWriter(f)
+	if err != nil {
+		f.Close()
+		return nil, err
+	}
+	w.closer = f
+	return w, nil
+}
+
+// WritePacket writes the packet as UDP datagram from src to dst, which was
+// captured at the time t.
+func (w *Writer) WritePacket(t time.Time, src, dst *net.UDPAddr, packet osc.Packet) error {
+	data, err := packet.MarshalBinary()
+	if err != nil {
+		return err
+	}
+	return w.WriteDatagram(t, src, dst, data)
+}
+
+// WriteDatagram writes a UDP datagram with the given payload from src to
+// dst, which was captured at the time t.
+func (w *Writer) WriteDatagram(t time.Time, src, dst *net.UDPAddr, payload []byte) error {
+	var ip []byte
+	srcIP, dstIP := src.IP.To4(), dst.IP.To4()
+	switch {
+	case srcIP != nil && dstIP != nil:
+		if ipv4HeaderLen+udpHeaderLen+len(payload) > 0xffff {
+			return ErrDatagramSize
+		}
+		ip = make([]byte, ipv4HeaderLen, ipv4HeaderLen+udpHeaderLen+len(payload))
+		ip[0] = 0x45 // Version and header length
+		binary.BigEndian.PutUint16(ip[2:], uint16(cap(ip)))
+		binary.BigEndian.PutUint16(ip[6:], 0x4000) // Don't fragment
+		ip[8] = 64                                 // TTL
+		ip[9] = protocolUDP
+		copy(ip[12:], srcIP)
+		copy(ip[16:], dstIP)
+		binary.BigEndian.PutUint16(ip[10:], ^checksum(0, ip))
+
+	case src.IP.To16() != nil && dst.IP.To16() != nil && srcIP == nil && dstIP == nil:
+		if udpHeaderLen+len(payload) > 0xffff {
+			return ErrDatagramSize
+		}
+		ip = make([]byte, ipv6HeaderLen, ipv6HeaderLen+udpHeaderLen+len(payload))
+		ip[0] = 0x60 // Version
+		binary.BigEndian.PutUint16(ip[4:], uint16(udpHeaderLen+len(payload)))
+		ip[6] = protocolUDP
+		ip[7] = 64 // Hop limit
+		srcIP, dstIP = src.IP.To16(), dst.IP.To16()
+		copy(ip[8:], srcIP)
+		copy(ip[24:], dstIP)
+
+	default:
+		return ErrAddressFamily
+	}
+
+	udp := make([]byte, udpHeaderLen, udpHeaderLen+len(payload))
+	binary.BigEndian.PutUint16(udp, uint16(src.Port))
+	binary.BigEndian.PutUint16(udp[2:], uint16(dst.Port))
+	binary.BigEndian.PutUint16(udp[4:], uint16(cap(udp)))
+	udp = append(udp, payload...)
+
+	// The checksum covers a pseudo header of the addresses, the protocol and
+	// the UDP length
+	sum := checksum(0, srcIP)
+	sum = checksum(sum, dstIP)
+	sum = checksum(sum, []byte{0, protocolUDP, udp[4], udp[5]})
+	sum = ^checksum(sum, udp)
+	if sum == 0 {
+		sum = 0xffff
+	}
+	binary.BigEndian.PutUint16(udp[6:], sum)
+	ip = append(ip, udp...)
+
+	record := make([]byte, 16+ethernetHeaderLen, 16+ethernetHeaderLen+len(ip))
+	binary.LittleEndian.PutUint32(record, uint32(t.Unix()))
+	binary.LittleEndian.PutUint32(record[4:], uint32(t.Nanosecond()))
+	binary.LittleEndian.PutUint32(record[8:], uint32(ethernetHeaderLen+len(ip)))
+	binary.LittleEndian.PutUint32(record[12:], uint32(ethernetHeaderLen+len(ip)))
+	etherType := uint16(etherTypeIPv4)
+	if ip[0]>>4 == 6 {
+		etherType = etherTypeIPv6
+	}
+	binary.BigEndian.PutUint16(record[16+12:], etherType)
+	record = append(record, ip...)
+	_, err := w.w.Write(record)
+	return err
+}
+
+// Close closes the file if the writer was created by Create.
+func (w *Writer) Close() error {
+	if w.closer == nil {
+		return nil
+	}
+	return w.closer.Close()
+}
+
+// checksum adds data to the ones' complement sum of 16 bit words, see RFC
+// 1071. The checksum is the complement of the sum.
+func checksum(sum uint16, data []byte) uint16 {
+	s := uint32(sum)
+	for i := 0; i+1 < len(data); i += 2 {
+		s += uint32(binary.BigEndian.Uint16(data[i:]))
+	}
+	if len(data)%2 == 1 {
+		s += uint32(data[len(data)-1]) << 8
+	}
+	for s > 0xffff {
+		s = s&0xffff + s>>16
+	}
+	return uint16(s)
+}
@@ -0,0 +1,260 @@
+package osc
+
+import (
+	"errors"
+	"hash/crc32"
+	"sync"
+	"time"
+)
+
+// DefaultChunkSize is the size of the blob chunks if no chunk size is given.
+// Chunk messages of this size fit into a single Ethernet frame.
+const DefaultChunkSize = 1024
+
+// ErrChunkChecksum is passed to BlobAssembler.OnError if a reassembled blob
+// doesn't match the checksum of its chunks.
+var ErrChunkChecksum = errors.New("osc: checksum mismatch of reassembled blob")
+
+// ErrInvalidChunk is passed to BlobAssembler.OnError for messages that
+// aren't chunks.
+var ErrInvalidChunk = errors.New("osc: invalid blob chunk")
+
+// ErrBlobTooLarge is passed to BlobAssembler.OnError for chunks of transfers
+// that exceed MaxChunks or MaxBlobSize. The transfer is discarded.
+var ErrBlobTooLarge = errors.New("osc: blob transfer too large")
+
+// ErrTooManyTransfers is passed to BlobAssembler.OnError for chunks that
+// would start a new transfer while MaxPending transfers are incomplete.
+var ErrTooManyTransfers = errors.New("osc: too many pending blob transfers")
+
+// Default limits of a BlobAssembler, which allow blobs of 16 MiB in chunks of
+// DefaultChunkSize.
+const (
+	DefaultMaxChunks   = 16384
+	DefaultMaxBlobSize = 16 << 20
+	DefaultMaxPending  = 16
+)
+
+// SplitBlob splits data into chunk messages with the given address, whose
+// blobs have at most chunkSize bytes, so that data that doesn't fit into a
+// single datagram can be sent, e.g. a firmware image or an audio sample.
+// Every message has the arguments transfer id, chunk index, number of chunks,
+// CRC-32 (IEEE) checksum of data, and the chunk blob. The id distinguishes
+// concurrent transfers to the same address. If chunkSize isn't positive,
+// DefaultChunkSize is used. Empty data is sent as a single empty chunk.
+func SplitBlob(addr string, id int32, data []byte, chunkSize int) []*Message {
+	if chunkSize <= 0 {
+		chunkSize = DefaultChunkSize
+	}
+	total := (len(data) + chunkSize - 1) / chunkSize
+	if total == 0 {
+		total = 1
+	}
+	checksum := int32(crc32.ChecksumIEEE(data))
+
+	msgs := make([]*Message, 0, total)
+	for i := 0; i < total; i++ {
+		end := (i + 1) * chunkSize
+		if end > len(data) {
+			end = len(data)
+		}
+		chunk := data[i*chunkSize : end]
+		msgs = append(msgs, NewMessage(addr, id, int32(i), int32(total), checksum, chunk))
+	}
+	return msgs
+}
+
+// SendBlob splits data with SplitBlob and sends the chunks with sender in
+// order. It returns the first error of sender.
+func SendBlob(sender Sender, addr string, id int32, data []byte, chunkSize int) error {
+	for _, msg := range SplitBlob(addr, id, data, chunkSize) {
+		if _, err := sender.Send(msg); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// BlobAssembler reassembles the blobs that were split with SplitBlob. It
+// implements the Handler interface, so it can be registered as the handler
+// for the address of the chunks. Chunks may arrive in any order, duplicates
+// are ignored. A BlobAssembler is safe for concurrent use.
+type BlobAssembler struct {
+	// Timeout discards incomplete transfers that received no chunk for
+	// Timeout, e.g. because chunks were lost. A zero value keeps them until
+	// they are complete.
+	Timeout time.Duration
+
+	// MaxChunks is the maximum number of chunks of a transfer, MaxBlobSize
+	// the maximum size of its received chunks. Chunks of larger transfers are
+	// rejected, since the number of chunks is sent by the peer and would
+	// otherwise allow it to allocate any amount of memory. If they are zero,
+	// DefaultMaxChunks and DefaultMaxBlobSize are used.
+	MaxChunks   int
+	MaxBlobSize int
+
+	// MaxPending is the maximum number of incomplete transfers. Chunks of
+	// further transfers are rejected until a transfer completes or times
+	// out. If it is zero, DefaultMaxPending is used.
+	MaxPending int
+
+	// OnError is called with the address and transfer id for blobs that
+	// don't match their checksum, for messages that aren't chunks and for
+	// chunks that exceed the limits. The errors are ignored if OnError is
+	// nil.
+	OnError func(addr string, id int32, err error)
+
+	onComplete func(addr string, id int32, data []byte)
+
+	mu        sync.Mutex
+	transfers map[blobTransferKey]*blobTransfer
+}
+
+// Verify that BlobAssembler implements the Handler interface.
+var _ Handler = (*BlobAssembler)(nil)
+
+type blobTransferKey struct {
+	addr string
+	id   int32
+}
+
+// blobTransfer is an incomplete blob.
+type blobTransfer struct {
+	chunks   [][]byte
+	missing  int
+	checksum int32
+	size     int // Size of the received chunks
+	updated  time.Time
+}
+
+// NewBlobAssembler returns a BlobAssembler that calls onComplete with every
+// reassembled blob, its address and transfer id.
+func NewBlobAssembler(onComplete func(addr string, id int32, data []byte)) *BlobAssembler {
+	return &BlobAssembler{
+		onComplete: onComplete,
+		transfers:  make(map[blobTransferKey]*blobTransfer),
+	}
+}
+
+// Pending returns the number of incomplete transfers.
+func (a *BlobAssembler) Pending() int {
+	a.mu.Lock()
+	defer a.mu.Unlock()
+	return len(a.transfers)
+}
+
+// HandleMessage adds the chunk msg to its transfer. Implements the Handler
+// interface.
+func (a *BlobAssembler) HandleMessage(msg *Message) {
+	id, index, total, checksum, chunk, ok := parseChunk(msg)
+	if !ok {
+		a.fail(msg.Address, id, ErrInvalidChunk)
+		return
+	}
+
+	maxChunks, maxSize, maxPending := a.limits()
+	if int(total) > maxChunks || len(chunk) > maxSize {
+		a.fail(msg.Address, id, ErrBlobTooLarge)
+		return
+	}
+
+	key := blobTransferKey{msg.Address, id}
+	now := time.Now()
+	a.mu.Lock()
+	a.expire(now)
+	t := a.transfers[key]
+	if t == nil || len(t.chunks) != int(total) || t.checksum != checksum {
+		if t == nil && len(a.transfers) >= maxPending {
+			a.mu.Unlock()
+			a.fail(msg.Address, id, ErrTooManyTransfers)
+			return
+		}
+		t = &blobTransfer{chunks: make([][]byte, total), missing: int(total), checksum: checksum}
+		a.transfers[key] = t
+	}
+	t.updated = now
+	if t.chunks[index] == nil {
+		if t.size+len(chunk) > maxSize {
+			delete(a.transfers, key)
+			a.mu.Unlock()
+			a.fail(msg.Address, id, ErrBlobTooLarge)
+			return
+		}
+		// Received blobs may be recycled after the handler returned
+		t.chunks[index] = append([]byte{}, chunk...)
+		t.size += len(chunk)
+		t.missing--
+	}
+	if t.missing > 0 {
+		a.mu.Unlock()
+		return
+	}
+	delete(a.transfers, key)
+	a.mu.Unlock()
+
+	data := make([]byte, 0, t.size)
+	for _, c := range t.chunks {
+		data = append(data, c...)
+	}
+	if int32(crc32.ChecksumIEEE(data)) != t.checksum {
+		a.fail(msg.Address, id, ErrChunkChecksum)
+		return
+	}
+	if a.onComplete != nil {
+		a.onComplete(msg.Address, id, data)
+	}
+}
+
+// limits returns the limits of the assembler, with the defaults for unset
+// ones.
+func (a *BlobAssembler) limits() (maxChunks, maxSize, maxPending int) {
+	maxChunks, maxSize, maxPending = a.MaxChunks, a.MaxBlobSize, a.MaxPending
+	if maxChunks <= 0 {
+		maxChunks = DefaultMaxChunks
+	}
+	if maxSize <= 0 {
+		maxSize = DefaultMaxBlobSize
+	}
+	if maxPending <= 0 {
+		maxPending = DefaultMaxPending
+	}
+	return maxChunks, maxSize, maxPending
+}
+
+// expire discards the transfers that timed out. The caller must hold the
+// lock.
+func (a *BlobAssembler) expire(now time.Time) {
+	if a.Timeout <= 0 {
+		return
+	}
+	for key, t := range a.transfers {
+		if now.Sub(t.updated) > a.Timeout {
+			delete(a.transfers, key)
+		}
+	}
+}
+
+func (a *BlobAssembler) fail(addr string, id int32, err error) {
+	if a.OnError != nil {
+		a.OnError(addr, id, err)
+	}
+}
+
+// parseChunk returns the arguments of a chunk message.
+func parseChunk(msg *Message) (id, index, total, checksum int32, chunk []byte, ok bool) {
+	if len(msg.Arguments) != 5 {
+		return 0, 0, 0, 0, nil, false
+	}
+	var ints [4]int32
+	for i := range ints {
+		if ints[i], ok = msg.Arguments[i].(int32); !ok {
+			return 0, 0, 0, 0, nil, false
+		}
+	}
+	chunk, ok = msg.Arguments[4].([]byte)
+	id, index, total, checksum = ints[0], ints[1], ints[2], ints[3]
+	if !ok || total <= 0 || index < 0 || index >= total {
+		return id, 0, 0, 0, nil, false
+	}
+	return id, index, total, checksum, chunk, true
+}
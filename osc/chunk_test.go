@@ -0,0 +1,134 @@
+package osc
+
+import (
+	"bytes"
+	"math/rand"
+	"testing"
+	"time"
+)
+
+func TestSplitBlob(t *testing.T) {
+	data := make([]byte, 10000)
+	rand.New(rand.NewSource(1)).Read(data)
+
+	type blob struct {
+		addr string
+		id   int32
+		data []byte
+	}
+	var completed []blob
+	var errs []error
+	a := NewBlobAssembler(func(addr string, id int32, data []byte) {
+		completed = append(completed, blob{addr, id, data})
+	})
+	a.OnError = func(addr string, id int32, err error) { errs = append(errs, err) }
+
+	msgs := SplitBlob("/firmware", 7, data, 0)
+	if len(msgs) != 10 {
+		t.Fatalf("SplitBlob() returned %d chunks, want = 10", len(msgs))
+	}
+	for _, msg := range msgs {
+		if b, err := msg.MarshalBinary(); err != nil || len(b) > 1472 {
+			t.Errorf("chunk of %d bytes, %v exceeds an Ethernet frame", len(b), err)
+		}
+	}
+
+	// Chunks arrive out of order and twice, interleaved with another transfer
+	other := SplitBlob("/firmware", 8, []byte("small"), 0)
+	for i := len(msgs) - 1; i >= 0; i-- {
+		a.HandleMessage(msgs[i])
+		if i == 5 {
+			a.HandleMessage(msgs[i])
+			a.HandleMessage(other[0])
+		}
+	}
+	if len(completed) != 2 || a.Pending() != 0 {
+		t.Fatalf("completed %d transfers, %d pending, want = 2, 0", len(completed), a.Pending())
+	}
+	if c := completed[0]; c.addr != "/firmware" || c.id != 8 || string(c.data) != "small" {
+		t.Errorf("first transfer = %s %d %q, want = /firmware 8 small", c.addr, c.id, c.data)
+	}
+	if c := completed[1]; c.id != 7 || !bytes.Equal(c.data, data) {
+		t.Errorf("second transfer %d has %d bytes, want = 7 with the original data", c.id, len(c.data))
+	}
+
+	// Corrupted chunks are reported
+	msgs = SplitBlob("/firmware", 9, data, 4096)
+	msgs[1].Arguments[4] = make([]byte, 4096)
+	for _, msg := range msgs {
+		a.HandleMessage(msg)
+	}
+	a.HandleMessage(NewMessage("/firmware", int32(1)))
+	if len(errs) != 2 || errs[0] != ErrChunkChecksum || errs[1] != ErrInvalidChunk || len(completed) != 2 {
+		t.Errorf("errors = %v, want = [%v %v]", errs, ErrChunkChecksum, ErrInvalidChunk)
+	}
+
+	// Empty blobs are a single chunk
+	if msgs := SplitBlob("/empty", 1, nil, 0); len(msgs) != 1 {
+		t.Errorf("SplitBlob() of empty data returned %d chunks, want = 1", len(msgs))
+	}
+}
+
+func TestBlobAssembler_Timeout(t *testing.T) {
+	a := NewBlobAssembler(nil)
+	a.Timeout = 10 * time.Millisecond
+	msgs := SplitBlob("/sample", 1, make([]byte, 3000), 0)
+	a.HandleMessage(msgs[0])
+	time.Sleep(20 * time.Millisecond)
+	a.HandleMessage(SplitBlob("/sample", 2, make([]byte, 3000), 0)[0])
+	if n := a.Pending(); n != 1 {
+		t.Errorf("Pending() = %d, want = 1", n)
+	}
+}
+
+func TestBlobAssembler_Limits(t *testing.T) {
+	var errs []error
+	a := NewBlobAssembler(nil)
+	a.OnError = func(addr string, id int32, err error) { errs = append(errs, err) }
+
+	// A huge chunk count must not be allocated
+	a.HandleMessage(NewMessage("/firmware", int32(1), int32(0), int32(2147483647), int32(0), []byte{1}))
+	if len(errs) != 1 || errs[0] != ErrBlobTooLarge || a.Pending() != 0 {
+		t.Errorf("errors = %v, pending = %d, want = [%v], 0", errs, a.Pending(), ErrBlobTooLarge)
+	}
+
+	// Transfers whose chunks exceed MaxBlobSize are discarded
+	errs = nil
+	a.MaxBlobSize = 6
+	msgs := SplitBlob("/firmware", 2, []byte("0123456789"), 4)
+	a.HandleMessage(msgs[0])
+	a.HandleMessage(msgs[1])
+	if len(errs) != 1 || errs[0] != ErrBlobTooLarge || a.Pending() != 0 {
+		t.Errorf("errors = %v, pending = %d, want = [%v], 0", errs, a.Pending(), ErrBlobTooLarge)
+	}
+
+	// New transfers are rejected while MaxPending transfers are incomplete
+	errs = nil
+	a.MaxBlobSize = 0
+	a.MaxPending = 2
+	for id := int32(0); id < 3; id++ {
+		a.HandleMessage(SplitBlob("/firmware", id, []byte("0123456789"), 4)[0])
+	}
+	if len(errs) != 1 || errs[0] != ErrTooManyTransfers || a.Pending() != 2 {
+		t.Errorf("errors = %v, pending = %d, want = [%v], 2", errs, a.Pending(), ErrTooManyTransfers)
+	}
+	// Chunks of pending transfers are still accepted
+	a.HandleMessage(SplitBlob("/firmware", 1, []byte("0123456789"), 4)[1])
+	if len(errs) != 1 {
+		t.Errorf("errors = %v, want = [%v]", errs, ErrTooManyTransfers)
+	}
+}
+
+func TestSendBlob(t *testing.T) {
+	sender := &recordingSender{}
+	if err := SendBlob(sender, "/scene", 3, []byte("0123456789"), 4); err != nil {
+		t.Fatal(err)
+	}
+	msgs := sender.messages()
+	if len(msgs) != 3 {
+		t.Fatalf("sent %d chunks, want = 3", len(msgs))
+	}
+	if chunk := msgs[2].Arguments[4].([]byte); string(chunk) != "89" {
+		t.Errorf("last chunk = %q, want = 89", chunk)
+	}
+}
@@ -0,0 +1,170 @@
+package osc
+
+import (
+	"net"
+	"sync"
+	"time"
+)
+
+// ClientPool sends OSC packets to many destinations. It keeps one UDP
+// connection per destination address, which is dialed on the first send to the
+// address and closed after it wasn't used for the idle timeout. A ClientPool is
+// safe for concurrent use.
+type ClientPool struct {
+	// WriteTimeout limits the time a send may take. A zero value disables the
+	// timeout.
+	WriteTimeout time.Duration
+
+	idleTimeout time.Duration
+
+	mu     sync.Mutex
+	conns  map[string]*poolConn
+	closed bool
+}
+
+// poolConn is a connection of a ClientPool.
+type poolConn struct {
+	conn     *net.UDPConn
+	lastUsed time.Time
+	active   int // Number of sends in progress
+	timer    *time.Timer
+}
+
+// NewClientPool returns a new ClientPool that closes connections that weren't
+// used for idleTimeout. A zero idleTimeout keeps connections open until the
+// pool is closed.
+func NewClientPool(idleTimeout time.Duration) *ClientPool {
+	return &ClientPool{
+		idleTimeout: idleTimeout,
+		conns:       make(map[string]*poolConn),
+	}
+}
+
+// SendTo sends an OSC Bundle or an OSC Message to addr, which has the form
+// "host:port". It returns the number of bytes that were sent. Returns
+// ErrClientClosed if the pool was closed.
+func (p *ClientPool) SendTo(addr string, packet Packet) (int, error) {
+	data, err := packet.MarshalBinary()
+	if err != nil {
+		return 0, err
+	}
+
+	pc, err := p.acquire(addr)
+	if err != nil {
+		return 0, err
+	}
+	defer p.release(pc)
+
+	if p.WriteTimeout != 0 {
+		if err = pc.conn.SetWriteDeadline(time.Now().Add(p.WriteTimeout)); err != nil {
+			return 0, err
+		}
+	}
+	n, err := pc.conn.Write(data)
+	if err != nil {
+		// Redial on the next send, e.g. after the destination was unreachable
+		p.remove(addr, pc)
+	}
+	return n, err
+}
+
+// Len returns the number of open connections.
+func (p *ClientPool) Len() int {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	return len(p.conns)
+}
+
+// Close closes all connections. Sends after Close fail with ErrClientClosed.
+func (p *ClientPool) Close() error {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	var err error
+	for addr, pc := range p.conns {
+		if pc.timer != nil {
+			pc.timer.Stop()
+		}
+		if cerr := pc.conn.Close(); cerr != nil && err == nil {
+			err = cerr
+		}
+		delete(p.conns, addr)
+	}
+	p.closed = true
+	return err
+}
+
+// acquire returns the connection to addr and dials it if necessary. The
+// connection can't be closed for being idle until it is released.
+func (p *ClientPool) acquire(addr string) (*poolConn, error) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	if p.closed {
+		return nil, ErrClientClosed
+	}
+
+	pc, ok := p.conns[addr]
+	if !ok {
+		raddr, err := net.ResolveUDPAddr("udp", addr)
+		if err != nil {
+			return nil, err
+		}
+		conn, err := net.DialUDP("udp", nil, raddr)
+		if err != nil {
+			return nil, err
+		}
+		pc = &poolConn{conn: conn, lastUsed: time.Now()}
+		if p.idleTimeout > 0 {
+			pc.timer = time.AfterFunc(p.idleTimeout, func() { p.closeIdle(addr, pc) })
+		}
+		p.conns[addr] = pc
+	}
+	pc.active++
+	return pc, nil
+}
+
+// release marks the end of a send on pc.
+func (p *ClientPool) release(pc *poolConn) {
+	p.mu.Lock()
+	pc.active--
+	pc.lastUsed = time.Now()
+	p.mu.Unlock()
+}
+
+// remove closes pc and removes it from the pool, if it is still the connection
+// to addr.
+func (p *ClientPool) remove(addr string, pc *poolConn) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	if p.conns[addr] != pc {
+		return
+	}
+	if pc.timer != nil {
+		pc.timer.Stop()
+	}
+	pc.conn.Close()
+	delete(p.conns, addr)
+}
+
+// closeIdle closes pc if it wasn't used for the idle timeout. Otherwise the
+// check is rescheduled.
+func (p *ClientPool) closeIdle(addr string, pc *poolConn) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	if p.conns[addr] != pc {
+		return
+	}
+	if pc.active > 0 {
+		pc.timer.Reset(p.idleTimeout)
+		return
+	}
+	if idle := time.Since(pc.lastUsed); idle < p.idleTimeout {
+		pc.timer.Reset(p.idleTimeout - idle)
+		return
+	}
+	pc.conn.Close()
+	delete(p.conns, addr)
+}
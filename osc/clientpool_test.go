@@ -0,0 +1,98 @@
+package osc
+
+import (
+	"net"
+	"testing"
+	"time"
+)
+
+func TestClientPool_SendTo(t *testing.T) {
+	var conns []net.PacketConn
+	for i := 0; i < 3; i++ {
+		conn, err := net.ListenPacket("udp", "127.0.0.1:0")
+		if err != nil {
+			t.Fatal(err)
+		}
+		defer conn.Close()
+		conns = append(conns, conn)
+	}
+
+	pool := NewClientPool(0)
+	defer pool.Close()
+
+	server := &Server{ReadTimeout: 5 * time.Second}
+	for round := 0; round < 2; round++ {
+		for i, conn := range conns {
+			msg := NewMessage("/cue", int32(i))
+			if _, err := pool.SendTo(conn.LocalAddr().String(), msg); err != nil {
+				t.Fatal(err)
+			}
+			p, err := server.ReceivePacket(conn)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if !p.(*Message).Equals(msg) {
+				t.Errorf("received %v, want = %v", p, msg)
+			}
+		}
+	}
+	if got, want := pool.Len(), len(conns); got != want {
+		t.Errorf("Len() = %d, want = %d", got, want)
+	}
+
+	if err := pool.Close(); err != nil {
+		t.Fatal(err)
+	}
+	if pool.Len() != 0 {
+		t.Errorf("Len() = %d after Close, want = 0", pool.Len())
+	}
+	if _, err := pool.SendTo(conns[0].LocalAddr().String(), NewMessage("/cue")); err != ErrClientClosed {
+		t.Errorf("SendTo() after Close error = %v, want = %v", err, ErrClientClosed)
+	}
+}
+
+func TestClientPool_IdleTimeout(t *testing.T) {
+	conn, err := net.ListenPacket("udp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer conn.Close()
+
+	pool := NewClientPool(20 * time.Millisecond)
+	defer pool.Close()
+
+	if _, err := pool.SendTo(conn.LocalAddr().String(), NewMessage("/cue")); err != nil {
+		t.Fatal(err)
+	}
+	if pool.Len() != 1 {
+		t.Fatalf("Len() = %d, want = 1", pool.Len())
+	}
+
+	deadline := time.Now().Add(5 * time.Second)
+	for pool.Len() != 0 {
+		if time.Now().After(deadline) {
+			t.Fatal("idle connection wasn't closed")
+		}
+		time.Sleep(5 * time.Millisecond)
+	}
+
+	// The connection is dialed again on the next send
+	if _, err := pool.SendTo(conn.LocalAddr().String(), NewMessage("/cue")); err != nil {
+		t.Fatal(err)
+	}
+	if pool.Len() != 1 {
+		t.Errorf("Len() = %d, want = 1", pool.Len())
+	}
+}
+
+func TestClientPool_InvalidAddress(t *testing.T) {
+	pool := NewClientPool(0)
+	defer pool.Close()
+
+	if _, err := pool.SendTo("not an address", NewMessage("/cue")); err == nil {
+		t.Error("expected error for invalid address")
+	}
+	if pool.Len() != 0 {
+		t.Errorf("Len() = %d, want = 0", pool.Len())
+	}
+}
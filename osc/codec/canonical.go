@@ -0,0 +1,75 @@
+package codec
+
+import (
+	"fmt"
+	"math"
+	"time"
+)
+
+// Bits of the quiet NaNs that all NaN arguments are encoded as by
+// CanonicalBytes.
+const (
+	canonicalNaN32 = 0x7fc00000
+	canonicalNaN64 = 0x7ff8000000000000
+)
+
+// CanonicalBytes returns the canonical encoding of the message, which is
+// guaranteed to stay the same in all future versions of the package, so that
+// checksums of it can be stored, e.g. to detect duplicate messages or changed
+// cues. MarshalBinary returns the same bytes for most messages, but without
+// this guarantee. Messages that are equal according to Equals have the same
+// canonical encoding.
+//
+// The canonical encoding is the OSC 1.0 encoding of the address, the type
+// tags and the arguments in their order, with the following rules:
+//   - the address is validated, even if SkipValidation is set
+//   - float NaNs are encoded as the quiet NaN 0x7fc00000 or
+//     0x7ff8000000000000, negative zeros as positive zeros
+//   - time.Time and Timetag arguments are encoded as 't', nil and Nil as 'N'
+//   - arrays are encoded with the OSC 1.1 '[' and ']' tags
+//
+// Returns an error for arguments of custom types, see RegisterType, because
+// their encoding is defined by their codec.
+func (msg *Message) CanonicalBytes() ([]byte, error) {
+	args, err := canonicalArguments(msg.Arguments)
+	if err != nil {
+		return nil, err
+	}
+	canonical := &Message{Address: msg.Address, Arguments: args}
+	return canonical.MarshalBinary()
+}
+
+// canonicalArguments returns a copy of args with canonical floats. Returns an
+// error for arguments of custom types.
+func canonicalArguments(args []interface{}) ([]interface{}, error) {
+	canonical := make([]interface{}, len(args))
+	for i, arg := range args {
+		switch t := arg.(type) {
+		case float32:
+			switch {
+			case t != t:
+				arg = math.Float32frombits(canonicalNaN32)
+			case t == 0:
+				arg = float32(0)
+			}
+		case float64:
+			switch {
+			case t != t:
+				arg = math.Float64frombits(canonicalNaN64)
+			case t == 0:
+				arg = float64(0)
+			}
+		case []interface{}:
+			nested, err := canonicalArguments(t)
+			if err != nil {
+				return nil, err
+			}
+			arg = nested
+		case nil, Nil, bool, Impulse, Char, MIDI, RGBA, int32, int64, string, []byte, Timetag, time.Time:
+		default:
+			return nil, fmt.Errorf("osc: argument %d of type %T has no canonical encoding", i, arg)
+		}
+		canonical[i] = arg
+	}
+	return canonical, nil
+}
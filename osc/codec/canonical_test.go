@@ -0,0 +1,64 @@
+package codec
+
+import (
+	"bytes"
+	"encoding/hex"
+	"math"
+	"testing"
+	"time"
+)
+
+func TestMessage_CanonicalBytes(t *testing.T) {
+	// The canonical encoding must never change
+	msg := NewMessage("/cue/1", int32(1), float32(0.5), "go", []byte{1}, int64(-1), 0.25, true, nil,
+		Impulse{}, Char('a'), MIDI{1, 0x90, 60, 100}, RGBA{1, 2, 3, 4}, *NewTimetagFromTimetag(1),
+		[]interface{}{int32(2)})
+	want := "2f6375652f310000" + // Address
+		"2c69667362686454" + "4e49636d72745b695d000000" + // Type tags
+		"00000001" + "3f000000" + "676f0000" + "0000000101000000" +
+		"ffffffffffffffff" + "3fd0000000000000" + "00000061" + "01903c64" +
+		"01020304" + "0000000000000001" + "00000002"
+	got, err := msg.CanonicalBytes()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if hex.EncodeToString(got) != want {
+		t.Errorf("CanonicalBytes() = %x, want = %s", got, want)
+	}
+
+	// Equal messages have the same encoding
+	for _, pair := range [][2]*Message{
+		{
+			NewMessage("/a", float32(math.NaN()), math.NaN()),
+			NewMessage("/a", math.Float32frombits(0x7fc00123), math.Float64frombits(0xfff8000000000001)),
+		},
+		{NewMessage("/a", float32(0), 0.0), NewMessage("/a", float32(math.Copysign(0, -1)), math.Copysign(0, -1))},
+		{NewMessage("/a", nil), NewMessage("/a", Nil{})},
+		{NewMessage("/a", []interface{}{math.NaN()}), NewMessage("/a", []interface{}{-math.NaN()})},
+	} {
+		a, errA := pair[0].CanonicalBytes()
+		b, errB := pair[1].CanonicalBytes()
+		if errA != nil || errB != nil || !bytes.Equal(a, b) {
+			t.Errorf("CanonicalBytes() = %x, %x, want equal bytes", a, b)
+		}
+	}
+
+	timetag := NewMessage("/a", *NewTimetag(time.Unix(1, 0)))
+	stdTime := NewMessage("/a", time.Unix(1, 0))
+	a, _ := timetag.CanonicalBytes()
+	b, _ := stdTime.CanonicalBytes()
+	if !bytes.Equal(a, b) {
+		t.Errorf("CanonicalBytes() = %x for Timetag, %x for time.Time, want equal bytes", a, b)
+	}
+}
+
+func TestMessage_CanonicalBytes_errors(t *testing.T) {
+	invalid := &Message{Address: "no/slash", SkipValidation: true}
+	if _, err := invalid.CanonicalBytes(); err == nil {
+		t.Error("CanonicalBytes() = nil error for an invalid address, want error")
+	}
+	custom := NewMessage("/a", []interface{}{uint8(1)})
+	if _, err := custom.CanonicalBytes(); err == nil {
+		t.Error("CanonicalBytes() = nil error for a custom type, want error")
+	}
+}
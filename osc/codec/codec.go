@@ -0,0 +1,234 @@
+package codec
+
+import (
+	"errors"
+	"fmt"
+	"math"
+	"strings"
+	"sync"
+)
+
+// ErrUnsupportedType is returned by the encoder of a custom type, see
+// RegisterType, if it doesn't support the given value.
+var ErrUnsupportedType = errors.New("osc: unsupported argument type")
+
+// typeCodec encodes and decodes the arguments of a custom type tag.
+type typeCodec struct {
+	tag byte
+	enc func(v interface{}, w *Writer) error
+	dec func(r *Reader) (interface{}, error)
+}
+
+// codecs holds the registered custom types in the order of registration.
+var codecs struct {
+	sync.RWMutex
+	list []*typeCodec
+}
+
+// builtinTags are the type tags that are handled by this package and can't be
+// registered.
+const builtinTags = "ihfdsbtcmrNITF[],"
+
+// RegisterType registers a custom argument type with the given type tag, e.g.
+// a proprietary type of a hardware device. Registering a tag again replaces
+// its codec. The type tags of this package can't be registered.
+//
+// When a message is encoded, the encoders of all registered types are tried
+// in the order of registration for arguments of types that aren't supported
+// by this package. An encoder must return ErrUnsupportedType if it doesn't
+// support the value. When a message is decoded, arguments with the tag are
+// decoded by dec. The data written and read by a codec is padded to a
+// multiple of 4 bytes. Numbers are big-endian regardless of the decode
+// options.
+func RegisterType(tag byte, enc func(v interface{}, w *Writer) error, dec func(r *Reader) (interface{}, error)) error {
+	if strings.IndexByte(builtinTags, tag) >= 0 || tag == 0 {
+		return fmt.Errorf("osc: type tag %q is reserved", tag)
+	}
+	if enc == nil || dec == nil {
+		return errors.New("osc: RegisterType needs an encoder and a decoder")
+	}
+
+	codecs.Lock()
+	defer codecs.Unlock()
+	c := &typeCodec{tag: tag, enc: enc, dec: dec}
+	for i, registered := range codecs.list {
+		if registered.tag == tag {
+			codecs.list[i] = c
+			return nil
+		}
+	}
+	codecs.list = append(codecs.list, c)
+	return nil
+}
+
+// UnregisterType removes the custom type with the given type tag.
+func UnregisterType(tag byte) {
+	codecs.Lock()
+	defer codecs.Unlock()
+	for i, c := range codecs.list {
+		if c.tag == tag {
+			codecs.list = append(codecs.list[:i], codecs.list[i+1:]...)
+			return
+		}
+	}
+}
+
+// codecForTag returns the codec of a custom type tag, or nil if the tag isn't
+// registered.
+func codecForTag(tag byte) *typeCodec {
+	codecs.RLock()
+	defer codecs.RUnlock()
+	for _, c := range codecs.list {
+		if c.tag == tag {
+			return c
+		}
+	}
+	return nil
+}
+
+// RegisteredTags returns the type tags of the registered custom types in the
+// order of registration.
+func RegisteredTags() string {
+	codecs.RLock()
+	defer codecs.RUnlock()
+	tags := make([]byte, len(codecs.list))
+	for i, c := range codecs.list {
+		tags[i] = c.tag
+	}
+	return string(tags)
+}
+
+// appendCustom appends arg to buf using the first registered codec that
+// supports it and returns its type tag.
+func appendCustom(buf []byte, arg interface{}) ([]byte, byte, error) {
+	codecs.RLock()
+	list := codecs.list
+	codecs.RUnlock()
+
+	start := len(buf)
+	for _, c := range list {
+		w := Writer{buf: buf[:start]}
+		err := c.enc(arg, &w)
+		if err == ErrUnsupportedType {
+			continue
+		}
+		if err != nil {
+			return nil, 0, err
+		}
+		for i := blobPadBytesNeeded(len(w.buf) - start); i > 0; i-- {
+			w.buf = append(w.buf, 0)
+		}
+		return w.buf, c.tag, nil
+	}
+	return nil, 0, fmt.Errorf("OSC - unsupported type: %T", arg)
+}
+
+// readCustom decodes an argument with the custom type tag of c.
+func (r *byteReader) readCustom(c *typeCodec) (interface{}, error) {
+	start := r.pos
+	v, err := c.dec(&Reader{r: r})
+	if err != nil {
+		return nil, err
+	}
+	n := r.pos - start
+	if n += blobPadBytesNeeded(n); n > len(r.data)-start {
+		return nil, r.errorAt(r.pos, ErrUnexpectedEOF)
+	}
+	r.pos = start + n
+	return v, nil
+}
+
+// Writer writes the data of a custom argument type, see RegisterType.
+type Writer struct {
+	buf []byte
+}
+
+// WriteInt32 writes a 32-bit integer.
+func (w *Writer) WriteInt32(v int32) {
+	w.buf = appendUint32(w.buf, uint32(v))
+}
+
+// WriteInt64 writes a 64-bit integer.
+func (w *Writer) WriteInt64(v int64) {
+	w.buf = appendUint64(w.buf, uint64(v))
+}
+
+// WriteFloat32 writes a 32-bit float.
+func (w *Writer) WriteFloat32(v float32) {
+	w.buf = appendUint32(w.buf, math.Float32bits(v))
+}
+
+// WriteFloat64 writes a 64-bit float.
+func (w *Writer) WriteFloat64(v float64) {
+	w.buf = appendUint64(w.buf, math.Float64bits(v))
+}
+
+// WriteString writes a null-terminated string that is padded to a multiple
+// of 4 bytes.
+func (w *Writer) WriteString(s string) {
+	w.buf = appendPaddedString(w.buf, s)
+}
+
+// WriteBlob writes a size-prefixed blob that is padded to a multiple of 4
+// bytes.
+func (w *Writer) WriteBlob(b []byte) {
+	w.buf = appendBlob(w.buf, b)
+}
+
+// WriteBytes writes b without size and padding.
+func (w *Writer) WriteBytes(b []byte) {
+	w.buf = append(w.buf, b...)
+}
+
+// Reader reads the data of a custom argument type, see RegisterType. Reading
+// beyond the end of the message returns a *DecodeError.
+type Reader struct {
+	r *byteReader
+}
+
+// ReadInt32 reads a 32-bit integer.
+func (r *Reader) ReadInt32() (int32, error) {
+	v, err := r.r.readUint32()
+	return int32(v), err
+}
+
+// ReadInt64 reads a 64-bit integer.
+func (r *Reader) ReadInt64() (int64, error) {
+	v, err := r.r.readUint64()
+	return int64(v), err
+}
+
+// ReadFloat32 reads a 32-bit float.
+func (r *Reader) ReadFloat32() (float32, error) {
+	v, err := r.r.readUint32()
+	return math.Float32frombits(v), err
+}
+
+// ReadFloat64 reads a 64-bit float.
+func (r *Reader) ReadFloat64() (float64, error) {
+	v, err := r.r.readUint64()
+	return math.Float64frombits(v), err
+}
+
+// ReadString reads a null-terminated string that is padded to a multiple of
+// 4 bytes.
+func (r *Reader) ReadString() (string, error) {
+	return r.r.readPaddedString()
+}
+
+// ReadBlob reads a size-prefixed blob that is padded to a multiple of 4
+// bytes.
+func (r *Reader) ReadBlob() ([]byte, error) {
+	return r.r.readBlob()
+}
+
+// ReadBytes reads n bytes without size and padding. The returned slice is a
+// copy.
+func (r *Reader) ReadBytes(n int) ([]byte, error) {
+	if n < 0 || n > r.r.remaining() {
+		return nil, r.r.errorAt(r.r.pos, ErrUnexpectedEOF)
+	}
+	b := append([]byte(nil), r.r.data[r.r.pos:r.r.pos+n]...)
+	r.r.pos += n
+	return b, nil
+}
@@ -0,0 +1,95 @@
+package codec
+
+import (
+	"reflect"
+	"testing"
+)
+
+// vendorValue is a proprietary argument type of a fictional device.
+type vendorValue struct {
+	ID    int32
+	Name  string
+	Flags []byte
+}
+
+func encodeVendor(v interface{}, w *Writer) error {
+	x, ok := v.(vendorValue)
+	if !ok {
+		return ErrUnsupportedType
+	}
+	w.WriteInt32(x.ID)
+	w.WriteString(x.Name)
+	w.WriteBytes(x.Flags[:3])
+	return nil
+}
+
+func decodeVendor(r *Reader) (interface{}, error) {
+	var x vendorValue
+	var err error
+	if x.ID, err = r.ReadInt32(); err != nil {
+		return nil, err
+	}
+	if x.Name, err = r.ReadString(); err != nil {
+		return nil, err
+	}
+	if x.Flags, err = r.ReadBytes(3); err != nil {
+		return nil, err
+	}
+	return x, nil
+}
+
+func TestRegisterType(t *testing.T) {
+	if err := RegisterType('X', encodeVendor, decodeVendor); err != nil {
+		t.Fatal(err)
+	}
+	defer UnregisterType('X')
+
+	msg := NewMessage("/vendor", int32(1), vendorValue{7, "fx", []byte{1, 2, 3}}, "end")
+	if tags, err := msg.TypeTags(); err != nil || tags != ",iXs" {
+		t.Fatalf("TypeTags() = '%s', %v, want = ',iXs'", tags, err)
+	}
+	data, err := msg.MarshalBinary()
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := "/vendor\x00,iXs\x00\x00\x00\x00" + "\x00\x00\x00\x01" +
+		"\x00\x00\x00\x07fx\x00\x00\x01\x02\x03\x00" + "end\x00"
+	if string(data) != want {
+		t.Errorf("MarshalBinary() = % x, want = % x", data, want)
+	}
+
+	p, err := ParsePacket(string(data))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !reflect.DeepEqual(p.(*Message).Arguments, msg.Arguments) {
+		t.Errorf("decoded arguments = %#v, want = %#v", p.(*Message).Arguments, msg.Arguments)
+	}
+
+	if _, err := ParsePacket("/vendor\x00,X\x00\x00\x00\x00\x00\x07fx\x00\x00"); err == nil {
+		t.Error("expected error for truncated custom argument")
+	}
+	if _, err := NewMessage("/vendor", struct{}{}).MarshalBinary(); err == nil {
+		t.Error("expected error for unsupported type")
+	}
+
+	UnregisterType('X')
+	if _, err := msg.MarshalBinary(); err == nil {
+		t.Error("expected error after UnregisterType")
+	}
+	if _, err := ParsePacket(string(data)); err == nil {
+		t.Error("expected error for unknown type tag after UnregisterType")
+	}
+}
+
+func TestRegisterType_Reserved(t *testing.T) {
+	for _, tag := range []byte("ifsb[],") {
+		if err := RegisterType(tag, encodeVendor, decodeVendor); err == nil {
+			t.Errorf("RegisterType(%q) expected error", tag)
+			UnregisterType(tag)
+		}
+	}
+	if err := RegisterType('Y', nil, decodeVendor); err == nil {
+		t.Error("RegisterType without encoder expected error")
+	}
+}
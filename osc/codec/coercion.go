@@ -0,0 +1,84 @@
+package codec
+
+import (
+	"math"
+	"time"
+)
+
+// Coercion is a policy that defines how Message.Append converts native Go
+// values into OSC argument types.
+type Coercion int
+
+const (
+	// CoerceStrict appends arguments as they are. Arguments of unsupported
+	// types are reported as an error when the message is serialized. This is
+	// the default policy.
+	CoerceStrict Coercion = iota
+
+	// CoerceNative converts native Go types to their closest OSC type. Integer
+	// types are mapped to int32 ('i') if the value fits, otherwise to int64
+	// ('h'). Floats are kept at their precision, i.e. float64 is encoded as a
+	// double ('d'). A time.Time is mapped to a Timetag ('t').
+	CoerceNative
+
+	// CoerceNative32 behaves like CoerceNative, but maps float64 to float32
+	// ('f'), which is supported by more OSC implementations than doubles.
+	CoerceNative32
+)
+
+// coerce converts the given argument according to the coercion policy.
+// Arguments that can't be converted are returned unchanged.
+func (c Coercion) coerce(arg interface{}) interface{} {
+	if c == CoerceStrict {
+		return arg
+	}
+
+	switch t := arg.(type) {
+	case int:
+		return coerceInt(int64(t))
+	case int8:
+		return int32(t)
+	case int16:
+		return int32(t)
+	case uint:
+		if uint64(t) <= math.MaxInt64 {
+			return coerceInt(int64(t))
+		}
+	case uint8:
+		return int32(t)
+	case uint16:
+		return int32(t)
+	case uint32:
+		return coerceInt(int64(t))
+	case uint64:
+		if t <= math.MaxInt64 {
+			return coerceInt(int64(t))
+		}
+	case float64:
+		if c == CoerceNative32 {
+			return float32(t)
+		}
+	case time.Time:
+		return *NewTimetag(t)
+	case *Timetag:
+		if t != nil {
+			return *t
+		}
+	case []interface{}:
+		coerced := make([]interface{}, len(t))
+		for i, a := range t {
+			coerced[i] = c.coerce(a)
+		}
+		return coerced
+	}
+
+	return arg
+}
+
+// coerceInt returns i as int32 if it fits into 32 bits, otherwise as int64.
+func coerceInt(i int64) interface{} {
+	if i >= math.MinInt32 && i <= math.MaxInt32 {
+		return int32(i)
+	}
+	return i
+}
@@ -0,0 +1,50 @@
+package codec
+
+import (
+	"math"
+	"testing"
+	"time"
+)
+
+func TestMessage_SetCoercion(t *testing.T) {
+	now := time.Now()
+	for _, tt := range []struct {
+		desc     string
+		coercion Coercion
+		arg      interface{}
+		tags     string
+	}{
+		{"strict_int", CoerceStrict, 1, ""},
+		{"native_int", CoerceNative, 1, ",i"},
+		{"native_big_int", CoerceNative, math.MaxInt32 + 1, ",h"},
+		{"native_int8", CoerceNative, int8(-1), ",i"},
+		{"native_uint16", CoerceNative, uint16(1), ",i"},
+		{"native_uint32", CoerceNative, uint32(math.MaxUint32), ",h"},
+		{"native_huge_uint64", CoerceNative, uint64(math.MaxUint64), ""},
+		{"native_float64", CoerceNative, 1.5, ",d"},
+		{"native32_float64", CoerceNative32, 1.5, ",f"},
+		{"native_time", CoerceNative, now, ",t"},
+		{"native_timetag_ptr", CoerceNative, NewTimetag(now), ",t"},
+		{"native_bool", CoerceNative, true, ",T"},
+		{"native_string", CoerceNative, "s", ",s"},
+	} {
+		msg := NewMessage("/address")
+		msg.SetCoercion(tt.coercion)
+		msg.Append(tt.arg)
+
+		tags, err := msg.TypeTags()
+		if tt.tags == "" {
+			if err == nil {
+				t.Errorf("%s: TypeTags() expected an error, got '%s'", tt.desc, tags)
+			}
+			continue
+		}
+		if err != nil {
+			t.Errorf("%s: TypeTags() unexpected error: %s", tt.desc, err)
+			continue
+		}
+		if got, want := tags, tt.tags; got != want {
+			t.Errorf("%s: TypeTags() = '%s', want = '%s'", tt.desc, got, want)
+		}
+	}
+}
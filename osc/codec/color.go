@@ -0,0 +1,30 @@
+package codec
+
+import "image/color"
+
+// RGBA represents the OSC 'r' argument type, a 32-bit RGBA color. The
+// components are not alpha-premultiplied, as sent by lighting and video
+// applications.
+type RGBA struct {
+	R, G, B, A uint8
+}
+
+// Verify that RGBA implements the color.Color interface.
+var _ color.Color = RGBA{}
+
+// NewRGBA converts c to an 'r' argument.
+func NewRGBA(c color.Color) RGBA {
+	n := color.NRGBAModel.Convert(c).(color.NRGBA)
+	return RGBA{R: n.R, G: n.G, B: n.B, A: n.A}
+}
+
+// RGBA returns the alpha-premultiplied components of the color. Implements
+// the color.Color interface.
+func (c RGBA) RGBA() (r, g, b, a uint32) {
+	return color.NRGBA{R: c.R, G: c.G, B: c.B, A: c.A}.RGBA()
+}
+
+// Color converts the color to an alpha-premultiplied color.RGBA.
+func (c RGBA) Color() color.RGBA {
+	return color.RGBAModel.Convert(c).(color.RGBA)
+}
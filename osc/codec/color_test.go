@@ -0,0 +1,61 @@
+package codec
+
+import (
+	"image/color"
+	"reflect"
+	"testing"
+)
+
+func TestRGBA_Color(t *testing.T) {
+	for _, tt := range []struct {
+		rgba  RGBA
+		color color.RGBA
+	}{
+		{RGBA{255, 128, 0, 255}, color.RGBA{255, 128, 0, 255}},
+		{RGBA{255, 255, 255, 0}, color.RGBA{0, 0, 0, 0}},
+		{RGBA{200, 100, 50, 128}, color.RGBA{100, 50, 25, 128}},
+	} {
+		if got := tt.rgba.Color(); got != tt.color {
+			t.Errorf("%v.Color() = %v, want = %v", tt.rgba, got, tt.color)
+		}
+	}
+
+	if got, want := NewRGBA(color.RGBA{255, 128, 0, 255}), (RGBA{255, 128, 0, 255}); got != want {
+		t.Errorf("NewRGBA(rgba) = %v, want = %v", got, want)
+	}
+
+	if got, want := NewRGBA(color.Gray{Y: 0x80}), (RGBA{0x80, 0x80, 0x80, 0xFF}); got != want {
+		t.Errorf("NewRGBA(gray) = %v, want = %v", got, want)
+	}
+	if got, want := NewRGBA(color.NRGBA{200, 100, 50, 128}), (RGBA{200, 100, 50, 128}); got != want {
+		t.Errorf("NewRGBA(nrgba) = %v, want = %v", got, want)
+	}
+}
+
+func TestRGBAMessage_RoundTrip(t *testing.T) {
+	msg := NewMessage("/color", RGBA{0x11, 0x22, 0x33, 0x44}, int32(1))
+	if tags, err := msg.TypeTags(); err != nil || tags != ",ri" {
+		t.Fatalf("TypeTags() = '%s', %v, want = ',ri'", tags, err)
+	}
+
+	data, err := msg.MarshalBinary()
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := []byte("/color\x00\x00,ri\x00\x11\x22\x33\x44\x00\x00\x00\x01")
+	if !reflect.DeepEqual(data, want) {
+		t.Errorf("MarshalBinary() = % X, want = % X", data, want)
+	}
+
+	p, err := ParsePacket(string(data))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !p.(*Message).Equals(msg) {
+		t.Errorf("decoded message = %v, want = %v", p, msg)
+	}
+
+	if _, err := ParsePacket("/color\x00\x00,r\x00\x00\x11\x22"); err == nil {
+		t.Error("expected error for truncated RGBA argument")
+	}
+}
@@ -0,0 +1,548 @@
+package codec
+
+import (
+	"bufio"
+	"bytes"
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"io"
+	"math"
+	"strings"
+)
+
+var (
+	// ErrUnexpectedEOF means that a packet ended in the middle of a value.
+	ErrUnexpectedEOF = errors.New("osc: unexpected end of packet")
+
+	// ErrInvalidTypeTag means that the type tag string of a message doesn't
+	// start with ',', contains an unsupported type tag or unbalanced array
+	// brackets.
+	ErrInvalidTypeTag = errors.New("osc: invalid type tag")
+
+	// ErrInvalidBundleTag means that a bundle doesn't start with "#bundle".
+	ErrInvalidBundleTag = errors.New("osc: invalid bundle tag")
+
+	// ErrInvalidPacket means that data is neither a message nor a bundle.
+	ErrInvalidPacket = errors.New("osc: neither a message nor a bundle")
+
+	// ErrTooManyArguments means that a message has more arguments than
+	// DecodeOptions.MaxArguments allows.
+	ErrTooManyArguments = errors.New("osc: too many arguments")
+
+	// ErrBundleTooDeep means that bundles are nested deeper than
+	// DecodeOptions.MaxBundleDepth allows.
+	ErrBundleTooDeep = errors.New("osc: bundles nested too deep")
+
+	// ErrInvalidTimetag means that a nested bundle is scheduled before its
+	// enclosing bundle, which ProfileOSC10 and ProfileOSC11 reject.
+	ErrInvalidTimetag = errors.New("osc: bundle scheduled before its enclosing bundle")
+)
+
+// DecodeError describes a malformed packet. Err is one of ErrUnexpectedEOF,
+// ErrInvalidTypeTag, ErrInvalidBundleTag, ErrInvalidBundleElement,
+// ErrInvalidPacket, ErrTooManyArguments, ErrBundleTooDeep, ErrInvalidTimetag
+// and ErrInvalidString, which allows to distinguish malformed packets from I/O
+// errors.
+type DecodeError struct {
+	Offset int    // Position of the error in the packet
+	Bytes  []byte // Up to 16 bytes of the packet starting at Offset
+	Err    error
+}
+
+func (e *DecodeError) Error() string {
+	return fmt.Sprintf("%s at offset %d (% x)", e.Err, e.Offset, e.Bytes)
+}
+
+// Unwrap returns the underlying error.
+func (e *DecodeError) Unwrap() error {
+	return e.Err
+}
+
+// Decoder decodes OSC packets from byte slices or from a stream. The zero
+// value decodes byte slices with the default options.
+type Decoder struct {
+	Options DecodeOptions
+
+	// Framing delimits the packets in the stream read by Decode. Defaults to
+	// SizePrefixFraming.
+	Framing Framing
+
+	// ReuseMessages takes the decoded messages from a pool, see ReleasePacket.
+	ReuseMessages bool
+
+	r *bufio.Reader
+}
+
+// NewDecoder returns a new Decoder that reads packets from r. The decoder
+// buffers r and may read data beyond the requested packet.
+func NewDecoder(r io.Reader) *Decoder {
+	return &Decoder{r: bufio.NewReader(r)}
+}
+
+// Decode reads the next packet from the stream. Returns io.EOF at the end of
+// the stream.
+func (d *Decoder) Decode() (Packet, error) {
+	if d.r == nil {
+		return nil, errors.New("osc: Decoder has no reader, use NewDecoder")
+	}
+	framing := d.Framing
+	if framing == nil {
+		framing = SizePrefixFraming
+	}
+
+	frame, err := framing.ReadFrame(d.r)
+	if err != nil {
+		return nil, err
+	}
+	return d.DecodeBytes(frame)
+}
+
+// DecodeBytes decodes the OSC packet in data. Data that is neither an OSC
+// message nor an OSC bundle is reported as ErrInvalidPacket. The returned
+// packet doesn't reference data. Malformed packets are reported as *DecodeError.
+func (d *Decoder) DecodeBytes(data []byte) (Packet, error) {
+	if len(data) == 0 {
+		return nil, &DecodeError{Err: ErrUnexpectedEOF}
+	}
+	if d.Options.Raw {
+		// The raw bytes of the messages must not reference data
+		data = append([]byte(nil), data...)
+	}
+	r := &byteReader{data: data, reuse: d.ReuseMessages}
+	p, err := r.readPacket(&d.Options)
+	if err == nil && d.Options.Raw {
+		setRawPacket(p, data)
+	}
+	return p, err
+}
+
+// setRawPacket sets the raw packet of all messages of p.
+func setRawPacket(p Packet, data []byte) {
+	switch p := p.(type) {
+	case *Message:
+		p.rawPacket = data
+	case *Bundle:
+		for _, msg := range p.Messages {
+			msg.rawPacket = data
+		}
+		for _, b := range p.Bundles {
+			setRawPacket(b, data)
+		}
+	}
+}
+
+// byteReader reads OSC data types from a byte slice.
+type byteReader struct {
+	data  []byte
+	pos   int
+	base  int    // Offset of data in the packet
+	depth int    // Number of bundles that contain data
+	outer uint64 // Timetag of the enclosing bundle if depth > 0
+	reuse bool
+}
+
+// remaining returns the number of unread bytes.
+func (r *byteReader) remaining() int {
+	return len(r.data) - r.pos
+}
+
+// errorAt returns a DecodeError for the error err at the position pos.
+func (r *byteReader) errorAt(pos int, err error) *DecodeError {
+	end := pos + 16
+	if end > len(r.data) {
+		end = len(r.data)
+	}
+	return &DecodeError{
+		Offset: r.base + pos,
+		Bytes:  append([]byte(nil), r.data[pos:end]...),
+		Err:    err,
+	}
+}
+
+// readPacket reads an OSC message or bundle.
+func (r *byteReader) readPacket(opts *DecodeOptions) (Packet, error) {
+	if r.remaining() == 0 {
+		return nil, r.errorAt(r.pos, ErrUnexpectedEOF)
+	}
+
+	switch r.data[r.pos] {
+	case '/': // An OSC Message starts with a '/'
+		return r.readMessage(opts)
+	case '#': // An OSC bundle starts with a '#'
+		return r.readBundle(opts)
+	}
+	return nil, r.errorAt(r.pos, ErrInvalidPacket)
+}
+
+// readBundle reads an OSC bundle.
+func (r *byteReader) readBundle(opts *DecodeOptions) (*Bundle, error) {
+	// Read the '#bundle' OSC string
+	start := r.pos
+	startTag, err := r.readPaddedString()
+	if err != nil {
+		return nil, err
+	}
+	if startTag != bundleTagString {
+		return nil, r.errorAt(start, ErrInvalidBundleTag)
+	}
+	if opts.MaxBundleDepth > 0 && r.depth >= opts.MaxBundleDepth {
+		return nil, r.errorAt(start, ErrBundleTooDeep)
+	}
+
+	// Read the timetag
+	timeTag, err := r.readUint64()
+	if err != nil {
+		return nil, err
+	}
+	if opts.Profile.checksTimetags() && r.depth > 0 && timeTag < r.outer {
+		return nil, r.errorAt(r.pos-8, ErrInvalidTimetag)
+	}
+	bundle := NewBundle(timetagToTime(timeTag))
+
+	// Read until the end of the buffer
+	for r.remaining() > 0 {
+		// Read the size of the bundle element
+		start := r.pos
+		length, err := r.readUint32()
+		if err != nil {
+			return nil, err
+		}
+
+		// The element must be 32-bit aligned and fit into the remaining data
+		if int32(length) <= 0 || length%4 != 0 || int(length) > r.remaining() {
+			return nil, r.errorAt(start, ErrInvalidBundleElement)
+		}
+
+		// Decode exactly the bundle element on its own
+		element := &byteReader{
+			data:  r.data[r.pos : r.pos+int(length)],
+			base:  r.base + r.pos,
+			depth: r.depth + 1,
+			outer: timeTag,
+			reuse: r.reuse,
+		}
+		r.pos += int(length)
+		if length > 0 && element.data[0] != '/' && element.data[0] != '#' {
+			return nil, r.errorAt(start, ErrInvalidBundleElement)
+		}
+
+		p, err := element.readPacket(opts)
+		if err != nil {
+			return nil, err
+		}
+		if err = bundle.Append(p); err != nil {
+			return nil, err
+		}
+	}
+
+	return bundle, nil
+}
+
+// readMessage reads an OSC message.
+func (r *byteReader) readMessage(opts *DecodeOptions) (*Message, error) {
+	start := r.pos
+
+	// First, read the OSC address
+	addr, err := r.readString(opts)
+	if err != nil {
+		return nil, err
+	}
+
+	// Read all arguments
+	var msg *Message
+	if r.reuse {
+		msg = getMessage(addr)
+	} else {
+		msg = NewMessage(addr)
+	}
+	if err = r.readArguments(msg, opts); err != nil {
+		putMessage(msg)
+		return nil, err
+	}
+	if opts.Raw {
+		msg.raw = r.data[start:r.pos:r.pos]
+	}
+
+	return msg, nil
+}
+
+// readArguments reads the type tag string and all arguments and adds them to
+// the OSC message `msg`.
+func (r *byteReader) readArguments(msg *Message, opts *DecodeOptions) error {
+	// Read the type tag string
+	tagsStart := r.pos
+	typetags, err := r.readPaddedString()
+	if err != nil {
+		return err
+	}
+
+	// If the typetag doesn't start with ',', it's not valid
+	if len(typetags) == 0 || typetags[0] != ',' {
+		return r.errorAt(tagsStart, ErrInvalidTypeTag)
+	}
+
+	// Remove ',' from the type tag
+	typetags = typetags[1:]
+	if opts.MaxArguments > 0 && len(typetags) > opts.MaxArguments {
+		return r.errorAt(tagsStart, ErrTooManyArguments)
+	}
+	if cap(msg.Arguments) < len(typetags) {
+		msg.Arguments = make([]interface{}, 0, len(typetags))
+	}
+	order := opts.argumentByteOrder()
+	accepted := opts.Profile.acceptedTags()
+
+	// Elements of arrays are appended to the arguments and moved into the
+	// array when it is closed
+	var arrays []int // Indices of the first elements of the open arrays
+
+tags:
+	for i, c := range typetags {
+		if accepted != "" && strings.IndexRune(accepted, c) < 0 {
+			return r.errorAt(tagsStart+1+i, ErrInvalidTypeTag)
+		}
+
+		switch c {
+		default:
+			if codec := codecForTag(typetags[i]); codec != nil {
+				v, err := r.readCustom(codec)
+				if err != nil {
+					return err
+				}
+				msg.Append(v)
+				continue
+			}
+			if size, ok := opts.TagSizes[typetags[i]]; ok {
+				if err := r.skipArgument(size); err != nil {
+					return err
+				}
+				if opts.OnUnknownTag != nil {
+					opts.OnUnknownTag(msg.Address, typetags[i])
+				}
+				continue
+			}
+			if opts.Profile != ProfileLoose {
+				// The offset points to the tag, the ',' was removed
+				return r.errorAt(tagsStart+1+i, ErrInvalidTypeTag)
+			}
+
+			// The size of the unknown argument is unknown, the remaining
+			// arguments are dropped
+			if opts.OnUnknownTag != nil {
+				opts.OnUnknownTag(msg.Address, typetags[i])
+			}
+			for j := len(arrays) - 1; j >= 0; j-- {
+				closeArray(msg, arrays[j])
+			}
+			arrays = nil
+			break tags
+
+		case 'i': // int32
+			i, err := r.readUint32Order(order)
+			if err != nil {
+				return err
+			}
+			msg.Append(int32(i))
+
+		case 'h': // int64
+			i, err := r.readUint64Order(order)
+			if err != nil {
+				return err
+			}
+			msg.Append(int64(i))
+
+		case 'f': // float32
+			f, err := r.readUint32Order(order)
+			if err != nil {
+				return err
+			}
+			msg.Append(math.Float32frombits(f))
+
+		case 'd': // float64/double
+			d, err := r.readUint64Order(order)
+			if err != nil {
+				return err
+			}
+			msg.Append(math.Float64frombits(d))
+
+		case 's': // string
+			s, err := r.readString(opts)
+			if err != nil {
+				return err
+			}
+			msg.Append(s)
+
+		case 'b': // blob
+			b, err := r.readBlob()
+			if err != nil {
+				return err
+			}
+			msg.Append(b)
+
+		case 't': // OSC time tag
+			tt, err := r.readUint64()
+			if err != nil {
+				return err
+			}
+			if opts.TimeArguments {
+				msg.Append(timetagToTime(tt))
+			} else {
+				msg.Append(*NewTimetagFromTimetag(tt))
+			}
+
+		case 'c': // char
+			c, err := r.readUint32Order(order)
+			if err != nil {
+				return err
+			}
+			msg.Append(Char(int32(c)))
+
+		case 'm': // MIDI message
+			if r.remaining() < 4 {
+				return r.errorAt(r.pos, ErrUnexpectedEOF)
+			}
+			m := r.data[r.pos : r.pos+4]
+			r.pos += 4
+			msg.Append(MIDI{Port: m[0], Status: m[1], Data1: m[2], Data2: m[3]})
+
+		case 'r': // RGBA color
+			if r.remaining() < 4 {
+				return r.errorAt(r.pos, ErrUnexpectedEOF)
+			}
+			c := r.data[r.pos : r.pos+4]
+			r.pos += 4
+			msg.Append(RGBA{R: c[0], G: c[1], B: c[2], A: c[3]})
+
+		case 'N': // nil
+			msg.Append(Nil{})
+
+		case 'I': // impulse
+			msg.Append(Impulse{})
+
+		case 'T': // true
+			msg.Append(true)
+
+		case 'F': // false
+			msg.Append(false)
+
+		case '[': // array start
+			arrays = append(arrays, len(msg.Arguments))
+
+		case ']': // array end
+			if len(arrays) == 0 {
+				return r.errorAt(tagsStart+1+i, ErrInvalidTypeTag)
+			}
+			closeArray(msg, arrays[len(arrays)-1])
+			arrays = arrays[:len(arrays)-1]
+		}
+	}
+	if len(arrays) != 0 {
+		return r.errorAt(tagsStart+1+len(typetags), ErrInvalidTypeTag)
+	}
+
+	return nil
+}
+
+// closeArray moves the arguments of msg starting at index start into an array
+// argument.
+func closeArray(msg *Message, start int) {
+	array := append([]interface{}{}, msg.Arguments[start:]...)
+	msg.Arguments = append(msg.Arguments[:start], array)
+}
+
+// skipArgument skips an argument of the given size, see
+// DecodeOptions.TagSizes.
+func (r *byteReader) skipArgument(size int) error {
+	switch {
+	case size == TagSizeString:
+		_, err := r.readPaddedString()
+		return err
+	case size == TagSizeBlob:
+		length, err := r.readUint32()
+		if err != nil {
+			return err
+		}
+		n := int(length) + blobPadBytesNeeded(int(length))
+		if int32(length) < 0 || n > r.remaining() {
+			return r.errorAt(r.pos-4, ErrUnexpectedEOF)
+		}
+		r.pos += n
+		return nil
+	case size < 0:
+		return fmt.Errorf("osc: invalid argument size %d", size)
+	}
+
+	n := size + blobPadBytesNeeded(size)
+	if n > r.remaining() {
+		return r.errorAt(r.pos, ErrUnexpectedEOF)
+	}
+	r.pos += n
+	return nil
+}
+
+// readUint32 reads a big-endian 32-bit value.
+func (r *byteReader) readUint32() (uint32, error) {
+	return r.readUint32Order(binary.BigEndian)
+}
+
+// readUint32Order reads a 32-bit value in the given byte order.
+func (r *byteReader) readUint32Order(order binary.ByteOrder) (uint32, error) {
+	if r.remaining() < 4 {
+		return 0, r.errorAt(r.pos, ErrUnexpectedEOF)
+	}
+	v := order.Uint32(r.data[r.pos:])
+	r.pos += 4
+	return v, nil
+}
+
+// readUint64 reads a big-endian 64-bit value.
+func (r *byteReader) readUint64() (uint64, error) {
+	return r.readUint64Order(binary.BigEndian)
+}
+
+// readUint64Order reads a 64-bit value in the given byte order.
+func (r *byteReader) readUint64Order(order binary.ByteOrder) (uint64, error) {
+	if r.remaining() < 8 {
+		return 0, r.errorAt(r.pos, ErrUnexpectedEOF)
+	}
+	v := order.Uint64(r.data[r.pos:])
+	r.pos += 8
+	return v, nil
+}
+
+// readPaddedString reads an OSC string. The string is terminated by a null
+// byte and padded with null bytes to a multiple of 4 bytes. Terminator and
+// padding are consumed, but not returned.
+func (r *byteReader) readPaddedString() (string, error) {
+	end := bytes.IndexByte(r.data[r.pos:], 0)
+	if end < 0 {
+		return "", r.errorAt(r.pos, ErrUnexpectedEOF)
+	}
+	n := end + padBytesNeeded(end)
+	if n > r.remaining() {
+		return "", r.errorAt(r.pos, ErrUnexpectedEOF)
+	}
+
+	str := string(r.data[r.pos : r.pos+end])
+	r.pos += n
+	return str, nil
+}
+
+// readBlob reads an OSC blob. The blob data is copied, padding bytes are
+// consumed but not returned.
+func (r *byteReader) readBlob() ([]byte, error) {
+	length, err := r.readUint32()
+	if err != nil {
+		return nil, err
+	}
+	n := int(length) + blobPadBytesNeeded(int(length))
+	if int32(length) < 0 || n > r.remaining() {
+		return nil, r.errorAt(r.pos-4, ErrUnexpectedEOF)
+	}
+
+	blob := make([]byte, length)
+	copy(blob, r.data[r.pos:])
+	r.pos += n
+	return blob, nil
+}
@@ -0,0 +1,14 @@
+// Package codec contains the OSC packet types and their binary encoding:
+// Message, Bundle and Timetag, the argument types, the Encoder and Decoder
+// for streams, and the address patterns that messages are matched with.
+//
+// The package doesn't depend on package net, so it can be used without a
+// network stack, e.g. on embedded targets or in the browser with
+// GOOS=js GOARCH=wasm. Package osc aliases all of its types and functions,
+// so code that imports osc doesn't need to import codec.
+//
+//	msg := codec.NewMessage("/synth/1/freq", float32(440))
+//	data, err := msg.MarshalBinary()
+//	...
+//	p, err := codec.ParsePacket(string(data))
+package codec
@@ -0,0 +1,31 @@
+package codec
+
+import "io"
+
+// Encoder writes OSC packets to a stream.
+type Encoder struct {
+	// Framing delimits the packets in the stream. Defaults to
+	// SizePrefixFraming.
+	Framing Framing
+
+	w io.Writer
+}
+
+// NewEncoder returns a new Encoder that writes packets to w.
+func NewEncoder(w io.Writer) *Encoder {
+	return &Encoder{w: w}
+}
+
+// Encode writes the packet as one frame to the stream. Every packet is
+// written with a single call to the Write method of the underlying writer.
+func (e *Encoder) Encode(packet Packet) error {
+	data, err := packet.MarshalBinary()
+	if err != nil {
+		return err
+	}
+	framing := e.Framing
+	if framing == nil {
+		framing = SizePrefixFraming
+	}
+	return framing.WriteFrame(e.w, data)
+}
@@ -0,0 +1,134 @@
+package codec
+
+import (
+	"bufio"
+	"bytes"
+	"encoding/binary"
+	"errors"
+	"io"
+)
+
+// Framing delimits OSC packets in a byte stream. It is used by Encoder and
+// Decoder.
+type Framing interface {
+	// ReadFrame reads the next packet from r. Returns io.EOF if r ended
+	// before the first byte of a frame.
+	ReadFrame(r *bufio.Reader) ([]byte, error)
+	// WriteFrame writes the packet data as one frame to w.
+	WriteFrame(w io.Writer, data []byte) error
+}
+
+var (
+	// SizePrefixFraming prefixes every packet with its size as int32, as
+	// defined by the OSC 1.0 specification for stream based transports.
+	SizePrefixFraming Framing = sizePrefixFraming{}
+
+	// SLIPFraming delimits packets with SLIP (RFC 1055) as defined by the OSC
+	// 1.1 specification for stream based transports.
+	SLIPFraming Framing = slipFraming{}
+)
+
+// streamSizePrefixLen is the length of the size prefix of SizePrefixFraming.
+const streamSizePrefixLen = 4
+
+type sizePrefixFraming struct{}
+
+// ReadFrame implements the Framing interface.
+func (sizePrefixFraming) ReadFrame(r *bufio.Reader) ([]byte, error) {
+	var prefix [streamSizePrefixLen]byte
+	if _, err := io.ReadFull(r, prefix[:]); err != nil {
+		return nil, err
+	}
+	size := int32(binary.BigEndian.Uint32(prefix[:]))
+	if size < 0 {
+		return nil, errors.New("osc: negative frame size")
+	}
+
+	// Read without allocating the whole frame upfront, the size may be bogus
+	var frame bytes.Buffer
+	n, err := frame.ReadFrom(io.LimitReader(r, int64(size)))
+	if err != nil {
+		return nil, err
+	}
+	if n < int64(size) {
+		return nil, io.ErrUnexpectedEOF
+	}
+	return frame.Bytes(), nil
+}
+
+// WriteFrame implements the Framing interface.
+func (sizePrefixFraming) WriteFrame(w io.Writer, data []byte) error {
+	frame := make([]byte, streamSizePrefixLen+len(data))
+	binary.BigEndian.PutUint32(frame, uint32(len(data)))
+	copy(frame[streamSizePrefixLen:], data)
+	_, err := w.Write(frame)
+	return err
+}
+
+// Special characters of SLIP.
+const (
+	slipEnd    = 0xC0
+	slipEsc    = 0xDB
+	slipEscEnd = 0xDC
+	slipEscEsc = 0xDD
+)
+
+type slipFraming struct{}
+
+// ReadFrame implements the Framing interface. Empty frames are skipped.
+func (slipFraming) ReadFrame(r *bufio.Reader) ([]byte, error) {
+	var frame []byte
+	for {
+		c, err := r.ReadByte()
+		if err != nil {
+			if err == io.EOF && len(frame) > 0 {
+				err = io.ErrUnexpectedEOF
+			}
+			return nil, err
+		}
+
+		switch c {
+		case slipEnd:
+			if len(frame) > 0 {
+				return frame, nil
+			}
+		case slipEsc:
+			c, err = r.ReadByte()
+			if err == io.EOF {
+				return nil, io.ErrUnexpectedEOF
+			} else if err != nil {
+				return nil, err
+			}
+			switch c {
+			case slipEscEnd:
+				frame = append(frame, slipEnd)
+			case slipEscEsc:
+				frame = append(frame, slipEsc)
+			default:
+				return nil, errors.New("osc: invalid SLIP escape sequence")
+			}
+		default:
+			frame = append(frame, c)
+		}
+	}
+}
+
+// WriteFrame implements the Framing interface. The frame starts and ends with
+// an END character, as recommended by the OSC 1.1 specification.
+func (slipFraming) WriteFrame(w io.Writer, data []byte) error {
+	frame := make([]byte, 0, len(data)+2)
+	frame = append(frame, slipEnd)
+	for _, c := range data {
+		switch c {
+		case slipEnd:
+			frame = append(frame, slipEsc, slipEscEnd)
+		case slipEsc:
+			frame = append(frame, slipEsc, slipEscEsc)
+		default:
+			frame = append(frame, c)
+		}
+	}
+	frame = append(frame, slipEnd)
+	_, err := w.Write(frame)
+	return err
+}
@@ -0,0 +1,98 @@
+package codec
+
+import (
+	"bytes"
+	"io"
+	"testing"
+	"time"
+)
+
+func TestEncoderDecoder(t *testing.T) {
+	bundle := NewBundle(time.Unix(1500000000, 0))
+	bundle.Append(NewMessage("/b", int32(2)))
+	packets := []Packet{
+		NewMessage("/a", int32(1), "text"),
+		// 0xC0 and 0xDB are the SLIP END and ESC characters
+		NewMessage("/blob", []byte{0xC0, 0xDB, 0x00, 0xDC}),
+		bundle,
+	}
+
+	for _, framing := range []Framing{nil, SizePrefixFraming, SLIPFraming} {
+		var buf bytes.Buffer
+		enc := NewEncoder(&buf)
+		enc.Framing = framing
+		for _, p := range packets {
+			if err := enc.Encode(p); err != nil {
+				t.Fatal(err)
+			}
+		}
+
+		dec := NewDecoder(&buf)
+		dec.Framing = framing
+		for _, want := range packets {
+			got, err := dec.Decode()
+			if err != nil {
+				t.Fatalf("%T: Decode() unexpected error: %s", framing, err)
+			}
+			if !packetsEqual(got, want) {
+				t.Errorf("%T: Decode() = %v, want = %v", framing, got, want)
+			}
+		}
+		if _, err := dec.Decode(); err != io.EOF {
+			t.Errorf("%T: Decode() at end of stream error = %v, want = %v", framing, err, io.EOF)
+		}
+	}
+}
+
+// packetsEqual returns true if both packets are equal messages or bundles.
+func packetsEqual(a, b Packet) bool {
+	switch x := a.(type) {
+	case *Message:
+		y, ok := b.(*Message)
+		return ok && x.Equals(y)
+	case *Bundle:
+		y, ok := b.(*Bundle)
+		return ok && x.Equals(y)
+	}
+	return false
+}
+
+func TestSLIPFraming_WriteFrame(t *testing.T) {
+	var buf bytes.Buffer
+	if err := SLIPFraming.WriteFrame(&buf, []byte{1, 0xC0, 2, 0xDB, 3}); err != nil {
+		t.Fatal(err)
+	}
+	want := []byte{0xC0, 1, 0xDB, 0xDC, 2, 0xDB, 0xDD, 3, 0xC0}
+	if !bytes.Equal(buf.Bytes(), want) {
+		t.Errorf("WriteFrame() = % X, want = % X", buf.Bytes(), want)
+	}
+}
+
+func TestDecoder_Decode_Errors(t *testing.T) {
+	for _, tt := range []struct {
+		name    string
+		framing Framing
+		data    []byte
+		want    error
+	}{
+		{"truncated size", SizePrefixFraming, []byte{0, 0}, io.ErrUnexpectedEOF},
+		{"truncated frame", SizePrefixFraming, []byte{0, 0, 0, 8, '/', 'a', 0, 0}, io.ErrUnexpectedEOF},
+		{"negative size", SizePrefixFraming, []byte{0xFF, 0xFF, 0xFF, 0xFF}, nil},
+		{"no packet", SizePrefixFraming, []byte{0, 0, 0, 4, 'a', 0, 0, 0}, nil},
+		{"unterminated SLIP frame", SLIPFraming, []byte{0xC0, '/', 'a'}, io.ErrUnexpectedEOF},
+		{"invalid SLIP escape", SLIPFraming, []byte{0xC0, 0xDB, 0x01, 0xC0}, nil},
+	} {
+		dec := NewDecoder(bytes.NewReader(tt.data))
+		dec.Framing = tt.framing
+		_, err := dec.Decode()
+		if err == nil {
+			t.Errorf("%s: expected error", tt.name)
+		} else if tt.want != nil && err != tt.want {
+			t.Errorf("%s: error = %v, want = %v", tt.name, err, tt.want)
+		}
+	}
+
+	if _, err := new(Decoder).Decode(); err == nil {
+		t.Error("expected error for Decoder without reader")
+	}
+}
@@ -0,0 +1,56 @@
+package codec
+
+import "time"
+
+// Addr is the address of a sender. It has the methods of net.Addr, so any
+// net.Addr can be used, without making this package depend on net.
+type Addr interface {
+	Network() string
+	String() string
+}
+
+// MessageInfo describes how a message was received, e.g. by an osc.Server.
+type MessageInfo struct {
+	Source   Addr      // Address of the sender, a net.Addr for servers
+	Received time.Time // Local time the packet was received at
+	// Timetag is the timetag of the innermost bundle that contained the
+	// message. It is zero if the message wasn't received in a bundle.
+	Timetag Timetag
+	// Transport is the network of the connection, e.g. "udp", or "reader"
+	// for osc.Server.ServeReader.
+	Transport string
+}
+
+// InBundle returns true if the message was received in a bundle.
+func (i MessageInfo) InBundle() bool {
+	return i.Timetag.TimeTag() != 0
+}
+
+// Info returns how the message was received, e.g. to reply to its sender.
+// The fields are zero if the message wasn't received by a transport, e.g. if
+// it was decoded by ParsePacket or created by an osc.Stage.
+func (msg *Message) Info() MessageInfo {
+	if msg.info == nil {
+		return MessageInfo{}
+	}
+	return *msg.info
+}
+
+// SetPacketInfo sets the info of all messages of p, which is used by
+// transports that received p. The messages of a bundle share a copy of info
+// with the timetag of the bundle.
+func SetPacketInfo(p Packet, info *MessageInfo) {
+	switch p := p.(type) {
+	case *Message:
+		p.info = info
+	case *Bundle:
+		bundleInfo := *info
+		bundleInfo.Timetag = p.Timetag
+		for _, msg := range p.Messages {
+			msg.info = &bundleInfo
+		}
+		for _, b := range p.Bundles {
+			SetPacketInfo(b, info)
+		}
+	}
+}
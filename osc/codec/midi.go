@@ -0,0 +1,123 @@
+package codec
+
+import "fmt"
+
+// MIDI represents the OSC 'm' argument type, a MIDI message of 4 bytes. The
+// bytes are the port id, the status byte and the two data bytes.
+type MIDI struct {
+	Port   uint8
+	Status uint8
+	Data1  uint8
+	Data2  uint8
+}
+
+// Status bytes of MIDI channel messages without the channel.
+const (
+	midiNoteOff       = 0x80
+	midiNoteOn        = 0x90
+	midiControlChange = 0xB0
+)
+
+// MIDIEvent is a MIDI channel message that can be sent as OSC 'm' argument.
+// It is implemented by NoteOn, NoteOff and ControlChange.
+type MIDIEvent interface {
+	// MIDI returns the 'm' argument that sends the event on the given port.
+	MIDI(port uint8) MIDI
+}
+
+// NoteOn is a MIDI note on event.
+type NoteOn struct {
+	Channel  uint8 // 0-15
+	Key      uint8 // 0-127
+	Velocity uint8 // 0-127
+}
+
+// MIDI implements the MIDIEvent interface.
+func (e NoteOn) MIDI(port uint8) MIDI {
+	return newMIDI(port, midiNoteOn, e.Channel, e.Key, e.Velocity)
+}
+
+// NoteOff is a MIDI note off event.
+type NoteOff struct {
+	Channel  uint8 // 0-15
+	Key      uint8 // 0-127
+	Velocity uint8 // 0-127
+}
+
+// MIDI implements the MIDIEvent interface.
+func (e NoteOff) MIDI(port uint8) MIDI {
+	return newMIDI(port, midiNoteOff, e.Channel, e.Key, e.Velocity)
+}
+
+// ControlChange is a MIDI control change (CC) event.
+type ControlChange struct {
+	Channel    uint8 // 0-15
+	Controller uint8 // 0-127
+	Value      uint8 // 0-127
+}
+
+// MIDI implements the MIDIEvent interface.
+func (e ControlChange) MIDI(port uint8) MIDI {
+	return newMIDI(port, midiControlChange, e.Channel, e.Controller, e.Value)
+}
+
+// newMIDI returns a MIDI channel message. Values that are out of range are
+// truncated.
+func newMIDI(port, status, channel, data1, data2 uint8) MIDI {
+	return MIDI{
+		Port:   port,
+		Status: status | channel&0x0F,
+		Data1:  data1 & 0x7F,
+		Data2:  data2 & 0x7F,
+	}
+}
+
+// Channel returns the channel of a MIDI channel message.
+func (m MIDI) Channel() uint8 {
+	return m.Status & 0x0F
+}
+
+// Event returns the MIDI event of m. A note on with a velocity of 0 is
+// returned as NoteOff, as defined by the MIDI specification. Returns an error
+// for messages other than note on, note off and control change.
+func (m MIDI) Event() (MIDIEvent, error) {
+	switch m.Status & 0xF0 {
+	case midiNoteOn:
+		if m.Data2 == 0 {
+			return NoteOff{Channel: m.Channel(), Key: m.Data1}, nil
+		}
+		return NoteOn{Channel: m.Channel(), Key: m.Data1, Velocity: m.Data2}, nil
+	case midiNoteOff:
+		return NoteOff{Channel: m.Channel(), Key: m.Data1, Velocity: m.Data2}, nil
+	case midiControlChange:
+		return ControlChange{Channel: m.Channel(), Controller: m.Data1, Value: m.Data2}, nil
+	}
+	return nil, fmt.Errorf("osc: unsupported MIDI status byte 0x%02X", m.Status)
+}
+
+// NewMIDIMessage returns a new OSC message with one 'm' argument per event.
+func NewMIDIMessage(addr string, port uint8, events ...MIDIEvent) *Message {
+	msg := NewMessage(addr)
+	for _, e := range events {
+		msg.Append(e.MIDI(port))
+	}
+	return msg
+}
+
+// MIDIEvents returns the events of all 'm' arguments of the message. Other
+// arguments are ignored.
+func (msg *Message) MIDIEvents() ([]MIDIEvent, error) {
+	var events []MIDIEvent
+	for _, arg := range msg.Arguments {
+		m, ok := arg.(MIDI)
+		if !ok {
+			continue
+		}
+		e, err := m.Event()
+		if err != nil {
+			return nil, err
+		}
+		events = append(events, e)
+	}
+	return events, nil
+}
@@ -0,0 +1,79 @@
+package codec
+
+import (
+	"reflect"
+	"testing"
+)
+
+func TestMIDI_Event(t *testing.T) {
+	for _, tt := range []struct {
+		event MIDIEvent
+		midi  MIDI
+	}{
+		{NoteOn{Channel: 1, Key: 60, Velocity: 100}, MIDI{2, 0x91, 60, 100}},
+		{NoteOff{Channel: 15, Key: 60, Velocity: 64}, MIDI{2, 0x8F, 60, 64}},
+		{ControlChange{Channel: 0, Controller: 7, Value: 127}, MIDI{2, 0xB0, 7, 127}},
+	} {
+		if got := tt.event.MIDI(2); got != tt.midi {
+			t.Errorf("%#v.MIDI() = %v, want = %v", tt.event, got, tt.midi)
+		}
+		got, err := tt.midi.Event()
+		if err != nil {
+			t.Errorf("%v.Event() unexpected error: %s", tt.midi, err)
+		}
+		if got != tt.event {
+			t.Errorf("%v.Event() = %#v, want = %#v", tt.midi, got, tt.event)
+		}
+	}
+
+	got, err := MIDI{0, 0x93, 60, 0}.Event()
+	if want := (NoteOff{Channel: 3, Key: 60}); err != nil || got != want {
+		t.Errorf("note on with velocity 0: Event() = %#v, %v, want = %#v", got, err, want)
+	}
+	if _, err := (MIDI{0, 0xE0, 0, 64}).Event(); err == nil {
+		t.Error("expected error for pitch bend")
+	}
+	if got := (NoteOn{Channel: 17, Key: 200, Velocity: 128}).MIDI(0); got != (MIDI{0, 0x91, 72, 0}) {
+		t.Errorf("out of range values should be truncated, got %v", got)
+	}
+}
+
+func TestMIDIMessage_RoundTrip(t *testing.T) {
+	events := []MIDIEvent{
+		NoteOn{Channel: 0, Key: 64, Velocity: 90},
+		ControlChange{Channel: 9, Controller: 1, Value: 10},
+	}
+	msg := NewMIDIMessage("/midi", 1, events...)
+	msg.Append(int32(5))
+	if tags, err := msg.TypeTags(); err != nil || tags != ",mmi" {
+		t.Fatalf("TypeTags() = '%s', %v, want = ',mmi'", tags, err)
+	}
+
+	data, err := msg.MarshalBinary()
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := []byte("/midi\x00\x00\x00,mmi\x00\x00\x00\x00\x01\x90\x40\x5A\x01\xB9\x01\x0A\x00\x00\x00\x05")
+	if !reflect.DeepEqual(data, want) {
+		t.Errorf("MarshalBinary() = % X, want = % X", data, want)
+	}
+
+	p, err := ParsePacket(string(data))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !p.(*Message).Equals(msg) {
+		t.Errorf("decoded message = %v, want = %v", p, msg)
+	}
+	got, err := p.(*Message).MIDIEvents()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !reflect.DeepEqual(got, events) {
+		t.Errorf("MIDIEvents() = %#v, want = %#v", got, events)
+	}
+
+	if _, err := ParsePacket("/midi\x00\x00\x00,m\x00\x00\x01\x90"); err == nil {
+		t.Error("expected error for truncated MIDI argument")
+	}
+}
@@ -0,0 +1,959 @@
+package codec
+
+import (
+	"bytes"
+	"encoding"
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"math"
+	"reflect"
+	"strings"
+	"time"
+)
+
+const (
+	secondsFrom1900To1970 = 2208988800
+	bundleTagString       = "#bundle"
+)
+
+// padding holds the zero bytes that are used to align OSC data to 4 bytes.
+var padding [4]byte
+
+// ErrInvalidBundleElement is returned if the size of a bundle element is not
+// a positive multiple of 4, exceeds the bundle, or if the element is neither
+// a message nor a bundle.
+var ErrInvalidBundleElement = errors.New("osc: invalid bundle element")
+
+// Packet is the interface for Message and Bundle.
+type Packet interface {
+	encoding.BinaryMarshaler
+}
+
+// Message represents a single OSC message. An OSC message consists of an OSC
+// address pattern and zero or more arguments.
+type Message struct {
+	Address   string
+	Arguments []interface{}
+
+	// SkipValidation disables the validation of the address and the string
+	// arguments in MarshalBinary. It allows to send messages to receivers that
+	// expect addresses that don't conform to the OSC specification.
+	SkipValidation bool
+
+	coercion Coercion
+
+	pooled   bool // Taken from messagePool
+	retained bool // Set by Retain
+
+	raw       []byte // Encoded message, see DecodeOptions.Raw
+	rawPacket []byte // Encoded packet that contained the message
+
+	info *MessageInfo // See Info, shared by the messages of a bundle
+}
+
+// Verify that Messages implements the Packet interface.
+var _ Packet = (*Message)(nil)
+
+// Bundle represents an OSC bundle. It consists of the OSC-string "#bundle"
+// followed by an OSC Time Tag, followed by zero or more OSC bundle/message
+// elements. The OSC-timetag is a 64-bit fixed point time tag. See
+// http://opensoundcontrol.org/spec-1_0 for more information.
+type Bundle struct {
+	Timetag  Timetag
+	Messages []*Message
+	Bundles  []*Bundle
+}
+
+// Verify that Bundle implements the Packet interface.
+var _ Packet = (*Bundle)(nil)
+
+// Timetag represents an OSC Time Tag.
+// An OSC Time Tag is defined as follows:
+// Time tags are represented by a 64 bit fixed point number. The first 32 bits
+// specify the number of seconds since midnight on January 1, 1900, and the
+// last 32 bits specify fractional parts of a second to a precision of about
+// 200 picoseconds. This is the representation used by Internet NTP timestamps.
+type Timetag struct {
+	timeTag  uint64 // The acutal time tag
+	time     time.Time
+	MinValue uint64 // Minimum value of an OSC Time Tag. Is always 1.
+}
+
+// DecodeOptions control how OSC packets are decoded.
+type DecodeOptions struct {
+	// TimeArguments decodes 't' arguments as time.Time instead of Timetag.
+	TimeArguments bool
+
+	// ArgumentByteOrder is the byte order of 'i', 'h', 'f', 'd' and 'c'
+	// arguments. The OSC specification requires big-endian, which is used if
+	// ArgumentByteOrder is nil. Setting it allows to decode packets of
+	// senders that wrongly encode numbers in little-endian. Sizes, timetags
+	// and bundle headers are always decoded as big-endian.
+	ArgumentByteOrder binary.ByteOrder
+
+	// MaxArguments limits the number of arguments of a message. Messages with
+	// more arguments are rejected with ErrTooManyArguments. Zero means no
+	// limit.
+	MaxArguments int
+
+	// MaxBundleDepth limits the nesting depth of bundles, a bundle that
+	// contains no bundles has the depth 1. Deeper bundles are rejected with
+	// ErrBundleTooDeep. Zero means no limit.
+	MaxBundleDepth int
+
+	// Profile selects which type tags are accepted and whether the timetags
+	// of nested bundles are checked.
+	Profile Profile
+
+	// TagSizes maps type tags that aren't supported by this package, e.g.
+	// vendor extensions, to the size of their arguments in bytes, or to
+	// TagSizeString or TagSizeBlob. Arguments with these tags are skipped
+	// and the other arguments of the message are still decoded. The
+	// profiles ProfileOSC10 and ProfileOSC11 reject unknown tags anyway.
+	TagSizes map[byte]int
+
+	// OnUnknownTag is called with the address of a message and the unknown
+	// type tag if an argument was skipped because of TagSizes or if
+	// ProfileLoose skipped arguments of the message. It may be nil.
+	OnUnknownTag func(address string, tag byte)
+
+	// Strings selects which characters addresses and string arguments may
+	// contain. Messages with other characters are rejected with
+	// ErrInvalidString.
+	Strings StringPolicy
+
+	// Raw keeps a copy of the encoded bytes, which are available through
+	// Message.Raw and Message.RawPacket, e.g. to forward packets byte-exact
+	// without encoding them again.
+	Raw bool
+}
+
+// Sizes of variable-length arguments for DecodeOptions.TagSizes.
+const (
+	// TagSizeString is the size of arguments that are encoded like an OSC
+	// string.
+	TagSizeString = -1
+
+	// TagSizeBlob is the size of arguments that are encoded like an OSC blob.
+	TagSizeBlob = -2
+)
+
+// argumentByteOrder returns the byte order of numeric arguments.
+func (o *DecodeOptions) argumentByteOrder() binary.ByteOrder {
+	if o.ArgumentByteOrder == nil {
+		return binary.BigEndian
+	}
+	return o.ArgumentByteOrder
+}
+
+// Impulse represents the OSC 'I' (Impulse, also known as Infinitum) argument
+// type. It carries no payload and is commonly used to trigger events.
+type Impulse struct{}
+
+// Nil represents the OSC 'N' (Nil) argument type. It carries no payload.
+// Decoded messages contain Nil for 'N' arguments. Messages may contain Nil or
+// an untyped nil argument, which are both encoded as 'N' and are considered
+// equal by Message.Equals.
+type Nil struct{}
+
+// Char represents the OSC 'c' argument type, an ASCII character that is sent
+// as 32 bits.
+type Char rune
+
+////
+// Message
+////
+
+// NewMessage returns a new Message. The address parameter is the OSC address.
+func NewMessage(addr string, args ...interface{}) *Message {
+	return &Message{Address: addr, Arguments: args}
+}
+
+// Append appends the given arguments to the arguments list. The arguments are
+// converted according to the coercion policy of the message, see SetCoercion.
+func (msg *Message) Append(args ...interface{}) {
+	for _, arg := range args {
+		msg.Arguments = append(msg.Arguments, msg.coercion.coerce(arg))
+	}
+}
+
+// SetCoercion sets the coercion policy that is applied to all arguments that
+// are appended afterwards.
+func (msg *Message) SetCoercion(c Coercion) {
+	msg.coercion = c
+}
+
+// Equals returns true if the given OSC Message `m` is equal to the current OSC
+// Message. It checks if the OSC address and the arguments are equal. Returns
+// true if the current object and `m` are equal.
+func (msg *Message) Equals(m *Message) bool {
+	return msg.EqualsApprox(m, 0)
+}
+
+// EqualsApprox is like Equals, but float arguments are considered equal if
+// they differ by at most epsilon.
+func (msg *Message) EqualsApprox(m *Message, epsilon float64) bool {
+	if msg == nil || m == nil {
+		return msg == m
+	}
+	if msg.Address != m.Address || len(msg.Arguments) != len(m.Arguments) {
+		return false
+	}
+	for i := range msg.Arguments {
+		if !ArgumentsEqual(msg.Arguments[i], m.Arguments[i], epsilon) {
+			return false
+		}
+	}
+	return true
+}
+
+// Clone returns a deep copy of the OSC Message. Blob arguments are copied,
+// so the clone can be retained and modified independently of msg.
+func (msg *Message) Clone() *Message {
+	if msg == nil {
+		return nil
+	}
+
+	clone := &Message{Address: msg.Address, SkipValidation: msg.SkipValidation, coercion: msg.coercion, raw: msg.raw, rawPacket: msg.rawPacket, info: msg.info}
+	if msg.Arguments != nil {
+		clone.Arguments = make([]interface{}, len(msg.Arguments))
+	}
+	for i, arg := range msg.Arguments {
+		clone.Arguments[i] = CloneArgument(arg)
+	}
+	return clone
+}
+
+// CloneArgument returns a deep copy of blob and array arguments, as
+// Message.Clone does. Other arguments are returned unchanged.
+func CloneArgument(arg interface{}) interface{} {
+	switch t := arg.(type) {
+	case []byte:
+		if t != nil {
+			return append([]byte{}, t...)
+		}
+	case []interface{}:
+		if t != nil {
+			clone := make([]interface{}, len(t))
+			for i, a := range t {
+				clone[i] = CloneArgument(a)
+			}
+			return clone
+		}
+	}
+	return arg
+}
+
+// Clear clears the OSC address and all arguments.
+func (msg *Message) Clear() {
+	msg.Address = ""
+	msg.ClearData()
+}
+
+// ClearData removes all arguments from the OSC Message.
+func (msg *Message) ClearData() {
+	msg.Arguments = msg.Arguments[len(msg.Arguments):]
+}
+
+// Match returns true, if the OSC address pattern of the OSC Message matches the given
+// address. The match is case sensitive! The pattern is compiled with
+// CompilePattern, an invalid pattern matches no address. Like the catch-all
+// handler of an osc.StandardDispatcher, the address "*" matches every address.
+func (msg *Message) Match(addr string) bool {
+	if msg.Address == "*" {
+		return true
+	}
+	p, err := CompilePattern(msg.Address)
+	if err != nil {
+		return false
+	}
+	return p.Match(addr)
+}
+
+// TypeTags returns the type tag string.
+func (msg *Message) TypeTags() (string, error) {
+	if msg == nil {
+		return "", fmt.Errorf("message is nil")
+	}
+
+	tags := ","
+	for _, m := range msg.Arguments {
+		s, err := getTypeTag(m)
+		if err != nil {
+			return "", err
+		}
+		tags += s
+	}
+
+	return tags, nil
+}
+
+// String implements the fmt.Stringer interface.
+func (msg *Message) String() string {
+	if msg == nil {
+		return ""
+	}
+
+	tags, err := msg.TypeTags()
+	if err != nil {
+		return ""
+	}
+
+	formatString := "%s %s"
+	var args []interface{}
+	args = append(args, msg.Address)
+	args = append(args, tags)
+
+	for _, arg := range msg.Arguments {
+		switch arg.(type) {
+		case bool, int32, int64, float32, float64, string:
+			formatString += " %v"
+			args = append(args, arg)
+
+		case nil, Nil:
+			formatString += " %s"
+			args = append(args, "Nil")
+
+		case Impulse:
+			formatString += " %s"
+			args = append(args, "Impulse")
+
+		case Char:
+			formatString += " %c"
+			args = append(args, arg)
+
+		case MIDI, RGBA, []interface{}:
+			formatString += " %v"
+			args = append(args, arg)
+
+		default:
+			formatString += " %v"
+			args = append(args, arg)
+
+		case []byte:
+			formatString += " %s"
+			args = append(args, "blob")
+
+		case Timetag:
+			formatString += " %d"
+			timeTag := arg.(Timetag)
+			args = append(args, timeTag.TimeTag())
+
+		case time.Time:
+			formatString += " %d"
+			args = append(args, timeToTimetag(arg.(time.Time)))
+		}
+	}
+
+	return fmt.Sprintf(formatString, args...)
+}
+
+// CountArguments returns the number of arguments.
+func (msg *Message) CountArguments() int {
+	return len(msg.Arguments)
+}
+
+// MarshalBinary serializes the OSC message to a byte buffer. The byte buffer
+// has the following format:
+// 1. OSC Address Pattern
+// 2. OSC Type Tag String
+// 3. OSC Arguments
+func (msg *Message) MarshalBinary() ([]byte, error) {
+	// Most arguments need at most 8 bytes, strings and blobs may grow the
+	// buffer
+	size := len(msg.Address) + 4 + 2*(len(msg.Arguments)+4) + 8*len(msg.Arguments)
+	return msg.appendBinary(make([]byte, 0, size))
+}
+
+// Validate returns the error that MarshalBinary returns if the address isn't
+// a valid OSC address pattern or a string argument contains a null byte. It
+// validates the message even if SkipValidation is set.
+func (msg *Message) Validate() error {
+	if err := validateAddressPattern(msg.Address); err != nil {
+		return err
+	}
+	return validateStrings(msg.Arguments)
+}
+
+// validateStrings returns an error if a string argument contains a null byte.
+func validateStrings(args []interface{}) error {
+	for i, arg := range args {
+		switch t := arg.(type) {
+		case string:
+			if strings.IndexByte(t, 0) >= 0 {
+				return fmt.Errorf("osc: string argument %d contains a null byte", i)
+			}
+		case []interface{}:
+			if err := validateStrings(t); err != nil {
+				return err
+			}
+		}
+	}
+	return nil
+}
+
+// appendBinary appends the serialized OSC message to buf and returns the
+// extended buffer.
+func (msg *Message) appendBinary(buf []byte) ([]byte, error) {
+	if !msg.SkipValidation {
+		if err := validateAddressPattern(msg.Address); err != nil {
+			return nil, err
+		}
+	}
+	buf = appendPaddedString(buf, msg.Address)
+
+	// The type tag string starts with "," and has one tag per argument, plus
+	// the '[' and ']' tags of arrays. Its space is reserved here and filled
+	// while the arguments are appended.
+	tagsLen := 1 + countTags(msg.Arguments)
+	tagsStart := len(buf)
+	for i := tagsLen + padBytesNeeded(tagsLen); i > 0; i-- {
+		buf = append(buf, 0)
+	}
+	buf[tagsStart] = ','
+
+	buf, _, err := msg.appendArguments(buf, tagsStart+1, msg.Arguments)
+	if err != nil {
+		return nil, err
+	}
+	return buf, nil
+}
+
+// appendArguments appends the arguments to buf and writes their type tags to
+// buf starting at tagPos. Returns the extended buf and the position after the
+// last written type tag.
+func (msg *Message) appendArguments(buf []byte, tagPos int, args []interface{}) ([]byte, int, error) {
+	for i, arg := range args {
+		var tag byte
+		switch t := arg.(type) {
+		default:
+			var err error
+			if buf, tag, err = appendCustom(buf, t); err != nil {
+				return nil, 0, err
+			}
+
+		case bool:
+			if t {
+				tag = 'T'
+			} else {
+				tag = 'F'
+			}
+
+		case nil, Nil:
+			tag = 'N'
+
+		case Impulse:
+			tag = 'I'
+
+		case Char:
+			tag = 'c'
+			buf = appendUint32(buf, uint32(t))
+
+		case MIDI:
+			tag = 'm'
+			buf = append(buf, t.Port, t.Status, t.Data1, t.Data2)
+
+		case RGBA:
+			tag = 'r'
+			buf = append(buf, t.R, t.G, t.B, t.A)
+
+		case int32:
+			tag = 'i'
+			buf = appendUint32(buf, uint32(t))
+
+		case float32:
+			tag = 'f'
+			buf = appendUint32(buf, math.Float32bits(t))
+
+		case string:
+			if !msg.SkipValidation && strings.IndexByte(t, 0) >= 0 {
+				return nil, 0, fmt.Errorf("osc: string argument %d contains a null byte", i)
+			}
+			tag = 's'
+			buf = appendPaddedString(buf, t)
+
+		case []byte:
+			tag = 'b'
+			buf = appendBlob(buf, t)
+
+		case int64:
+			tag = 'h'
+			buf = appendUint64(buf, uint64(t))
+
+		case float64:
+			tag = 'd'
+			buf = appendUint64(buf, math.Float64bits(t))
+
+		case Timetag:
+			tag = 't'
+			buf = appendUint64(buf, t.TimeTag())
+
+		case time.Time:
+			tag = 't'
+			buf = appendUint64(buf, timeToTimetag(t))
+
+		case []interface{}:
+			buf[tagPos] = '['
+			var err error
+			if buf, tagPos, err = msg.appendArguments(buf, tagPos+1, t); err != nil {
+				return nil, 0, err
+			}
+			tag = ']'
+		}
+		buf[tagPos] = tag
+		tagPos++
+	}
+	return buf, tagPos, nil
+}
+
+// countTags returns the number of type tags of the arguments.
+func countTags(args []interface{}) int {
+	n := len(args)
+	for _, arg := range args {
+		if a, ok := arg.([]interface{}); ok {
+			n += 1 + countTags(a)
+		}
+	}
+	return n
+}
+
+////
+// Bundle
+////
+
+// NewBundle returns an OSC Bundle. Use this function to create a new OSC
+// Bundle.
+func NewBundle(time time.Time) *Bundle {
+	return &Bundle{Timetag: *NewTimetag(time)}
+}
+
+// NewBundleIn returns an OSC bundle whose time tag is delay from now.
+func NewBundleIn(delay time.Duration) *Bundle {
+	return NewBundle(time.Now().Add(delay))
+}
+
+// AppendAt appends an OSC bundle or OSC message that is scheduled for the time
+// t. If t is the time of the bundle, the packet is appended to the bundle.
+// Otherwise it is appended to the nested bundle with the time tag t, which is
+// created if the bundle doesn't contain one yet. This allows to schedule
+// packets for different times in one bundle. Returns an error if t is before
+// the time of the bundle, because the OSC specification requires nested
+// bundles to be scheduled no earlier than the enclosing bundle.
+func (b *Bundle) AppendAt(pck Packet, t time.Time) error {
+	tt := timeToTimetag(t)
+	if tt == b.Timetag.TimeTag() {
+		return b.Append(pck)
+	}
+	if b.Timetag.TimeTag() > 1 && tt < b.Timetag.TimeTag() {
+		return fmt.Errorf("osc: packet scheduled for %s before its bundle", t)
+	}
+
+	for _, nested := range b.Bundles {
+		if nested.Timetag.TimeTag() == tt {
+			return nested.Append(pck)
+		}
+	}
+	nested := NewBundle(t)
+	if err := nested.Append(pck); err != nil {
+		return err
+	}
+	b.Bundles = append(b.Bundles, nested)
+	return nil
+}
+
+// AppendAfter appends an OSC bundle or OSC message that is scheduled offset
+// after the time of the bundle, see AppendAt. If the bundle is scheduled
+// immediately, the offset is relative to the current time.
+func (b *Bundle) AppendAfter(pck Packet, offset time.Duration) error {
+	base := b.Timetag.Time()
+	if b.Timetag.TimeTag() <= 1 {
+		base = time.Now()
+	}
+	return b.AppendAt(pck, base.Add(offset))
+}
+
+// Append appends an OSC bundle or OSC message to the bundle.
+func (b *Bundle) Append(pck Packet) error {
+	switch t := pck.(type) {
+	default:
+		return fmt.Errorf("unsupported OSC packet type: only Bundle and Message are supported")
+
+	case *Bundle:
+		b.Bundles = append(b.Bundles, t)
+
+	case *Message:
+		b.Messages = append(b.Messages, t)
+	}
+
+	return nil
+}
+
+// Equals returns true if the given OSC Bundle `bundle` is equal to the current
+// OSC Bundle. It checks if the time tags and all messages and nested bundles
+// are equal.
+func (b *Bundle) Equals(bundle *Bundle) bool {
+	if b == nil || bundle == nil {
+		return b == bundle
+	}
+	if b.Timetag.TimeTag() != bundle.Timetag.TimeTag() ||
+		len(b.Messages) != len(bundle.Messages) ||
+		len(b.Bundles) != len(bundle.Bundles) {
+		return false
+	}
+	for i := range b.Messages {
+		if !b.Messages[i].Equals(bundle.Messages[i]) {
+			return false
+		}
+	}
+	for i := range b.Bundles {
+		if !b.Bundles[i].Equals(bundle.Bundles[i]) {
+			return false
+		}
+	}
+	return true
+}
+
+// Clone returns a deep copy of the OSC Bundle, including all messages and
+// nested bundles.
+func (b *Bundle) Clone() *Bundle {
+	if b == nil {
+		return nil
+	}
+
+	clone := &Bundle{Timetag: b.Timetag}
+	for _, m := range b.Messages {
+		clone.Messages = append(clone.Messages, m.Clone())
+	}
+	for _, nested := range b.Bundles {
+		clone.Bundles = append(clone.Bundles, nested.Clone())
+	}
+	return clone
+}
+
+// MarshalBinary serializes the OSC bundle to a byte array with the following
+// format:
+// 1. Bundle string: '#bundle'
+// 2. OSC timetag
+// 3. Length of first OSC bundle element
+// 4. First bundle element
+// 5. Length of n OSC bundle element
+// 6. n bundle element
+func (b *Bundle) MarshalBinary() ([]byte, error) {
+	return b.appendBinary(nil)
+}
+
+// appendBinary appends the serialized OSC bundle to buf and returns the
+// extended buffer.
+func (b *Bundle) appendBinary(buf []byte) ([]byte, error) {
+	// Add the '#bundle' string and the time tag
+	buf = appendPaddedString(buf, bundleTagString)
+	buf = appendUint64(buf, b.Timetag.TimeTag())
+
+	// Process all OSC Messages and Bundles. Every element is prefixed with
+	// its size, which is filled in after the element was appended.
+	var err error
+	for _, m := range b.Messages {
+		start := len(buf)
+		if buf, err = m.appendBinary(appendUint32(buf, 0)); err != nil {
+			return nil, err
+		}
+		binary.BigEndian.PutUint32(buf[start:], uint32(len(buf)-start-4))
+	}
+	for _, nested := range b.Bundles {
+		start := len(buf)
+		if buf, err = nested.appendBinary(appendUint32(buf, 0)); err != nil {
+			return nil, err
+		}
+		binary.BigEndian.PutUint32(buf[start:], uint32(len(buf)-start-4))
+	}
+
+	return buf, nil
+}
+
+// ParsePacket parses the given msg string and returns a Packet
+func ParsePacket(msg string) (Packet, error) {
+	return ParsePacketWithOptions(msg, DecodeOptions{})
+}
+
+// ParsePacketWithOptions parses the given msg string according to opts and
+// returns a Packet.
+func ParsePacketWithOptions(msg string, opts DecodeOptions) (Packet, error) {
+	d := Decoder{Options: opts}
+	p, err := d.DecodeBytes([]byte(msg))
+	if err != nil {
+		return nil, err
+	}
+	return p, nil
+}
+
+////
+// Timetag
+////
+
+// NewTimetag returns a new OSC time tag object.
+func NewTimetag(timeStamp time.Time) *Timetag {
+	return &Timetag{
+		time:     timeStamp,
+		timeTag:  timeToTimetag(timeStamp),
+		MinValue: uint64(1)}
+}
+
+// NewTimetagFromTimetag creates a new Timetag from the given `timetag`.
+func NewTimetagFromTimetag(timetag uint64) *Timetag {
+	time := timetagToTime(timetag)
+	return NewTimetag(time)
+}
+
+// Time returns the time.
+func (t *Timetag) Time() time.Time {
+	return t.time
+}
+
+// FractionalSecond returns the last 32 bits of the OSC time tag. Specifies the
+// fractional part of a second.
+func (t *Timetag) FractionalSecond() uint32 {
+	return uint32(t.timeTag << 32)
+}
+
+// SecondsSinceEpoch returns the first 32 bits (the number of seconds since the
+// midnight 1900) from the OSC time tag.
+func (t *Timetag) SecondsSinceEpoch() uint32 {
+	return uint32(t.timeTag >> 32)
+}
+
+// TimeTag returns the time tag value
+func (t *Timetag) TimeTag() uint64 {
+	return t.timeTag
+}
+
+// MarshalBinary converts the OSC time tag to a byte array.
+func (t *Timetag) MarshalBinary() ([]byte, error) {
+	return appendUint64(nil, t.timeTag), nil
+}
+
+// SetTime sets the value of the OSC time tag.
+func (t *Timetag) SetTime(time time.Time) {
+	t.time = time
+	t.timeTag = timeToTimetag(time)
+}
+
+// ExpiresIn calculates the number of seconds until the current time is the
+// same as the value of the time tag. It returns zero if the value of the
+// time tag is in the past.
+func (t *Timetag) ExpiresIn() time.Duration {
+	if t.timeTag <= 1 {
+		return 0
+	}
+
+	tt := timetagToTime(t.timeTag)
+	seconds := tt.Sub(time.Now())
+
+	if seconds <= 0 {
+		return 0
+	}
+
+	return seconds
+}
+
+// timeToTimetag converts the given time to an OSC time tag.
+//
+// An OSC time tag is defined as follows:
+// Time tags are represented by a 64 bit fixed point number. The first 32 bits
+// specify the number of seconds since midnight on January 1, 1900, and the
+// last 32 bits specify fractional parts of a second to a precision of about
+// 200 picoseconds. This is the representation used by Internet NTP timestamps.
+//
+// The time tag value consisting of 63 zero bits followed by a one in the least
+// significant bit is a special case meaning "immediately."
+func timeToTimetag(time time.Time) (timetag uint64) {
+	timetag = uint64((secondsFrom1900To1970 + time.Unix()) << 32)
+	return (timetag + uint64(uint32(time.Nanosecond())))
+}
+
+// timetagToTime converts the given timetag to a time object.
+func timetagToTime(timetag uint64) (t time.Time) {
+	return time.Unix(int64((timetag>>32)-secondsFrom1900To1970), int64(timetag&0xffffffff))
+}
+
+////
+// De/Encoding functions
+////
+
+// appendBlob appends the data byte array as an OSC blob to buf. If the length
+// of data isn't 32-bit aligned, padding bytes will be added.
+func appendBlob(buf []byte, data []byte) []byte {
+	buf = appendUint32(buf, uint32(len(data)))
+	buf = append(buf, data...)
+	return append(buf, padding[:blobPadBytesNeeded(len(data))]...)
+}
+
+// appendPaddedString appends a string with padding bytes to buf.
+func appendPaddedString(buf []byte, str string) []byte {
+	buf = append(buf, str...)
+	return append(buf, padding[:padBytesNeeded(len(str))]...)
+}
+
+// appendUint32 appends v in big-endian byte order to buf.
+func appendUint32(buf []byte, v uint32) []byte {
+	return append(buf, byte(v>>24), byte(v>>16), byte(v>>8), byte(v))
+}
+
+// appendUint64 appends v in big-endian byte order to buf.
+func appendUint64(buf []byte, v uint64) []byte {
+	return append(buf,
+		byte(v>>56), byte(v>>48), byte(v>>40), byte(v>>32),
+		byte(v>>24), byte(v>>16), byte(v>>8), byte(v))
+}
+
+// padBytesNeeded determines how many bytes are needed to fill up to the next 4
+// byte length.
+func padBytesNeeded(elementLen int) int {
+	return 4*(elementLen/4+1) - elementLen
+}
+
+// blobPadBytesNeeded determines how many bytes are needed to fill a blob of
+// the given length up to a multiple of 4 bytes. In contrast to strings, blobs
+// have no terminator, so no padding is needed if the length is a multiple of
+// 4.
+func blobPadBytesNeeded(blobLen int) int {
+	return (4 - blobLen%4) % 4
+}
+
+////
+// Utility and helper functions
+////
+
+// PrintMessage pretty prints an OSC message to the standard output.
+func PrintMessage(msg *Message) {
+	fmt.Println(msg)
+}
+
+// ArgumentsEqual returns true if the OSC arguments a and b are equal, as
+// compared by Message.EqualsApprox. Floats are compared with the given
+// tolerance, NaN is considered equal to NaN. Time tags are compared by their
+// OSC time tag value, Nil is equal to nil.
+func ArgumentsEqual(a, b interface{}, epsilon float64) bool {
+	switch x := a.(type) {
+	case nil, Nil:
+		return b == nil || b == Nil{}
+
+	case float32:
+		y, ok := b.(float32)
+		return ok && floatsEqual(float64(x), float64(y), epsilon)
+
+	case float64:
+		y, ok := b.(float64)
+		return ok && floatsEqual(x, y, epsilon)
+
+	case []byte:
+		y, ok := b.([]byte)
+		return ok && bytes.Equal(x, y)
+
+	case Timetag:
+		y, ok := b.(Timetag)
+		return ok && x.TimeTag() == y.TimeTag()
+
+	case time.Time:
+		y, ok := b.(time.Time)
+		return ok && x.Equal(y)
+
+	case []interface{}:
+		y, ok := b.([]interface{})
+		if !ok || len(x) != len(y) {
+			return false
+		}
+		for i := range x {
+			if !ArgumentsEqual(x[i], y[i], epsilon) {
+				return false
+			}
+		}
+		return true
+
+	default:
+		return reflect.DeepEqual(a, b)
+	}
+}
+
+// floatsEqual returns true if x and y differ by at most epsilon or are both
+// NaN.
+func floatsEqual(x, y, epsilon float64) bool {
+	if math.IsNaN(x) || math.IsNaN(y) {
+		return math.IsNaN(x) && math.IsNaN(y)
+	}
+	return x == y || math.Abs(x-y) <= epsilon
+}
+
+// validateAddressPattern returns an error if addr isn't a valid OSC address
+// pattern, i.e. if it doesn't start with '/', contains characters that aren't
+// allowed or has a syntax error.
+func validateAddressPattern(addr string) error {
+	if !strings.HasPrefix(addr, "/") {
+		return &PatternSyntaxError{Pattern: addr, Offset: 0, Reason: "address must start with '/'"}
+	}
+	wildcard := false
+	for i := 0; i < len(addr); i++ {
+		switch addr[i] {
+		case 0, ' ', '#':
+			return &PatternSyntaxError{Pattern: addr, Offset: i, Reason: fmt.Sprintf("invalid character %q", addr[i])}
+		case '*', '?', '[', ']', '{', '}', '\\':
+			wildcard = true
+		}
+	}
+	if wildcard {
+		_, err := CompilePattern(addr)
+		return err
+	}
+	return nil
+}
+
+// getTypeTag returns the OSC type tag for the given argument.
+func getTypeTag(arg interface{}) (string, error) {
+	switch t := arg.(type) {
+	case bool:
+		if arg.(bool) {
+			return "T", nil
+		}
+		return "F", nil
+	case nil, Nil:
+		return "N", nil
+	case Impulse:
+		return "I", nil
+	case Char:
+		return "c", nil
+	case MIDI:
+		return "m", nil
+	case RGBA:
+		return "r", nil
+	case int32:
+		return "i", nil
+	case float32:
+		return "f", nil
+	case string:
+		return "s", nil
+	case []byte:
+		return "b", nil
+	case int64:
+		return "h", nil
+	case float64:
+		return "d", nil
+	case Timetag, time.Time:
+		return "t", nil
+	case []interface{}:
+		tags := "["
+		for _, arg := range t {
+			s, err := getTypeTag(arg)
+			if err != nil {
+				return "", err
+			}
+			tags += s
+		}
+		return tags + "]", nil
+	default:
+		_, tag, err := appendCustom(nil, t)
+		if err != nil {
+			return "", fmt.Errorf("Unsupported type: %T", t)
+		}
+		return string(tag), nil
+	}
+}
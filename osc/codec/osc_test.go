@@ -0,0 +1,956 @@
+package codec
+
+import (
+	"bytes"
+	"encoding/binary"
+	"fmt"
+	"math"
+	"reflect"
+	"testing"
+	"time"
+)
+
+func TestMessage_Append(t *testing.T) {
+	oscAddress := "/address"
+	message := NewMessage(oscAddress)
+	if message.Address != oscAddress {
+		t.Errorf("OSC address should be \"%s\" and is \"%s\"", oscAddress, message.Address)
+	}
+
+	message.Append("string argument")
+	message.Append(123456789)
+	message.Append(true)
+
+	if message.CountArguments() != 3 {
+		t.Errorf("Number of arguments should be %d and is %d", 3, message.CountArguments())
+	}
+}
+
+func TestMessage_Equals(t *testing.T) {
+	msg1 := NewMessage("/address")
+	msg2 := NewMessage("/address")
+	msg1.Append(1234)
+	msg2.Append(1234)
+	msg1.Append("test string")
+	msg2.Append("test string")
+
+	if !msg1.Equals(msg2) {
+		t.Error("Messages should be equal")
+	}
+}
+
+func TestMessage_EqualsApprox(t *testing.T) {
+	now := time.Now()
+	for _, tt := range []struct {
+		desc    string
+		a, b    *Message
+		epsilon float64
+		want    bool
+	}{
+		{"nil", nil, nil, 0, true},
+		{"one_nil", NewMessage("/a"), nil, 0, false},
+		{"address", NewMessage("/a"), NewMessage("/b"), 0, false},
+		{"arg_count", NewMessage("/a", int32(1)), NewMessage("/a"), 0, false},
+		{"blob", NewMessage("/a", []byte{1, 2}), NewMessage("/a", []byte{1, 2}), 0, true},
+		{"blob_differs", NewMessage("/a", []byte{1, 2}), NewMessage("/a", []byte{1, 3}), 0, false},
+		{"nil_arg", NewMessage("/a", nil), NewMessage("/a", nil), 0, true},
+		{"nil_vs_false", NewMessage("/a", nil), NewMessage("/a", false), 0, false},
+		{"timetag", NewMessage("/a", *NewTimetag(now)), NewMessage("/a", *NewTimetagFromTimetag(timeToTimetag(now))), 0, true},
+		{"float32_exact", NewMessage("/a", float32(0.1)), NewMessage("/a", float32(0.1)), 0, true},
+		{"float32_strict", NewMessage("/a", float32(0.1)), NewMessage("/a", float32(0.1001)), 0, false},
+		{"float32_approx", NewMessage("/a", float32(0.1)), NewMessage("/a", float32(0.1001)), 0.001, true},
+		{"float64_approx", NewMessage("/a", 0.1), NewMessage("/a", 0.2), 0.01, false},
+		{"float_types", NewMessage("/a", float32(1)), NewMessage("/a", float64(1)), 1, false},
+		{"nan", NewMessage("/a", math.NaN()), NewMessage("/a", math.NaN()), 0, true},
+	} {
+		if got := tt.a.EqualsApprox(tt.b, tt.epsilon); got != tt.want {
+			t.Errorf("%s: EqualsApprox() = %t, want = %t", tt.desc, got, tt.want)
+		}
+	}
+}
+
+func TestBundle_Equals(t *testing.T) {
+	newBundle := func(arg int32) *Bundle {
+		inner := NewBundle(time.Unix(100, 0))
+		inner.Append(NewMessage("/inner", arg))
+		b := NewBundle(time.Unix(200, 0))
+		b.Append(NewMessage("/outer", []byte{1}))
+		b.Append(inner)
+		return b
+	}
+
+	if !newBundle(1).Equals(newBundle(1)) {
+		t.Error("bundles should be equal")
+	}
+	if newBundle(1).Equals(newBundle(2)) {
+		t.Error("bundles with different nested arguments should not be equal")
+	}
+	if newBundle(1).Equals(NewBundle(time.Unix(200, 0))) {
+		t.Error("bundles with different elements should not be equal")
+	}
+	if newBundle(1).Equals(nil) {
+		t.Error("bundle should not be equal to nil")
+	}
+}
+
+func TestMessage_Clone(t *testing.T) {
+	blob := []byte{1, 2, 3}
+	msg := NewMessage("/address", int32(1), blob, "str")
+	clone := msg.Clone()
+	if !clone.Equals(msg) {
+		t.Fatalf("clone = %v, want = %v", clone, msg)
+	}
+
+	blob[0] = 42
+	clone.Arguments[0] = int32(2)
+	if got := clone.Arguments[1].([]byte)[0]; got != 1 {
+		t.Errorf("clone shares the blob with the original, blob[0] = %d", got)
+	}
+	if got := msg.Arguments[0].(int32); got != 1 {
+		t.Errorf("original was modified through the clone, argument = %d", got)
+	}
+	if (*Message)(nil).Clone() != nil {
+		t.Error("clone of nil message should be nil")
+	}
+}
+
+func TestBundle_Clone(t *testing.T) {
+	inner := NewBundle(time.Unix(100, 0))
+	inner.Append(NewMessage("/inner", []byte{1}))
+	bundle := NewBundle(time.Unix(200, 0))
+	bundle.Append(NewMessage("/outer", int32(1)))
+	bundle.Append(inner)
+
+	clone := bundle.Clone()
+	if !clone.Equals(bundle) {
+		t.Fatal("clone should be equal to the original bundle")
+	}
+
+	inner.Messages[0].Arguments[0].([]byte)[0] = 42
+	inner.Messages[0].Address = "/changed"
+	if got := clone.Bundles[0].Messages[0]; got.Address != "/inner" || got.Arguments[0].([]byte)[0] != 1 {
+		t.Errorf("nested message of the clone was modified: %v", got)
+	}
+}
+
+func TestMessage_TypeTags(t *testing.T) {
+	for _, tt := range []struct {
+		desc string
+		msg  *Message
+		tags string
+		ok   bool
+	}{
+		{"addr_only", NewMessage("/"), ",", true},
+		{"nil", NewMessage("/", nil), ",N", true},
+		{"bool_true", NewMessage("/", true), ",T", true},
+		{"bool_false", NewMessage("/", false), ",F", true},
+		{"int32", NewMessage("/", int32(1)), ",i", true},
+		{"int64", NewMessage("/", int64(2)), ",h", true},
+		{"float32", NewMessage("/", float32(3.0)), ",f", true},
+		{"float64", NewMessage("/", float64(4.0)), ",d", true},
+		{"string", NewMessage("/", "5"), ",s", true},
+		{"[]byte", NewMessage("/", []byte{'6'}), ",b", true},
+		{"impulse", NewMessage("/", Impulse{}), ",I", true},
+		{"char", NewMessage("/", Char('7')), ",c", true},
+		{"two_args", NewMessage("/", "123", int32(456)), ",si", true},
+		{"invalid_msg", nil, "", false},
+		{"invalid_arg", NewMessage("/foo/bar", 789), "", false},
+	} {
+		tags, err := tt.msg.TypeTags()
+		if err != nil && tt.ok {
+			t.Errorf("%s: TypeTags() unexpected error: %s", tt.desc, err)
+			continue
+		}
+		if err == nil && !tt.ok {
+			t.Errorf("%s: TypeTags() expected an error", tt.desc)
+			continue
+		}
+		if !tt.ok {
+			continue
+		}
+		if got, want := tags, tt.tags; got != want {
+			t.Errorf("%s: TypeTags() = '%s', want = '%s'", tt.desc, got, want)
+		}
+	}
+}
+
+func TestMessage_String(t *testing.T) {
+	for _, tt := range []struct {
+		desc string
+		msg  *Message
+		str  string
+	}{
+		{"nil", nil, ""},
+		{"addr_only", NewMessage("/foo/bar"), "/foo/bar ,"},
+		{"one_addr", NewMessage("/foo/bar", "123"), "/foo/bar ,s 123"},
+		{"two_args", NewMessage("/foo/bar", "123", int32(456)), "/foo/bar ,si 123 456"},
+	} {
+		if got, want := tt.msg.String(), tt.str; got != want {
+			t.Errorf("%s: String() = '%s', want = '%s'", tt.desc, got, want)
+		}
+	}
+}
+
+func TestMessage_MarshalBinary(t *testing.T) {
+	msg := NewMessage("/a/bc", int32(-2), int64(3), float32(1.5), 2.25, "abcd", "ab",
+		[]byte{1, 2, 3, 4}, []byte{5}, true, false, nil, Impulse{}, Char('z'))
+	want := "/a/bc" + nulls(3) +
+		",ihfdssbbTFNIc" + nulls(2) +
+		"\xff\xff\xff\xfe" +
+		"\x00\x00\x00\x00\x00\x00\x00\x03" +
+		"\x3f\xc0\x00\x00" +
+		"\x40\x02\x00\x00\x00\x00\x00\x00" +
+		"abcd" + nulls(4) +
+		"ab" + nulls(2) +
+		"\x00\x00\x00\x04\x01\x02\x03\x04" +
+		"\x00\x00\x00\x01\x05" + nulls(3) +
+		"\x00\x00\x00z"
+
+	data, err := msg.MarshalBinary()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got := string(data); got != want {
+		t.Errorf("MarshalBinary() = %x, want = %x", got, want)
+	}
+}
+
+func TestMessage_MarshalBinary_Validation(t *testing.T) {
+	for _, tt := range []struct {
+		msg   *Message
+		valid bool
+	}{
+		{NewMessage("/a/b"), true},
+		{NewMessage("/a/*/[0-9]/{x,y}"), true},
+		{NewMessage("/a", "text"), true},
+		{NewMessage(""), false},
+		{NewMessage("a/b"), false},
+		{NewMessage("/a b"), false},
+		{NewMessage("/a#b"), false},
+		{NewMessage("/a\x00b"), false},
+		{NewMessage("/a/[0-9"), false},
+		{NewMessage("/a/{x,y"), false},
+		{NewMessage("/a", "te\x00xt"), false},
+	} {
+		_, err := tt.msg.MarshalBinary()
+		if tt.valid && err != nil {
+			t.Errorf("%q: unexpected error: %s", tt.msg.Address, err)
+		}
+		if !tt.valid && err == nil {
+			t.Errorf("%q %v: expected error", tt.msg.Address, tt.msg.Arguments)
+		}
+
+		tt.msg.SkipValidation = true
+		if _, err := tt.msg.MarshalBinary(); err != nil {
+			t.Errorf("%q: unexpected error with SkipValidation: %s", tt.msg.Address, err)
+		}
+	}
+
+	bundle := NewBundle(time.Now())
+	bundle.Append(NewMessage("no/slash"))
+	if _, err := bundle.MarshalBinary(); err == nil {
+		t.Error("expected error for bundle with invalid message")
+	}
+}
+
+func BenchmarkMessage_MarshalBinary(b *testing.B) {
+	msg := NewMessage("/mixer/channel/1/eq")
+	for i := 0; i < 8; i++ {
+		msg.Append(float32(i) * 0.1)
+	}
+
+	b.ReportAllocs()
+	for i := 0; i < b.N; i++ {
+		if _, err := msg.MarshalBinary(); err != nil {
+			b.Fatal(err)
+		}
+	}
+}
+
+func BenchmarkBundle_MarshalBinary(b *testing.B) {
+	bundle := NewBundle(time.Now())
+	for i := 0; i < 8; i++ {
+		bundle.Append(NewMessage(fmt.Sprintf("/mixer/channel/%d/fader", i), float32(i)*0.1))
+	}
+
+	b.ReportAllocs()
+	for i := 0; i < b.N; i++ {
+		if _, err := bundle.MarshalBinary(); err != nil {
+			b.Fatal(err)
+		}
+	}
+}
+
+func TestReadPaddedString(t *testing.T) {
+	for _, tt := range []struct {
+		buf []byte // buffer
+		n   int    // bytes needed
+		s   string // resulting string
+	}{
+		{[]byte{'t', 'e', 's', 't', 's', 't', 'r', 'i', 'n', 'g', 0, 0}, 12, "teststring"},
+		{[]byte{'t', 'e', 's', 't', 0, 0, 0, 0}, 8, "test"},
+		{[]byte{'t', 'e', 's', 't', 0, 0, 0, 0, 'x', 0, 0, 0}, 8, "test"},
+		{[]byte{0, 0, 0, 0}, 4, ""},
+	} {
+		r := &byteReader{data: tt.buf}
+		s, err := r.readPaddedString()
+		n := r.pos
+		if err != nil {
+			t.Errorf("%s: Error reading padded string: %s", s, err)
+		}
+		if got, want := n, tt.n; got != want {
+			t.Errorf("%s: Bytes needed don't match; got = %d, want = %d", tt.s, got, want)
+		}
+		if got, want := s, tt.s; got != want {
+			t.Errorf("%s: Strings don't match; got = %s, want = %s", tt.s, got, want)
+		}
+	}
+}
+
+func TestAppendPaddedString(t *testing.T) {
+	buf := []byte{}
+	testString := "testString"
+	expectedNumberOfWrittenBytes := len(testString) + padBytesNeeded(len(testString))
+
+	buf = appendPaddedString(buf, testString)
+
+	if n := len(buf); n != expectedNumberOfWrittenBytes {
+		t.Errorf("Expected number of written bytes should be \"%d\" and is \"%d\"", expectedNumberOfWrittenBytes, n)
+	}
+	if got, want := string(buf), testString+nulls(2); got != want {
+		t.Errorf("Expected padded string to be %q and is %q", want, got)
+	}
+}
+
+func TestDecoder_DecodeBytes(t *testing.T) {
+	msg := NewMessage("/blob", []byte{1, 2, 3, 4}, []byte{5}, "abcd", int32(1))
+	data, err := msg.MarshalBinary()
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	var d Decoder
+	p, err := d.DecodeBytes(data)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !p.(*Message).Equals(msg) {
+		t.Errorf("DecodeBytes() = %v, want = %v", p, msg)
+	}
+
+	// Truncated packets must be reported as error
+	for i := 1; i < len(data); i++ {
+		if _, err := d.DecodeBytes(data[:i]); err == nil {
+			t.Errorf("DecodeBytes() of %d/%d bytes expected an error", i, len(data))
+		}
+	}
+
+	// Decoded blobs must not share memory with the input
+	p, _ = d.DecodeBytes(data)
+	data[bytes.Index(data, []byte{1, 2, 3, 4})] = 42
+	if got := p.(*Message).Arguments[0].([]byte)[0]; got != 1 {
+		t.Errorf("decoded blob changed with the input to %d", got)
+	}
+}
+
+func TestBlobPadBytesNeeded(t *testing.T) {
+	for l, want := range []int{0, 3, 2, 1, 0, 3} {
+		if got := blobPadBytesNeeded(l); got != want {
+			t.Errorf("blobPadBytesNeeded(%d) = %d, want = %d", l, got, want)
+		}
+	}
+}
+
+func TestPadBytesNeeded(t *testing.T) {
+	var n int
+	n = padBytesNeeded(4)
+	if n != 4 {
+		t.Errorf("Number of pad bytes should be 4 and is: %d", n)
+	}
+
+	n = padBytesNeeded(3)
+	if n != 1 {
+		t.Errorf("Number of pad bytes should be 1 and is: %d", n)
+	}
+
+	n = padBytesNeeded(1)
+	if n != 3 {
+		t.Errorf("Number of pad bytes should be 3 and is: %d", n)
+	}
+
+	n = padBytesNeeded(0)
+	if n != 4 {
+		t.Errorf("Number of pad bytes should be 4 and is: %d", n)
+	}
+
+	n = padBytesNeeded(32)
+	if n != 4 {
+		t.Errorf("Number of pad bytes should be 4 and is: %d", n)
+	}
+
+	n = padBytesNeeded(63)
+	if n != 1 {
+		t.Errorf("Number of pad bytes should be 1 and is: %d", n)
+	}
+
+	n = padBytesNeeded(10)
+	if n != 2 {
+		t.Errorf("Number of pad bytes should be 2 and is: %d", n)
+	}
+}
+
+func TestTypeTagsString(t *testing.T) {
+	msg := NewMessage("/some/address")
+	msg.Append(int32(100))
+	msg.Append(true)
+	msg.Append(false)
+
+	typeTags, err := msg.TypeTags()
+	if err != nil {
+		t.Error(err.Error())
+	}
+
+	if typeTags != ",iTF" {
+		t.Errorf("Type tag string should be ',iTF' and is: %s", typeTags)
+	}
+}
+
+func TestParsePacket(t *testing.T) {
+	for _, tt := range []struct {
+		desc string
+		msg  string
+		pkt  Packet
+		ok   bool
+	}{
+		{"no_args",
+			"/a/b/c" + nulls(2) + "," + nulls(3),
+			makePacket("/a/b/c", nil),
+			true},
+		{"string_arg",
+			"/d/e/f" + nulls(2) + ",s" + nulls(2) + "foo" + nulls(1),
+			makePacket("/d/e/f", []string{"foo"}),
+			true},
+		{"empty", "", nil, false},
+	} {
+		pkt, err := ParsePacket(tt.msg)
+		if err != nil && tt.ok {
+			t.Errorf("%s: ParsePacket() returned unexpected error; %s", tt.desc, err)
+		}
+		if err == nil && !tt.ok {
+			t.Errorf("%s: ParsePacket() expected error", tt.desc)
+		}
+		if !tt.ok {
+			continue
+		}
+
+		pktBytes, err := pkt.MarshalBinary()
+		if err != nil {
+			t.Errorf("%s: failure converting pkt to byte array; %s", tt.desc, err)
+			continue
+		}
+		ttpktBytes, err := tt.pkt.MarshalBinary()
+		if err != nil {
+			t.Errorf("%s: failure converting tt.pkt to byte array; %s", tt.desc, err)
+			continue
+		}
+		if got, want := pktBytes, ttpktBytes; !reflect.DeepEqual(got, want) {
+			t.Errorf("%s: ParsePacket() as bytes = '%s', want = '%s'", tt.desc, got, want)
+			continue
+		}
+	}
+}
+
+func TestImpulseAndCharRoundTrip(t *testing.T) {
+	msg := NewMessage("/trigger", Impulse{}, Char('x'), int32(0))
+	data, err := msg.MarshalBinary()
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	pkt, err := ParsePacket(string(data))
+	if err != nil {
+		t.Fatal(err)
+	}
+	got, ok := pkt.(*Message)
+	if !ok {
+		t.Fatalf("expected *Message, got %T", pkt)
+	}
+	if !got.Equals(msg) {
+		t.Errorf("round trip = %v, want = %v", got.Arguments, msg.Arguments)
+	}
+	if _, ok := got.Arguments[0].(Impulse); !ok {
+		t.Errorf("first argument should be an Impulse and is %T", got.Arguments[0])
+	}
+	if c, ok := got.Arguments[1].(Char); !ok || c != 'x' {
+		t.Errorf("second argument should be Char('x') and is %T(%v)", got.Arguments[1], got.Arguments[1])
+	}
+}
+
+func TestPayloadlessArgumentsRoundTrip(t *testing.T) {
+	msg := NewMessage("/flags")
+	msg.Append(true, false, nil, Impulse{}, int32(7), false)
+	if tags, _ := msg.TypeTags(); tags != ",TFNIiF" {
+		t.Fatalf("TypeTags() = %s, want = ,TFNIiF", tags)
+	}
+
+	data, err := msg.MarshalBinary()
+	if err != nil {
+		t.Fatal(err)
+	}
+	// 'T', 'F', 'N' and 'I' carry no data, only the int32 has a payload
+	if want := "/flags" + nulls(2) + ",TFNIiF" + nulls(1) + "\x00\x00\x00\x07"; string(data) != want {
+		t.Errorf("MarshalBinary() = %q, want = %q", data, want)
+	}
+
+	pkt, err := ParsePacket(string(data))
+	if err != nil {
+		t.Fatal(err)
+	}
+	got := pkt.(*Message)
+	if !got.Equals(msg) {
+		t.Errorf("round trip = %#v, want = %#v", got.Arguments, msg.Arguments)
+	}
+}
+
+func TestNilArgument(t *testing.T) {
+	msg := NewMessage("/clear", Nil{}, int32(1))
+	if tags, _ := msg.TypeTags(); tags != ",Ni" {
+		t.Errorf("TypeTags() = %s, want = ,Ni", tags)
+	}
+	data, err := msg.MarshalBinary()
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	pkt, err := ParsePacket(string(data))
+	if err != nil {
+		t.Fatal(err)
+	}
+	got := pkt.(*Message)
+	if got.Arguments[0] != (Nil{}) {
+		t.Errorf("decoded argument = %#v, want = Nil{}", got.Arguments[0])
+	}
+	if !got.Equals(msg) || !got.Equals(NewMessage("/clear", nil, int32(1))) {
+		t.Errorf("decoded message %v should equal messages with Nil and nil", got)
+	}
+	if got.Equals(NewMessage("/clear", false, int32(1))) {
+		t.Error("Nil should not equal false")
+	}
+	if s := got.String(); s != "/clear ,Ni Nil 1" {
+		t.Errorf("String() = %q, want = %q", s, "/clear ,Ni Nil 1")
+	}
+}
+
+func TestBundle_AppendAt(t *testing.T) {
+	start := time.Now().Add(time.Second)
+	b := NewBundle(start)
+	cues := []struct {
+		msg    *Message
+		offset time.Duration
+	}{
+		{NewMessage("/light/1", float32(1)), 0},
+		{NewMessage("/light/2", float32(1)), 500 * time.Millisecond},
+		{NewMessage("/sound/1", "go"), 0},
+		{NewMessage("/light/3", float32(1)), 2 * time.Second},
+		{NewMessage("/light/2", float32(0)), 500 * time.Millisecond},
+	}
+	for _, c := range cues {
+		if err := b.AppendAfter(c.msg, c.offset); err != nil {
+			t.Fatal(err)
+		}
+	}
+	if err := b.AppendAt(NewMessage("/late"), start.Add(-time.Millisecond)); err == nil {
+		t.Error("expected error for a packet scheduled before the bundle")
+	}
+
+	if len(b.Messages) != 2 || len(b.Bundles) != 2 {
+		t.Fatalf("bundle has %d messages and %d bundles, want = 2 and 2", len(b.Messages), len(b.Bundles))
+	}
+	for i, want := range []struct {
+		time     time.Time
+		messages int
+	}{
+		{start.Add(500 * time.Millisecond), 2},
+		{start.Add(2 * time.Second), 1},
+	} {
+		nested := b.Bundles[i]
+		if got := nested.Timetag.TimeTag(); got != timeToTimetag(want.time) {
+			t.Errorf("nested bundle %d has time tag %d, want = %d", i, got, timeToTimetag(want.time))
+		}
+		if len(nested.Messages) != want.messages {
+			t.Errorf("nested bundle %d has %d messages, want = %d", i, len(nested.Messages), want.messages)
+		}
+	}
+
+	// The nested bundles survive the round trip through the wire format
+	data, err := b.MarshalBinary()
+	if err != nil {
+		t.Fatal(err)
+	}
+	p, err := ParsePacket(string(data))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !p.(*Bundle).Equals(b) {
+		t.Errorf("decoded bundle = %v, want = %v", p, b)
+	}
+
+	in := NewBundleIn(time.Minute)
+	if d := in.Timetag.ExpiresIn(); d < 59*time.Second || d > time.Minute {
+		t.Errorf("NewBundleIn(time.Minute) expires in %s", d)
+	}
+}
+
+func TestParsePacket_Bundle(t *testing.T) {
+	inner := NewBundle(time.Unix(0, 0))
+	if err := inner.Append(NewMessage("/inner", "abcd")); err != nil {
+		t.Fatal(err)
+	}
+	bundle := NewBundle(time.Unix(0, 0))
+	for _, p := range []Packet{NewMessage("/first", int32(1)), NewMessage("/second", []byte{1, 2, 3}), inner} {
+		if err := bundle.Append(p); err != nil {
+			t.Fatal(err)
+		}
+	}
+	data, err := bundle.MarshalBinary()
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	pkt, err := ParsePacket(string(data))
+	if err != nil {
+		t.Fatal(err)
+	}
+	got, ok := pkt.(*Bundle)
+	if !ok {
+		t.Fatalf("expected *Bundle, got %T", pkt)
+	}
+	if len(got.Messages) != 2 || len(got.Bundles) != 1 || len(got.Bundles[0].Messages) != 1 {
+		t.Fatalf("unexpected bundle structure: %d messages, %d bundles", len(got.Messages), len(got.Bundles))
+	}
+	for i, want := range bundle.Messages {
+		if !got.Messages[i].Equals(want) {
+			t.Errorf("message %d = %v, want = %v", i, got.Messages[i], want)
+		}
+	}
+	if !got.Bundles[0].Messages[0].Equals(inner.Messages[0]) {
+		t.Errorf("nested message = %v, want = %v", got.Bundles[0].Messages[0], inner.Messages[0])
+	}
+}
+
+func TestParsePacket_InvalidBundleElement(t *testing.T) {
+	header := "#bundle" + nulls(1) + nulls(8)
+	message := "/a" + nulls(2) + "," + nulls(3)
+	for _, tt := range []struct {
+		desc   string
+		length byte
+		data   string
+	}{
+		{"unaligned", 7, message},
+		{"too_long", 12, message},
+		{"zero", 0, message},
+		{"no_packet", 8, "abcd" + nulls(4)},
+	} {
+		_, err := ParsePacket(header + nulls(3) + string([]byte{tt.length}) + tt.data)
+		if de, ok := err.(*DecodeError); !ok || de.Err != ErrInvalidBundleElement {
+			t.Errorf("%s: ParsePacket() error = %v, want = %v", tt.desc, err, ErrInvalidBundleElement)
+		}
+	}
+}
+
+func TestDecodeError(t *testing.T) {
+	message := "/a" + nulls(2) + ",i" + nulls(2) + nulls(4)
+	for _, tt := range []struct {
+		desc   string
+		data   string
+		err    error
+		offset int
+	}{
+		{"empty", "", ErrUnexpectedEOF, 0},
+		{"unterminated address", "/abc", ErrUnexpectedEOF, 0},
+		{"missing argument", "/a" + nulls(2) + ",i" + nulls(2), ErrUnexpectedEOF, 8},
+		{"missing comma", "/a" + nulls(2) + "i" + nulls(3), ErrInvalidTypeTag, 4},
+		{"unsupported tag", "/a" + nulls(2) + ",ix" + nulls(1) + nulls(4), ErrInvalidTypeTag, 6},
+		{"truncated blob", "/a" + nulls(2) + ",b" + nulls(2) + nulls(3) + "\x08abcd", ErrUnexpectedEOF, 8},
+		{"bundle tag", "#bundlex" + nulls(8), ErrInvalidBundleTag, 0},
+		{"neither message nor bundle", "garbage" + nulls(1), ErrInvalidPacket, 0},
+		{"nested", "#bundle" + nulls(1) + nulls(8) + nulls(3) + "\x0c" + "/a" + nulls(2) + ",x" + nulls(2) + nulls(4), ErrInvalidTypeTag, 25},
+		{"nested ok", "#bundle" + nulls(1) + nulls(8) + nulls(3) + "\x0c" + message, nil, 0},
+	} {
+		_, err := ParsePacket(tt.data)
+		if tt.err == nil {
+			if err != nil {
+				t.Errorf("%s: unexpected error: %s", tt.desc, err)
+			}
+			continue
+		}
+		de, ok := err.(*DecodeError)
+		if !ok {
+			t.Errorf("%s: error = %#v, want a *DecodeError", tt.desc, err)
+			continue
+		}
+		if de.Err != tt.err || de.Offset != tt.offset {
+			t.Errorf("%s: error = %v at offset %d, want = %v at offset %d", tt.desc, de.Err, de.Offset, tt.err, tt.offset)
+		}
+		if de.Unwrap() != tt.err {
+			t.Errorf("%s: Unwrap() = %v, want = %v", tt.desc, de.Unwrap(), tt.err)
+		}
+		if want := tt.data[tt.offset:]; len(want) > 16 {
+			want = want[:16]
+			if string(de.Bytes) != want {
+				t.Errorf("%s: Bytes = %q, want = %q", tt.desc, de.Bytes, want)
+			}
+		}
+	}
+}
+
+func TestTimeArguments(t *testing.T) {
+	now := time.Unix(1500000000, 123456789)
+	msg := NewMessage("/sensor", now)
+	if tags, err := msg.TypeTags(); err != nil || tags != ",t" {
+		t.Fatalf("TypeTags() = '%s', %v, want = ',t'", tags, err)
+	}
+	data, err := msg.MarshalBinary()
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	pkt, err := ParsePacket(string(data))
+	if err != nil {
+		t.Fatal(err)
+	}
+	tt, ok := pkt.(*Message).Arguments[0].(Timetag)
+	if !ok {
+		t.Fatalf("expected Timetag argument, got %T", pkt.(*Message).Arguments[0])
+	}
+	if got, want := tt.TimeTag(), timeToTimetag(now); got != want {
+		t.Errorf("decoded time tag = %d, want = %d", got, want)
+	}
+
+	pkt, err = ParsePacketWithOptions(string(data), DecodeOptions{TimeArguments: true})
+	if err != nil {
+		t.Fatal(err)
+	}
+	got, ok := pkt.(*Message).Arguments[0].(time.Time)
+	if !ok {
+		t.Fatalf("expected time.Time argument, got %T", pkt.(*Message).Arguments[0])
+	}
+	if !got.Equal(now) {
+		t.Errorf("decoded time = %s, want = %s", got, now)
+	}
+	if !pkt.(*Message).Equals(msg) {
+		t.Errorf("decoded message = %v, want = %v", pkt, msg)
+	}
+}
+
+// littleEndianMessage returns the message "/le" with the arguments int32(1),
+// float32(2), int64(3) and float64(4) encoded as little-endian.
+func littleEndianMessage() []byte {
+	buf := bytes.NewBuffer(appendPaddedString(nil, "/le"))
+	buf.Write(appendPaddedString(nil, ",ifhd"))
+	for _, v := range []interface{}{int32(1), float32(2), int64(3), float64(4)} {
+		binary.Write(buf, binary.LittleEndian, v)
+	}
+	return buf.Bytes()
+}
+
+func TestMessage_Arrays(t *testing.T) {
+	msg := NewMessage("/points",
+		[]interface{}{float32(1), float32(2), float32(3)},
+		[]interface{}{"nested", []interface{}{int32(4)}, []interface{}{}},
+		int32(5))
+	if tags, err := msg.TypeTags(); err != nil || tags != ",[fff][s[i][]]i" {
+		t.Fatalf("TypeTags() = '%s', %v, want = ',[fff][s[i][]]i'", tags, err)
+	}
+
+	data, err := msg.MarshalBinary()
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := "/points\x00,[fff][s[i][]]i\x00" +
+		"\x3f\x80\x00\x00\x40\x00\x00\x00\x40\x40\x00\x00" +
+		"nested\x00\x00\x00\x00\x00\x04\x00\x00\x00\x05"
+	if string(data) != want {
+		t.Errorf("MarshalBinary() = % x, want = % x", data, want)
+	}
+
+	p, err := ParsePacket(string(data))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !reflect.DeepEqual(p.(*Message).Arguments, msg.Arguments) {
+		t.Errorf("decoded arguments = %#v, want = %#v", p.(*Message).Arguments, msg.Arguments)
+	}
+	if !p.(*Message).Equals(msg) {
+		t.Errorf("decoded message = %v, want = %v", p, msg)
+	}
+
+	clone := msg.Clone()
+	clone.Arguments[0].([]interface{})[0] = float32(9)
+	if msg.Arguments[0].([]interface{})[0] != float32(1) {
+		t.Error("Clone() didn't copy the array")
+	}
+	if msg.Equals(clone) {
+		t.Error("Equals() = true for different arrays")
+	}
+
+	for _, tt := range []string{
+		"/a\x00\x00,[i\x00\x00\x00\x00\x01",
+		"/a\x00\x00,i]\x00\x00\x00\x00\x01",
+		"/a\x00\x00,]\x00\x00",
+	} {
+		_, err := ParsePacket(tt)
+		if de, ok := err.(*DecodeError); !ok || de.Err != ErrInvalidTypeTag {
+			t.Errorf("ParsePacket(%q) error = %v, want = %v", tt, err, ErrInvalidTypeTag)
+		}
+	}
+}
+
+func TestMessage_Append_CoerceArrays(t *testing.T) {
+	msg := NewMessage("/a")
+	msg.SetCoercion(CoerceNative32)
+	elements := []interface{}{1, 2.5}
+	msg.Append(elements)
+	if want := []interface{}{int32(1), float32(2.5)}; !reflect.DeepEqual(msg.Arguments[0], want) {
+		t.Errorf("Append() = %#v, want = %#v", msg.Arguments[0], want)
+	}
+	if elements[0] != 1 {
+		t.Error("Append() modified the appended array")
+	}
+}
+
+func TestDecodeOptions_Limits(t *testing.T) {
+	msg := NewMessage("/test", int32(1), int32(2), int32(3), int32(4), int32(5))
+	inner := NewBundle(time.Now())
+	if err := inner.Append(msg); err != nil {
+		t.Fatal(err)
+	}
+	middle := NewBundle(time.Now())
+	if err := middle.Append(inner); err != nil {
+		t.Fatal(err)
+	}
+	outer := NewBundle(time.Now())
+	if err := outer.Append(middle); err != nil {
+		t.Fatal(err)
+	}
+
+	for _, tt := range []struct {
+		desc   string
+		packet Packet
+		opts   DecodeOptions
+		err    error
+	}{
+		{"no limits", outer, DecodeOptions{}, nil},
+		{"arguments within limit", msg, DecodeOptions{MaxArguments: 5}, nil},
+		{"too many arguments", msg, DecodeOptions{MaxArguments: 4}, ErrTooManyArguments},
+		{"nested too many arguments", outer, DecodeOptions{MaxArguments: 4}, ErrTooManyArguments},
+		{"depth within limit", outer, DecodeOptions{MaxBundleDepth: 3}, nil},
+		{"bundle too deep", outer, DecodeOptions{MaxBundleDepth: 2}, ErrBundleTooDeep},
+		{"bundle depth 1", inner, DecodeOptions{MaxBundleDepth: 1}, nil},
+		{"message depth 0", msg, DecodeOptions{MaxBundleDepth: 1}, nil},
+	} {
+		data, err := tt.packet.MarshalBinary()
+		if err != nil {
+			t.Fatal(err)
+		}
+		_, err = ParsePacketWithOptions(string(data), tt.opts)
+		if tt.err == nil {
+			if err != nil {
+				t.Errorf("%s: unexpected error: %s", tt.desc, err)
+			}
+			continue
+		}
+		if de, ok := err.(*DecodeError); !ok || de.Err != tt.err {
+			t.Errorf("%s: error = %v, want = %v", tt.desc, err, tt.err)
+		}
+	}
+}
+
+func TestDecodeOptions_ArgumentByteOrder(t *testing.T) {
+	want := NewMessage("/le", int32(1), float32(2), int64(3), float64(4))
+
+	pkt, err := ParsePacket(string(littleEndianMessage()))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if pkt.(*Message).Equals(want) {
+		t.Error("little-endian arguments were decoded with the default byte order")
+	}
+
+	pkt, err = ParsePacketWithOptions(string(littleEndianMessage()), DecodeOptions{ArgumentByteOrder: binary.LittleEndian})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !pkt.(*Message).Equals(want) {
+		t.Errorf("decoded message = %v, want = %v", pkt, want)
+	}
+}
+
+func TestOscMessageMatch(t *testing.T) {
+	tc := []struct {
+		desc        string
+		addr        string
+		addrPattern string
+		want        bool
+	}{
+		{
+			"match everything",
+			"*",
+			"/a/b",
+			true,
+		},
+		{
+			"don't match",
+			"/a/b",
+			"/a",
+			false,
+		},
+		{
+			"match alternatives",
+			"/a/{foo,bar}",
+			"/a/foo",
+			true,
+		},
+		{
+			"don't match if address is not part of the alternatives",
+			"/a/{foo,bar}",
+			"/a/bob",
+			false,
+		},
+		{"negated class", "/[!a]bc", "/xbc", true},
+		{"negated class excludes", "/[!a]bc", "/abc", false},
+		{"anchored", "/foo", "/x/foo/y", false},
+		{"wildcard doesn't cross parts", "/a*", "/a/b", false},
+		{"any depth", "//volume", "/mixer/1/volume", true},
+		{"invalid pattern", "/a[", "/a[", false},
+	}
+
+	for _, tt := range tc {
+		msg := NewMessage(tt.addr)
+
+		got := msg.Match(tt.addrPattern)
+		if got != tt.want {
+			t.Errorf("%s: msg.Match('%s') = '%t', want = '%t'", tt.desc, tt.addrPattern, got, tt.want)
+		}
+	}
+}
+
+const zero = string(byte(0))
+
+// nulls returns a string of `i` nulls.
+func nulls(i int) string {
+	s := ""
+	for j := 0; j < i; j++ {
+		s += zero
+	}
+	return s
+}
+
+// makePacket creates a fake Message Packet.
+func makePacket(addr string, args []string) Packet {
+	msg := NewMessage(addr)
+	for _, arg := range args {
+		msg.Append(arg)
+	}
+	return msg
+}
@@ -0,0 +1,70 @@
+package codec
+
+import "github.com/hypebeast/go-osc/osc/internal/pattern"
+
+// Pattern is a compiled OSC address pattern. It can be matched against OSC
+// addresses without parsing the pattern again. A Pattern is safe for
+// concurrent use.
+//
+// The following pattern characters are supported within an address part,
+// i.e. between two '/':
+//   - '?' matches any single character
+//   - '*' matches any sequence of zero or more characters
+//   - '[abc]' and '[a-z]' match any single character of the given set or range
+//   - '[!abc]' and '[!a-z]' match any single character that isn't in the set
+//     or range
+//   - '{foo,bar}' matches any of the given strings
+//
+// A '-' at the start or end of a character class matches itself. A backslash
+// escapes the following character, e.g. "/a\*b" matches only the address
+// "/a*b", see Quote.
+//
+// The OSC 1.1 operator "//" matches any number of address parts, including
+// none, e.g. "//volume" matches "/volume" and "/mixer/1/volume", and
+// "/mixer//mute" matches "/mixer/mute" and "/mixer/bus/2/mute". Patterns
+// compiled with CompilePatternOSC10 don't support it.
+type Pattern = pattern.Pattern
+
+// MaxPatternLength is the maximum length of an address pattern in bytes.
+// CompilePattern rejects longer patterns, which also limits the time it takes
+// to match the address patterns of received messages.
+const MaxPatternLength = pattern.MaxLength
+
+// MaxAnyDepthOperators is the maximum number of "//" operators in an address
+// pattern. CompilePattern rejects patterns with more operators. Consecutive
+// operators, e.g. "///", count as one.
+const MaxAnyDepthOperators = pattern.MaxAnyDepthOperators
+
+// PatternSyntaxError describes a syntax error in an OSC address pattern.
+type PatternSyntaxError = pattern.SyntaxError
+
+// CompilePattern parses an OSC address pattern and returns a Pattern that can
+// be used to match it against OSC addresses. The pattern must start with '/'.
+func CompilePattern(p string) (*Pattern, error) {
+	return pattern.Compile(p, true)
+}
+
+// CompilePatternOSC10 is like CompilePattern but follows the OSC 1.0
+// specification strictly, i.e. "//" matches an empty address part and not
+// any number of parts.
+func CompilePatternOSC10(p string) (*Pattern, error) {
+	return pattern.Compile(p, false)
+}
+
+// MustCompilePattern is like CompilePattern but panics if the pattern can't be
+// parsed.
+func MustCompilePattern(p string) *Pattern {
+	compiled, err := CompilePattern(p)
+	if err != nil {
+		panic(err)
+	}
+	return compiled
+}
+
+// Quote returns a pattern that matches exactly the given address, i.e. all
+// pattern characters in addr are escaped with a backslash. It allows to
+// match addresses that contain pattern characters, which aren't valid OSC
+// addresses but are sent by some devices.
+func Quote(addr string) string {
+	return pattern.Quote(addr)
+}
@@ -0,0 +1,137 @@
+package codec
+
+import (
+	"strings"
+	"testing"
+)
+
+func TestPattern_Match(t *testing.T) {
+	for _, tt := range []struct {
+		pattern string
+		addr    string
+		want    bool
+	}{
+		{"/foo", "/foo", true},
+		{"/foo", "/fo", false},
+		{"/foo", "/fooo", false},
+		{"/foo", "/foo/bar", false},
+		{"/*", "/", true},
+		{"/*", "/anything", true},
+		{"/*", "/a/b", false},
+		{"/*/*", "/a/b", true},
+		{"/f*o", "/fo", true},
+		{"/f*o", "/fooo", true},
+		{"/f*o", "/foob", false},
+		{"/f**o", "/fo", true},
+		{"/?oo", "/foo", true},
+		{"/?oo", "/oo", false},
+		{"/[abc]x", "/bx", true},
+		{"/[abc]x", "/dx", false},
+		{"/[a-c]", "/b", true},
+		{"/[a-c]", "/d", false},
+		{"/[a-cx]", "/x", true},
+		{"/{foo,bar}", "/bar", true},
+		{"/{foo,bar}", "/baz", false},
+		{"/{foo,bar}*", "/foobar", true},
+		{"/{f,fo}o", "/foo", true},
+		{"/{a,ab}c", "/abc", true},
+		{"/*a*b", "/xaxb", true},
+		{"/*a*b", "/xaxbx", false},
+		{"/*a?", "/aab", true},
+		{"/mixer/*/mute", "/mixer/1/mute", true},
+		{"/mixer/*/mute", "/mixer/1/solo", false},
+		{"/[!abc]x", "/dx", true},
+		{"/[!abc]x", "/bx", false},
+		{"/[!a-c]", "/d", true},
+		{"/[!a-c]", "/b", false},
+		{"/[-a]", "/-", true},
+		{"/[a-]", "/-", true},
+		{"/[a\\-c]", "/-", true},
+		{"/[a\\-c]", "/b", false},
+		{"/[\\]]", "/]", true},
+		{`/a\*b`, "/a*b", true},
+		{`/a\*b`, "/axb", false},
+		{`/\{x\}/*`, "/{x}/y", true},
+		{`/a\?`, "/a?", true},
+		{`/a\\`, `/a\`, true},
+		{"//volume", "/volume", true},
+		{"//volume", "/mixer/1/volume", true},
+		{"//volume", "/mixer/1/mute", false},
+		{"/mixer//mute", "/mixer/mute", true},
+		{"/mixer//mute", "/mixer/bus/2/mute", true},
+		{"/mixer//mute", "/synth/mute", false},
+		{"/mixer///mute", "/mixer/1/mute", true},
+		{"//*/mute", "/a/b/mute", true},
+		{"//a//b", "/a/a/b", true},
+		{"//a//b", "/b", false},
+	} {
+		p, err := CompilePattern(tt.pattern)
+		if err != nil {
+			t.Errorf("CompilePattern(%q) unexpected error: %s", tt.pattern, err)
+			continue
+		}
+		if got := p.Match(tt.addr); got != tt.want {
+			t.Errorf("CompilePattern(%q).Match(%q) = %t, want = %t", tt.pattern, tt.addr, got, tt.want)
+		}
+	}
+}
+
+func TestCompilePattern_SyntaxError(t *testing.T) {
+	for _, tt := range []struct {
+		pattern string
+		offset  int
+	}{
+		{"foo", 0},
+		{"/a/[abc", 3},
+		{"/a/{foo,bar", 3},
+		{"/a/b]", 4},
+		{"/a/b}", 4},
+		{"/a/[]", 4},
+		{"/a/[z-a]", 4},
+		{"/a/{f*,b}", 5},
+		{"/a/[!]", 5},
+		{"/a/[b\\]", 3},
+		{`/a/b\`, 4},
+		{"/" + strings.Repeat("a", MaxPatternLength), MaxPatternLength},
+		{strings.Repeat("//x", MaxAnyDepthOperators+1), 3 * MaxAnyDepthOperators},
+	} {
+		_, err := CompilePattern(tt.pattern)
+		serr, ok := err.(*PatternSyntaxError)
+		if !ok {
+			t.Errorf("CompilePattern(%q) error = %v, want *PatternSyntaxError", tt.pattern, err)
+			continue
+		}
+		if serr.Offset != tt.offset || serr.Pattern != tt.pattern {
+			t.Errorf("CompilePattern(%q) error at %d in %q, want offset %d", tt.pattern, serr.Offset, serr.Pattern, tt.offset)
+		}
+	}
+}
+
+func TestQuote(t *testing.T) {
+	for _, addr := range []string{"/plain", "/a*b", "/[1]/{x,y}", `/back\slash`, "/what?"} {
+		p, err := CompilePattern(Quote(addr))
+		if err != nil {
+			t.Errorf("CompilePattern(Quote(%q)) unexpected error: %s", addr, err)
+			continue
+		}
+		if !p.Match(addr) {
+			t.Errorf("Quote(%q) = %q doesn't match the address", addr, Quote(addr))
+		}
+	}
+	if p := MustCompilePattern(Quote("/a*")); p.Match("/ab") {
+		t.Error(`Quote("/a*") matches "/ab"`)
+	}
+}
+
+func TestCompilePatternOSC10(t *testing.T) {
+	p, err := CompilePatternOSC10("/mixer//mute")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if p.Match("/mixer/1/mute") {
+		t.Error(`"/mixer//mute" matches "/mixer/1/mute" in OSC 1.0 mode`)
+	}
+	if !p.Match("/mixer//mute") {
+		t.Error(`"/mixer//mute" doesn't match itself in OSC 1.0 mode`)
+	}
+}
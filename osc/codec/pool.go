@@ -0,0 +1,69 @@
+package codec
+
+import "sync"
+
+// messagePool recycles decoded messages and their argument slices.
+var messagePool = sync.Pool{
+	New: func() interface{} { return &Message{} },
+}
+
+// Retain keeps a message that was decoded with Decoder.ReuseMessages set from
+// being recycled by ReleasePacket. A handler must call Retain if it keeps a
+// reference to the message or its arguments. Retain has no effect on other
+// messages.
+func (msg *Message) Retain() {
+	msg.retained = true
+}
+
+// getMessage returns a message from the pool.
+func getMessage(addr string) *Message {
+	msg := messagePool.Get().(*Message)
+	msg.Address = addr
+	msg.pooled = true
+	return msg
+}
+
+// putMessage resets msg and returns it to the pool, unless it wasn't taken
+// from the pool or was retained.
+func putMessage(msg *Message) {
+	if !msg.pooled || msg.retained {
+		return
+	}
+	for i := range msg.Arguments {
+		msg.Arguments[i] = nil
+	}
+	*msg = Message{Arguments: msg.Arguments[:0]}
+	messagePool.Put(msg)
+}
+
+// ReleasePacket recycles all messages of a packet that was decoded with
+// Decoder.ReuseMessages set, except retained messages. The messages and
+// their arguments must not be used afterwards.
+func ReleasePacket(packet Packet) {
+	switch p := packet.(type) {
+	case *Message:
+		putMessage(p)
+	case *Bundle:
+		for _, msg := range p.Messages {
+			putMessage(msg)
+		}
+		for _, b := range p.Bundles {
+			ReleasePacket(b)
+		}
+	}
+}
+
+// RetainPacket retains all messages of the packet, see Message.Retain.
+func RetainPacket(packet Packet) {
+	switch p := packet.(type) {
+	case *Message:
+		p.Retain()
+	case *Bundle:
+		for _, msg := range p.Messages {
+			msg.Retain()
+		}
+		for _, b := range p.Bundles {
+			RetainPacket(b)
+		}
+	}
+}
@@ -0,0 +1,64 @@
+package codec
+
+import (
+	"reflect"
+	"testing"
+)
+
+func TestReleasePacket_NotPooled(t *testing.T) {
+	msg := NewMessage("/a", int32(1))
+	ReleasePacket(msg)
+	if want := NewMessage("/a", int32(1)); !reflect.DeepEqual(msg, want) {
+		t.Errorf("ReleasePacket() modified a message that wasn't pooled: %v", msg)
+	}
+}
+
+func TestDecoder_ReuseArguments(t *testing.T) {
+	data, err := NewMessage("/a", int32(1), int32(2)).MarshalBinary()
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	msg := getMessage("")
+	msg.Arguments = make([]interface{}, 0, 8)
+	r := &byteReader{data: data}
+	addr, err := r.readPaddedString()
+	if err != nil {
+		t.Fatal(err)
+	}
+	msg.Address = addr
+	if err := r.readArguments(msg, &DecodeOptions{}); err != nil {
+		t.Fatal(err)
+	}
+	if cap(msg.Arguments) != 8 {
+		t.Errorf("argument slice was reallocated, cap = %d", cap(msg.Arguments))
+	}
+	if want := NewMessage("/a", int32(1), int32(2)); !msg.Equals(want) {
+		t.Errorf("decoded message = %v, want = %v", msg, want)
+	}
+}
+
+func BenchmarkDecodeBytes_Reuse(b *testing.B) {
+	data, err := NewMessage("/osc/address", int32(1), float32(2), "three").MarshalBinary()
+	if err != nil {
+		b.Fatal(err)
+	}
+
+	for _, reuse := range []bool{false, true} {
+		name := "alloc"
+		if reuse {
+			name = "reuse"
+		}
+		b.Run(name, func(b *testing.B) {
+			dec := Decoder{ReuseMessages: reuse}
+			b.ReportAllocs()
+			for i := 0; i < b.N; i++ {
+				p, err := dec.DecodeBytes(data)
+				if err != nil {
+					b.Fatal(err)
+				}
+				ReleasePacket(p)
+			}
+		})
+	}
+}
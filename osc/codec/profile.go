@@ -0,0 +1,45 @@
+package codec
+
+// Profile selects how strictly received packets are checked against the OSC
+// specification.
+type Profile int
+
+const (
+	// ProfileDefault accepts all type tags that are supported by this
+	// package and rejects messages with unknown type tags. Timetags of nested
+	// bundles aren't checked.
+	ProfileDefault Profile = iota
+
+	// ProfileOSC10 accepts only the required type tags of OSC 1.0, 'i', 'f',
+	// 's' and 'b', and requires that nested bundles aren't scheduled before
+	// their enclosing bundle.
+	ProfileOSC10
+
+	// ProfileOSC11 is like ProfileOSC10, but also accepts the required type
+	// tags of OSC 1.1, 'T', 'F', 'N', 'I' and 't'.
+	ProfileOSC11
+
+	// ProfileLoose accepts all supported type tags and doesn't reject
+	// messages with unknown type tags. The arguments before the first unknown
+	// tag are delivered and the unknown tag is reported to
+	// DecodeOptions.OnUnknownTag.
+	ProfileLoose
+)
+
+// acceptedTags returns the type tags that are accepted by the profile, or an
+// empty string if all supported type tags are accepted.
+func (p Profile) acceptedTags() string {
+	switch p {
+	case ProfileOSC10:
+		return "ifsb"
+	case ProfileOSC11:
+		return "ifsbTFNIt"
+	}
+	return ""
+}
+
+// checksTimetags returns true if the profile requires that nested bundles
+// aren't scheduled before their enclosing bundle.
+func (p Profile) checksTimetags() bool {
+	return p == ProfileOSC10 || p == ProfileOSC11
+}
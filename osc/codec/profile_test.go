@@ -0,0 +1,127 @@
+package codec
+
+import (
+	"reflect"
+	"testing"
+	"time"
+)
+
+func TestDecodeOptions_Profile(t *testing.T) {
+	for _, tt := range []struct {
+		profile Profile
+		args    []interface{}
+		ok      bool
+	}{
+		{ProfileDefault, []interface{}{int32(1), int64(2), Char('c'), true}, true},
+		{ProfileOSC10, []interface{}{int32(1), float32(2), "s", []byte{1}}, true},
+		{ProfileOSC10, []interface{}{int32(1), true}, false},
+		{ProfileOSC11, []interface{}{int32(1), true, nil, Impulse{}, *NewTimetag(time.Now())}, true},
+		{ProfileOSC11, []interface{}{int64(1)}, false},
+		{ProfileOSC11, []interface{}{[]interface{}{int32(1)}}, false},
+		{ProfileLoose, []interface{}{int64(1), RGBA{}}, true},
+	} {
+		msg := NewMessage("/test", tt.args...)
+		data, err := msg.MarshalBinary()
+		if err != nil {
+			t.Fatal(err)
+		}
+		_, err = ParsePacketWithOptions(string(data), DecodeOptions{Profile: tt.profile})
+		if tt.ok && err != nil {
+			t.Errorf("profile %d: %v unexpected error: %s", tt.profile, msg, err)
+		}
+		if de, ok := err.(*DecodeError); !tt.ok && (!ok || de.Err != ErrInvalidTypeTag) {
+			t.Errorf("profile %d: %v error = %v, want = %v", tt.profile, msg, err, ErrInvalidTypeTag)
+		}
+	}
+}
+
+func TestDecodeOptions_ProfileLoose(t *testing.T) {
+	data := "/test" + nulls(3) + ",i[iX" + nulls(3) + "\x00\x00\x00\x01" + "\x00\x00\x00\x02" + "\x00\x00\x00\x03"
+	if _, err := ParsePacket(data); err == nil {
+		t.Error("expected error for unknown type tag with the default profile")
+	}
+
+	var unknown []byte
+	opts := DecodeOptions{
+		Profile:      ProfileLoose,
+		OnUnknownTag: func(address string, tag byte) { unknown = append(unknown, tag) },
+	}
+	p, err := ParsePacketWithOptions(data, opts)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if want := []interface{}{int32(1), []interface{}{int32(2)}}; !reflect.DeepEqual(p.(*Message).Arguments, want) {
+		t.Errorf("Arguments = %#v, want = %#v", p.(*Message).Arguments, want)
+	}
+	if string(unknown) != "X" {
+		t.Errorf("OnUnknownTag called with %q, want = X", unknown)
+	}
+}
+
+func TestDecodeOptions_ProfileTimetags(t *testing.T) {
+	now := time.Now()
+	inner := NewBundle(now)
+	if err := inner.Append(NewMessage("/test")); err != nil {
+		t.Fatal(err)
+	}
+	for _, tt := range []struct {
+		outer   time.Time
+		profile Profile
+		ok      bool
+	}{
+		{now.Add(time.Second), ProfileDefault, true},
+		{now.Add(time.Second), ProfileLoose, true},
+		{now.Add(time.Second), ProfileOSC10, false},
+		{now.Add(time.Second), ProfileOSC11, false},
+		{now, ProfileOSC11, true},
+		{now.Add(-time.Second), ProfileOSC11, true},
+	} {
+		outer := NewBundle(tt.outer)
+		if err := outer.Append(inner); err != nil {
+			t.Fatal(err)
+		}
+		data, err := outer.MarshalBinary()
+		if err != nil {
+			t.Fatal(err)
+		}
+		_, err = ParsePacketWithOptions(string(data), DecodeOptions{Profile: tt.profile})
+		if tt.ok && err != nil {
+			t.Errorf("profile %d, outer %s: unexpected error: %s", tt.profile, tt.outer.Sub(now), err)
+		}
+		if de, ok := err.(*DecodeError); !tt.ok && (!ok || de.Err != ErrInvalidTimetag) {
+			t.Errorf("profile %d, outer %s: error = %v, want = %v", tt.profile, tt.outer.Sub(now), err, ErrInvalidTimetag)
+		}
+	}
+}
+
+func TestDecodeOptions_TagSizes(t *testing.T) {
+	data := "/test" + nulls(3) + ",iXiSiBi" + nulls(4) +
+		"\x00\x00\x00\x01" + "\x00\x00\x00\x00\x00\x00\x00\x00" +
+		"\x00\x00\x00\x02" + "sym" + nulls(1) +
+		"\x00\x00\x00\x03" + "\x00\x00\x00\x05abcde" + nulls(3) +
+		"\x00\x00\x00\x04"
+	var unknown []byte
+	opts := DecodeOptions{
+		TagSizes:     map[byte]int{'X': 8, 'S': TagSizeString, 'B': TagSizeBlob},
+		OnUnknownTag: func(address string, tag byte) { unknown = append(unknown, tag) },
+	}
+	p, err := ParsePacketWithOptions(data, opts)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if want := []interface{}{int32(1), int32(2), int32(3), int32(4)}; !reflect.DeepEqual(p.(*Message).Arguments, want) {
+		t.Errorf("Arguments = %#v, want = %#v", p.(*Message).Arguments, want)
+	}
+	if string(unknown) != "XSB" {
+		t.Errorf("OnUnknownTag called with %q, want = XSB", unknown)
+	}
+
+	opts.TagSizes['X'] = 12
+	if _, err := ParsePacketWithOptions(data, opts); err == nil {
+		t.Error("expected error for wrong argument size")
+	}
+	opts.Profile = ProfileOSC11
+	if _, err := ParsePacketWithOptions(data, opts); err == nil {
+		t.Error("expected error for unknown type tag with ProfileOSC11")
+	}
+}
@@ -0,0 +1,17 @@
+package codec
+
+// Raw returns the encoded bytes of the message as they were received, if it
+// was decoded with DecodeOptions.Raw set. Otherwise it returns nil. The
+// bytes aren't updated if the message is modified, e.g. by an osc.Stage, and
+// must not be modified.
+func (msg *Message) Raw() []byte {
+	return msg.raw
+}
+
+// RawPacket returns the encoded bytes of the packet that contained the
+// message, i.e. the message itself or its enclosing bundle, if it was decoded
+// with DecodeOptions.Raw set. Otherwise it returns nil. It allows proxies to
+// forward packets byte-exact. The bytes must not be modified.
+func (msg *Message) RawPacket() []byte {
+	return msg.rawPacket
+}
@@ -0,0 +1,46 @@
+package codec
+
+import (
+	"bytes"
+	"testing"
+	"time"
+)
+
+func TestDecodeOptions_Raw(t *testing.T) {
+	bundle := NewBundle(time.Now())
+	bundle.Append(NewMessage("/a", int32(1)))
+	nested := NewBundle(time.Now().Add(time.Second))
+	nested.Append(NewMessage("/b", "foo", float32(2)))
+	bundle.Append(nested)
+	data, err := bundle.MarshalBinary()
+	if err != nil {
+		t.Fatal(err)
+	}
+	original := append([]byte(nil), data...)
+
+	d := Decoder{Options: DecodeOptions{Raw: true}}
+	p, err := d.DecodeBytes(data)
+	if err != nil {
+		t.Fatal(err)
+	}
+	for i := range data {
+		data[i] = 0
+	}
+	decoded := p.(*Bundle)
+	for _, msg := range []*Message{decoded.Messages[0], decoded.Bundles[0].Messages[0]} {
+		want, err := msg.MarshalBinary()
+		if err != nil {
+			t.Fatal(err)
+		}
+		if !bytes.Equal(msg.Raw(), want) {
+			t.Errorf("%s: Raw() = % x, want = % x", msg.Address, msg.Raw(), want)
+		}
+		if !bytes.Equal(msg.RawPacket(), original) {
+			t.Errorf("%s: RawPacket() = % x, want = % x", msg.Address, msg.RawPacket(), original)
+		}
+	}
+
+	if p, err := ParsePacket(string(original)); err != nil || p.(*Bundle).Messages[0].Raw() != nil {
+		t.Errorf("Raw() without DecodeOptions.Raw = %v, %v, want = nil", p.(*Bundle).Messages[0].Raw(), err)
+	}
+}
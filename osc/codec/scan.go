@@ -0,0 +1,109 @@
+package codec
+
+import (
+	"fmt"
+	"reflect"
+)
+
+// ScanError is returned by Message.Scan if an argument can't be stored in
+// its destination.
+type ScanError struct {
+	Index   int          // Index of the argument
+	Want    reflect.Type // Type of the destination
+	Arg     interface{}  // The argument
+	Missing bool         // Set if the message has too few arguments
+}
+
+func (e *ScanError) Error() string {
+	if e.Missing {
+		return fmt.Sprintf("osc: wanted %s at index %d, got no argument", e.Want, e.Index)
+	}
+	got := "Nil"
+	if e.Arg != nil && e.Arg != (Nil{}) {
+		got = fmt.Sprintf("%T", e.Arg)
+	}
+	return fmt.Sprintf("osc: wanted %s at index %d, got %s", e.Want, e.Index, got)
+}
+
+// Scan stores the arguments of the message in order in the values that dest
+// points to, e.g.
+//
+//	var freq float32
+//	var wave string
+//	err := msg.Scan(&freq, &wave)
+//
+// The arguments are converted by ConvertArgument: integers are converted to
+// every integer or float type that can hold their value, floats to every
+// float type, and pointers to interfaces accept every argument that
+// implements the interface. Arguments after the last
+// destination are ignored. Returns a *ScanError for the first argument that
+// is missing or can't be converted, the destinations before it are set.
+func (msg *Message) Scan(dest ...interface{}) error {
+	for i, d := range dest {
+		v := reflect.ValueOf(d)
+		if v.Kind() != reflect.Ptr || v.IsNil() {
+			return fmt.Errorf("osc: Scan destination %d must be a non-nil pointer, got %T", i, d)
+		}
+		want := v.Type().Elem()
+		if i >= len(msg.Arguments) {
+			return &ScanError{Index: i, Want: want, Missing: true}
+		}
+		arg, ok := ConvertArgument(msg.Arguments[i], want)
+		if !ok {
+			return &ScanError{Index: i, Want: want, Arg: msg.Arguments[i]}
+		}
+		v.Elem().Set(arg)
+	}
+	return nil
+}
+
+// ConvertArgument converts the message argument arg to a value of type t, as
+// Message.Scan does. Integers are converted to every integer or float type
+// that can hold their value, floats to every float type. Interface types
+// accept every argument that implements them, Nil arguments are converted to
+// the nil interface. Returns false if arg can't be converted without losing
+// its meaning.
+func ConvertArgument(arg interface{}, t reflect.Type) (reflect.Value, bool) {
+	if arg == nil || arg == (Nil{}) {
+		if t.Kind() == reflect.Interface {
+			return reflect.Zero(t), true
+		}
+		return reflect.Value{}, false
+	}
+
+	v := reflect.ValueOf(arg)
+	if v.Type().AssignableTo(t) {
+		return v, true
+	}
+
+	switch x := arg.(type) {
+	case int32:
+		return convertInt(int64(x), t)
+	case int64:
+		return convertInt(x, t)
+	case float32, float64:
+		switch t.Kind() {
+		case reflect.Float32, reflect.Float64:
+			return v.Convert(t), true
+		}
+	}
+	return reflect.Value{}, false
+}
+
+// convertInt converts i to a value of the integer or float type t. Returns
+// false if t can't hold i.
+func convertInt(i int64, t reflect.Type) (reflect.Value, bool) {
+	switch t.Kind() {
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64:
+		if !reflect.Zero(t).OverflowInt(i) {
+			return reflect.ValueOf(i).Convert(t), true
+		}
+	case reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64:
+		if i >= 0 && !reflect.Zero(t).OverflowUint(uint64(i)) {
+			return reflect.ValueOf(uint64(i)).Convert(t), true
+		}
+	case reflect.Float32, reflect.Float64:
+		return reflect.ValueOf(float64(i)).Convert(t), true
+	}
+	return reflect.Value{}, false
+}
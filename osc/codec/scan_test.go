@@ -0,0 +1,62 @@
+package codec
+
+import "testing"
+
+func TestMessage_Scan(t *testing.T) {
+	msg := NewMessage("/synth", float32(440), "sine", int32(3), int32(-1), nil, []byte{1})
+	var (
+		freq  float64
+		wave  string
+		voice uint8
+		i     int
+		value interface{}
+		blob  []byte
+	)
+	if err := msg.Scan(&freq, &wave, &voice, &i, &value, &blob); err != nil {
+		t.Fatal(err)
+	}
+	if freq != 440 || wave != "sine" || voice != 3 || i != -1 || value != nil || len(blob) != 1 {
+		t.Errorf("Scan() = %v, %v, %v, %v, %v, %v", freq, wave, voice, i, value, blob)
+	}
+
+	// Remaining arguments are ignored
+	var first float32
+	if err := msg.Scan(&first); err != nil || first != 440 {
+		t.Errorf("Scan() = %v, %v, want = 440, nil", first, err)
+	}
+}
+
+func TestMessage_Scan_errors(t *testing.T) {
+	msg := NewMessage("/synth", float32(440), "sine", int32(-1), nil)
+	var (
+		f float32
+		s string
+		u uint
+		n int
+	)
+	for _, tt := range []struct {
+		dest  []interface{}
+		index int
+		want  string
+	}{
+		{[]interface{}{&f, &f}, 1, "osc: wanted float32 at index 1, got string"},
+		{[]interface{}{&s}, 0, "osc: wanted string at index 0, got float32"},
+		{[]interface{}{&f, &s, &u}, 2, "osc: wanted uint at index 2, got int32"},
+		{[]interface{}{&f, &s, &n, &n}, 3, "osc: wanted int at index 3, got Nil"},
+		{[]interface{}{&f, &s, &n, &n, &n}, 3, "osc: wanted int at index 3, got Nil"},
+	} {
+		err := msg.Scan(tt.dest...)
+		se, ok := err.(*ScanError)
+		if !ok || se.Index != tt.index || err.Error() != tt.want {
+			t.Errorf("Scan() = %v, want = %s", err, tt.want)
+		}
+	}
+
+	short := NewMessage("/a")
+	if err := short.Scan(&f); err == nil || err.Error() != "osc: wanted float32 at index 0, got no argument" {
+		t.Errorf("Scan() = %v, want missing argument error", err)
+	}
+	if err := msg.Scan(f); err == nil {
+		t.Error("Scan() = nil for a non-pointer destination, want error")
+	}
+}
@@ -0,0 +1,79 @@
+package codec
+
+import (
+	"errors"
+	"unicode/utf8"
+)
+
+// ErrInvalidString means that an address or string argument contains
+// characters that DecodeOptions.Strings doesn't allow.
+var ErrInvalidString = errors.New("osc: invalid characters in string")
+
+// StringPolicy defines which characters the addresses and string arguments of
+// received messages may contain.
+//
+// OSC strings are sequences of bytes that are terminated by a null byte and
+// padded to a multiple of 4 bytes. The padding is always computed from the
+// number of bytes, not characters, so strings with multibyte UTF-8 characters
+// are encoded correctly. Go strings are passed through unchanged, i.e. UTF-8
+// strings survive a round-trip.
+type StringPolicy int
+
+const (
+	// StringsAny accepts any bytes except null. This is the default.
+	StringsAny StringPolicy = iota
+
+	// StringsUTF8 accepts only valid UTF-8, which most current
+	// implementations use for non-English labels.
+	StringsUTF8
+
+	// StringsASCII accepts only printable ASCII characters, as required by
+	// the OSC 1.0 specification.
+	StringsASCII
+)
+
+// Validate returns ErrInvalidString if s contains characters that the policy
+// doesn't allow, e.g. to check strings before they are sent to a strict
+// receiver.
+func (p StringPolicy) Validate(s string) error {
+	if p.index(s) >= 0 {
+		return ErrInvalidString
+	}
+	return nil
+}
+
+// index returns the byte index of the first character of s that the policy
+// doesn't allow, or -1 if s is valid.
+func (p StringPolicy) index(s string) int {
+	switch p {
+	case StringsUTF8:
+		for i, r := range s {
+			if r == utf8.RuneError {
+				if _, size := utf8.DecodeRuneInString(s[i:]); size == 1 {
+					return i
+				}
+			}
+		}
+	case StringsASCII:
+		for i := 0; i < len(s); i++ {
+			if s[i] < ' ' || s[i] > '~' {
+				return i
+			}
+		}
+	}
+	return -1
+}
+
+// readString reads an OSC string and checks it against the string policy of
+// opts.
+func (r *byteReader) readString(opts *DecodeOptions) (string, error) {
+	start := r.pos
+	s, err := r.readPaddedString()
+	if err != nil {
+		return "", err
+	}
+	if i := opts.Strings.index(s); i >= 0 {
+		return "", r.errorAt(start+i, ErrInvalidString)
+	}
+	return s, nil
+}
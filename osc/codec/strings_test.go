@@ -0,0 +1,61 @@
+package codec
+
+import (
+	"testing"
+)
+
+func TestMultibyteStringRoundTrip(t *testing.T) {
+	for _, s := range []string{"ü", "Grüße", "日本語", "Кан", "a€", "🎚️ Fader"} {
+		msg := NewMessage("/label", s, int32(1))
+		data, err := msg.MarshalBinary()
+		if err != nil {
+			t.Fatal(err)
+		}
+		if len(data)%4 != 0 {
+			t.Errorf("%q: encoded size %d isn't a multiple of 4", s, len(data))
+		}
+
+		p, err := ParsePacketWithOptions(string(data), DecodeOptions{Strings: StringsUTF8})
+		if err != nil {
+			t.Fatalf("%q: %s", s, err)
+		}
+		if !p.(*Message).Equals(msg) {
+			t.Errorf("%q: decoded %v, want = %v", s, p, msg)
+		}
+	}
+}
+
+func TestDecodeOptions_Strings(t *testing.T) {
+	for _, tt := range []struct {
+		policy StringPolicy
+		addr   string
+		arg    string
+		ok     bool
+	}{
+		{StringsAny, "/test", "\xff\x01", true},
+		{StringsUTF8, "/test", "Grüße", true},
+		{StringsUTF8, "/test", "\xffoo", false},
+		{StringsUTF8, "/t\xe9st", "foo", false},
+		{StringsASCII, "/test", "foo bar", true},
+		{StringsASCII, "/test", "Grüße", false},
+		{StringsASCII, "/test", "tab\t", false},
+		{StringsASCII, "/tëst", "foo", false},
+	} {
+		msg := NewMessage(tt.addr, tt.arg)
+		msg.SkipValidation = true
+		data, err := msg.MarshalBinary()
+		if err != nil {
+			t.Fatal(err)
+		}
+		_, err = ParsePacketWithOptions(string(data), DecodeOptions{Strings: tt.policy})
+		if tt.ok && err != nil {
+			t.Errorf("policy %d: %q %q unexpected error: %s", tt.policy, tt.addr, tt.arg, err)
+		}
+		if de, ok := err.(*DecodeError); !tt.ok && (!ok || de.Err != ErrInvalidString) {
+			t.Errorf("policy %d: %q %q error = %v, want = %v", tt.policy, tt.addr, tt.arg, err, ErrInvalidString)
+		}
+		if got := tt.policy.Validate(tt.addr+tt.arg) == nil; got != tt.ok {
+			t.Errorf("policy %d: Validate(%q) ok = %v, want = %v", tt.policy, tt.addr+tt.arg, got, tt.ok)
+		}
+	}
+}
@@ -0,0 +1,44 @@
+package osc
+
+import "time"
+
+// Names of earlier versions of the package, which prefixed the types with
+// "Osc". They are kept as aliases, so that code written against those
+// versions, or against forks that still use them, compiles without renames.
+// Changed signatures, e.g. of Client.Send, can't be aliased, see the
+// migration notes in the README.
+type (
+	// Deprecated: Use Message.
+	OscMessage = Message
+	// Deprecated: Use Bundle.
+	OscBundle = Bundle
+	// Deprecated: Use Packet.
+	OscPacket = Packet
+	// Deprecated: Use StandardDispatcher.
+	OscDispatcher = StandardDispatcher
+	// Deprecated: Use Server.
+	OscServer = Server
+	// Deprecated: Use Client.
+	OscClient = Client
+)
+
+// NewOscMessage returns a new message with the given address and arguments.
+//
+// Deprecated: Use NewMessage.
+func NewOscMessage(addr string, args ...interface{}) *Message {
+	return NewMessage(addr, args...)
+}
+
+// NewOscBundle returns a new bundle with the given time.
+//
+// Deprecated: Use NewBundle.
+func NewOscBundle(time time.Time) *Bundle {
+	return NewBundle(time)
+}
+
+// NewOscDispatcher returns a new StandardDispatcher.
+//
+// Deprecated: Use NewStandardDispatcher.
+func NewOscDispatcher() *StandardDispatcher {
+	return NewStandardDispatcher()
+}
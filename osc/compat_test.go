@@ -0,0 +1,30 @@
+package osc
+
+import (
+	"testing"
+	"time"
+)
+
+func TestCompatNames(t *testing.T) {
+	var msg *OscMessage = NewOscMessage("/a", int32(1))
+	if want := NewMessage("/a", int32(1)); !msg.Equals(want) {
+		t.Errorf("NewOscMessage() = %v, want = %v", msg, want)
+	}
+
+	now := time.Now()
+	var bundle *OscBundle = NewOscBundle(now)
+	bundle.Append(msg)
+	var packet OscPacket = bundle
+	if want := NewBundle(now); packet.(*Bundle).Timetag != want.Timetag {
+		t.Errorf("NewOscBundle() has timetag %v, want = %v", bundle.Timetag, want.Timetag)
+	}
+
+	var d *OscDispatcher = NewOscDispatcher()
+	called := false
+	d.AddMsgHandler("/a", func(msg *OscMessage) { called = true })
+	var server = &OscServer{Dispatcher: d}
+	server.Dispatcher.Dispatch(msg)
+	if !called {
+		t.Error("handler of OscDispatcher not called")
+	}
+}
@@ -0,0 +1,139 @@
+package osc
+
+import (
+	"bytes"
+	"compress/gzip"
+	"errors"
+	"io"
+	"io/ioutil"
+	"strings"
+)
+
+// CompressionSuffix is appended to the address of messages whose blobs were
+// compressed by CompressBlobs, e.g. "/scene/data" becomes "/scene/data.gz".
+// Receivers without DecompressBlobs see the address with the suffix and the
+// gzip compressed blobs, so the handlers of the original address aren't
+// called with unexpected data.
+const CompressionSuffix = ".gz"
+
+// errBlobTooLarge means that a blob exceeds the size limit of
+// DecompressBlobs.
+var errBlobTooLarge = errors.New("osc: decompressed blob is too large")
+
+// CompressBlobs returns a SendHook that compresses the blob arguments of
+// messages with gzip, e.g. to move scene files or images over constrained
+// links. If any blob of a message is larger than threshold bytes, all its
+// blobs are compressed and CompressionSuffix is appended to its address.
+// Other messages are sent unchanged. The receiver must use DecompressBlobs.
+func CompressBlobs(threshold int) SendHook {
+	var compress func(packet Packet) (Packet, error)
+	compress = func(packet Packet) (Packet, error) {
+		switch p := packet.(type) {
+		case *Message:
+			if !hasLargeBlob(p, threshold) {
+				return p, nil
+			}
+			msg := p.Clone()
+			msg.Address += CompressionSuffix
+			for i, arg := range msg.Arguments {
+				if blob, ok := arg.([]byte); ok {
+					compressed, err := gzipBlob(blob)
+					if err != nil {
+						return nil, err
+					}
+					msg.Arguments[i] = compressed
+				}
+			}
+			return msg, nil
+
+		case *Bundle:
+			compressed := &Bundle{Timetag: p.Timetag}
+			for _, msg := range p.Messages {
+				c, err := compress(msg)
+				if err != nil {
+					return nil, err
+				}
+				compressed.Messages = append(compressed.Messages, c.(*Message))
+			}
+			for _, b := range p.Bundles {
+				c, err := compress(b)
+				if err != nil {
+					return nil, err
+				}
+				compressed.Bundles = append(compressed.Bundles, c.(*Bundle))
+			}
+			return compressed, nil
+		}
+		return packet, nil
+	}
+	return compress
+}
+
+// DecompressBlobs returns a Stage that decompresses the blobs of messages
+// whose address ends with CompressionSuffix and removes the suffix, see
+// CompressBlobs. Messages with blobs that can't be decompressed or that are
+// larger than maxSize bytes after decompression are dropped, which protects
+// against compression bombs. If maxSize isn't positive, the size isn't
+// limited.
+func DecompressBlobs(maxSize int) Stage {
+	return func(msg *Message) *Message {
+		if !strings.HasSuffix(msg.Address, CompressionSuffix) {
+			return msg
+		}
+		for i, arg := range msg.Arguments {
+			if blob, ok := arg.([]byte); ok {
+				data, err := gunzipBlob(blob, maxSize)
+				if err != nil {
+					return nil
+				}
+				msg.Arguments[i] = data
+			}
+		}
+		msg.Address = strings.TrimSuffix(msg.Address, CompressionSuffix)
+		return msg
+	}
+}
+
+// hasLargeBlob returns true if msg has a blob argument that is larger than
+// threshold bytes.
+func hasLargeBlob(msg *Message, threshold int) bool {
+	for _, arg := range msg.Arguments {
+		if blob, ok := arg.([]byte); ok && len(blob) > threshold {
+			return true
+		}
+	}
+	return false
+}
+
+func gzipBlob(blob []byte) ([]byte, error) {
+	var buf bytes.Buffer
+	w := gzip.NewWriter(&buf)
+	if _, err := w.Write(blob); err != nil {
+		return nil, err
+	}
+	if err := w.Close(); err != nil {
+		return nil, err
+	}
+	return buf.Bytes(), nil
+}
+
+// gunzipBlob decompresses blob. Returns an error if the result is larger than
+// maxSize, unless maxSize isn't positive.
+func gunzipBlob(blob []byte, maxSize int) ([]byte, error) {
+	r, err := gzip.NewReader(bytes.NewReader(blob))
+	if err != nil {
+		return nil, err
+	}
+	var src io.Reader = r
+	if maxSize > 0 {
+		src = io.LimitReader(r, int64(maxSize)+1)
+	}
+	data, err := ioutil.ReadAll(src)
+	if err != nil {
+		return nil, err
+	}
+	if maxSize > 0 && len(data) > maxSize {
+		return nil, errBlobTooLarge
+	}
+	return data, nil
+}
@@ -0,0 +1,64 @@
+package osc
+
+import (
+	"bytes"
+	"net"
+	"testing"
+	"time"
+)
+
+func TestCompressBlobs(t *testing.T) {
+	conn, err := net.ListenPacket("udp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer conn.Close()
+	addr := conn.LocalAddr().(*net.UDPAddr)
+	client := NewClient(addr.IP.String(), addr.Port)
+	client.AddSendHook(CompressBlobs(256))
+
+	scene := bytes.Repeat([]byte("channel 1: gain 0dB, mute off\n"), 1000)
+	server := &Server{ReadTimeout: 5 * time.Second}
+	decompress := DecompressBlobs(len(scene))
+	for _, tt := range []struct {
+		msg     *Message
+		address string
+	}{
+		{NewMessage("/scene/data", int32(1), scene, []byte("small")), "/scene/data.gz"},
+		{NewMessage("/scene/name", []byte("small")), "/scene/name"},
+	} {
+		original := tt.msg.Clone()
+		if _, err := client.Send(tt.msg); err != nil {
+			t.Fatal(err)
+		}
+		if !tt.msg.Equals(original) {
+			t.Errorf("the hook modified the original message")
+		}
+		p, err := server.ReceivePacket(conn)
+		if err != nil {
+			t.Fatal(err)
+		}
+		msg := p.(*Message)
+		if msg.Address != tt.address {
+			t.Errorf("received address %s, want = %s", msg.Address, tt.address)
+		}
+		if got := decompress(msg); got == nil || !got.Equals(original) {
+			t.Errorf("decompressed %v, want = %v", got, original)
+		}
+	}
+	if n := client.Stats().Bytes; n > 1000 {
+		t.Errorf("sent %d bytes, the scene wasn't compressed", n)
+	}
+
+	// Blobs beyond the size limit and invalid data are dropped
+	msg, err := CompressBlobs(0)(NewMessage("/scene/data", scene))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if DecompressBlobs(100)(msg.(*Message).Clone()) != nil {
+		t.Error("DecompressBlobs() passed a blob beyond the size limit")
+	}
+	if DecompressBlobs(0)(NewMessage("/scene/data.gz", []byte("invalid"))) != nil {
+		t.Error("DecompressBlobs() passed invalid data")
+	}
+}
@@ -0,0 +1,168 @@
+package osc
+
+import (
+	"bytes"
+	"flag"
+	"fmt"
+	"io/ioutil"
+	"net"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+)
+
+var (
+	conformanceCorpus = flag.String("osc.corpus", filepath.Join("testdata", "conformance"),
+		"directory with the captured packets for TestConformance")
+	conformanceRecord = flag.String("osc.record", "",
+		"UDP address that TestConformanceRecord stores the received packets from")
+	conformancePrefix = flag.String("osc.record.prefix", "capture",
+		"file name prefix of the packets stored by TestConformanceRecord")
+)
+
+// TestConformance decodes and re-encodes every captured packet "<name>.osc"
+// of the corpus directory. If "<name>.txt" exists, the decoded packet must
+// match its description. See testdata/conformance/README.md.
+func TestConformance(t *testing.T) {
+	captures, err := filepath.Glob(filepath.Join(*conformanceCorpus, "*.osc"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(captures) == 0 {
+		t.Fatalf("no captures in %s", *conformanceCorpus)
+	}
+
+	for _, capture := range captures {
+		name := strings.TrimSuffix(filepath.Base(capture), ".osc")
+		t.Run(name, func(t *testing.T) {
+			data, err := ioutil.ReadFile(capture)
+			if err != nil {
+				t.Fatal(err)
+			}
+			p, err := ParsePacket(string(data))
+			if err != nil {
+				t.Fatalf("decoding failed: %s\ncapture:\n%s", err, hexDump(data))
+			}
+			if p == nil {
+				t.Fatalf("capture is neither a message nor a bundle:\n%s", hexDump(data))
+			}
+
+			want, err := ioutil.ReadFile(strings.TrimSuffix(capture, ".osc") + ".txt")
+			if err == nil {
+				if got := describePacket(p, ""); got != strings.TrimSpace(string(want)) {
+					t.Errorf("decoded packet:\n%s\nwant:\n%s", got, strings.TrimSpace(string(want)))
+				}
+			} else if !os.IsNotExist(err) {
+				t.Fatal(err)
+			}
+
+			encoded, err := p.MarshalBinary()
+			if err != nil {
+				t.Fatalf("encoding failed: %s", err)
+			}
+			if !bytes.Equal(encoded, data) {
+				t.Errorf("re-encoded packet differs at offset %d (%s)\ncapture:\n%sencoded:\n%s",
+					firstDifference(encoded, data), describeDifference(encoded, data), hexDump(data), hexDump(encoded))
+			}
+		})
+	}
+}
+
+// TestConformanceRecord stores every packet received on the address of the
+// -osc.record flag as "<prefix>-<n>.osc" in the corpus directory. It waits up
+// to 30 seconds for the first packet and stops once no packet was received
+// for two seconds. It is skipped without the flag, see
+// testdata/conformance/capture.sh.
+func TestConformanceRecord(t *testing.T) {
+	if *conformanceRecord == "" {
+		t.Skip("no -osc.record address")
+	}
+	conn, err := net.ListenPacket("udp", *conformanceRecord)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer conn.Close()
+
+	buf := make([]byte, receiveBufferSize)
+	idle := 30 * time.Second
+	for n := 1; ; n++ {
+		conn.SetReadDeadline(time.Now().Add(idle))
+		size, _, err := conn.ReadFrom(buf)
+		if ne, ok := err.(net.Error); ok && ne.Timeout() {
+			if n == 1 {
+				t.Fatal("no packet received")
+			}
+			return
+		}
+		if err != nil {
+			t.Fatal(err)
+		}
+		name := filepath.Join(*conformanceCorpus, fmt.Sprintf("%s-%d.osc", *conformancePrefix, n))
+		if err := ioutil.WriteFile(name, buf[:size], 0644); err != nil {
+			t.Fatal(err)
+		}
+		t.Logf("recorded %s", name)
+		idle = 2 * time.Second
+	}
+}
+
+// describePacket returns the messages of the packet in the format of
+// Message.String, one per line. Bundles are described by their timetag and
+// their indented elements.
+func describePacket(p Packet, indent string) string {
+	switch p := p.(type) {
+	case *Message:
+		return indent + p.String()
+	case *Bundle:
+		lines := []string{fmt.Sprintf("%s#bundle %d", indent, p.Timetag.TimeTag())}
+		for _, msg := range p.Messages {
+			lines = append(lines, describePacket(msg, indent+"\t"))
+		}
+		for _, b := range p.Bundles {
+			lines = append(lines, describePacket(b, indent+"\t"))
+		}
+		return strings.Join(lines, "\n")
+	}
+	return ""
+}
+
+// firstDifference returns the offset of the first byte that differs.
+func firstDifference(a, b []byte) int {
+	for i := 0; i < len(a) && i < len(b); i++ {
+		if a[i] != b[i] {
+			return i
+		}
+	}
+	if len(a) < len(b) {
+		return len(a)
+	}
+	return len(b)
+}
+
+// describeDifference explains the most common interoperability problems.
+func describeDifference(encoded, capture []byte) string {
+	offset := firstDifference(encoded, capture)
+	switch {
+	case len(encoded) != len(capture) && offset < len(encoded) && offset < len(capture) &&
+		(encoded[offset] == 0 || capture[offset] == 0):
+		return "different padding of a string or blob"
+	case len(encoded) != len(capture):
+		return fmt.Sprintf("%d bytes encoded, %d bytes captured", len(encoded), len(capture))
+	}
+	return "different value"
+}
+
+// hexDump returns a hex dump of OSC data with one row per 4 bytes.
+func hexDump(data []byte) string {
+	var b bytes.Buffer
+	for i := 0; i < len(data); i += 4 {
+		end := i + 4
+		if end > len(data) {
+			end = len(data)
+		}
+		fmt.Fprintf(&b, "\t%04x  % -11x  %q\n", i, data[i:end], data[i:end])
+	}
+	return b.String()
+}
@@ -0,0 +1,37 @@
+package osc
+
+import (
+	"sync"
+	"time"
+)
+
+// DedupHook returns a SendHook that drops a message if a message with the
+// same address and arguments was sent within window, e.g. for user interfaces
+// that send the value of every control in every frame. Only the last message
+// sent for each address is compared, so a changed value is always sent. An
+// unchanged value is sent again once window elapsed since it was last sent.
+// Bundles are sent unchanged.
+func DedupHook(window time.Duration) SendHook {
+	type sent struct {
+		msg  *Message
+		time time.Time
+	}
+	var mu sync.Mutex
+	last := make(map[string]sent)
+
+	return func(packet Packet) (Packet, error) {
+		msg, ok := packet.(*Message)
+		if !ok {
+			return packet, nil
+		}
+		now := time.Now()
+
+		mu.Lock()
+		defer mu.Unlock()
+		if prev, ok := last[msg.Address]; ok && now.Sub(prev.time) < window && prev.msg.Equals(msg) {
+			return nil, nil
+		}
+		last[msg.Address] = sent{msg.Clone(), now}
+		return msg, nil
+	}
+}
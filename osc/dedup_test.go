@@ -0,0 +1,39 @@
+package osc
+
+import (
+	"testing"
+	"time"
+)
+
+func TestDedupHook(t *testing.T) {
+	hook := DedupHook(50 * time.Millisecond)
+	send := func(p Packet) bool {
+		out, err := hook(p)
+		if err != nil {
+			t.Fatal(err)
+		}
+		return out != nil
+	}
+
+	for _, tt := range []struct {
+		packet Packet
+		sent   bool
+	}{
+		{NewMessage("/fader", float32(0.5)), true},
+		{NewMessage("/fader", float32(0.5)), false},
+		{NewMessage("/knob", float32(0.5)), true},
+		{NewMessage("/fader", float32(0.6)), true},
+		{NewMessage("/fader", float32(0.5)), true},
+		{NewMessage("/fader", float32(0.5), "x"), true},
+		{&Bundle{Messages: []*Message{NewMessage("/fader", float32(0.5), "x")}}, true},
+	} {
+		if sent := send(tt.packet); sent != tt.sent {
+			t.Errorf("send %v = %v, want = %v", tt.packet, sent, tt.sent)
+		}
+	}
+
+	time.Sleep(60 * time.Millisecond)
+	if !send(NewMessage("/fader", float32(0.5), "x")) {
+		t.Error("unchanged message wasn't sent again after the window")
+	}
+}
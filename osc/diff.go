@@ -0,0 +1,108 @@
+package osc
+
+import (
+	"bytes"
+	"fmt"
+
+	"github.com/hypebeast/go-osc/osc/codec"
+)
+
+// Diff returns the differences between the messages a and b, one per line,
+// or an empty string if they are equal. It reports differing addresses, type
+// tags and argument counts, and for every differing argument its index, the
+// types and values, and the wire bytes in hex, e.g.
+//
+//	address: "/a" != "/b"
+//	type tags: ",if" != ",ii"
+//	argument 1: float32(0.5) != int32(1), wire 3f000000 != 00000001
+//
+// Arguments are compared like Equals does. This is useful to debug protocol
+// differences against other implementations.
+func Diff(a, b *Message) string {
+	if a == nil || b == nil {
+		if a == b {
+			return ""
+		}
+		return fmt.Sprintf("message: %s != %s\n", formatDiffMessage(a), formatDiffMessage(b))
+	}
+
+	var d bytes.Buffer
+	if a.Address != b.Address {
+		fmt.Fprintf(&d, "address: %q != %q\n", a.Address, b.Address)
+	}
+	tagsA, errA := a.TypeTags()
+	tagsB, errB := b.TypeTags()
+	if tagsA != tagsB || errA != nil || errB != nil {
+		fmt.Fprintf(&d, "type tags: %s != %s\n", formatDiffTags(tagsA, errA), formatDiffTags(tagsB, errB))
+	}
+	if len(a.Arguments) != len(b.Arguments) {
+		fmt.Fprintf(&d, "arguments: %d != %d\n", len(a.Arguments), len(b.Arguments))
+	}
+
+	n := len(a.Arguments)
+	if len(b.Arguments) > n {
+		n = len(b.Arguments)
+	}
+	for i := 0; i < n; i++ {
+		switch {
+		case i >= len(a.Arguments):
+			fmt.Fprintf(&d, "argument %d: missing != %s\n", i, formatDiffArgument(b.Arguments[i]))
+		case i >= len(b.Arguments):
+			fmt.Fprintf(&d, "argument %d: %s != missing\n", i, formatDiffArgument(a.Arguments[i]))
+		case !codec.ArgumentsEqual(a.Arguments[i], b.Arguments[i], 0):
+			x, y := a.Arguments[i], b.Arguments[i]
+			fmt.Fprintf(&d, "argument %d: %s != %s, wire %s != %s\n", i, formatDiffArgument(x), formatDiffArgument(y), argumentWire(x), argumentWire(y))
+		}
+	}
+	return d.String()
+}
+
+// formatDiffMessage returns the address of msg, or "nil".
+func formatDiffMessage(msg *Message) string {
+	if msg == nil {
+		return "nil"
+	}
+	return fmt.Sprintf("%q", msg.Address)
+}
+
+// formatDiffTags returns the quoted type tags, or the error that prevented
+// computing them.
+func formatDiffTags(tags string, err error) string {
+	if err != nil {
+		return fmt.Sprintf("<%s>", err)
+	}
+	return fmt.Sprintf("%q", tags)
+}
+
+// formatDiffArgument returns the type and value of an argument, e.g.
+// float32(0.5).
+func formatDiffArgument(arg interface{}) string {
+	switch t := arg.(type) {
+	case nil:
+		return "nil"
+	case string:
+		return fmt.Sprintf("string(%q)", t)
+	case []byte:
+		return fmt.Sprintf("[]byte(%x)", t)
+	case Timetag:
+		return fmt.Sprintf("Timetag(%d)", t.TimeTag())
+	}
+	return fmt.Sprintf("%T(%v)", arg, arg)
+}
+
+// argumentWire returns the encoded argument as hex string, or the error that
+// prevented encoding it. The type tag isn't included.
+func argumentWire(arg interface{}) string {
+	msg := &Message{Address: "/", Arguments: []interface{}{arg}, SkipValidation: true}
+	data, err := msg.MarshalBinary()
+	if err != nil {
+		return fmt.Sprintf("<%s>", err)
+	}
+	// Skip the address "/" and the padded type tag string
+	tags := bytes.IndexByte(data[4:], 0)
+	data = data[4+4*(tags/4+1):]
+	if len(data) == 0 {
+		return "-" // Arguments without data, e.g. booleans
+	}
+	return fmt.Sprintf("%x", data)
+}
@@ -0,0 +1,49 @@
+package osc
+
+import "testing"
+
+func TestDiff(t *testing.T) {
+	for _, tt := range []struct {
+		name string
+		a, b *Message
+		want string
+	}{
+		{"equal", NewMessage("/a", int32(1), "x"), NewMessage("/a", int32(1), "x"), ""},
+		{"both nil", nil, nil, ""},
+		{"nil", NewMessage("/a"), nil, "message: \"/a\" != nil\n"},
+		{"address", NewMessage("/a"), NewMessage("/b"), "address: \"/a\" != \"/b\"\n"},
+		{
+			"value", NewMessage("/a", float32(0.5)), NewMessage("/a", float32(0.25)),
+			"argument 0: float32(0.5) != float32(0.25), wire 3f000000 != 3e800000\n",
+		},
+		{
+			"type", NewMessage("/a", "x", float32(0.5)), NewMessage("/a", "x", int32(1)),
+			"type tags: \",sf\" != \",si\"\n" +
+				"argument 1: float32(0.5) != int32(1), wire 3f000000 != 00000001\n",
+		},
+		{
+			"string", NewMessage("/a", "ab"), NewMessage("/a", "abcd"),
+			"argument 0: string(\"ab\") != string(\"abcd\"), wire 61620000 != 6162636400000000\n",
+		},
+		{
+			"bool", NewMessage("/a", true), NewMessage("/a", false),
+			"type tags: \",T\" != \",F\"\n" +
+				"argument 0: bool(true) != bool(false), wire - != -\n",
+		},
+		{
+			"count", NewMessage("/a", int32(1)), NewMessage("/a", int32(1), []byte{1, 2}),
+			"type tags: \",i\" != \",ib\"\n" +
+				"arguments: 1 != 2\n" +
+				"argument 1: missing != []byte(0102)\n",
+		},
+		{
+			"unsupported", NewMessage("/a", uint8(1)), NewMessage("/a", int32(1)),
+			"type tags: <Unsupported type: uint8> != \",i\"\n" +
+				"argument 0: uint8(1) != int32(1), wire <OSC - unsupported type: uint8> != 00000001\n",
+		},
+	} {
+		if got := Diff(tt.a, tt.b); got != tt.want {
+			t.Errorf("%s: Diff() = %q, want = %q", tt.name, got, tt.want)
+		}
+	}
+}
@@ -0,0 +1,25 @@
+package dispatch
+
+import "github.com/hypebeast/go-osc/osc/codec"
+
+// Dispatcher is an interface for an OSC message dispatcher. A dispatcher is
+// responsible for dispatching received OSC messages.
+type Dispatcher interface {
+	Dispatch(packet codec.Packet)
+}
+
+// Handler is an interface for message handlers. Every handler implementation
+// for an OSC message must implement this interface.
+type Handler interface {
+	HandleMessage(msg *codec.Message)
+}
+
+// HandlerFunc implements the Handler interface. Type definition for an OSC
+// handler function.
+type HandlerFunc func(msg *codec.Message)
+
+// HandleMessage calls itself with the given OSC Message. Implements the
+// Handler interface.
+func (f HandlerFunc) HandleMessage(msg *codec.Message) {
+	f(msg)
+}
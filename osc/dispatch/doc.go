@@ -0,0 +1,8 @@
+// Package dispatch contains the interfaces that route received OSC packets
+// to message handlers, Dispatcher and Handler, and Mux, a dispatcher with
+// net/http.ServeMux like semantics. It only depends on package codec, so
+// dispatchers can be used with any transport.
+//
+// Package osc aliases the types of this package. StandardDispatcher stays in
+// package osc, because it reports to the trace of its Server.
+package dispatch
@@ -0,0 +1,205 @@
+package dispatch
+
+import (
+	"errors"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/hypebeast/go-osc/osc/codec"
+	"github.com/hypebeast/go-osc/osc/internal/pattern"
+)
+
+// Mux is a message dispatcher with net/http.ServeMux like semantics. Handlers
+// are registered for one of three kinds of addresses:
+//   - an exact address, e.g. "/synth/1/freq"
+//   - a prefix that ends with '/', e.g. "/synth/", which matches all
+//     addresses below it. The prefix "/" matches all addresses.
+//   - an address pattern, e.g. "/synth/*/freq", which matches all addresses
+//     that the pattern matches
+//
+// A message is dispatched to the handlers of the entry with the highest
+// precedence that matches its address: an exact match shadows all prefixes,
+// and the longest matching prefix shadows shorter prefixes and all patterns.
+// If neither an exact address nor a prefix matches, the message is dispatched
+// to the handlers of all matching patterns, in the order the patterns were
+// registered.
+//
+// Multiple handlers can be registered for the same address. They are called in
+// the order they were registered.
+//
+// A message whose address is itself an address pattern, as allowed by the OSC
+// specification, is dispatched to the handlers of all exact addresses that it
+// matches, in lexical order of the addresses.
+//
+// A Mux is safe for concurrent use.
+type Mux struct {
+	mu             sync.RWMutex
+	exact          map[string][]Handler
+	prefixes       map[string][]Handler
+	patterns       []muxPattern
+	defaultHandler Handler
+}
+
+// Verify that Mux implements the Dispatcher interface.
+var _ Dispatcher = (*Mux)(nil)
+
+// muxPattern is a pattern entry of a Mux.
+type muxPattern struct {
+	pattern  *codec.Pattern
+	handlers []Handler
+}
+
+// NewMux returns a new Mux.
+func NewMux() *Mux {
+	return &Mux{
+		exact:    make(map[string][]Handler),
+		prefixes: make(map[string][]Handler),
+	}
+}
+
+// Handle registers the handler for the given address, prefix or address
+// pattern. Handlers that were registered before for the same address are
+// kept, the handler is called after them.
+func (m *Mux) Handle(addr string, handler Handler) error {
+	if handler == nil {
+		return errors.New("osc: nil handler")
+	}
+	if !strings.HasPrefix(addr, "/") {
+		return errors.New("osc: address must start with '/'")
+	}
+
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	if pattern.IsPattern(addr) {
+		p, err := codec.CompilePattern(addr)
+		if err != nil {
+			return err
+		}
+		for i := range m.patterns {
+			if m.patterns[i].pattern.String() == addr {
+				m.patterns[i].handlers = append(m.patterns[i].handlers, handler)
+				return nil
+			}
+		}
+		m.patterns = append(m.patterns, muxPattern{p, []Handler{handler}})
+		return nil
+	}
+
+	if err := pattern.ValidateAddress(addr); err != nil {
+		return err
+	}
+	if strings.HasSuffix(addr, "/") {
+		m.prefixes[addr] = append(m.prefixes[addr], handler)
+	} else {
+		m.exact[addr] = append(m.exact[addr], handler)
+	}
+	return nil
+}
+
+// HandleFunc registers the handler function for the given address, prefix or
+// address pattern, see Handle.
+func (m *Mux) HandleFunc(addr string, handler func(msg *codec.Message)) error {
+	return m.Handle(addr, HandlerFunc(handler))
+}
+
+// SetDefaultHandler sets a handler that is called for every message that no
+// registered entry matches. Passing nil removes the default handler.
+func (m *Mux) SetDefaultHandler(handler Handler) {
+	m.mu.Lock()
+	m.defaultHandler = handler
+	m.mu.Unlock()
+}
+
+// Handlers returns the handlers that a message with the given address is
+// dispatched to, in the order they are called. The default handler is not
+// included.
+func (m *Mux) Handlers(addr string) []Handler {
+	m.mu.RLock()
+	defer m.mu.RUnlock()
+	return append([]Handler(nil), m.handlers(addr)...)
+}
+
+// Dispatch dispatches the messages of the packet. The messages of a bundle are
+// dispatched when its timetag expires, they are retained, see
+// osc.Server.ReuseMessages. Implements the Dispatcher interface.
+func (m *Mux) Dispatch(packet codec.Packet) {
+	switch p := packet.(type) {
+	case *codec.Message:
+		m.dispatchMessage(p)
+
+	case *codec.Bundle:
+		// The server recycles the messages when Dispatch returns
+		codec.RetainPacket(p)
+		timer := time.NewTimer(p.Timetag.ExpiresIn())
+		go func() {
+			<-timer.C
+			for _, msg := range p.Messages {
+				m.dispatchMessage(msg)
+			}
+			for _, b := range p.Bundles {
+				m.Dispatch(b)
+			}
+		}()
+	}
+}
+
+// dispatchMessage calls the handlers for the address of msg, or the default
+// handler if there are none.
+func (m *Mux) dispatchMessage(msg *codec.Message) {
+	m.mu.RLock()
+	handlers := m.handlers(msg.Address)
+	if len(handlers) == 0 && m.defaultHandler != nil {
+		handlers = []Handler{m.defaultHandler}
+	}
+	m.mu.RUnlock()
+
+	for _, h := range handlers {
+		h.HandleMessage(msg)
+	}
+}
+
+// handlers returns the handlers for addr according to the precedence rules.
+// The caller must hold the read lock.
+func (m *Mux) handlers(addr string) []Handler {
+	if pattern.IsPattern(addr) {
+		p, err := codec.CompilePattern(addr)
+		if err != nil {
+			return nil
+		}
+		var addrs []string
+		for a := range m.exact {
+			if p.Match(a) {
+				addrs = append(addrs, a)
+			}
+		}
+		sort.Strings(addrs)
+
+		var handlers []Handler
+		for _, a := range addrs {
+			handlers = append(handlers, m.exact[a]...)
+		}
+		return handlers
+	}
+
+	if handlers, ok := m.exact[addr]; ok {
+		return handlers
+	}
+
+	// Try the prefixes from the longest to the shortest
+	for i := strings.LastIndexByte(addr, '/'); i >= 0; i = strings.LastIndexByte(addr[:i], '/') {
+		if handlers, ok := m.prefixes[addr[:i+1]]; ok {
+			return handlers
+		}
+	}
+
+	var handlers []Handler
+	for _, p := range m.patterns {
+		if p.pattern.Match(addr) {
+			handlers = append(handlers, p.handlers...)
+		}
+	}
+	return handlers
+}
@@ -0,0 +1,79 @@
+package dispatch
+
+import (
+	"reflect"
+	"testing"
+
+	"github.com/hypebeast/go-osc/osc/codec"
+)
+
+func TestMux_Precedence(t *testing.T) {
+	var got []string
+	handler := func(name string) func(msg *codec.Message) {
+		return func(msg *codec.Message) { got = append(got, name) }
+	}
+
+	m := NewMux()
+	for _, h := range []struct{ addr, name string }{
+		{"/synth/1/freq", "exact"},
+		{"/synth/1/freq", "exact2"},
+		{"/synth/", "prefix"},
+		{"/synth/1/", "longprefix"},
+		{"/drums/*/level", "pattern"},
+		{"/drums/kick/*", "pattern2"},
+		{"/drums/*/level", "pattern3"},
+		{"/fx/1/mix", "fx1"},
+		{"/fx/2/mix", "fx2"},
+	} {
+		if err := m.HandleFunc(h.addr, handler(h.name)); err != nil {
+			t.Fatalf("HandleFunc(%s) unexpected error: %s", h.addr, err)
+		}
+	}
+	m.SetDefaultHandler(HandlerFunc(handler("default")))
+
+	for _, tt := range []struct {
+		addr string
+		want []string
+	}{
+		{"/synth/1/freq", []string{"exact", "exact2"}},
+		{"/synth/1/gain", []string{"longprefix"}},
+		{"/synth/2/freq", []string{"prefix"}},
+		{"/synth", []string{"default"}},
+		{"/drums/kick/level", []string{"pattern", "pattern3", "pattern2"}},
+		{"/drums/snare/level", []string{"pattern", "pattern3"}},
+		{"/fx/*/mix", []string{"fx1", "fx2"}},
+		{"/fx/[2-9]/mix", []string{"fx2"}},
+		{"/other", []string{"default"}},
+	} {
+		got = nil
+		m.Dispatch(codec.NewMessage(tt.addr))
+		if !reflect.DeepEqual(got, tt.want) {
+			t.Errorf("%s dispatched to %v, want = %v", tt.addr, got, tt.want)
+		}
+		if n := len(m.Handlers(tt.addr)); tt.want[0] != "default" && n != len(tt.want) {
+			t.Errorf("Handlers(%s) returned %d handlers, want = %d", tt.addr, n, len(tt.want))
+		}
+	}
+
+	// The root prefix shadows the patterns
+	if err := m.HandleFunc("/", handler("root")); err != nil {
+		t.Fatal(err)
+	}
+	got = nil
+	m.Dispatch(codec.NewMessage("/drums/kick/level"))
+	if want := []string{"root"}; !reflect.DeepEqual(got, want) {
+		t.Errorf("/drums/kick/level dispatched to %v, want = %v", got, want)
+	}
+}
+
+func TestMux_Handle_Errors(t *testing.T) {
+	m := NewMux()
+	for _, addr := range []string{"", "synth", "/a b", "/a/[", "/a,b"} {
+		if err := m.HandleFunc(addr, func(msg *codec.Message) {}); err == nil {
+			t.Errorf("HandleFunc(%q) expected error", addr)
+		}
+	}
+	if err := m.Handle("/a", nil); err == nil {
+		t.Error("Handle() expected error for nil handler")
+	}
+}
@@ -16,7 +16,8 @@ and other multimedia devices.
 Features:
 - Supports OSC messages with 'i' (Int32), 'f' (Float32),
  's' (string), 'b' (blob / binary data), 'h' (Int64), 't' (OSC timetag),
-  'd' (Double/int64), 'T' (True), 'F' (False), 'N' (Nil) types.
+  'd' (Double/int64), 'T' (True), 'F' (False), 'N' (Nil), 'I' (Impulse),
+  'c' (Char), 'm' (MIDI), 'r' (RGBA) types and '[', ']' arrays.
 - OSC bundles, including timetags
 - Support for OSC address pattern including '*', '?', '{,}' and '[]' wildcards
 
@@ -48,10 +49,14 @@ OSC Bundle.
 
 The following argument types are supported: 'i' (Int32), 'f' (Float32),
 's' (string), 'b' (blob / binary data), 'h' (Int64), 't' (OSC timetag),
-'d' (Double/int64), 'T' (True), 'F' (False), 'N' (Nil).
+'d' (Double/int64), 'T' (True), 'F' (False), 'N' (Nil), 'I' (Impulse),
+'c' (Char), 'm' (MIDI), 'r' (RGBA). Arrays, i.e. arguments enclosed in
+'[' and ']', are represented as []interface{}.
 
 go-osc supports the following OSC address patterns:
 - '*', '?', '{,}' and '[]' wildcards.
+- '[!]' negated character classes and backslash escapes, see Quote.
+- The OSC 1.1 '//' operator that matches any number of address parts.
 
 Usage
 
@@ -77,5 +82,23 @@ OSC server example:
         Dispatcher:d,
     }
     server.ListenAndServe()
+
+Packages
+
+The package is layered into subpackages, whose types are aliased here, so
+code that only imports osc keeps working:
+- codec contains Message, Bundle, Timetag, the argument types, the encoder
+  and the decoder. It doesn't depend on package net.
+- dispatch contains Dispatcher, Handler and Mux.
+- transport contains StreamClient, Sender and ListenConfig.
+
+WebAssembly
+
+Package codec builds for GOOS=js GOARCH=wasm without package net. Package
+oscws builds on it and sends and receives packets over a browser WebSocket:
+its Client is the Sender to use in the browser instead of Client. This
+package builds for js/wasm as well, but only because Go provides stubs of
+net there: its Client, Server and Peer use UDP and fail at run time in the
+browser.
 */
 package osc
@@ -0,0 +1,73 @@
+package osc
+
+import (
+	"errors"
+	"reflect"
+
+	"github.com/hypebeast/go-osc/osc/codec"
+)
+
+// AddFuncHandler adds a new message handler for the given OSC address that
+// calls fn with the message arguments as parameters, e.g. a function
+// func(freq float32, wave string) receives messages with a float32 and a
+// string argument. fn must be a function without results. A variadic function
+// receives all remaining arguments.
+//
+// Integer arguments are converted to every integer or float parameter type
+// that can hold their value, float arguments to every float parameter type.
+// Parameters of an interface type, e.g. interface{}, accept every argument
+// that implements the interface, Nil arguments are passed as nil. Messages
+// with arguments that can't be converted are passed to the type mismatch
+// handler, see SetTypeMismatchHandler, with the signature of fn.
+func (s *StandardDispatcher) AddFuncHandler(addr string, fn interface{}) error {
+	fv := reflect.ValueOf(fn)
+	if fv.Kind() != reflect.Func {
+		return errors.New("osc: handler must be a function")
+	}
+	ft := fv.Type()
+	if ft.NumOut() != 0 {
+		return errors.New("osc: handler function must not return results")
+	}
+	signature := ft.String()
+
+	return s.AddMsgHandler(addr, func(msg *Message) {
+		in, ok := funcArguments(ft, msg.Arguments)
+		if !ok {
+			if s.typeMismatchHandler != nil {
+				s.typeMismatchHandler(msg, signature)
+			}
+			return
+		}
+		fv.Call(in)
+	})
+}
+
+// funcArguments converts args to the parameters of the function type ft.
+// Returns false if the number or types of the arguments don't match.
+func funcArguments(ft reflect.Type, args []interface{}) ([]reflect.Value, bool) {
+	n := ft.NumIn()
+	if ft.IsVariadic() {
+		n--
+		if len(args) < n {
+			return nil, false
+		}
+	} else if len(args) != n {
+		return nil, false
+	}
+
+	in := make([]reflect.Value, len(args))
+	for i, arg := range args {
+		var t reflect.Type
+		if i < n {
+			t = ft.In(i)
+		} else {
+			t = ft.In(n).Elem()
+		}
+		v, ok := codec.ConvertArgument(arg, t)
+		if !ok {
+			return nil, false
+		}
+		in[i] = v
+	}
+	return in, true
+}
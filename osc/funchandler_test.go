@@ -0,0 +1,85 @@
+package osc
+
+import (
+	"fmt"
+	"reflect"
+	"testing"
+)
+
+func TestStandardDispatcher_AddFuncHandler(t *testing.T) {
+	var calls []string
+	var rejected []string
+	d := NewStandardDispatcher()
+	d.SetTypeMismatchHandler(func(msg *Message, want string) {
+		rejected = append(rejected, msg.Address+" "+want)
+	})
+
+	for addr, fn := range map[string]interface{}{
+		"/synth": func(freq float32, wave string) {
+			calls = append(calls, fmt.Sprintf("synth %v %s", freq, wave))
+		},
+		"/note": func(key uint8, velocity int) {
+			calls = append(calls, fmt.Sprintf("note %d %d", key, velocity))
+		},
+		"/gain": func(db float64) {
+			calls = append(calls, fmt.Sprintf("gain %v", db))
+		},
+		"/any": func(v interface{}) {
+			calls = append(calls, fmt.Sprintf("any %v", v))
+		},
+		"/chord": func(name string, keys ...int32) {
+			calls = append(calls, fmt.Sprintf("chord %s %v", name, keys))
+		},
+		"/trigger": func() {
+			calls = append(calls, "trigger")
+		},
+	} {
+		if err := d.AddFuncHandler(addr, fn); err != nil {
+			t.Fatalf("AddFuncHandler(%s) unexpected error: %s", addr, err)
+		}
+	}
+
+	for _, tt := range []struct {
+		msg  *Message
+		want string // Empty if the message is rejected
+	}{
+		{NewMessage("/synth", float32(440), "saw"), "synth 440 saw"},
+		{NewMessage("/synth", int32(440), "saw"), "synth 440 saw"},
+		{NewMessage("/synth", "saw", float32(440)), ""},
+		{NewMessage("/synth", float32(440)), ""},
+		{NewMessage("/note", int32(60), int64(100)), "note 60 100"},
+		{NewMessage("/note", int32(256), int32(100)), ""},
+		{NewMessage("/note", int32(-1), int32(100)), ""},
+		{NewMessage("/note", float32(60), int32(100)), ""},
+		{NewMessage("/gain", float32(-6.5)), "gain -6.5"},
+		{NewMessage("/any", nil), "any <nil>"},
+		{NewMessage("/any", true), "any true"},
+		{NewMessage("/chord", "c", int32(60), int32(64)), "chord c [60 64]"},
+		{NewMessage("/chord", "c"), "chord c []"},
+		{NewMessage("/chord"), ""},
+		{NewMessage("/trigger"), "trigger"},
+		{NewMessage("/trigger", Char('x')), ""},
+	} {
+		calls, rejected = nil, nil
+		d.Dispatch(tt.msg)
+		if tt.want == "" {
+			if len(calls) != 0 || len(rejected) != 1 {
+				t.Errorf("%v: calls = %v, rejected = %v, want the message to be rejected", tt.msg, calls, rejected)
+			}
+		} else if !reflect.DeepEqual(calls, []string{tt.want}) || len(rejected) != 0 {
+			t.Errorf("%v: calls = %v, rejected = %v, want = %s", tt.msg, calls, rejected, tt.want)
+		}
+	}
+
+	rejected = nil
+	d.Dispatch(NewMessage("/synth", "saw"))
+	if want := "/synth func(float32, string)"; len(rejected) != 1 || rejected[0] != want {
+		t.Errorf("rejected = %v, want = %s", rejected, want)
+	}
+
+	for _, fn := range []interface{}{nil, 42, func() error { return nil }} {
+		if err := d.AddFuncHandler("/invalid", fn); err == nil {
+			t.Errorf("AddFuncHandler(%T) expected error", fn)
+		}
+	}
+}
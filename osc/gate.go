@@ -0,0 +1,94 @@
+package osc
+
+import "github.com/hypebeast/go-osc/osc/codec"
+
+// MaxGateAddresses is the maximum number of addresses whose last arguments
+// the change gates of a StandardDispatcher keep. The addresses are chosen by
+// the senders, so messages with further addresses pass the gates unchanged,
+// to bound the memory of the gates.
+const MaxGateAddresses = 1024
+
+// changeGate drops messages whose arguments didn't change, see
+// StandardDispatcher.SetChangeGate.
+type changeGate struct {
+	pattern *Pattern
+	epsilon float64
+}
+
+// SetChangeGate makes the dispatcher drop received messages whose address
+// matches pattern if their arguments equal the arguments of the last
+// dispatched message with the same address, e.g. to call the handlers of a
+// noisy sensor only if its value actually changed. Floats are considered
+// equal if they differ by at most epsilon, the other arguments must be equal.
+// Since messages are compared with the last dispatched one and not with the
+// last received one, slow drifts beyond epsilon are dispatched as well. At
+// most MaxGateAddresses addresses are gated.
+//
+// Setting a gate for the same pattern again changes its epsilon, a negative
+// epsilon removes the gate. Gates must be set before messages are
+// dispatched. Messages passed to Invoke aren't gated.
+func (s *StandardDispatcher) SetChangeGate(pattern string, epsilon float64) error {
+	pattern = s.routeAddress(pattern)
+	p, err := s.compilePattern(pattern)
+	if err != nil {
+		return err
+	}
+	for i, g := range s.gates {
+		if g.pattern.String() != pattern {
+			continue
+		}
+		if epsilon < 0 {
+			s.gates = append(s.gates[:i], s.gates[i+1:]...)
+		} else {
+			s.gates[i].epsilon = epsilon
+		}
+		return nil
+	}
+	if epsilon >= 0 {
+		s.gates = append(s.gates, changeGate{p, epsilon})
+	}
+	return nil
+}
+
+// changed returns false if msg must be dropped by a change gate. Otherwise
+// its arguments are remembered for the next message with its address.
+func (s *StandardDispatcher) changed(msg *Message) bool {
+	if len(s.gates) == 0 {
+		return true
+	}
+	addr := s.routeAddress(msg.Address)
+	var gate *changeGate
+	for i := range s.gates {
+		if s.gates[i].pattern.Match(addr) {
+			gate = &s.gates[i]
+			break
+		}
+	}
+	if gate == nil {
+		return true
+	}
+
+	s.gateMu.Lock()
+	defer s.gateMu.Unlock()
+	if last, ok := s.gateLast[addr]; ok && len(last) == len(msg.Arguments) {
+		unchanged := true
+		for i, arg := range msg.Arguments {
+			if !codec.ArgumentsEqual(arg, last[i], gate.epsilon) {
+				unchanged = false
+				break
+			}
+		}
+		if unchanged {
+			return false
+		}
+	}
+	if s.gateLast == nil {
+		s.gateLast = make(map[string][]interface{})
+	}
+	if _, ok := s.gateLast[addr]; !ok && len(s.gateLast) >= MaxGateAddresses {
+		return true
+	}
+	// Copy the arguments, received messages may be recycled
+	s.gateLast[addr] = msg.Clone().Arguments
+	return true
+}
@@ -0,0 +1,96 @@
+package osc
+
+import (
+	"fmt"
+	"reflect"
+	"testing"
+)
+
+func TestStandardDispatcher_SetChangeGate(t *testing.T) {
+	var got []interface{}
+	d := NewStandardDispatcher()
+	if err := d.AddMsgHandler("*", func(msg *Message) { got = append(got, msg.Arguments[0]) }); err != nil {
+		t.Fatal(err)
+	}
+	if err := d.SetChangeGate("/sensor/*", 0.01); err != nil {
+		t.Fatal(err)
+	}
+	if err := d.SetChangeGate("/sensor/[", 0.01); err == nil {
+		t.Error("SetChangeGate() expected error for invalid pattern")
+	}
+
+	for _, tt := range []struct {
+		addr string
+		arg  interface{}
+	}{
+		{"/sensor/1", float32(0.5)},
+		{"/sensor/1", float32(0.505)},
+		{"/sensor/1", float32(0.509)},
+		{"/sensor/2", float32(0.5)},
+		{"/sensor/1", float32(0.52)},
+		{"/sensor/1", "off"},
+		{"/sensor/1", "off"},
+		{"/fader", float32(1)},
+		{"/fader", float32(1)},
+	} {
+		d.Dispatch(NewMessage(tt.addr, tt.arg))
+	}
+	want := []interface{}{float32(0.5), float32(0.5), float32(0.52), "off", float32(1), float32(1)}
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("dispatched %v, want = %v", got, want)
+	}
+
+	// Removing the gate dispatches every message again
+	if err := d.SetChangeGate("/sensor/*", -1); err != nil {
+		t.Fatal(err)
+	}
+	got = nil
+	d.Dispatch(NewMessage("/sensor/1", "off"))
+	if len(got) != 1 {
+		t.Errorf("dispatched %v after removing the gate, want = [off]", got)
+	}
+}
+
+func TestStandardDispatcher_ChangeGateLimit(t *testing.T) {
+	n := 0
+	d := NewStandardDispatcher()
+	if err := d.AddMsgHandler("*", func(msg *Message) { n++ }); err != nil {
+		t.Fatal(err)
+	}
+	if err := d.SetChangeGate("/spam/*", 0); err != nil {
+		t.Fatal(err)
+	}
+	for i := 0; i < MaxGateAddresses+10; i++ {
+		d.Dispatch(NewMessage(fmt.Sprintf("/spam/%d", i), int32(1)))
+	}
+	if len(d.gateLast) != MaxGateAddresses {
+		t.Errorf("gates keep %d addresses, want = %d", len(d.gateLast), MaxGateAddresses)
+	}
+
+	// Addresses beyond the limit aren't gated
+	d.Dispatch(NewMessage(fmt.Sprintf("/spam/%d", MaxGateAddresses), int32(1)))
+	if want := MaxGateAddresses + 11; n != want {
+		t.Errorf("dispatched %d messages, want = %d", n, want)
+	}
+}
+
+func TestStandardDispatcher_ChangeGateRoutingOptions(t *testing.T) {
+	var got []string
+	d := NewStandardDispatcher()
+	d.SetCaseInsensitive(true)
+	d.SetNormalizeAddresses(true)
+	if err := d.AddMsgHandler("/sensor/x", func(msg *Message) { got = append(got, msg.Address) }); err != nil {
+		t.Fatal(err)
+	}
+	if err := d.SetChangeGate("/Sensor/*/", 0); err != nil {
+		t.Fatal(err)
+	}
+
+	// The addresses are equivalent, so only the first message passes
+	for _, addr := range []string{"/SENSOR/x", "/SENSOR/x", "/sensor/X", "//sensor/x/"} {
+		d.Dispatch(NewMessage(addr, int32(1)))
+	}
+	if want := []string{"/SENSOR/x"}; !reflect.DeepEqual(got, want) {
+		t.Errorf("dispatched %v, want = %v", got, want)
+	}
+}
@@ -0,0 +1,247 @@
+package osc
+
+import (
+	"context"
+	"errors"
+	"net"
+	"strings"
+	"time"
+
+	"github.com/hypebeast/go-osc/osc/codec"
+)
+
+// Addresses of the handshake messages. Both messages have the string
+// arguments implementation name, OSC version and supported type tags, see
+// Capabilities.
+const (
+	HandshakeAddress      = "/sys/ping"
+	HandshakeReplyAddress = "/sys/pong"
+)
+
+// maxHandshakeSources is the number of addresses whose capabilities and
+// first packet a Peer remembers before it forgets the ones older than
+// handshakeExpiry. Further addresses aren't remembered until entries expired.
+const maxHandshakeSources = 1024
+
+// handshakeExpiry is the age after which a remembered address may be
+// forgotten.
+const handshakeExpiry = time.Minute
+
+// errIntercepted is returned by Server.ReceivePacket for packets that were
+// consumed by the server itself, e.g. handshake messages of a Peer.
+var errIntercepted = errors.New("osc: packet was consumed by the server")
+
+// Capabilities describe an OSC implementation. They are exchanged by the
+// handshake of a Peer, so that heterogeneous systems can adapt to each
+// other, e.g. by not sending int64 arguments to a receiver without 'h'.
+type Capabilities struct {
+	Implementation string // Name of the implementation, e.g. "go-osc"
+	Version        string // Supported OSC version, e.g. "1.1"
+	TypeTags       string // Supported type tags, e.g. "ifsb"
+}
+
+// DefaultCapabilities returns the capabilities of this package, including the
+// type tags of the custom types registered with RegisterType.
+func DefaultCapabilities() Capabilities {
+	tags := "ifsbhdtTFNIcmr[]"
+	tags += codec.RegisteredTags()
+	return Capabilities{Implementation: "go-osc", Version: "1.1", TypeTags: tags}
+}
+
+// Supports returns true if the type tag is one of the supported type tags.
+func (c Capabilities) Supports(tag byte) bool {
+	return strings.IndexByte(c.TypeTags, tag) >= 0
+}
+
+// message returns the handshake message with the given address.
+func (c Capabilities) message(addr string) *Message {
+	return NewMessage(addr, c.Implementation, c.Version, c.TypeTags)
+}
+
+// capabilitiesFromMessage parses the arguments of a handshake message.
+func capabilitiesFromMessage(msg *Message) (Capabilities, bool) {
+	if len(msg.Arguments) < 3 {
+		return Capabilities{}, false
+	}
+	var args [3]string
+	for i := range args {
+		s, ok := msg.Arguments[i].(string)
+		if !ok {
+			return Capabilities{}, false
+		}
+		args[i] = s
+	}
+	return Capabilities{Implementation: args[0], Version: args[1], TypeTags: args[2]}, true
+}
+
+// handshakeState is the handshake state of a Peer.
+type handshakeState struct {
+	local   Capabilities
+	answer  bool // Reply to handshake requests
+	auto    bool // Start a handshake with every new source address
+	remote  map[string]remoteCapabilities
+	seen    map[string]time.Time // Last packet of every address, if auto is set
+	waiters map[string][]chan Capabilities
+}
+
+// remoteCapabilities are the capabilities of a remote address and the time
+// they were received.
+type remoteCapabilities struct {
+	caps     Capabilities
+	received time.Time
+}
+
+// EnableHandshake makes the peer reply to handshake requests with caps and
+// consume them, i.e. they aren't dispatched. If auto is set, the peer also
+// sends a handshake request to every address it receives a packet from for
+// the first time. The capabilities of the remote side are available through
+// RemoteCapabilities once the handshake completed. It must be called before
+// Serve.
+func (p *Peer) EnableHandshake(caps Capabilities, auto bool) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	p.handshake.local = caps
+	p.handshake.answer = true
+	p.handshake.auto = auto
+}
+
+// Handshake sends a handshake request to addr, which has the form
+// "host:port", and waits for the reply. Serve must be running to receive the
+// reply. Returns the error of ctx if it is done before the reply was
+// received.
+func (p *Peer) Handshake(ctx context.Context, addr string) (Capabilities, error) {
+	raddr, err := net.ResolveUDPAddr("udp", addr)
+	if err != nil {
+		return Capabilities{}, err
+	}
+
+	reply := make(chan Capabilities, 1)
+	key := raddr.String()
+	p.mu.Lock()
+	p.handshake.waiters[key] = append(p.handshake.waiters[key], reply)
+	local := p.handshake.local
+	p.mu.Unlock()
+	defer p.removeWaiter(key, reply)
+
+	if _, err := p.SendToAddr(raddr, local.message(HandshakeAddress)); err != nil {
+		return Capabilities{}, err
+	}
+	select {
+	case caps := <-reply:
+		return caps, nil
+	case <-ctx.Done():
+		return Capabilities{}, ctx.Err()
+	}
+}
+
+// RemoteCapabilities returns the capabilities of addr that were received by
+// a handshake. Returns false if no handshake with addr completed yet.
+func (p *Peer) RemoteCapabilities(addr net.Addr) (Capabilities, bool) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	remote, ok := p.handshake.remote[addr.String()]
+	return remote.caps, ok
+}
+
+// removeWaiter removes the reply channel of a Handshake call.
+func (p *Peer) removeWaiter(key string, reply chan Capabilities) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	waiters := p.handshake.waiters[key]
+	for i, w := range waiters {
+		if w == reply {
+			waiters = append(waiters[:i], waiters[i+1:]...)
+			break
+		}
+	}
+	if len(waiters) == 0 {
+		delete(p.handshake.waiters, key)
+	} else {
+		p.handshake.waiters[key] = waiters
+	}
+}
+
+// interceptHandshake handles the handshake messages received by the peer.
+// Returns true if the packet was consumed.
+func (p *Peer) interceptHandshake(packet Packet, addr net.Addr) bool {
+	key, now := addr.String(), time.Now()
+	msg, _ := packet.(*Message)
+
+	p.mu.Lock()
+	h := &p.handshake
+	sendRequest := false
+	if h.auto {
+		_, seen := h.seen[key]
+		if !seen && len(h.seen) >= maxHandshakeSources {
+			h.forget(now)
+		}
+		if seen || len(h.seen) < maxHandshakeSources {
+			sendRequest = !seen
+			h.seen[key] = now
+		}
+	}
+
+	var reply *Message
+	consumed := false
+	if msg != nil {
+		switch {
+		case msg.Address == HandshakeReplyAddress:
+			if caps, ok := capabilitiesFromMessage(msg); ok {
+				h.setRemote(key, caps, now)
+				for _, w := range h.waiters[key] {
+					w <- caps
+				}
+				delete(h.waiters, key)
+			}
+			consumed, sendRequest = true, false
+
+		case msg.Address == HandshakeAddress && h.answer:
+			if caps, ok := capabilitiesFromMessage(msg); ok {
+				h.setRemote(key, caps, now)
+			}
+			reply = h.local.message(HandshakeReplyAddress)
+			consumed, sendRequest = true, false
+		}
+	}
+	var request *Message
+	if sendRequest {
+		request = h.local.message(HandshakeAddress)
+	}
+	p.mu.Unlock()
+
+	// Errors are ignored, the remote side may retry the handshake
+	if reply != nil {
+		p.SendToAddr(addr, reply)
+	}
+	if request != nil {
+		p.SendToAddr(addr, request)
+	}
+	return consumed
+}
+
+// setRemote stores the capabilities of the address key. They aren't stored
+// if maxHandshakeSources addresses are remembered and none of them expired.
+func (h *handshakeState) setRemote(key string, caps Capabilities, now time.Time) {
+	if _, ok := h.remote[key]; !ok && len(h.remote) >= maxHandshakeSources {
+		h.forget(now)
+		if len(h.remote) >= maxHandshakeSources {
+			return
+		}
+	}
+	h.remote[key] = remoteCapabilities{caps: caps, received: now}
+}
+
+// forget removes the addresses that are older than handshakeExpiry, which
+// bounds the memory if many addresses send packets.
+func (h *handshakeState) forget(now time.Time) {
+	for key, t := range h.seen {
+		if now.Sub(t) >= handshakeExpiry {
+			delete(h.seen, key)
+		}
+	}
+	for key, r := range h.remote {
+		if now.Sub(r.received) >= handshakeExpiry {
+			delete(h.remote, key)
+		}
+	}
+}
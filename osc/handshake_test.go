@@ -0,0 +1,118 @@
+package osc
+
+import (
+	"context"
+	"net"
+	"testing"
+	"time"
+)
+
+func TestPeer_Handshake(t *testing.T) {
+	a, err := NewPeer("127.0.0.1:0", nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer a.Close()
+	b, err := NewPeer("127.0.0.1:0", nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer b.Close()
+
+	device := Capabilities{Implementation: "device", Version: "1.0", TypeTags: "ifsb"}
+	b.EnableHandshake(device, false)
+	go a.Serve()
+	go b.Serve()
+
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+	caps, err := a.Handshake(ctx, b.LocalAddr().String())
+	if err != nil {
+		t.Fatal(err)
+	}
+	if caps != device {
+		t.Errorf("Handshake() = %+v, want = %+v", caps, device)
+	}
+	if caps.Supports('h') || !caps.Supports('f') {
+		t.Errorf("Supports() of %q is wrong", caps.TypeTags)
+	}
+	if caps, ok := b.RemoteCapabilities(a.LocalAddr()); !ok || caps != DefaultCapabilities() {
+		t.Errorf("RemoteCapabilities() = %+v, %v, want = %+v", caps, ok, DefaultCapabilities())
+	}
+}
+
+func TestPeer_AutoHandshake(t *testing.T) {
+	received := make(chan *Message, 1)
+	d := NewStandardDispatcher()
+	if err := d.AddMsgHandler("/fader", func(msg *Message) { received <- msg }); err != nil {
+		t.Fatal(err)
+	}
+	a, err := NewPeer("127.0.0.1:0", d)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer a.Close()
+	b, err := NewPeer("127.0.0.1:0", nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer b.Close()
+
+	device := Capabilities{Implementation: "device", Version: "1.0", TypeTags: "ifsb"}
+	a.EnableHandshake(DefaultCapabilities(), true)
+	b.EnableHandshake(device, false)
+	go a.Serve()
+	go b.Serve()
+
+	// The first packet of b makes a start a handshake
+	if _, err := b.SendTo(a.LocalAddr().String(), NewMessage("/fader", float32(1))); err != nil {
+		t.Fatal(err)
+	}
+	select {
+	case <-received:
+	case <-time.After(5 * time.Second):
+		t.Fatal("message wasn't dispatched")
+	}
+
+	deadline := time.Now().Add(5 * time.Second)
+	for {
+		if caps, ok := a.RemoteCapabilities(b.LocalAddr()); ok {
+			if caps != device {
+				t.Errorf("RemoteCapabilities() = %+v, want = %+v", caps, device)
+			}
+			break
+		}
+		if time.Now().After(deadline) {
+			t.Fatal("handshake didn't complete")
+		}
+		time.Sleep(10 * time.Millisecond)
+	}
+}
+
+func TestPeer_HandshakeSources(t *testing.T) {
+	p, err := NewPeer("127.0.0.1:0", nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer p.Close()
+
+	// Without EnableHandshake the sources aren't tracked
+	for port := 1; port <= 10; port++ {
+		p.intercept(NewMessage("/fader"), &net.UDPAddr{IP: net.IPv4(127, 0, 0, 1), Port: port})
+	}
+	if n := len(p.handshake.seen); n != 0 {
+		t.Errorf("%d sources were tracked, want = 0", n)
+	}
+
+	p.EnableHandshake(DefaultCapabilities(), true)
+	reply := DefaultCapabilities().message(HandshakeReplyAddress)
+	for port := 1; port <= maxHandshakeSources+10; port++ {
+		p.intercept(reply, &net.UDPAddr{IP: net.IPv4(127, 0, 0, 1), Port: port})
+	}
+	if n := len(p.handshake.seen); n != maxHandshakeSources {
+		t.Errorf("%d sources were tracked, want = %d", n, maxHandshakeSources)
+	}
+	if n := len(p.handshake.remote); n != maxHandshakeSources {
+		t.Errorf("capabilities of %d sources were stored, want = %d", n, maxHandshakeSources)
+	}
+}
@@ -0,0 +1,127 @@
+package osc
+
+import (
+	"sync"
+	"time"
+)
+
+// Heartbeat sends a message periodically, e.g. "/xremote" every 9 seconds to
+// keep a Behringer X32 console sending updates. It can also watch the
+// heartbeats of the remote side: every received heartbeat must be reported
+// with Alive, and OnTimeout is called if none was received for Timeout.
+//
+// Heartbeat implements the Handler interface, so it can be registered as the
+// handler for the address of the remote heartbeats.
+type Heartbeat struct {
+	sender   Sender
+	msg      *Message
+	interval time.Duration
+
+	// Timeout is the time after which the remote side is considered gone if
+	// no heartbeat was received. A zero value disables the watchdog.
+	Timeout time.Duration
+	// OnTimeout is called if no heartbeat was received for Timeout. It is
+	// called again only after heartbeats were received again and stopped.
+	OnTimeout func()
+	// OnError is called if the heartbeat message couldn't be sent. Errors are
+	// ignored if OnError is nil.
+	OnError func(err error)
+
+	mu    sync.Mutex
+	timer *time.Timer // Fires after Timeout without heartbeat
+	stop  chan struct{}
+	done  chan struct{}
+}
+
+// Verify that Heartbeat implements the Handler interface.
+var _ Handler = (*Heartbeat)(nil)
+
+// NewHeartbeat returns a new Heartbeat that sends msg with sender every
+// interval, which must be positive. A nil msg only watches the remote
+// heartbeats. The fields must be set before Start is called.
+func NewHeartbeat(sender Sender, msg *Message, interval time.Duration) *Heartbeat {
+	return &Heartbeat{sender: sender, msg: msg, interval: interval}
+}
+
+// Start sends the first heartbeat and starts sending and watching the
+// heartbeats in the background. The watchdog starts with the first call of
+// Start, i.e. OnTimeout is called if no heartbeat arrives within Timeout.
+func (h *Heartbeat) Start() {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	if h.stop != nil {
+		return
+	}
+
+	if h.Timeout > 0 {
+		h.timer = time.AfterFunc(h.Timeout, h.timeout)
+	}
+	h.stop = make(chan struct{})
+	h.done = make(chan struct{})
+	go h.run(h.stop, h.done)
+}
+
+// Stop stops sending and watching the heartbeats. The heartbeat can be
+// started again.
+func (h *Heartbeat) Stop() {
+	h.mu.Lock()
+	stop, done := h.stop, h.done
+	h.stop, h.done = nil, nil
+	if h.timer != nil {
+		h.timer.Stop()
+		h.timer = nil
+	}
+	h.mu.Unlock()
+
+	if stop != nil {
+		close(stop)
+		<-done
+	}
+}
+
+// Alive reports that a heartbeat of the remote side was received, which
+// restarts the watchdog.
+func (h *Heartbeat) Alive() {
+	h.mu.Lock()
+	if h.timer != nil {
+		h.timer.Reset(h.Timeout)
+	}
+	h.mu.Unlock()
+}
+
+// HandleMessage calls Alive. Implements the Handler interface.
+func (h *Heartbeat) HandleMessage(msg *Message) {
+	h.Alive()
+}
+
+// timeout calls OnTimeout, unless the heartbeat was stopped.
+func (h *Heartbeat) timeout() {
+	h.mu.Lock()
+	stopped := h.stop == nil
+	h.mu.Unlock()
+	if !stopped && h.OnTimeout != nil {
+		h.OnTimeout()
+	}
+}
+
+// run sends the heartbeat message every interval until stop is closed.
+func (h *Heartbeat) run(stop <-chan struct{}, done chan<- struct{}) {
+	defer close(done)
+	if h.msg == nil {
+		<-stop
+		return
+	}
+
+	ticker := time.NewTicker(h.interval)
+	defer ticker.Stop()
+	for {
+		if _, err := h.sender.Send(h.msg); err != nil && h.OnError != nil {
+			h.OnError(err)
+		}
+		select {
+		case <-ticker.C:
+		case <-stop:
+			return
+		}
+	}
+}
@@ -0,0 +1,103 @@
+package osc
+
+import (
+	"errors"
+	"testing"
+	"time"
+)
+
+func TestHeartbeat_Send(t *testing.T) {
+	sender := &recordingSender{}
+	h := NewHeartbeat(sender, NewMessage("/xremote"), 10*time.Millisecond)
+	h.Start()
+	time.Sleep(55 * time.Millisecond)
+	h.Stop()
+
+	n := len(sender.messages())
+	if n < 3 {
+		t.Errorf("sent %d heartbeats, want at least 3", n)
+	}
+	for _, msg := range sender.messages() {
+		if msg.Address != "/xremote" {
+			t.Errorf("sent %v, want = /xremote", msg)
+		}
+	}
+
+	time.Sleep(30 * time.Millisecond)
+	if got := len(sender.messages()); got != n {
+		t.Errorf("sent %d heartbeats after Stop", got-n)
+	}
+}
+
+func TestHeartbeat_Timeout(t *testing.T) {
+	timeouts := make(chan struct{}, 10)
+	h := NewHeartbeat(nil, nil, 0)
+	h.Timeout = 50 * time.Millisecond
+	h.OnTimeout = func() { timeouts <- struct{}{} }
+
+	d := NewStandardDispatcher()
+	if err := d.AddMsgHandler("/heartbeat", h.HandleMessage); err != nil {
+		t.Fatal(err)
+	}
+
+	h.Start()
+	defer h.Stop()
+
+	// Heartbeats keep the watchdog from firing
+	for i := 0; i < 5; i++ {
+		time.Sleep(20 * time.Millisecond)
+		d.Dispatch(NewMessage("/heartbeat"))
+	}
+	select {
+	case <-timeouts:
+		t.Fatal("OnTimeout called while heartbeats were received")
+	default:
+	}
+
+	// Missing heartbeats trigger exactly one timeout
+	select {
+	case <-timeouts:
+	case <-time.After(5 * time.Second):
+		t.Fatal("OnTimeout wasn't called")
+	}
+	time.Sleep(100 * time.Millisecond)
+	if len(timeouts) != 0 {
+		t.Errorf("OnTimeout called %d more times without heartbeats", len(timeouts))
+	}
+
+	// A new heartbeat restarts the watchdog
+	h.Alive()
+	select {
+	case <-timeouts:
+	case <-time.After(5 * time.Second):
+		t.Fatal("OnTimeout wasn't called after heartbeats stopped again")
+	}
+}
+
+type failingSender struct{}
+
+func (failingSender) Send(packet Packet) (int, error) {
+	return 0, errors.New("network unreachable")
+}
+
+func TestHeartbeat_OnError(t *testing.T) {
+	errs := make(chan error, 1)
+	h := NewHeartbeat(failingSender{}, NewMessage("/ping"), time.Hour)
+	h.OnError = func(err error) {
+		select {
+		case errs <- err:
+		default:
+		}
+	}
+	h.Start()
+	defer h.Stop()
+
+	select {
+	case err := <-errs:
+		if err == nil {
+			t.Error("OnError called with nil error")
+		}
+	case <-time.After(5 * time.Second):
+		t.Fatal("OnError wasn't called")
+	}
+}
@@ -0,0 +1,77 @@
+package osc
+
+import (
+	"bytes"
+	"net"
+	"testing"
+	"time"
+)
+
+func TestMessage_Info(t *testing.T) {
+	received := make(chan *Message, 3)
+	d := NewStandardDispatcher()
+	d.AddMsgHandler("*", func(msg *Message) {
+		received <- msg
+	})
+	conn, addr, err := Listen("127.0.0.1:0")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer conn.Close()
+	go (&Server{Dispatcher: d}).Serve(conn)
+
+	sender, err := net.ListenPacket("udp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer sender.Close()
+	at := time.Now().Add(-time.Second)
+	bundle := NewBundle(at)
+	bundle.Append(NewMessage("/b"))
+	bundle.AppendAt(NewMessage("/c"), at.Add(time.Millisecond))
+	start := time.Now()
+	for _, p := range []Packet{NewMessage("/a"), bundle} {
+		data, _ := p.MarshalBinary()
+		if _, err := sender.WriteTo(data, addr); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	timetags := map[string]uint64{"/a": 0, "/b": NewTimetag(at).TimeTag(), "/c": NewTimetag(at.Add(time.Millisecond)).TimeTag()}
+	for range timetags {
+		var msg *Message
+		select {
+		case msg = <-received:
+		case <-time.After(time.Second):
+			t.Fatal("message not received")
+		}
+		info := msg.Info()
+		if info.Source.String() != sender.LocalAddr().String() || info.Transport != "udp" {
+			t.Errorf("%s: Info() = %v via %s, want = %v via udp", msg.Address, info.Source, info.Transport, sender.LocalAddr())
+		}
+		if info.Received.Before(start) || info.Received.After(time.Now()) {
+			t.Errorf("%s: Info().Received = %v, want time after %v", msg.Address, info.Received, start)
+		}
+		if got, want := info.Timetag.TimeTag(), timetags[msg.Address]; got != want || info.InBundle() != (want != 0) {
+			t.Errorf("%s: Info().Timetag = %d, want = %d", msg.Address, got, want)
+		}
+	}
+}
+
+func TestMessage_Info_reader(t *testing.T) {
+	if info := NewMessage("/a").Info(); info != (MessageInfo{}) {
+		t.Errorf("Info() = %v, want zero value", info)
+	}
+
+	var stream bytes.Buffer
+	NewEncoder(&stream).Encode(NewMessage("/a"))
+	var info MessageInfo
+	d := NewStandardDispatcher()
+	d.AddMsgHandler("/a", func(msg *Message) { info = msg.Clone().Info() })
+	if err := (&Server{Dispatcher: d}).ServeReader(&stream, SizePrefixFraming); err != nil {
+		t.Fatal(err)
+	}
+	if info.Transport != "reader" || info.Source.String() != "reader" {
+		t.Errorf("Info() = %v via %s, want = reader via reader", info.Source, info.Transport)
+	}
+}
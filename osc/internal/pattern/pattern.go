@@ -0,0 +1,464 @@
+// Package pattern compiles and matches OSC address patterns. It is shared by
+// the packages osc and osc/codec, which document the pattern syntax.
+package pattern
+
+import (
+	"errors"
+	"fmt"
+	"strings"
+)
+
+// Pattern is a compiled OSC address pattern. A Pattern is safe for
+// concurrent use.
+type Pattern struct {
+	pattern  string
+	parts    []Part
+	anyDepth bool // The pattern contains "//"
+}
+
+// MaxLength is the maximum length of an address pattern in bytes. Compile
+// rejects longer patterns, which also limits the time it takes to match the
+// address patterns of received messages.
+const MaxLength = 1024
+
+// MaxAnyDepthOperators is the maximum number of "//" operators in an address
+// pattern. Compile rejects patterns with more operators. Consecutive
+// operators, e.g. "///", count as one.
+const MaxAnyDepthOperators = 8
+
+// SyntaxError describes a syntax error in an OSC address pattern.
+type SyntaxError struct {
+	Pattern string // The invalid pattern
+	Offset  int    // Position of the error in the pattern
+	Reason  string // Description of the error
+}
+
+func (e *SyntaxError) Error() string {
+	return fmt.Sprintf("osc: invalid address pattern %q at offset %d: %s", e.Pattern, e.Offset, e.Reason)
+}
+
+// Part is a compiled part of an address pattern, i.e. the text between two
+// '/'. Parts without wildcards are matched by string comparison.
+type Part struct {
+	Literal  string         // Text of the part if it has no wildcards
+	AnyDepth bool           // Matches any number of parts, from "//"
+	tokens   []patternToken // nil if the part is a literal
+}
+
+// IsLiteral returns true if the part has no wildcards, i.e. it only matches
+// Literal. It is true for parts with AnyDepth set as well.
+func (p *Part) IsLiteral() bool {
+	return p.tokens == nil
+}
+
+// Parts returns the compiled parts of p, which must not be modified.
+func Parts(p *Pattern) []Part {
+	return p.parts
+}
+
+// AnyDepth returns true if p contains the operator "//".
+func AnyDepth(p *Pattern) bool {
+	return p.anyDepth
+}
+
+type tokenKind int
+
+const (
+	tokenLiteral tokenKind = iota
+	tokenAnyChar
+	tokenAnyString
+	tokenClass
+	tokenAlternatives
+)
+
+type patternToken struct {
+	kind   tokenKind
+	text   string   // tokenLiteral
+	class  []byte   // tokenClass, pairs of inclusive range bounds
+	negate bool     // tokenClass, matches characters that aren't in class
+	alts   []string // tokenAlternatives
+}
+
+// Compile compiles an OSC address pattern, anyDepth enables the OSC 1.1
+// operator "//". Syntax errors are reported as *SyntaxError.
+func Compile(pattern string, anyDepth bool) (*Pattern, error) {
+	if !strings.HasPrefix(pattern, "/") {
+		return nil, &SyntaxError{pattern, 0, "pattern must start with '/'"}
+	}
+	if len(pattern) > MaxLength {
+		return nil, &SyntaxError{pattern, MaxLength, "pattern is too long"}
+	}
+
+	p := &Pattern{
+		pattern: pattern,
+		parts:   make([]Part, 0, strings.Count(pattern, "/")+1),
+	}
+	operators := 0 // Number of "//" operators
+	for offset := 0; offset <= len(pattern); {
+		end := strings.IndexByte(pattern[offset:], '/')
+		if end < 0 {
+			end = len(pattern) - offset
+		}
+		if anyDepth && end == 0 && 0 < offset && offset < len(pattern) {
+			// Consecutive operators are equivalent to a single one
+			if n := len(p.parts); !p.parts[n-1].AnyDepth {
+				if operators++; operators > MaxAnyDepthOperators {
+					return nil, &SyntaxError{pattern, offset - 1, "too many '//' operators"}
+				}
+				p.parts = append(p.parts, Part{AnyDepth: true})
+			}
+			p.anyDepth = true
+			offset++
+			continue
+		}
+		compiled, err := compilePart(pattern[offset : offset+end])
+		if err != nil {
+			err.Pattern = pattern
+			err.Offset += offset
+			return nil, err
+		}
+		p.parts = append(p.parts, compiled)
+		offset += end + 1
+	}
+	return p, nil
+}
+
+// Literal returns a Pattern that matches only the given address, i.e.
+// pattern characters in addr have no special meaning.
+func Literal(addr string) *Pattern {
+	p := &Pattern{pattern: addr}
+	for _, part := range strings.Split(addr, "/") {
+		p.parts = append(p.parts, Part{Literal: part})
+	}
+	return p
+}
+
+// String returns the source of the pattern.
+func (p *Pattern) String() string {
+	return p.pattern
+}
+
+// Match returns true if the pattern matches the given OSC address.
+func (p *Pattern) Match(addr string) bool {
+	parts := strings.Split(addr, "/")
+	if p.anyDepth {
+		return matchParts(p.parts, parts)
+	}
+	if len(parts) != len(p.parts) {
+		return false
+	}
+	for i, part := range parts {
+		if !p.parts[i].Match(part) {
+			return false
+		}
+	}
+	return true
+}
+
+// matchParts returns true if the pattern parts match the address parts. It
+// supports parts that match any number of address parts. Like matchTokens it
+// tracks the set of reachable address parts, i.e. it doesn't backtrack.
+func matchParts(pattern []Part, parts []string) bool {
+	n := len(parts) + 1
+	buf := make([]bool, 2*n)
+	cur, next := buf[:n], buf[n:]
+	cur[0] = true
+	for i := range pattern {
+		part := &pattern[i]
+		for j := range next {
+			next[j] = false
+		}
+		reachable := false
+		for j := 0; j < n; j++ {
+			if !cur[j] {
+				continue
+			}
+			if part.AnyDepth {
+				// Every address part from the first reachable one on
+				for k := j; k < n; k++ {
+					next[k] = true
+				}
+				reachable = true
+				break
+			}
+			if j < len(parts) && part.Match(parts[j]) {
+				next[j+1] = true
+				reachable = true
+			}
+		}
+		if !reachable {
+			return false
+		}
+		cur, next = next, cur
+	}
+	return cur[len(parts)]
+}
+
+// compilePart compiles a single address part. The returned error has an
+// offset relative to the part.
+func compilePart(part string) (Part, *SyntaxError) {
+	if !HasWildcard(part) {
+		return Part{Literal: part}, nil
+	}
+
+	var tokens []patternToken
+	for i := 0; i < len(part); {
+		switch c := part[i]; c {
+		case '?':
+			tokens = append(tokens, patternToken{kind: tokenAnyChar})
+			i++
+
+		case '*':
+			// Consecutive stars are equivalent to a single one
+			if len(tokens) == 0 || tokens[len(tokens)-1].kind != tokenAnyString {
+				tokens = append(tokens, patternToken{kind: tokenAnyString})
+			}
+			i++
+
+		case '[':
+			end := classEnd(part[i:])
+			if end < 0 {
+				return Part{}, &SyntaxError{Offset: i, Reason: "missing closing ']'"}
+			}
+			class, negate := part[i+1:i+end], false
+			offset := i + 1
+			if strings.HasPrefix(class, "!") {
+				class, negate = class[1:], true
+				offset++
+			}
+			ranges, err := compileClass(class)
+			if err != nil {
+				err.Offset += offset
+				return Part{}, err
+			}
+			tokens = append(tokens, patternToken{kind: tokenClass, class: ranges, negate: negate})
+			i += end + 1
+
+		case '{':
+			end := strings.IndexByte(part[i:], '}')
+			if end < 0 {
+				return Part{}, &SyntaxError{Offset: i, Reason: "missing closing '}'"}
+			}
+			alts := part[i+1 : i+end]
+			if j := strings.IndexAny(alts, "*?[]{"); j >= 0 {
+				return Part{}, &SyntaxError{Offset: i + 1 + j, Reason: "wildcards are not allowed in alternatives"}
+			}
+			tokens = append(tokens, patternToken{kind: tokenAlternatives, alts: strings.Split(alts, ",")})
+			i += end + 1
+
+		case ']', '}':
+			return Part{}, &SyntaxError{Offset: i, Reason: fmt.Sprintf("unexpected '%c'", c)}
+
+		case '\\':
+			if i+1 == len(part) {
+				return Part{}, &SyntaxError{Offset: i, Reason: "trailing '\\'"}
+			}
+			tokens = appendLiteral(tokens, part[i+1:i+2])
+			i += 2
+
+		default:
+			end := strings.IndexAny(part[i:], patternChars)
+			if end < 0 {
+				end = len(part) - i
+			}
+			tokens = appendLiteral(tokens, part[i:i+end])
+			i += end
+		}
+	}
+
+	// Parts that only contain escaped characters are literals
+	if len(tokens) == 1 && tokens[0].kind == tokenLiteral {
+		return Part{Literal: tokens[0].text}, nil
+	}
+	return Part{tokens: tokens}, nil
+}
+
+// appendLiteral appends the literal text to tokens. It is merged into the last
+// token if that is a literal as well.
+func appendLiteral(tokens []patternToken, text string) []patternToken {
+	if n := len(tokens); n > 0 && tokens[n-1].kind == tokenLiteral {
+		tokens[n-1].text += text
+		return tokens
+	}
+	return append(tokens, patternToken{kind: tokenLiteral, text: text})
+}
+
+// classEnd returns the index of the ']' that closes the character class at
+// the start of s, or -1 if it isn't closed. Escaped characters are skipped.
+func classEnd(s string) int {
+	for i := 1; i < len(s); i++ {
+		switch s[i] {
+		case '\\':
+			i++
+		case ']':
+			return i
+		}
+	}
+	return -1
+}
+
+// compileClass compiles the content of a character class, i.e. the characters
+// between '[' and ']' without a leading '!'. A '-' between two characters
+// denotes a range, a backslash escapes the following character.
+func compileClass(class string) ([]byte, *SyntaxError) {
+	if class == "" {
+		return nil, &SyntaxError{Reason: "empty character class"}
+	}
+
+	// Resolve the escapes first, escaped '-' are remembered as literals
+	var chars []byte
+	var offsets []int // Position of every character in class
+	var literal []bool
+	for i := 0; i < len(class); i++ {
+		offsets = append(offsets, i)
+		if class[i] == '\\' {
+			if i+1 == len(class) {
+				return nil, &SyntaxError{Offset: i, Reason: "trailing '\\'"}
+			}
+			i++
+			chars, literal = append(chars, class[i]), append(literal, true)
+			continue
+		}
+		chars, literal = append(chars, class[i]), append(literal, false)
+	}
+
+	var ranges []byte
+	for i := 0; i < len(chars); i++ {
+		if i+2 < len(chars) && chars[i+1] == '-' && !literal[i+1] {
+			if chars[i] > chars[i+2] {
+				return nil, &SyntaxError{Offset: offsets[i], Reason: "invalid character range"}
+			}
+			ranges = append(ranges, chars[i], chars[i+2])
+			i += 2
+			continue
+		}
+		ranges = append(ranges, chars[i], chars[i])
+	}
+	return ranges, nil
+}
+
+// Match returns true if the part matches the address part `name`.
+func (p *Part) Match(name string) bool {
+	if p.tokens == nil {
+		return p.Literal == name
+	}
+	return matchTokens(p.tokens, name)
+}
+
+// matchTokens returns true if the tokens match name. It tracks the set of
+// offsets in name that the tokens seen so far can reach instead of
+// backtracking, i.e. it runs in O(len(tokens)·len(name)) time.
+func matchTokens(tokens []patternToken, name string) bool {
+	n := len(name) + 1
+	buf := make([]bool, 2*n)
+	cur, next := buf[:n], buf[n:]
+	cur[0] = true
+	for i := range tokens {
+		t := &tokens[i]
+		for j := range next {
+			next[j] = false
+		}
+		reachable := false
+		for j := 0; j < n; j++ {
+			if !cur[j] {
+				continue
+			}
+			rest := name[j:]
+			switch t.kind {
+			case tokenLiteral:
+				if strings.HasPrefix(rest, t.text) {
+					next[j+len(t.text)] = true
+					reachable = true
+				}
+
+			case tokenAnyChar:
+				if len(rest) > 0 {
+					next[j+1] = true
+					reachable = true
+				}
+
+			case tokenAnyString:
+				// Every offset from the first reachable one on is reachable
+				for k := j; k < n; k++ {
+					next[k] = true
+				}
+				reachable = true
+				j = n
+
+			case tokenClass:
+				if len(rest) > 0 && matchClass(t.class, rest[0]) != t.negate {
+					next[j+1] = true
+					reachable = true
+				}
+
+			case tokenAlternatives:
+				for _, alt := range t.alts {
+					if strings.HasPrefix(rest, alt) {
+						next[j+len(alt)] = true
+						reachable = true
+					}
+				}
+			}
+		}
+		if !reachable {
+			return false
+		}
+		cur, next = next, cur
+	}
+	return cur[len(name)]
+}
+
+// matchClass returns true if c is within any of the given ranges.
+func matchClass(ranges []byte, c byte) bool {
+	for i := 0; i+1 < len(ranges); i += 2 {
+		if ranges[i] <= c && c <= ranges[i+1] {
+			return true
+		}
+	}
+	return false
+}
+
+// patternChars are the characters that have a special meaning in OSC address
+// patterns.
+const patternChars = "*?[]{}\\"
+
+// HasWildcard returns true if the given address part contains any of the OSC
+// address pattern characters.
+func HasWildcard(part string) bool {
+	return strings.ContainsAny(part, patternChars)
+}
+
+// IsPattern returns true if addr contains pattern characters or the "//"
+// operator, i.e. it must be compiled to be matched.
+func IsPattern(addr string) bool {
+	return HasWildcard(addr) || strings.Contains(addr, "//")
+}
+
+// Quote returns a pattern that matches exactly the given address, i.e. all
+// pattern characters in addr are escaped with a backslash. It allows to
+// match addresses that contain pattern characters, which aren't valid OSC
+// addresses but are sent by some devices.
+func Quote(addr string) string {
+	if !HasWildcard(addr) {
+		return addr
+	}
+	quoted := make([]byte, 0, len(addr)+4)
+	for i := 0; i < len(addr); i++ {
+		if strings.IndexByte(patternChars, addr[i]) >= 0 {
+			quoted = append(quoted, '\\')
+		}
+		quoted = append(quoted, addr[i])
+	}
+	return string(quoted)
+}
+
+// ValidateAddress returns an error if the given OSC address contains any
+// characters that are reserved for address patterns.
+func ValidateAddress(addr string) error {
+	for _, chr := range "*?,[]{}# " {
+		if strings.Contains(addr, fmt.Sprintf("%c", chr)) {
+			return errors.New("OSC Address string may not contain any characters in \"*?,[]{}#")
+		}
+	}
+	return nil
+}
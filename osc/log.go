@@ -0,0 +1,137 @@
+package osc
+
+import (
+	"bytes"
+	"fmt"
+	"log"
+	"net"
+	"time"
+)
+
+// LogLevel is the severity of a log record. The levels have the same values
+// as the levels of log/slog.
+type LogLevel int
+
+// Log levels of the records that are logged by a Server.
+const (
+	LogDebug LogLevel = -4 // Received packets and dispatch decisions
+	LogInfo  LogLevel = 0  // Start and stop of the server
+	LogWarn  LogLevel = 4  // Invalid packets and temporary errors
+	LogError LogLevel = 8  // Errors that stop the server
+)
+
+// String returns the name of the level, e.g. "DEBUG".
+func (l LogLevel) String() string {
+	switch l {
+	case LogDebug:
+		return "DEBUG"
+	case LogInfo:
+		return "INFO"
+	case LogWarn:
+		return "WARN"
+	case LogError:
+		return "ERROR"
+	}
+	return fmt.Sprintf("LEVEL(%d)", int(l))
+}
+
+// Logger receives the structured log records of a Server. keyvals are
+// alternating keys and values, e.g. "addr", addr, "size", 16. Loggers must
+// be safe for concurrent use. See Server.SetLogger to use a log/slog logger.
+type Logger interface {
+	// Enabled returns true if records with the given level are logged. It
+	// allows to skip building records that would be discarded.
+	Enabled(level LogLevel) bool
+
+	// Log logs a record.
+	Log(level LogLevel, msg string, keyvals ...interface{})
+}
+
+// NewStdLogger returns a Logger that writes the records with at least the
+// given level to l as lines of the form "DEBUG msg key=value ...". If l is
+// nil, the standard logger is used.
+func NewStdLogger(l *log.Logger, level LogLevel) Logger {
+	return &stdLogger{l, level}
+}
+
+type stdLogger struct {
+	l     *log.Logger // nil for the standard logger
+	level LogLevel
+}
+
+func (s *stdLogger) Enabled(level LogLevel) bool {
+	return level >= s.level
+}
+
+func (s *stdLogger) Log(level LogLevel, msg string, keyvals ...interface{}) {
+	if !s.Enabled(level) {
+		return
+	}
+	var b bytes.Buffer
+	b.WriteString(level.String())
+	b.WriteByte(' ')
+	b.WriteString(msg)
+	for i := 0; i < len(keyvals); i += 2 {
+		fmt.Fprintf(&b, " %v=", keyvals[i])
+		if i+1 < len(keyvals) {
+			fmt.Fprintf(&b, "%v", keyvals[i+1])
+		}
+	}
+	if s.l == nil {
+		log.Output(2, b.String())
+		return
+	}
+	s.l.Output(2, b.String())
+}
+
+// logEnabled returns true if the logger of the server logs records with the
+// given level.
+func (s *Server) logEnabled(level LogLevel) bool {
+	return s.Logger != nil && s.Logger.Enabled(level)
+}
+
+// log logs a record with the logger of the server, if it has one.
+func (s *Server) log(level LogLevel, msg string, keyvals ...interface{}) {
+	if s.logEnabled(level) {
+		s.Logger.Log(level, msg, keyvals...)
+	}
+}
+
+// logPacket logs a received packet at the debug level.
+func (s *Server) logPacket(p Packet, addr net.Addr, size int) {
+	if !s.logEnabled(LogDebug) {
+		return
+	}
+	switch p := p.(type) {
+	case *Message:
+		tags, _ := p.TypeTags()
+		s.Logger.Log(LogDebug, "osc: message received", "from", addr, "size", size, "address", p.Address, "types", tags)
+	case *Bundle:
+		s.Logger.Log(LogDebug, "osc: bundle received", "from", addr, "size", size, "messages", len(p.Messages), "bundles", len(p.Bundles))
+	}
+}
+
+// dispatchTrace returns the trace that is passed to a StandardDispatcher. It
+// logs the dispatch decisions in addition to the Trace of the server, if
+// debug logging is enabled.
+func (s *Server) dispatchTrace() *ServerTrace {
+	if !s.logEnabled(LogDebug) {
+		return s.Trace
+	}
+	trace := &ServerTrace{}
+	if s.Trace != nil {
+		*trace = *s.Trace
+	}
+	next := trace.OnMessageDispatched
+	trace.OnMessageDispatched = func(address string, handlerCount int, duration time.Duration) {
+		if handlerCount == 0 {
+			s.Logger.Log(LogDebug, "osc: message matched no handler", "address", address)
+		} else {
+			s.Logger.Log(LogDebug, "osc: message dispatched", "address", address, "handlers", handlerCount, "duration", duration)
+		}
+		if next != nil {
+			next(address, handlerCount, duration)
+		}
+	}
+	return trace
+}
@@ -0,0 +1,33 @@
+//go:build go1.21
+// +build go1.21
+
+package osc
+
+import (
+	"context"
+	"log/slog"
+)
+
+// SetLogger makes the server log received packets, dispatch decisions and
+// errors to l, see Logger. The levels that are logged are controlled by the
+// handler of l. Passing nil disables logging.
+func (s *Server) SetLogger(l *slog.Logger) {
+	if l == nil {
+		s.Logger = nil
+		return
+	}
+	s.Logger = slogLogger{l}
+}
+
+// slogLogger adapts a slog.Logger to the Logger interface.
+type slogLogger struct {
+	l *slog.Logger
+}
+
+func (s slogLogger) Enabled(level LogLevel) bool {
+	return s.l.Enabled(context.Background(), slog.Level(level))
+}
+
+func (s slogLogger) Log(level LogLevel, msg string, keyvals ...interface{}) {
+	s.l.Log(context.Background(), slog.Level(level), msg, keyvals...)
+}
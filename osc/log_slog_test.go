@@ -0,0 +1,29 @@
+//go:build go1.21
+// +build go1.21
+
+package osc
+
+import (
+	"bytes"
+	"log/slog"
+	"strings"
+	"testing"
+)
+
+func TestServer_SetLogger(t *testing.T) {
+	var buf bytes.Buffer
+	server := &Server{}
+	server.SetLogger(slog.New(slog.NewTextHandler(&buf, &slog.HandlerOptions{Level: slog.LevelInfo})))
+	if server.logEnabled(LogDebug) || !server.logEnabled(LogInfo) {
+		t.Error("the level of the slog handler isn't respected")
+	}
+	server.log(LogWarn, "osc: invalid packet", "size", 3)
+	if got := buf.String(); !strings.Contains(got, `level=WARN msg="osc: invalid packet" size=3`) {
+		t.Errorf("unexpected record %q", got)
+	}
+
+	server.SetLogger(nil)
+	if server.Logger != nil {
+		t.Error("SetLogger(nil) didn't disable logging")
+	}
+}
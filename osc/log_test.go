@@ -0,0 +1,75 @@
+package osc
+
+import (
+	"bytes"
+	"context"
+	"log"
+	"net"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestServer_Logger(t *testing.T) {
+	var buf bytes.Buffer
+	received := make(chan struct{})
+	d := NewStandardDispatcher()
+	if err := d.AddMsgHandler("/fader", func(msg *Message) { close(received) }); err != nil {
+		t.Fatal(err)
+	}
+	server := &Server{Addr: "127.0.0.1:0", Dispatcher: d, Logger: NewStdLogger(log.New(&buf, "", 0), LogDebug)}
+	if err := server.Start(); err != nil {
+		t.Fatal(err)
+	}
+
+	conn, err := net.Dial("udp", server.LocalAddr().String())
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer conn.Close()
+	if _, err := conn.Write([]byte("/garbage")); err != nil {
+		t.Fatal(err)
+	}
+	data, err := NewMessage("/fader", float32(0.5)).MarshalBinary()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, err := conn.Write(data); err != nil {
+		t.Fatal(err)
+	}
+	select {
+	case <-received:
+	case <-time.After(5 * time.Second):
+		t.Fatal("message wasn't dispatched")
+	}
+	if err := server.Stop(context.Background()); err != nil {
+		t.Fatal(err)
+	}
+
+	logs := buf.String()
+	for _, want := range []string{
+		"INFO osc: server started addr=127.0.0.1:",
+		"WARN osc: invalid packet from=127.0.0.1:",
+		"DEBUG osc: message received from=127.0.0.1:",
+		"size=16 address=/fader types=,f",
+		"DEBUG osc: message dispatched address=/fader handlers=1",
+		"INFO osc: server stopped",
+	} {
+		if !strings.Contains(logs, want) {
+			t.Errorf("log doesn't contain %q:\n%s", want, logs)
+		}
+	}
+}
+
+func TestNewStdLogger(t *testing.T) {
+	var buf bytes.Buffer
+	l := NewStdLogger(log.New(&buf, "", 0), LogWarn)
+	if l.Enabled(LogInfo) || !l.Enabled(LogError) {
+		t.Error("Enabled() doesn't respect the level")
+	}
+	l.Log(LogDebug, "hidden")
+	l.Log(LogError, "failed", "error", "broken", "odd")
+	if got, want := buf.String(), "ERROR failed error=broken odd=\n"; got != want {
+		t.Errorf("Log() wrote %q, want = %q", got, want)
+	}
+}
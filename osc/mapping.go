@@ -0,0 +1,179 @@
+package osc
+
+import (
+	"encoding/json"
+	"fmt"
+	"io/ioutil"
+	"log"
+	"math"
+	"sync/atomic"
+)
+
+// Actions of a Mapping.
+const (
+	MappingForward   = "forward"   // Send the message unchanged to Target
+	MappingLog       = "log"       // Log the message
+	MappingTransform = "transform" // Rewrite and scale the message and send it to Target
+)
+
+// Mapping describes the action that is applied to messages with a given
+// address. Mappings are read from a JSON file by a Mapper, e.g.
+//
+//	{
+//	  "mappings": [
+//	    {"address": "/fader/*", "action": "forward", "target": "192.168.1.20:8000"},
+//	    {"address": "/knob/1", "action": "transform", "target": "192.168.1.21:9000",
+//	     "rewrite": "/filter/cutoff", "scale": 127},
+//	    {"address": "/debug", "action": "log"}
+//	  ]
+//	}
+type Mapping struct {
+	// Address of the handler that applies the action.
+	Address string `json:"address"`
+	// Action is one of MappingForward, MappingLog and MappingTransform.
+	Action string `json:"action"`
+	// Target is the "host:port" address that messages are sent to.
+	Target string `json:"target,omitempty"`
+
+	// Rewrite replaces the address of transformed messages, if it isn't
+	// empty.
+	Rewrite string `json:"rewrite,omitempty"`
+	// Scale and Offset transform every numeric argument x of transformed
+	// messages to x*Scale+Offset. The argument type is kept, integers are
+	// rounded. A zero Scale is treated as 1.
+	Scale  float64 `json:"scale,omitempty"`
+	Offset float64 `json:"offset,omitempty"`
+}
+
+// MappingConfig is the content of a mapping file.
+type MappingConfig struct {
+	Mappings []Mapping `json:"mappings"`
+}
+
+// Mapper dispatches messages according to the mappings in a JSON file, see
+// Mapping. The file can be reloaded while the Mapper is in use, which allows
+// to remap addresses without restarting the server.
+//
+// Mapper implements the Dispatcher interface, so it can be used as the
+// dispatcher of a Server.
+type Mapper struct {
+	path    string
+	clients *ClientPool
+	table   atomic.Value // *StandardDispatcher
+
+	// Logger is used by the log action. The standard logger is used if
+	// Logger is nil.
+	Logger *log.Logger
+
+	// OnError is called if a message can't be sent to a target. Errors are
+	// ignored if OnError is nil.
+	OnError func(m Mapping, err error)
+}
+
+// Verify that Mapper implements the Dispatcher interface.
+var _ Dispatcher = (*Mapper)(nil)
+
+// NewMapper returns a new Mapper that loads its mappings from the JSON file
+// with the given name.
+func NewMapper(name string) (*Mapper, error) {
+	m := &Mapper{path: name, clients: NewClientPool(0)}
+	if err := m.Reload(); err != nil {
+		m.clients.Close()
+		return nil, err
+	}
+	return m, nil
+}
+
+// Reload reads the mapping file again and atomically replaces the mappings.
+// The previous mappings stay active if the file is invalid.
+func (m *Mapper) Reload() error {
+	data, err := ioutil.ReadFile(m.path)
+	if err != nil {
+		return err
+	}
+	var config MappingConfig
+	if err = json.Unmarshal(data, &config); err != nil {
+		return fmt.Errorf("osc: invalid mapping file %s: %s", m.path, err)
+	}
+
+	table := NewStandardDispatcher()
+	table.SetMatchMode(MatchBoth)
+	for _, mapping := range config.Mappings {
+		handler, err := m.handler(mapping)
+		if err != nil {
+			return err
+		}
+		if err = table.AddMsgHandler(mapping.Address, handler); err != nil {
+			return fmt.Errorf("osc: invalid mapping for %s: %s", mapping.Address, err)
+		}
+	}
+	m.table.Store(table)
+	return nil
+}
+
+// Dispatch applies the mappings to the packet. Implements the Dispatcher
+// interface.
+func (m *Mapper) Dispatch(packet Packet) {
+	m.table.Load().(*StandardDispatcher).Dispatch(packet)
+}
+
+// Close closes the connections to all targets.
+func (m *Mapper) Close() error {
+	return m.clients.Close()
+}
+
+// handler returns the handler that applies the action of mapping.
+func (m *Mapper) handler(mapping Mapping) (HandlerFunc, error) {
+	switch mapping.Action {
+	case MappingLog:
+		return func(msg *Message) {
+			if m.Logger != nil {
+				m.Logger.Println(msg)
+			} else {
+				log.Println(msg)
+			}
+		}, nil
+
+	case MappingForward, MappingTransform:
+		if mapping.Target == "" {
+			return nil, fmt.Errorf("osc: mapping for %s has no target", mapping.Address)
+		}
+		transform := mapping.Action == MappingTransform
+		return func(msg *Message) {
+			if transform {
+				msg = mapping.transform(msg)
+			}
+			if _, err := m.clients.SendTo(mapping.Target, msg); err != nil && m.OnError != nil {
+				m.OnError(mapping, err)
+			}
+		}, nil
+	}
+	return nil, fmt.Errorf("osc: unknown action %q in mapping for %s", mapping.Action, mapping.Address)
+}
+
+// transform returns a copy of msg with the rewritten address and scaled
+// arguments.
+func (mapping Mapping) transform(msg *Message) *Message {
+	out := msg.Clone()
+	if mapping.Rewrite != "" {
+		out.Address = mapping.Rewrite
+	}
+
+	scale := mapping.Scale
+	if scale == 0 {
+		scale = 1
+	}
+	for i, arg := range out.Arguments {
+		switch x := arg.(type) {
+		case int32:
+			out.Arguments[i] = int32(math.Round(float64(x)*scale + mapping.Offset))
+		case int64:
+			out.Arguments[i] = int64(math.Round(float64(x)*scale + mapping.Offset))
+		case float32:
+			out.Arguments[i] = float32(float64(x)*scale + mapping.Offset)
+		case float64:
+			out.Arguments[i] = x*scale + mapping.Offset
+		}
+	}
+	return out
+}
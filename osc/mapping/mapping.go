@@ -0,0 +1,158 @@
+// Package mapping provides functions to convert numeric OSC arguments between
+// value ranges, e.g. from a 0–1 fader to decibels or from MIDI values to 0–1,
+// and handlers that apply them to the messages of an address.
+package mapping
+
+import (
+	"math"
+
+	"github.com/hypebeast/go-osc/osc"
+)
+
+// Func converts a single argument value.
+type Func func(x float64) float64
+
+// Range is a closed interval of values. Min may be greater than Max, which
+// inverts the direction of a Linear mapping.
+type Range struct {
+	Min, Max float64
+}
+
+// Common ranges of controller values.
+var (
+	Unit = Range{0, 1}   // Faders and knobs that send floats
+	MIDI = Range{0, 127} // 7 bit MIDI controller values
+)
+
+// Clamp returns x limited to the range.
+func (r Range) Clamp(x float64) float64 {
+	lo, hi := r.Min, r.Max
+	if lo > hi {
+		lo, hi = hi, lo
+	}
+	return math.Max(lo, math.Min(hi, x))
+}
+
+// Linear returns a Func that maps the range from linearly to the range to.
+// Values outside of from are extrapolated, use Clamp to limit them.
+func Linear(from, to Range) Func {
+	span := from.Max - from.Min
+	return func(x float64) float64 {
+		if span == 0 {
+			return to.Min
+		}
+		return to.Min + (x-from.Min)/span*(to.Max-to.Min)
+	}
+}
+
+// Clamp returns a Func that limits values to r.
+func Clamp(r Range) Func {
+	return r.Clamp
+}
+
+// Compose returns a Func that applies the given functions in order.
+func Compose(fs ...Func) Func {
+	return func(x float64) float64 {
+		for _, f := range fs {
+			x = f(x)
+		}
+		return x
+	}
+}
+
+// Round rounds values to the nearest integer, e.g. after converting to MIDI.
+func Round(x float64) float64 {
+	return math.Round(x)
+}
+
+// GainToDB converts a linear amplitude gain to decibels. A gain of zero or
+// less is -Inf dB.
+func GainToDB(gain float64) float64 {
+	if gain <= 0 {
+		return math.Inf(-1)
+	}
+	return 20 * math.Log10(gain)
+}
+
+// DBToGain converts decibels to a linear amplitude gain.
+func DBToGain(db float64) float64 {
+	return math.Pow(10, db/20)
+}
+
+// UnitToDB returns a Func that maps a 0–1 fader position linearly to the
+// range from minDB to 0 dB. Positions of zero or less are -Inf dB, i.e.
+// silence.
+func UnitToDB(minDB float64) Func {
+	return func(x float64) float64 {
+		if x <= 0 {
+			return math.Inf(-1)
+		}
+		return minDB - Unit.Clamp(x)*minDB
+	}
+}
+
+// DBToUnit returns a Func that is the inverse of UnitToDB, i.e. it maps the
+// range from minDB to 0 dB to a 0–1 fader position. Values below minDB are 0.
+func DBToUnit(minDB float64) Func {
+	return func(db float64) float64 {
+		if db <= minDB {
+			return 0
+		}
+		return Unit.Clamp(1 - db/minDB)
+	}
+}
+
+// MIDIToUnit maps MIDI controller values 0–127 to 0–1.
+func MIDIToUnit(x float64) float64 {
+	return Unit.Clamp(x / MIDI.Max)
+}
+
+// UnitToMIDI maps 0–1 to the nearest MIDI controller value 0–127.
+func UnitToMIDI(x float64) float64 {
+	return Round(Unit.Clamp(x) * MIDI.Max)
+}
+
+// Apply returns a copy of msg with f applied to every numeric argument,
+// including the elements of arrays. float64 arguments stay float64, all other
+// numeric arguments become float32. Other arguments are copied unchanged.
+func Apply(msg *osc.Message, f Func) *osc.Message {
+	out := msg.Clone()
+	for i, arg := range out.Arguments {
+		out.Arguments[i] = apply(arg, f)
+	}
+	return out
+}
+
+func apply(arg interface{}, f Func) interface{} {
+	switch x := arg.(type) {
+	case int32:
+		return float32(f(float64(x)))
+	case int64:
+		return float32(f(float64(x)))
+	case float32:
+		return float32(f(float64(x)))
+	case float64:
+		return f(x)
+	case []interface{}:
+		for i, elem := range x {
+			x[i] = apply(elem, f)
+		}
+	}
+	return arg
+}
+
+// Handler returns a handler that calls h with the messages converted by f,
+// see Apply. Other handlers of the same message see the original arguments.
+func Handler(f Func, h osc.Handler) osc.Handler {
+	return osc.HandlerFunc(func(msg *osc.Message) {
+		h.HandleMessage(Apply(msg, f))
+	})
+}
+
+// AddMsgHandler registers a handler for addr in d that is called with the
+// messages converted by f, e.g.
+//
+//	mapping.AddMsgHandler(d, "/mixer/1/fader", mapping.UnitToDB(-60), setLevel)
+func AddMsgHandler(d *osc.StandardDispatcher, addr string, f Func, h osc.HandlerFunc) error {
+	return d.AddMsgHandler(addr, Handler(f, h).HandleMessage)
+}
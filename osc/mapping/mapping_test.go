@@ -0,0 +1,78 @@
+package mapping
+
+import (
+	"math"
+	"testing"
+
+	"github.com/hypebeast/go-osc/osc"
+)
+
+func TestFuncs(t *testing.T) {
+	for _, tt := range []struct {
+		name string
+		f    Func
+		in   float64
+		want float64
+	}{
+		{"Linear", Linear(Unit, Range{-1, 1}), 0.25, -0.5},
+		{"Linear inverted", Linear(Unit, Range{1, 0}), 0.25, 0.75},
+		{"Linear extrapolated", Linear(Unit, MIDI), 2, 254},
+		{"Linear empty range", Linear(Range{1, 1}, MIDI), 5, 0},
+		{"Clamp", Clamp(Range{1, -1}), 2, 1},
+		{"Compose", Compose(Linear(Unit, MIDI), Clamp(MIDI), Round), 1.5, 127},
+		{"GainToDB", GainToDB, 0.1, -20},
+		{"GainToDB zero", GainToDB, 0, math.Inf(-1)},
+		{"DBToGain", DBToGain, -20, 0.1},
+		{"UnitToDB", UnitToDB(-60), 0.5, -30},
+		{"UnitToDB top", UnitToDB(-60), 1, 0},
+		{"UnitToDB off", UnitToDB(-60), 0, math.Inf(-1)},
+		{"DBToUnit", DBToUnit(-60), -15, 0.75},
+		{"DBToUnit below", DBToUnit(-60), -90, 0},
+		{"DBToUnit off", DBToUnit(-60), math.Inf(-1), 0},
+		{"MIDIToUnit", MIDIToUnit, 127, 1},
+		{"UnitToMIDI", UnitToMIDI, 0.5, 64},
+		{"UnitToMIDI clamped", UnitToMIDI, -1, 0},
+	} {
+		if got := tt.f(tt.in); math.Abs(got-tt.want) > 1e-9 && got != tt.want {
+			t.Errorf("%s(%v) = %v, want = %v", tt.name, tt.in, got, tt.want)
+		}
+	}
+}
+
+func TestApply(t *testing.T) {
+	msg := osc.NewMessage("/fader", int32(127), float32(0.5), 0.25, "label", []interface{}{int64(0)})
+	got := Apply(msg, Linear(MIDI, Unit))
+
+	want := osc.NewMessage("/fader", float32(1), float32(0.5/127), 0.25/127, "label", []interface{}{float32(0)})
+	if !got.Equals(want) {
+		t.Errorf("Apply() = %v, want = %v", got, want)
+	}
+	if orig := osc.NewMessage("/fader", int32(127), float32(0.5), 0.25, "label", []interface{}{int64(0)}); !msg.Equals(orig) {
+		t.Errorf("Apply() modified the message: %v", msg)
+	}
+}
+
+func TestAddMsgHandler(t *testing.T) {
+	d := osc.NewStandardDispatcher()
+	d.SetMatchMode(osc.MatchBoth)
+	var levels []float32
+	if err := AddMsgHandler(d, "/mixer/*/fader", UnitToDB(-60), func(msg *osc.Message) {
+		levels = append(levels, msg.Arguments[0].(float32))
+	}); err != nil {
+		t.Fatal(err)
+	}
+	var raw interface{}
+	if err := d.AddMsgHandler("/mixer/1/fader", func(msg *osc.Message) {
+		raw = msg.Arguments[0]
+	}); err != nil {
+		t.Fatal(err)
+	}
+
+	d.Dispatch(osc.NewMessage("/mixer/1/fader", float32(0.5)))
+	if len(levels) != 1 || levels[0] != -30 {
+		t.Errorf("mapped levels = %v, want = [-30]", levels)
+	}
+	if raw != float32(0.5) {
+		t.Errorf("other handler got %v, want = 0.5", raw)
+	}
+}
@@ -0,0 +1,102 @@
+package osc
+
+import (
+	"bytes"
+	"io/ioutil"
+	"log"
+	"net"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestMapper(t *testing.T) {
+	target, err := net.ListenPacket("udp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer target.Close()
+
+	name := filepath.Join(t.TempDir(), "mapping.json")
+	writeMapping := func(config string) {
+		config = strings.Replace(config, "TARGET", target.LocalAddr().String(), -1)
+		if err := ioutil.WriteFile(name, []byte(config), 0644); err != nil {
+			t.Fatal(err)
+		}
+	}
+	writeMapping(`{"mappings": [
+		{"address": "/fader/*", "action": "forward", "target": "TARGET"},
+		{"address": "/knob/1", "action": "transform", "target": "TARGET",
+		 "rewrite": "/cutoff", "scale": 127, "offset": 1},
+		{"address": "/debug", "action": "log"}
+	]}`)
+
+	m, err := NewMapper(name)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer m.Close()
+	var logs bytes.Buffer
+	m.Logger = log.New(&logs, "", 0)
+
+	server := &Server{ReadTimeout: 5 * time.Second}
+	expect := func(want *Message) {
+		t.Helper()
+		p, err := server.ReceivePacket(target)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if !p.(*Message).Equals(want) {
+			t.Errorf("target received %v, want = %v", p, want)
+		}
+	}
+
+	m.Dispatch(NewMessage("/fader/3", float32(0.5)))
+	expect(NewMessage("/fader/3", float32(0.5)))
+
+	m.Dispatch(NewMessage("/knob/1", float32(0.5), int32(2)))
+	expect(NewMessage("/cutoff", float32(64.5), int32(255)))
+
+	m.Dispatch(NewMessage("/debug", "hello"))
+	if got := logs.String(); !strings.Contains(got, "/debug ,s hello") {
+		t.Errorf("log = %q, want the debug message", got)
+	}
+
+	// Remap /fader/* and check that invalid files keep the current mappings
+	writeMapping(`{"mappings": [
+		{"address": "/fader/*", "action": "transform", "target": "TARGET", "rewrite": "/level"}
+	]}`)
+	if err := m.Reload(); err != nil {
+		t.Fatal(err)
+	}
+	for _, config := range []string{
+		`{"mappings": [`,
+		`{"mappings": [{"address": "/a", "action": "explode"}]}`,
+		`{"mappings": [{"address": "/a", "action": "forward"}]}`,
+		`{"mappings": [{"address": "/a/[", "action": "log"}]}`,
+	} {
+		writeMapping(config)
+		if err := m.Reload(); err == nil {
+			t.Errorf("Reload() expected error for %s", config)
+		}
+	}
+
+	m.Dispatch(NewMessage("/fader/3", float32(0.5)))
+	expect(NewMessage("/level", float32(0.5)))
+}
+
+func TestNewMapper_Errors(t *testing.T) {
+	dir := t.TempDir()
+	if _, err := NewMapper(filepath.Join(dir, "missing.json")); err == nil {
+		t.Error("expected error for missing file")
+	}
+
+	name := filepath.Join(dir, "invalid.json")
+	if err := ioutil.WriteFile(name, []byte(`{"mappings": 1}`), 0644); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := NewMapper(name); err == nil {
+		t.Error("expected error for invalid file")
+	}
+}
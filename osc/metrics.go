@@ -0,0 +1,98 @@
+package osc
+
+import (
+	"sort"
+	"sync"
+	"time"
+
+	"github.com/hypebeast/go-osc/osc/codec"
+)
+
+// AddressStats are the metrics of the messages with one address that were
+// dispatched by a StandardDispatcher.
+type AddressStats struct {
+	Address       string
+	Count         uint64        // Number of dispatched messages
+	Matched       uint64        // Number of messages that matched any handler
+	LastReceived  time.Time     // Time the last message was dispatched
+	LastArguments []interface{} // Copy of the arguments of the last message
+}
+
+// MaxMetricsAddresses is the maximum number of addresses that the metrics of a
+// StandardDispatcher keep. The addresses are chosen by the senders, so
+// messages with further addresses aren't recorded, to bound the memory of the
+// metrics.
+const MaxMetricsAddresses = 1024
+
+// dispatcherMetrics collects the AddressStats of a dispatcher.
+type dispatcherMetrics struct {
+	mu    sync.Mutex
+	stats map[string]*AddressStats
+}
+
+// SetMetrics enables or disables collecting metrics per message address,
+// which can be read with Snapshot, e.g. to show the incoming control data in
+// a monitor view. Disabling the metrics discards the collected metrics. At
+// most MaxMetricsAddresses addresses are recorded. SetMetrics may be called
+// while messages are dispatched.
+func (s *StandardDispatcher) SetMetrics(enabled bool) {
+	s.metricsMu.Lock()
+	defer s.metricsMu.Unlock()
+	if !enabled {
+		s.metrics = nil
+	} else if s.metrics == nil {
+		s.metrics = &dispatcherMetrics{stats: make(map[string]*AddressStats)}
+	}
+}
+
+// loadMetrics returns the metrics of the dispatcher, or nil if they are
+// disabled.
+func (s *StandardDispatcher) loadMetrics() *dispatcherMetrics {
+	s.metricsMu.Lock()
+	defer s.metricsMu.Unlock()
+	return s.metrics
+}
+
+// Snapshot returns the metrics of all dispatched addresses sorted by address.
+// Returns nil if metrics aren't enabled, see SetMetrics.
+func (s *StandardDispatcher) Snapshot() []AddressStats {
+	m := s.loadMetrics()
+	if m == nil {
+		return nil
+	}
+
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	snapshot := make([]AddressStats, 0, len(m.stats))
+	for _, stats := range m.stats {
+		snapshot = append(snapshot, *stats)
+	}
+	sort.Slice(snapshot, func(i, j int) bool { return snapshot[i].Address < snapshot[j].Address })
+	return snapshot
+}
+
+// record adds a dispatched message that matched the given number of handlers
+// to the metrics.
+func (m *dispatcherMetrics) record(msg *Message, matched int) {
+	args := make([]interface{}, len(msg.Arguments))
+	for i, arg := range msg.Arguments {
+		args[i] = codec.CloneArgument(arg)
+	}
+
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	stats, ok := m.stats[msg.Address]
+	if !ok {
+		if len(m.stats) >= MaxMetricsAddresses {
+			return
+		}
+		stats = &AddressStats{Address: msg.Address}
+		m.stats[msg.Address] = stats
+	}
+	stats.Count++
+	if matched > 0 {
+		stats.Matched++
+	}
+	stats.LastReceived = time.Now()
+	stats.LastArguments = args
+}
@@ -0,0 +1,82 @@
+package osc
+
+import (
+	"fmt"
+	"reflect"
+	"sync"
+	"testing"
+	"time"
+)
+
+func TestStandardDispatcher_Snapshot(t *testing.T) {
+	d := NewStandardDispatcher()
+	if err := d.AddMsgHandler("/fader/1", func(msg *Message) {}); err != nil {
+		t.Fatal(err)
+	}
+	d.Invoke("/fader/1", float32(0.1))
+	if snapshot := d.Snapshot(); snapshot != nil {
+		t.Errorf("Snapshot() = %v without metrics, want = nil", snapshot)
+	}
+
+	d.SetMetrics(true)
+	start := time.Now()
+	d.Invoke("/fader/1", float32(0.2))
+	blob := []byte{1, 2}
+	d.Invoke("/fader/1", float32(0.3), blob)
+	d.Invoke("/unknown")
+	blob[0] = 9
+
+	snapshot := d.Snapshot()
+	if len(snapshot) != 2 {
+		t.Fatalf("Snapshot() = %v, want stats for 2 addresses", snapshot)
+	}
+	fader, unknown := snapshot[0], snapshot[1]
+	if fader.Address != "/fader/1" || fader.Count != 2 || fader.Matched != 2 {
+		t.Errorf("fader stats = %+v, want 2 messages that matched", fader)
+	}
+	if want := []interface{}{float32(0.3), []byte{1, 2}}; !reflect.DeepEqual(fader.LastArguments, want) {
+		t.Errorf("LastArguments = %v, want = %v", fader.LastArguments, want)
+	}
+	if fader.LastReceived.Before(start) {
+		t.Errorf("LastReceived = %s, want after %s", fader.LastReceived, start)
+	}
+	if unknown.Address != "/unknown" || unknown.Count != 1 || unknown.Matched != 0 {
+		t.Errorf("unknown stats = %+v, want 1 message that didn't match", unknown)
+	}
+
+	d.SetMetrics(false)
+	d.SetMetrics(true)
+	if snapshot := d.Snapshot(); len(snapshot) != 0 {
+		t.Errorf("Snapshot() = %v after re-enabling metrics, want no stats", snapshot)
+	}
+}
+
+func TestStandardDispatcher_MetricsLimit(t *testing.T) {
+	d := NewStandardDispatcher()
+	d.SetMetrics(true)
+	for i := 0; i < MaxMetricsAddresses+10; i++ {
+		d.Invoke(fmt.Sprintf("/spam/%d", i))
+	}
+	if n := len(d.Snapshot()); n != MaxMetricsAddresses {
+		t.Errorf("Snapshot() has %d addresses, want = %d", n, MaxMetricsAddresses)
+	}
+}
+
+func TestStandardDispatcher_SetMetricsConcurrent(t *testing.T) {
+	d := NewStandardDispatcher()
+	var wg sync.WaitGroup
+	wg.Add(2)
+	go func() {
+		defer wg.Done()
+		for i := 0; i < 1000; i++ {
+			d.SetMetrics(i%2 == 0)
+		}
+	}()
+	go func() {
+		defer wg.Done()
+		for i := 0; i < 1000; i++ {
+			d.Invoke("/fader/1", float32(0.5))
+		}
+	}()
+	wg.Wait()
+}
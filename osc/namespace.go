@@ -0,0 +1,276 @@
+package osc
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"sort"
+	"strings"
+	"sync"
+	"text/tabwriter"
+
+	"github.com/hypebeast/go-osc/osc/internal/pattern"
+)
+
+// ErrUnknownAddress is returned by Namespace.Validate for messages whose
+// address pattern matches no method of the namespace.
+var ErrUnknownAddress = errors.New("osc: address is not in the namespace")
+
+// Method describes an OSC address that an application accepts.
+type Method struct {
+	// Address of the method. It must not contain pattern characters.
+	Address string
+	// TypeTags is the type tag string of the arguments, e.g. "ff" or ",ff".
+	TypeTags string
+	// Description is a human-readable description of the method.
+	Description string
+	// Args optionally describes the arguments, in order. It may be shorter
+	// than the number of arguments.
+	Args []ArgInfo
+}
+
+// ArgInfo describes an argument of a Method.
+type ArgInfo struct {
+	Name string
+	// Min and Max are the inclusive range of numeric arguments. The range
+	// isn't checked if Min and Max are equal.
+	Min, Max float64
+	// Unit of the value, e.g. "dB" or "Hz".
+	Unit string
+}
+
+// hasRange returns true if the argument has a range.
+func (a ArgInfo) hasRange() bool {
+	return a.Min != a.Max
+}
+
+// ValidationError describes a message whose arguments don't conform to the
+// Method of its address.
+type ValidationError struct {
+	Address string // Address of the method
+	Index   int    // Index of the invalid argument, -1 for wrong type tags
+	Reason  string // Description of the error
+}
+
+func (e *ValidationError) Error() string {
+	if e.Index < 0 {
+		return fmt.Sprintf("osc: invalid arguments for %s: %s", e.Address, e.Reason)
+	}
+	return fmt.Sprintf("osc: invalid argument %d for %s: %s", e.Index, e.Address, e.Reason)
+}
+
+// Namespace is a registry of the addresses that an application accepts,
+// with their type signatures and metadata. It validates received messages,
+// see StandardDispatcher.SetNamespace, and can be exported to document the
+// addresses or to generate controller layouts, see WriteOSCQuery and
+// WriteText. A Namespace is safe for concurrent use.
+type Namespace struct {
+	mu      sync.RWMutex
+	methods map[string]*Method
+}
+
+// NewNamespace returns an empty namespace.
+func NewNamespace() *Namespace {
+	return &Namespace{methods: make(map[string]*Method)}
+}
+
+// Add adds a method to the namespace. It replaces a method with the same
+// address.
+func (n *Namespace) Add(m Method) error {
+	if err := pattern.ValidateAddress(m.Address); err != nil {
+		return err
+	}
+	m.TypeTags = strings.TrimPrefix(m.TypeTags, ",")
+	if len(m.Args) > len(m.TypeTags) {
+		return fmt.Errorf("osc: method %s describes %d arguments but has %d type tags", m.Address, len(m.Args), len(m.TypeTags))
+	}
+	m.Args = append([]ArgInfo(nil), m.Args...)
+
+	n.mu.Lock()
+	n.methods[m.Address] = &m
+	n.mu.Unlock()
+	return nil
+}
+
+// Lookup returns the method with the given address.
+func (n *Namespace) Lookup(addr string) (Method, bool) {
+	n.mu.RLock()
+	defer n.mu.RUnlock()
+	m, ok := n.methods[addr]
+	if !ok {
+		return Method{}, false
+	}
+	return *m, true
+}
+
+// Methods returns all methods of the namespace, sorted by address.
+func (n *Namespace) Methods() []Method {
+	n.mu.RLock()
+	methods := make([]Method, 0, len(n.methods))
+	for _, m := range n.methods {
+		methods = append(methods, *m)
+	}
+	n.mu.RUnlock()
+
+	sort.Slice(methods, func(i, j int) bool { return methods[i].Address < methods[j].Address })
+	return methods
+}
+
+// Validate checks that the address pattern of msg matches a method and that
+// its arguments conform to every matching method. Returns ErrUnknownAddress
+// or a *ValidationError.
+func (n *Namespace) Validate(msg *Message) error {
+	n.mu.RLock()
+	defer n.mu.RUnlock()
+
+	if !pattern.IsPattern(msg.Address) {
+		m, ok := n.methods[msg.Address]
+		if !ok {
+			return ErrUnknownAddress
+		}
+		return m.validate(msg)
+	}
+
+	p, err := CompilePattern(msg.Address)
+	if err != nil {
+		return ErrUnknownAddress
+	}
+	matched := false
+	for addr, m := range n.methods {
+		if !p.Match(addr) {
+			continue
+		}
+		matched = true
+		if err := m.validate(msg); err != nil {
+			return err
+		}
+	}
+	if !matched {
+		return ErrUnknownAddress
+	}
+	return nil
+}
+
+// validate checks the arguments of msg against the method.
+func (m *Method) validate(msg *Message) error {
+	tags, err := msg.TypeTags()
+	if err != nil {
+		return &ValidationError{m.Address, -1, err.Error()}
+	}
+	if tags[1:] != m.TypeTags {
+		return &ValidationError{m.Address, -1, fmt.Sprintf("got %s, want ,%s", tags, m.TypeTags)}
+	}
+	for i, info := range m.Args {
+		if !info.hasRange() {
+			continue
+		}
+		x, ok := numericValue(msg.Arguments[i])
+		if ok && (x < info.Min || x > info.Max) {
+			return &ValidationError{m.Address, i, fmt.Sprintf("%v is out of range [%v, %v]", x, info.Min, info.Max)}
+		}
+	}
+	return nil
+}
+
+// numericValue returns the value of a numeric argument as float64.
+func numericValue(arg interface{}) (float64, bool) {
+	switch x := arg.(type) {
+	case int32:
+		return float64(x), true
+	case int64:
+		return float64(x), true
+	case float32:
+		return float64(x), true
+	case float64:
+		return x, true
+	}
+	return 0, false
+}
+
+// oscQueryNode is a node of the OSCQuery namespace tree.
+type oscQueryNode struct {
+	FullPath    string                   `json:"FULL_PATH"`
+	Contents    map[string]*oscQueryNode `json:"CONTENTS,omitempty"`
+	Type        *string                  `json:"TYPE,omitempty"`
+	Description string                   `json:"DESCRIPTION,omitempty"`
+	Access      int                      `json:"ACCESS,omitempty"`
+	Range       []map[string]float64     `json:"RANGE,omitempty"`
+	Unit        []string                 `json:"UNIT,omitempty"`
+}
+
+// OSCQuery access value of methods that accept values.
+const oscQueryWriteOnly = 2
+
+// WriteOSCQuery writes the namespace as OSCQuery JSON, i.e. a tree of nodes
+// with the attributes FULL_PATH, CONTENTS, TYPE, DESCRIPTION, ACCESS, RANGE
+// and UNIT.
+func (n *Namespace) WriteOSCQuery(w io.Writer) error {
+	root := &oscQueryNode{FullPath: "/"}
+	for _, m := range n.Methods() {
+		node := root
+		parts := strings.Split(m.Address[1:], "/")
+		for i, part := range parts {
+			if node.Contents == nil {
+				node.Contents = make(map[string]*oscQueryNode)
+			}
+			child, ok := node.Contents[part]
+			if !ok {
+				child = &oscQueryNode{FullPath: "/" + strings.Join(parts[:i+1], "/")}
+				node.Contents[part] = child
+			}
+			node = child
+		}
+
+		typeTags := m.TypeTags
+		node.Type = &typeTags
+		node.Description = m.Description
+		node.Access = oscQueryWriteOnly
+		hasRange, hasUnit := false, false
+		for _, info := range m.Args {
+			hasRange = hasRange || info.hasRange()
+			hasUnit = hasUnit || info.Unit != ""
+		}
+		for _, info := range m.Args {
+			if hasRange {
+				r := map[string]float64{}
+				if info.hasRange() {
+					r["MIN"], r["MAX"] = info.Min, info.Max
+				}
+				node.Range = append(node.Range, r)
+			}
+			if hasUnit {
+				node.Unit = append(node.Unit, info.Unit)
+			}
+		}
+	}
+
+	enc := json.NewEncoder(w)
+	enc.SetIndent("", "  ")
+	return enc.Encode(root)
+}
+
+// WriteText writes a plain text listing of the namespace with one method per
+// line, e.g.
+//
+//	/mixer/1/fader  ,f  Level of channel 1  level [-60, 0] dB
+func (n *Namespace) WriteText(w io.Writer) error {
+	tw := tabwriter.NewWriter(w, 0, 4, 2, ' ', 0)
+	for _, m := range n.Methods() {
+		var args []string
+		for _, info := range m.Args {
+			arg := info.Name
+			if info.hasRange() {
+				arg = strings.TrimSpace(fmt.Sprintf("%s [%v, %v]", arg, info.Min, info.Max))
+			}
+			if info.Unit != "" {
+				arg = strings.TrimSpace(arg + " " + info.Unit)
+			}
+			if arg != "" {
+				args = append(args, arg)
+			}
+		}
+		fmt.Fprintf(tw, "%s\t,%s\t%s\t%s\n", m.Address, m.TypeTags, m.Description, strings.Join(args, ", "))
+	}
+	return tw.Flush()
+}
@@ -0,0 +1,166 @@
+package osc
+
+import (
+	"bytes"
+	"encoding/json"
+	"reflect"
+	"testing"
+)
+
+func testNamespace(t *testing.T) *Namespace {
+	ns := NewNamespace()
+	for _, m := range []Method{
+		{Address: "/mixer/1/fader", TypeTags: ",f", Description: "Level of channel 1",
+			Args: []ArgInfo{{Name: "level", Min: -60, Max: 0, Unit: "dB"}}},
+		{Address: "/mixer/2/fader", TypeTags: "f", Description: "Level of channel 2",
+			Args: []ArgInfo{{Name: "level", Min: -60, Max: 0, Unit: "dB"}}},
+		{Address: "/transport/play", Description: "Start playback"},
+		{Address: "/synth/note", TypeTags: "is", Args: []ArgInfo{{Name: "pitch", Min: 0, Max: 127}}},
+	} {
+		if err := ns.Add(m); err != nil {
+			t.Fatal(err)
+		}
+	}
+	return ns
+}
+
+func TestNamespace_Add(t *testing.T) {
+	ns := NewNamespace()
+	if err := ns.Add(Method{Address: "/a/*"}); err == nil {
+		t.Error("Add() accepted an address with pattern characters")
+	}
+	if err := ns.Add(Method{Address: "/a", TypeTags: "f", Args: make([]ArgInfo, 2)}); err == nil {
+		t.Error("Add() accepted more argument descriptions than type tags")
+	}
+
+	ns = testNamespace(t)
+	m, ok := ns.Lookup("/mixer/1/fader")
+	if !ok || m.TypeTags != "f" {
+		t.Errorf("Lookup() = %v, %v, want method with type tags f", m, ok)
+	}
+	var addrs []string
+	for _, m := range ns.Methods() {
+		addrs = append(addrs, m.Address)
+	}
+	want := []string{"/mixer/1/fader", "/mixer/2/fader", "/synth/note", "/transport/play"}
+	if !reflect.DeepEqual(addrs, want) {
+		t.Errorf("Methods() = %v, want = %v", addrs, want)
+	}
+}
+
+func TestNamespace_Validate(t *testing.T) {
+	ns := testNamespace(t)
+	for _, tt := range []struct {
+		msg   *Message
+		valid bool
+		index int // of the ValidationError
+	}{
+		{NewMessage("/mixer/1/fader", float32(-6)), true, 0},
+		{NewMessage("/mixer/1/fader", float32(6)), false, 0},
+		{NewMessage("/mixer/1/fader", int32(-6)), false, -1},
+		{NewMessage("/mixer/*/fader", float32(-6)), true, 0},
+		{NewMessage("/mixer/*/fader", "loud"), false, -1},
+		{NewMessage("/transport/play"), true, 0},
+		{NewMessage("/transport/play", true), false, -1},
+		{NewMessage("/synth/note", int32(60), "piano"), true, 0},
+		{NewMessage("/synth/note", int32(128), "piano"), false, 0},
+	} {
+		err := ns.Validate(tt.msg)
+		if tt.valid {
+			if err != nil {
+				t.Errorf("Validate(%v) = %v, want = nil", tt.msg, err)
+			}
+			continue
+		}
+		verr, ok := err.(*ValidationError)
+		if !ok || verr.Index != tt.index {
+			t.Errorf("Validate(%v) = %v, want ValidationError for argument %d", tt.msg, err, tt.index)
+		}
+	}
+
+	for _, addr := range []string{"/mixer/3/fader", "/mixer/*", "/unknown"} {
+		if err := ns.Validate(NewMessage(addr)); err != ErrUnknownAddress {
+			t.Errorf("Validate(%s) = %v, want = %v", addr, err, ErrUnknownAddress)
+		}
+	}
+}
+
+func TestStandardDispatcher_SetNamespace(t *testing.T) {
+	d := NewStandardDispatcher()
+	d.SetNamespace(testNamespace(t))
+	var handled, defaulted, mismatched []string
+	for _, addr := range []string{"/mixer/1/fader", "/undeclared"} {
+		addr := addr
+		if err := d.AddMsgHandler(addr, func(msg *Message) { handled = append(handled, addr) }); err != nil {
+			t.Fatal(err)
+		}
+	}
+	d.SetDefaultHandler(HandlerFunc(func(msg *Message) { defaulted = append(defaulted, msg.Address) }))
+	d.SetTypeMismatchHandler(func(msg *Message, want string) { mismatched = append(mismatched, msg.Address) })
+
+	d.Dispatch(NewMessage("/mixer/1/fader", float32(-12)))
+	d.Dispatch(NewMessage("/mixer/1/fader", float32(12)))
+	d.Dispatch(NewMessage("/undeclared"))
+
+	if want := []string{"/mixer/1/fader"}; !reflect.DeepEqual(handled, want) {
+		t.Errorf("handled = %v, want = %v", handled, want)
+	}
+	if want := []string{"/mixer/1/fader"}; !reflect.DeepEqual(mismatched, want) {
+		t.Errorf("mismatched = %v, want = %v", mismatched, want)
+	}
+	if want := []string{"/undeclared"}; !reflect.DeepEqual(defaulted, want) {
+		t.Errorf("defaulted = %v, want = %v", defaulted, want)
+	}
+}
+
+func TestNamespace_WriteOSCQuery(t *testing.T) {
+	var buf bytes.Buffer
+	if err := testNamespace(t).WriteOSCQuery(&buf); err != nil {
+		t.Fatal(err)
+	}
+	var root struct {
+		Contents map[string]struct {
+			FullPath string `json:"FULL_PATH"`
+			Contents map[string]struct {
+				Contents map[string]struct {
+					FullPath    string               `json:"FULL_PATH"`
+					Type        string               `json:"TYPE"`
+					Description string               `json:"DESCRIPTION"`
+					Access      int                  `json:"ACCESS"`
+					Range       []map[string]float64 `json:"RANGE"`
+					Unit        []string             `json:"UNIT"`
+				} `json:"CONTENTS"`
+			} `json:"CONTENTS"`
+		} `json:"CONTENTS"`
+	}
+	if err := json.Unmarshal(buf.Bytes(), &root); err != nil {
+		t.Fatal(err)
+	}
+	if got := root.Contents["mixer"].FullPath; got != "/mixer" {
+		t.Errorf("FULL_PATH = %q, want = /mixer", got)
+	}
+	fader := root.Contents["mixer"].Contents["1"].Contents["fader"]
+	if fader.FullPath != "/mixer/1/fader" || fader.Type != "f" || fader.Description != "Level of channel 1" || fader.Access != 2 {
+		t.Errorf("unexpected fader node %+v", fader)
+	}
+	if want := []map[string]float64{{"MIN": -60, "MAX": 0}}; !reflect.DeepEqual(fader.Range, want) {
+		t.Errorf("RANGE = %v, want = %v", fader.Range, want)
+	}
+	if want := []string{"dB"}; !reflect.DeepEqual(fader.Unit, want) {
+		t.Errorf("UNIT = %v, want = %v", fader.Unit, want)
+	}
+}
+
+func TestNamespace_WriteText(t *testing.T) {
+	var buf bytes.Buffer
+	if err := testNamespace(t).WriteText(&buf); err != nil {
+		t.Fatal(err)
+	}
+	want := "/mixer/1/fader   ,f   Level of channel 1  level [-60, 0] dB\n" +
+		"/mixer/2/fader   ,f   Level of channel 2  level [-60, 0] dB\n" +
+		"/synth/note      ,is                      pitch [0, 127]\n" +
+		"/transport/play  ,    Start playback      \n"
+	if got := buf.String(); got != want {
+		t.Errorf("WriteText() =\n%s\nwant =\n%s", got, want)
+	}
+}
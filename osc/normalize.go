@@ -0,0 +1,55 @@
+package osc
+
+import "strings"
+
+// SetCaseInsensitive makes the dispatcher match addresses without regard to
+// case if enabled is true, e.g. a handler for "/Mixer/Fader" receives
+// messages for "/mixer/fader" and "/MIXER/FADER". The handlers still receive
+// the messages with their original address, but the values that template
+// parameters capture are in lower case. It must be called before handlers and
+// change gates are added.
+func (s *StandardDispatcher) SetCaseInsensitive(enabled bool) {
+	s.caseInsensitive = enabled
+}
+
+// SetNormalizeAddresses makes the dispatcher normalize addresses before they
+// are matched if enabled is true: consecutive slashes are collapsed to one
+// and a trailing slash is removed, e.g. "//mixer/fader/" is matched like
+// "/mixer/fader". Since the OSC 1.1 operator "//" is collapsed as well, the
+// option is meant for devices that send literal but sloppy addresses. The
+// handlers still receive the messages with their original address. It must
+// be called before handlers and change gates are added.
+func (s *StandardDispatcher) SetNormalizeAddresses(enabled bool) {
+	s.normalize = enabled
+}
+
+// routeAddress returns the address that is used to match addr according to
+// the routing options of the dispatcher.
+func (s *StandardDispatcher) routeAddress(addr string) string {
+	if s.normalize {
+		addr = normalizeAddress(addr)
+	}
+	if s.caseInsensitive {
+		addr = strings.ToLower(addr)
+	}
+	return addr
+}
+
+// normalizeAddress collapses consecutive slashes of addr and removes a
+// trailing slash, unless addr is the root address "/".
+func normalizeAddress(addr string) string {
+	if !strings.Contains(addr, "//") && (len(addr) < 2 || !strings.HasSuffix(addr, "/")) {
+		return addr
+	}
+	b := make([]byte, 0, len(addr))
+	for i := 0; i < len(addr); i++ {
+		if addr[i] == '/' && len(b) > 0 && b[len(b)-1] == '/' {
+			continue
+		}
+		b = append(b, addr[i])
+	}
+	if len(b) > 1 && b[len(b)-1] == '/' {
+		b = b[:len(b)-1]
+	}
+	return string(b)
+}
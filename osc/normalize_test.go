@@ -0,0 +1,69 @@
+package osc
+
+import (
+	"reflect"
+	"testing"
+)
+
+func TestNormalizeAddress(t *testing.T) {
+	for _, tt := range []struct {
+		addr, want string
+	}{
+		{"/", "/"},
+		{"", ""},
+		{"/mixer/fader", "/mixer/fader"},
+		{"/mixer/fader/", "/mixer/fader"},
+		{"//mixer///fader//", "/mixer/fader"},
+		{"//", "/"},
+	} {
+		if got := normalizeAddress(tt.addr); got != tt.want {
+			t.Errorf("normalizeAddress(%q) = %q, want = %q", tt.addr, got, tt.want)
+		}
+	}
+}
+
+func TestStandardDispatcher_RoutingOptions(t *testing.T) {
+	var got []string
+	var params Params
+	d := NewStandardDispatcher()
+	d.SetCaseInsensitive(true)
+	d.SetNormalizeAddresses(true)
+	if err := d.AddMsgHandler("/Mixer/Fader/", func(msg *Message) { got = append(got, msg.Address) }); err != nil {
+		t.Fatal(err)
+	}
+	if err := d.AddParamHandler("/Track/{trackNo}/Mute", func(msg *Message, p Params) { params = p }); err != nil {
+		t.Fatal(err)
+	}
+	if err := d.AddMsgHandler("/mixer/fader", func(*Message) {}); err == nil {
+		t.Error("AddMsgHandler() with an equivalent address expected error")
+	}
+	if err := d.SetPriority("/MIXER//FADER", 1); err != nil {
+		t.Errorf("SetPriority() unexpected error: %s", err)
+	}
+
+	addrs := []string{"/mixer/fader", "/MIXER/FADER/", "//Mixer//Fader", "/mixer/f*"}
+	for _, addr := range addrs {
+		d.Invoke(addr)
+	}
+	if !reflect.DeepEqual(got, addrs) {
+		t.Errorf("dispatched %v, want = %v", got, addrs)
+	}
+
+	d.Invoke("/TRACK/A1/mute/")
+	if want := (Params{"trackNo": "a1"}); !reflect.DeepEqual(params, want) {
+		t.Errorf("params = %v, want = %v", params, want)
+	}
+
+	// Without the options, the addresses are matched exactly
+	got = nil
+	d = NewStandardDispatcher()
+	if err := d.AddMsgHandler("/mixer/fader", func(msg *Message) { got = append(got, msg.Address) }); err != nil {
+		t.Fatal(err)
+	}
+	for _, addr := range addrs[1:3] {
+		d.Invoke(addr)
+	}
+	if len(got) != 0 {
+		t.Errorf("dispatched %v, want none", got)
+	}
+}
@@ -3,100 +3,156 @@
 package osc
 
 import (
-	"bufio"
-	"bytes"
-	"encoding"
+	"context"
 	"encoding/binary"
 	"errors"
 	"fmt"
+	"io"
 	"net"
-	"reflect"
-	"regexp"
+	"strconv"
 	"strings"
+	"sync"
 	"time"
-)
 
-const (
-	secondsFrom1900To1970 = 2208988800
-	bundleTagString       = "#bundle"
+	"github.com/hypebeast/go-osc/osc/codec"
+	"github.com/hypebeast/go-osc/osc/internal/pattern"
 )
 
-// Packet is the interface for Message and Bundle.
-type Packet interface {
-	encoding.BinaryMarshaler
-}
-
-// Message represents a single OSC message. An OSC message consists of an OSC
-// address pattern and zero or more arguments.
-type Message struct {
-	Address   string
-	Arguments []interface{}
-}
-
-// Verify that Messages implements the Packet interface.
-var _ Packet = (*Message)(nil)
-
-// Bundle represents an OSC bundle. It consists of the OSC-string "#bundle"
-// followed by an OSC Time Tag, followed by zero or more OSC bundle/message
-// elements. The OSC-timetag is a 64-bit fixed point time tag. See
-// http://opensoundcontrol.org/spec-1_0 for more information.
-type Bundle struct {
-	Timetag  Timetag
-	Messages []*Message
-	Bundles  []*Bundle
-}
-
-// Verify that Bundle implements the Packet interface.
-var _ Packet = (*Bundle)(nil)
+// Verify that the clients implement the Sender interface.
+var (
+	_ Sender = (*Client)(nil)
+	_ Sender = (*StreamClient)(nil)
+)
 
 // Client enables you to send OSC packets. It sends OSC messages and bundles to
 // the given IP address and port.
 type Client struct {
-	ip    string
-	port  int
-	laddr *net.UDPAddr
+	ip           string
+	port         int
+	network      string // "udp", "udp4" or "udp6", empty for "udp"
+	laddr        *net.UDPAddr
+	conn         net.Conn // Connection supplied to NewClientFromConn
+	writeTimeout time.Duration
+	hooks        []SendHook
+	scheduleLead time.Duration // See SetScheduleLead, zero sends immediately
+	maxSize      int           // See SetMaxPacketSize, zero is the default, negative doesn't split
+
+	statsMu     sync.Mutex
+	stats       ClientStats
+	onSendError func(packet Packet, err error)
 }
 
+// SendHook is called with every packet before it is sent and returns the
+// packet to send instead, e.g. with rewritten addresses or clamped arguments.
+// It should return a modified copy rather than modify the packet, which
+// belongs to the caller. Returning a nil packet drops the packet, returning
+// an error aborts sending.
+type SendHook func(packet Packet) (Packet, error)
+
 // Server represents an OSC server. The server listens on Address and Port for
 // incoming OSC packets and bundles.
 type Server struct {
-	Addr        string
-	Dispatcher  Dispatcher
-	ReadTimeout time.Duration
-}
+	Addr       string
+	Dispatcher Dispatcher
+	Trace      *ServerTrace
 
-// Timetag represents an OSC Time Tag.
-// An OSC Time Tag is defined as follows:
-// Time tags are represented by a 64 bit fixed point number. The first 32 bits
-// specify the number of seconds since midnight on January 1, 1900, and the
-// last 32 bits specify fractional parts of a second to a precision of about
-// 200 picoseconds. This is the representation used by Internet NTP timestamps.
-type Timetag struct {
-	timeTag  uint64 // The acutal time tag
-	time     time.Time
-	MinValue uint64 // Minimum value of an OSC Time Tag. Is always 1.
-}
+	// Logger receives debug records of the received packets and dispatch
+	// decisions, and records of invalid packets and errors, if it isn't nil.
+	// See SetLogger to log to a log/slog logger.
+	Logger Logger
 
-// Dispatcher is an interface for an OSC message dispatcher. A dispatcher is
-// responsible for dispatching received OSC messages.
-type Dispatcher interface {
-	Dispatch(packet Packet)
-}
-
-// Handler is an interface for message handlers. Every handler implementation
-// for an OSC message must implement this interface.
-type Handler interface {
-	HandleMessage(msg *Message)
-}
-
-// HandlerFunc implements the Handler interface. Type definition for an OSC
-// handler function.
-type HandlerFunc func(msg *Message)
+	// ReadTimeout is the maximum duration of a single read. ReceivePacket
+	// returns the timeout error, Serve retries the read.
+	ReadTimeout time.Duration
 
-// HandleMessage calls itself with the given OSC Message. Implements the
-// Handler interface.
-func (f HandlerFunc) HandleMessage(msg *Message) {
-	f(msg)
+	// ListenConfig contains the socket options that are used by
+	// ListenAndServe and Start.
+	ListenConfig ListenConfig
+
+	// DecodeOptions control how received packets are decoded.
+	DecodeOptions DecodeOptions
+
+	// ArgumentByteOrder selects the byte order of the numeric arguments in
+	// packets received from addr, which allows to receive from senders that
+	// don't use big-endian. If it is nil or returns nil, the
+	// ArgumentByteOrder of DecodeOptions is used.
+	ArgumentByteOrder func(addr net.Addr) binary.ByteOrder
+
+	// ReuseMessages recycles received messages and their arguments after the
+	// handlers returned, which reduces the allocations per packet. Handlers
+	// that keep a message must call its Retain method. Messages passed to a
+	// Dispatcher other than StandardDispatcher are recycled when its Dispatch
+	// method returns, unless it retains them like Mux does with the messages
+	// of bundles.
+	ReuseMessages bool
+
+	// AuthKey returns the key that packets received from addr must be signed
+	// with, see Sign. Packets without a valid signature are discarded and
+	// reported to Trace.OnDecodeError with ErrUnauthenticated. If AuthKey is
+	// nil, packets aren't verified. If it returns nil, all packets from addr
+	// are discarded.
+	AuthKey func(addr net.Addr) []byte
+
+	// Sequence removes the sequence numbers added by SequenceHook from
+	// received packets and reports lost, reordered and duplicate messages.
+	// Packets that only contain duplicates are discarded, ReceivePacket
+	// returns ErrDuplicatePacket for them.
+	Sequence *SequenceChecker
+
+	// Acknowledge makes the server acknowledge every packet with sequence
+	// numbers to its sender, as required by ReliableClient. Duplicates are
+	// acknowledged as well, since the previous acknowledgment may have been
+	// lost. It has no effect if Sequence is nil.
+	Acknowledge bool
+
+	// MaxBundleAge drops the messages of received bundles whose timetag is
+	// more than MaxBundleAge in the past, e.g. a burst of bundles that were
+	// delayed by a network stall. Bundles with the timetag "immediately" are
+	// never dropped. Bundles that only contain stale messages are discarded,
+	// ReceivePacket returns ErrStaleBundle for them. See StaleMessages for
+	// the number of dropped messages. If it is zero, no messages are dropped.
+	MaxBundleAge time.Duration
+
+	// State records the last message of every address that passed the
+	// receive pipeline, if it isn't nil. Messages of bundles are recorded
+	// when the bundle is received, not when it is dispatched.
+	State *StateStore
+
+	// UnmatchedBuffer is the capacity of the channel returned by Unmatched.
+	// If it is zero, DefaultUnmatchedBuffer is used.
+	UnmatchedBuffer int
+
+	// PacketsBuffer is the capacity of the channel returned by Packets. If
+	// it is zero, DefaultPacketsBuffer is used.
+	PacketsBuffer int
+
+	// PacketsOverflow selects what happens to received packets if the
+	// channel returned by Packets is full. Defaults to OverflowBlock.
+	PacketsOverflow OverflowPolicy
+
+	mu       sync.Mutex
+	conn     net.PacketConn
+	listener net.PacketConn // Connection opened by Start
+	done     chan struct{}  // Closed when the server started by Start stopped
+	stopped  bool           // Stop was called
+	err      error          // Error that stopped the server started by Start
+	inflight sync.WaitGroup // Running dispatches
+
+	staleMessages uint64 // Messages dropped because of MaxBundleAge
+
+	unmatched   chan *Message // Created by Unmatched
+	unmatchedMu sync.Mutex    // Serializes sends to unmatched
+
+	packets     chan ReceivedPacket // Created by Packets
+	packetsMu   sync.Mutex          // Serializes overflow handling, guards packetStats
+	packetStats PacketStats
+
+	stages   []Stage // Receive pipeline, see Use
+	builtins bool    // See EnableBuiltins
+
+	// intercept is called with every received packet before it is
+	// dispatched and consumes the packet if it returns true
+	intercept func(packet Packet, addr net.Addr) bool
 }
 
 ////
@@ -104,51 +160,204 @@ func (f HandlerFunc) HandleMessage(msg *Message) {
 ////
 
 // StandardDispatcher is a dispatcher for OSC packets. It handles the dispatching of
-// received OSC packets to Handlers for their given address.
+// received OSC packets to Handlers for their given address. Handlers are stored
+// in a tree keyed on the address parts, so the dispatch time depends on the
+// length of the address and not on the number of registered handlers. The
+// handlers that match a message are called in a deterministic order, see
+// SetPriority.
 type StandardDispatcher struct {
-	handlers       map[string]Handler
-	defaultHandler Handler
+	handlers        *addressNode
+	patternHandlers []patternHandler // Handlers registered for address patterns
+	paramHandlers   []paramHandler   // Handlers registered for address templates
+	catchAllHandler Handler          // Receives every message, registered for "*"
+	defaultHandler  Handler          // Receives messages that no handler matched
+	matchMode       MatchMode
+	strictPatterns  bool // Patterns follow OSC 1.0, i.e. without "//"
+	caseInsensitive bool // See SetCaseInsensitive
+	normalize       bool // See SetNormalizeAddresses
+
+	// typeMismatchHandler receives messages that were rejected by a typed
+	// handler
+	typeMismatchHandler func(msg *Message, want string)
+
+	metrics   *dispatcherMetrics // nil if metrics are disabled
+	metricsMu sync.Mutex         // Protects metrics
+	namespace *Namespace         // Validates messages, if it isn't nil
+
+	gates    []changeGate             // See SetChangeGate
+	gateMu   sync.Mutex               // Protects gateLast
+	gateLast map[string][]interface{} // Arguments of the last gated messages
+}
+
+// MatchMode defines in which direction OSC address patterns are matched by a
+// StandardDispatcher. The modes can be combined.
+type MatchMode int
+
+const (
+	// MatchMessagePattern treats the address of a received message as
+	// pattern that is matched against the addresses of the handlers. This is
+	// the behavior defined by the OSC specification and the default.
+	MatchMessagePattern MatchMode = 1 << iota
+
+	// MatchHandlerPattern allows handlers to be registered for address
+	// patterns, which are matched against the address of a received message.
+	MatchHandlerPattern
+
+	// MatchBoth enables matching in both directions.
+	MatchBoth = MatchMessagePattern | MatchHandlerPattern
+)
+
+// patternHandler is a handler that is registered for an address pattern.
+type patternHandler struct {
+	pattern  *Pattern
+	handler  Handler
+	priority int
+	seq      uint64
 }
 
 // NewStandardDispatcher returns an StandardDispatcher.
 func NewStandardDispatcher() *StandardDispatcher {
-	return &StandardDispatcher{handlers: make(map[string]Handler)}
+	return &StandardDispatcher{handlers: newAddressNode(), matchMode: MatchMessagePattern}
+}
+
+// SetMatchMode sets the direction in which address patterns are matched. The
+// mode must include MatchHandlerPattern before handlers can be added for
+// address patterns.
+func (s *StandardDispatcher) SetMatchMode(mode MatchMode) {
+	s.matchMode = mode
+}
+
+// SetStrictPatterns makes the dispatcher follow the OSC 1.0 specification
+// strictly if strict is true, i.e. the OSC 1.1 operator "//" in address
+// patterns matches an empty address part and not any number of parts. See
+// CompilePatternOSC10.
+func (s *StandardDispatcher) SetStrictPatterns(strict bool) {
+	s.strictPatterns = strict
 }
 
-// AddMsgHandler adds a new message handler for the given OSC address.
+// compilePattern compiles an address pattern according to the pattern
+// options of the dispatcher.
+func (s *StandardDispatcher) compilePattern(addr string) (*Pattern, error) {
+	return pattern.Compile(addr, !s.strictPatterns)
+}
+
+// AddMsgHandler adds a new message handler for the given OSC address. If the
+// match mode includes MatchHandlerPattern, the address may be an OSC address
+// pattern.
 func (s *StandardDispatcher) AddMsgHandler(addr string, handler HandlerFunc) error {
 	if addr == "*" {
-		s.defaultHandler = handler
+		s.catchAllHandler = handler
 		return nil
 	}
-	for _, chr := range "*?,[]{}# " {
-		if strings.Contains(addr, fmt.Sprintf("%c", chr)) {
-			return errors.New("OSC Address string may not contain any characters in \"*?,[]{}#")
+	addr = s.routeAddress(addr)
+	if s.matchMode&MatchHandlerPattern != 0 && pattern.IsPattern(addr) {
+		p, err := s.compilePattern(addr)
+		if err != nil {
+			return err
 		}
+		for _, ph := range s.patternHandlers {
+			if ph.pattern.String() == addr {
+				return errors.New("OSC address exists already")
+			}
+		}
+		s.patternHandlers = append(s.patternHandlers, patternHandler{p, handler, 0, nextHandlerSeq()})
+		return nil
+	}
+	if err := pattern.ValidateAddress(addr); err != nil {
+		return err
 	}
 
-	if addressExists(addr, s.handlers) {
+	if s.handlers.lookup(addr) != nil {
 		return errors.New("OSC address exists already")
 	}
 
-	s.handlers[addr] = handler
+	s.handlers.insert(addr, handler)
 	return nil
 }
 
+// AddTypedHandler adds a new message handler for the given OSC address that
+// only accepts messages with the given type tag string, e.g. "f" or ",fs".
+// Messages with other arguments are passed to the type mismatch handler, see
+// SetTypeMismatchHandler, instead of the handler.
+func (s *StandardDispatcher) AddTypedHandler(addr, typetags string, handler HandlerFunc) error {
+	if !strings.HasPrefix(typetags, ",") {
+		typetags = "," + typetags
+	}
+	return s.AddMsgHandler(addr, func(msg *Message) {
+		if tags, err := msg.TypeTags(); err != nil || tags != typetags {
+			if s.typeMismatchHandler != nil {
+				s.typeMismatchHandler(msg, typetags)
+			}
+			return
+		}
+		handler(msg)
+	})
+}
+
+// SetTypeMismatchHandler sets a function that is called for every message that
+// a typed handler rejected. want describes the expected arguments, it is the
+// type tag string of handlers added with AddTypedHandler and the function
+// signature of handlers added with AddFuncHandler. It can be used to log the
+// message or to reply with an error. Passing nil removes the handler.
+func (s *StandardDispatcher) SetTypeMismatchHandler(handler func(msg *Message, want string)) {
+	s.typeMismatchHandler = handler
+}
+
+// SetNamespace makes the dispatcher validate messages against the namespace
+// before they are dispatched. Messages whose address isn't in the namespace
+// are treated as if no handler matched. Messages with invalid arguments are
+// passed to the type mismatch handler, see SetTypeMismatchHandler, with the
+// validation error as want. Passing nil disables the validation.
+func (s *StandardDispatcher) SetNamespace(ns *Namespace) {
+	s.namespace = ns
+}
+
+// Route mounts the handlers of the dispatcher `sub` under the address prefix
+// `prefix`. A message is dispatched to a handler of sub if its address pattern
+// matches the prefix followed by the address of the handler, e.g. a handler
+// for "/freq" that is mounted under "/synth/1" receives messages for
+// "/synth/1/freq". Handlers that are added to sub later are routed as well.
+// The default and catch-all handlers of sub are not used.
+func (s *StandardDispatcher) Route(prefix string, sub *StandardDispatcher) error {
+	prefix = strings.TrimSuffix(s.routeAddress(prefix), "/")
+	if !strings.HasPrefix(prefix, "/") {
+		return errors.New("OSC address prefix must start with '/'")
+	}
+	if err := pattern.ValidateAddress(prefix); err != nil {
+		return err
+	}
+
+	s.handlers.mount(prefix, sub.handlers)
+	return nil
+}
+
+// SetDefaultHandler sets a handler that is called for every message whose
+// address pattern doesn't match any registered address. Passing nil removes
+// the default handler.
+func (s *StandardDispatcher) SetDefaultHandler(handler Handler) {
+	s.defaultHandler = handler
+}
+
 // Dispatch dispatches OSC packets. Implements the Dispatcher interface.
 func (s *StandardDispatcher) Dispatch(packet Packet) {
+	s.dispatch(packet, nil, nil, false)
+}
+
+// dispatch dispatches the given packet and reports every dispatched message to
+// the trace, if it isn't nil. Messages that match no handler are passed to
+// unmatched, if it isn't nil. If release is set, messages are returned to the
+// message pool after their handlers returned.
+func (s *StandardDispatcher) dispatch(packet Packet, trace *ServerTrace, unmatched func(*Message), release bool) {
 	switch p := packet.(type) {
 	default:
 		return
 
 	case *Message:
-		for addr, handler := range s.handlers {
-			if p.Match(addr) {
-				handler.HandleMessage(p)
-			}
+		if s.changed(p) && s.dispatchMessage(p, trace) == 0 && unmatched != nil {
+			unmatched(p)
 		}
-		if s.defaultHandler != nil {
-			s.defaultHandler.HandleMessage(p)
+		if release {
+			codec.ReleasePacket(p)
 		}
 
 	case *Bundle:
@@ -157,312 +366,96 @@ func (s *StandardDispatcher) Dispatch(packet Packet) {
 		go func() {
 			<-timer.C
 			for _, message := range p.Messages {
-				for address, handler := range s.handlers {
-					if message.Match(address) {
-						handler.HandleMessage(message)
-					}
+				if s.changed(message) && s.dispatchMessage(message, trace) == 0 && unmatched != nil {
+					unmatched(message)
 				}
-				if s.defaultHandler != nil {
-					s.defaultHandler.HandleMessage(message)
+				if release {
+					codec.ReleasePacket(message)
 				}
 			}
 
 			// Process all bundles
 			for _, b := range p.Bundles {
-				s.Dispatch(b)
+				s.dispatch(b, trace, unmatched, release)
 			}
 		}()
 	}
 }
 
-////
-// Message
-////
-
-// NewMessage returns a new Message. The address parameter is the OSC address.
-func NewMessage(addr string, args ...interface{}) *Message {
-	return &Message{Address: addr, Arguments: args}
-}
-
-// Append appends the given arguments to the arguments list.
-func (msg *Message) Append(args ...interface{}) {
-	msg.Arguments = append(msg.Arguments, args...)
+// Invoke dispatches a message with the given address and arguments as if it
+// was received, which allows to simulate incoming messages or to use the
+// handlers for internal events. The handlers are called synchronously. Returns
+// the number of matching handlers, not counting the default and catch-all
+// handlers.
+func (s *StandardDispatcher) Invoke(addr string, args ...interface{}) int {
+	return s.dispatchMessage(NewMessage(addr, args...), nil)
 }
 
-// Equals returns true if the given OSC Message `m` is equal to the current OSC
-// Message. It checks if the OSC address and the arguments are equal. Returns
-// true if the current object and `m` are equal.
-func (msg *Message) Equals(m *Message) bool {
-	return reflect.DeepEqual(msg, m)
-}
-
-// Clear clears the OSC address and all arguments.
-func (msg *Message) Clear() {
-	msg.Address = ""
-	msg.ClearData()
-}
-
-// ClearData removes all arguments from the OSC Message.
-func (msg *Message) ClearData() {
-	msg.Arguments = msg.Arguments[len(msg.Arguments):]
-}
-
-// Match returns true, if the OSC address pattern of the OSC Message matches the given
-// address. The match is case sensitive!
-func (msg *Message) Match(addr string) bool {
-	exp := getRegEx(msg.Address)
-	if exp.MatchString(addr) {
-		return true
-	}
-	return false
-}
-
-// TypeTags returns the type tag string.
-func (msg *Message) TypeTags() (string, error) {
-	if msg == nil {
-		return "", fmt.Errorf("message is nil")
-	}
-
-	tags := ","
-	for _, m := range msg.Arguments {
-		s, err := getTypeTag(m)
-		if err != nil {
-			return "", err
+// dispatchMessage calls all handlers whose address matches the address
+// pattern of msg according to the match mode in order of their priority,
+// followed by the catch-all handler. The default handler is called if no
+// handler matched. Returns the number of matching handlers.
+func (s *StandardDispatcher) dispatchMessage(msg *Message, trace *ServerTrace) int {
+	start := time.Now()
+	var calls []handlerCall
+
+	// Messages that don't conform to the namespace skip the handlers
+	valid, rejected := true, false
+	if s.namespace != nil {
+		if err := s.namespace.Validate(msg); err != nil {
+			valid = false
+			rejected = err != ErrUnknownAddress
+			if rejected && s.typeMismatchHandler != nil {
+				s.typeMismatchHandler(msg, err.Error())
+			}
 		}
-		tags += s
 	}
 
-	return tags, nil
-}
-
-// String implements the fmt.Stringer interface.
-func (msg *Message) String() string {
-	if msg == nil {
-		return ""
-	}
-
-	tags, err := msg.TypeTags()
-	if err != nil {
-		return ""
+	addr := s.routeAddress(msg.Address)
+	var p *Pattern
+	switch {
+	case !valid:
+	case s.matchMode&MatchMessagePattern != 0:
+		p, _ = s.compilePattern(addr)
+	default:
+		p = pattern.Literal(addr)
 	}
-
-	formatString := "%s %s"
-	var args []interface{}
-	args = append(args, msg.Address)
-	args = append(args, tags)
-
-	for _, arg := range msg.Arguments {
-		switch arg.(type) {
-		case bool, int32, int64, float32, float64, string:
-			formatString += " %v"
-			args = append(args, arg)
-
-		case nil:
-			formatString += " %s"
-			args = append(args, "Nil")
-
-		case []byte:
-			formatString += " %s"
-			args = append(args, "blob")
-
-		case Timetag:
-			formatString += " %d"
-			timeTag := arg.(Timetag)
-			args = append(args, timeTag.TimeTag())
+	if p != nil {
+		s.handlers.match(p, func(n *addressNode) {
+			calls = append(calls, handlerCall{priority: n.priority, kind: callAddress, seq: n.seq, handler: n.handler})
+		})
+		for _, ph := range s.paramHandlers {
+			if params, ok := ph.template.match(p); ok {
+				calls = append(calls, handlerCall{priority: ph.priority, kind: callTemplate, seq: ph.seq, param: ph.handler, params: params})
+			}
 		}
 	}
-
-	return fmt.Sprintf(formatString, args...)
-}
-
-// CountArguments returns the number of arguments.
-func (msg *Message) CountArguments() int {
-	return len(msg.Arguments)
-}
-
-// MarshalBinary serializes the OSC message to a byte buffer. The byte buffer
-// has the following format:
-// 1. OSC Address Pattern
-// 2. OSC Type Tag String
-// 3. OSC Arguments
-func (msg *Message) MarshalBinary() ([]byte, error) {
-	// We can start with the OSC address and add it to the buffer
-	data := new(bytes.Buffer)
-	if _, err := writePaddedString(msg.Address, data); err != nil {
-		return nil, err
-	}
-
-	// Type tag string starts with ","
-	typetags := []byte{','}
-
-	// Process the type tags and collect all arguments
-	payload := new(bytes.Buffer)
-	for _, arg := range msg.Arguments {
-		// FIXME: Use t instead of arg
-		switch t := arg.(type) {
-		default:
-			return nil, fmt.Errorf("OSC - unsupported type: %T", t)
-
-		case bool:
-			if arg.(bool) == true {
-				typetags = append(typetags, 'T')
-			} else {
-				typetags = append(typetags, 'F')
-			}
-
-		case nil:
-			typetags = append(typetags, 'N')
-
-		case int32:
-			typetags = append(typetags, 'i')
-			if err := binary.Write(payload, binary.BigEndian, int32(t)); err != nil {
-				return nil, err
-			}
-
-		case float32:
-			typetags = append(typetags, 'f')
-			if err := binary.Write(payload, binary.BigEndian, float32(t)); err != nil {
-				return nil, err
-			}
-
-		case string:
-			typetags = append(typetags, 's')
-			if _, err := writePaddedString(t, payload); err != nil {
-				return nil, err
-			}
-
-		case []byte:
-			typetags = append(typetags, 'b')
-			if _, err := writeBlob(t, payload); err != nil {
-				return nil, err
-			}
-
-		case int64:
-			typetags = append(typetags, 'h')
-			if err := binary.Write(payload, binary.BigEndian, int64(t)); err != nil {
-				return nil, err
-			}
-
-		case float64:
-			typetags = append(typetags, 'd')
-			if err := binary.Write(payload, binary.BigEndian, float64(t)); err != nil {
-				return nil, err
-			}
-
-		case Timetag:
-			typetags = append(typetags, 't')
-			timeTag := arg.(Timetag)
-			b, err := timeTag.MarshalBinary()
-			if err != nil {
-				return nil, err
-			}
-			if _, err = payload.Write(b); err != nil {
-				return nil, err
+	if s.matchMode&MatchHandlerPattern != 0 && valid {
+		for _, ph := range s.patternHandlers {
+			if ph.pattern.Match(addr) {
+				calls = append(calls, handlerCall{priority: ph.priority, kind: callPattern, seq: ph.seq, handler: ph.handler})
 			}
 		}
 	}
-
-	// Write the type tag string to the data buffer
-	if _, err := writePaddedString(string(typetags), data); err != nil {
-		return nil, err
+	sortCalls(calls)
+	for i := range calls {
+		calls[i].call(msg)
 	}
-
-	// Write the payload (OSC arguments) to the data buffer
-	if _, err := data.Write(payload.Bytes()); err != nil {
-		return nil, err
+	matched := len(calls)
+	if matched == 0 && !rejected && s.defaultHandler != nil {
+		s.defaultHandler.HandleMessage(msg)
 	}
-
-	return data.Bytes(), nil
-}
-
-////
-// Bundle
-////
-
-// NewBundle returns an OSC Bundle. Use this function to create a new OSC
-// Bundle.
-func NewBundle(time time.Time) *Bundle {
-	return &Bundle{Timetag: *NewTimetag(time)}
-}
-
-// Append appends an OSC bundle or OSC message to the bundle.
-func (b *Bundle) Append(pck Packet) error {
-	switch t := pck.(type) {
-	default:
-		return fmt.Errorf("unsupported OSC packet type: only Bundle and Message are supported")
-
-	case *Bundle:
-		b.Bundles = append(b.Bundles, t)
-
-	case *Message:
-		b.Messages = append(b.Messages, t)
-	}
-
-	return nil
-}
-
-// MarshalBinary serializes the OSC bundle to a byte array with the following
-// format:
-// 1. Bundle string: '#bundle'
-// 2. OSC timetag
-// 3. Length of first OSC bundle element
-// 4. First bundle element
-// 5. Length of n OSC bundle element
-// 6. n bundle element
-func (b *Bundle) MarshalBinary() ([]byte, error) {
-	// Add the '#bundle' string
-	data := new(bytes.Buffer)
-	if _, err := writePaddedString("#bundle", data); err != nil {
-		return nil, err
-	}
-
-	// Add the time tag
-	bd, err := b.Timetag.MarshalBinary()
-	if err != nil {
-		return nil, err
-	}
-	if _, err = data.Write(bd); err != nil {
-		return nil, err
+	if s.catchAllHandler != nil {
+		s.catchAllHandler.HandleMessage(msg)
 	}
 
-	// Process all OSC Messages
-	for _, m := range b.Messages {
-		buf, err := m.MarshalBinary()
-		if err != nil {
-			return nil, err
-		}
-
-		// Append the length of the OSC message
-		if err = binary.Write(data, binary.BigEndian, int32(len(buf))); err != nil {
-			return nil, err
-		}
-
-		// Append the OSC message
-		if _, err = data.Write(buf); err != nil {
-			return nil, err
-		}
+	if m := s.loadMetrics(); m != nil {
+		m.record(msg, matched)
 	}
-
-	// Process all OSC Bundles
-	for _, b := range b.Bundles {
-		buf, err := b.MarshalBinary()
-		if err != nil {
-			return nil, err
-		}
-
-		// Write the size of the bundle
-		if err = binary.Write(data, binary.BigEndian, int32(len(buf))); err != nil {
-			return nil, err
-		}
-
-		// Append the bundle
-		_, err = data.Write(buf)
-		if err != nil {
-			return nil, err
-		}
+	if trace != nil && trace.OnMessageDispatched != nil {
+		trace.OnMessageDispatched(msg.Address, matched, time.Since(start))
 	}
-
-	return data.Bytes(), nil
+	return matched
 }
 
 ////
@@ -472,11 +465,28 @@ func (b *Bundle) MarshalBinary() ([]byte, error) {
 // NewClient creates a new OSC client. The Client is used to send OSC
 // messages and OSC bundles over an UDP network connection. The `ip` argument
 // specifies the IP address and `port` defines the target port where the
-// messages and bundles will be send to.
+// messages and bundles will be send to. IPv6 addresses are given without
+// brackets and may have a zone, e.g. "fe80::1%eth0".
 func NewClient(ip string, port int) *Client {
 	return &Client{ip: ip, port: port, laddr: nil}
 }
 
+// NewClientFromConn creates a new OSC client that sends all packets through
+// the given connection, e.g. a connected UDP socket that is shared with a
+// receiver or a tunneled connection. Every packet is written with a single
+// call to the Write method of conn. The client doesn't close conn.
+func NewClientFromConn(conn net.Conn) *Client {
+	c := &Client{conn: conn}
+	if addr, ok := conn.RemoteAddr().(*net.UDPAddr); ok {
+		c.ip = addr.IP.String()
+		if addr.Zone != "" {
+			c.ip += "%" + addr.Zone
+		}
+		c.port = addr.Port
+	}
+	return c
+}
+
 // IP returns the IP address.
 func (c *Client) IP() string { return c.ip }
 
@@ -489,9 +499,29 @@ func (c *Client) Port() int { return c.port }
 // SetPort sets a new port.
 func (c *Client) SetPort(port int) { c.port = port }
 
+// Network returns the network of the client, "udp", "udp4" or "udp6".
+func (c *Client) Network() string {
+	if c.network == "" {
+		return "udp"
+	}
+	return c.network
+}
+
+// SetNetwork selects whether host names are resolved to IPv4 and IPv6
+// addresses ("udp"), which is the default, or only to IPv4 ("udp4") or IPv6
+// ("udp6") addresses.
+func (c *Client) SetNetwork(network string) error {
+	switch network {
+	case "udp", "udp4", "udp6":
+		c.network = network
+		return nil
+	}
+	return fmt.Errorf("osc: unsupported network %q", network)
+}
+
 // SetLocalAddr sets the local address.
 func (c *Client) SetLocalAddr(ip string, port int) error {
-	laddr, err := net.ResolveUDPAddr("udp", fmt.Sprintf("%s:%d", ip, port))
+	laddr, err := net.ResolveUDPAddr(c.Network(), net.JoinHostPort(ip, strconv.Itoa(port)))
 	if err != nil {
 		return err
 	}
@@ -499,27 +529,153 @@ func (c *Client) SetLocalAddr(ip string, port int) error {
 	return nil
 }
 
-// Send sends an OSC Bundle or an OSC Message.
-func (c *Client) Send(packet Packet) error {
-	addr, err := net.ResolveUDPAddr("udp", fmt.Sprintf("%s:%d", c.ip, c.port))
-	if err != nil {
-		return err
+// WriteTimeout returns the write timeout.
+func (c *Client) WriteTimeout() time.Duration { return c.writeTimeout }
+
+// SetWriteTimeout sets the maximum duration that sending a packet may take.
+// A zero value disables the timeout.
+func (c *Client) SetWriteTimeout(timeout time.Duration) { c.writeTimeout = timeout }
+
+// AddSendHook adds a hook that is called with every packet before it is
+// sent. Hooks are called in the order they were added, each with the packet
+// returned by the previous hook. Hooks must be added before packets are sent.
+func (c *Client) AddSendHook(hook SendHook) {
+	c.hooks = append(c.hooks, hook)
+}
+
+// encode calls the send hooks and returns the resulting packet and its
+// encoding. Returns nil data if a hook dropped the packet.
+func (c *Client) encode(packet Packet) (Packet, []byte, error) {
+	for _, hook := range c.hooks {
+		var err error
+		if packet, err = hook(packet); err != nil {
+			return nil, nil, err
+		}
+		if packet == nil {
+			return nil, nil, nil
+		}
 	}
-	conn, err := net.DialUDP("udp", c.laddr, addr)
+	data, err := packet.MarshalBinary()
+	return packet, data, err
+}
+
+// Send sends an OSC Bundle or an OSC Message. It returns the number of bytes
+// that were sent, which is 0 if a send hook dropped the packet. Bundles that
+// are larger than the size set with SetMaxPacketSize are split.
+func (c *Client) Send(packet Packet) (int, error) {
+	encoded, data, err := c.encode(packet)
 	if err != nil {
-		return err
+		c.sendFailed(packet, err)
+		return 0, err
 	}
-	defer conn.Close()
+	if data == nil {
+		c.statsMu.Lock()
+		c.stats.Dropped++
+		c.statsMu.Unlock()
+		return 0, nil
+	}
+	if b, ok := encoded.(*Bundle); ok && c.MaxPacketSize() > 0 && len(data) > c.MaxPacketSize() {
+		return c.sendSplit(packet, b)
+	}
+	return c.sendData(packet, data)
+}
 
-	data, err := packet.MarshalBinary()
+// sendData writes the encoded packet and updates the statistics.
+func (c *Client) sendData(packet Packet, data []byte) (int, error) {
+	n, err := c.write(data)
 	if err != nil {
-		return err
+		c.sendFailed(packet, err)
+		return n, err
 	}
+	c.statsMu.Lock()
+	c.stats.Packets++
+	c.stats.Bytes += uint64(n)
+	c.stats.LastSend = time.Now()
+	c.statsMu.Unlock()
+	return n, nil
+}
 
-	if _, err = conn.Write(data); err != nil {
-		return err
+// write writes the encoded packet to the connection of the client, or to a
+// new connection if the client has none.
+func (c *Client) write(data []byte) (int, error) {
+	conn := c.conn
+	if conn == nil {
+		udpConn, err := c.dial()
+		if err != nil {
+			return 0, err
+		}
+		defer udpConn.Close()
+		conn = udpConn
 	}
-	return nil
+
+	if c.writeTimeout != 0 {
+		if err := conn.SetWriteDeadline(time.Now().Add(c.writeTimeout)); err != nil {
+			return 0, err
+		}
+	}
+	return conn.Write(data)
+}
+
+// sendFailed records the error of sending packet and calls the send error
+// handler.
+func (c *Client) sendFailed(packet Packet, err error) {
+	c.statsMu.Lock()
+	c.stats.Errors++
+	c.stats.LastError = err
+	c.stats.LastErrorTime = time.Now()
+	handler := c.onSendError
+	c.statsMu.Unlock()
+	if handler != nil {
+		handler(packet, err)
+	}
+}
+
+// ClientStats are the statistics of the packets sent by a Client.
+type ClientStats struct {
+	Packets       uint64    // Number of sent packets
+	Bytes         uint64    // Number of sent bytes
+	Dropped       uint64    // Number of packets dropped by send hooks
+	Errors        uint64    // Number of packets that couldn't be sent
+	LastError     error     // Error of the last packet that couldn't be sent
+	LastErrorTime time.Time // Time of LastError
+	LastSend      time.Time // Time the last packet was sent successfully
+}
+
+// Stats returns the statistics of the packets sent with Send, e.g. to show
+// the health of the link to the target in a user interface.
+func (c *Client) Stats() ClientStats {
+	c.statsMu.Lock()
+	defer c.statsMu.Unlock()
+	return c.stats
+}
+
+// OnSendError sets a function that is called with every packet that Send
+// failed to send and the error. Passing nil removes the function.
+func (c *Client) OnSendError(handler func(packet Packet, err error)) {
+	c.statsMu.Lock()
+	c.onSendError = handler
+	c.statsMu.Unlock()
+}
+
+// Emit builds a message with the given address and arguments and sends it,
+// e.g. client.Emit("/synth/freq", 440.0). The arguments are converted with
+// the CoerceNative32 policy, i.e. Go ints become int32 or int64 and floats
+// become float32.
+func (c *Client) Emit(addr string, args ...interface{}) error {
+	msg := NewMessage(addr)
+	msg.SetCoercion(CoerceNative32)
+	msg.Append(args...)
+	_, err := c.Send(msg)
+	return err
+}
+
+// dial opens a UDP connection to the address of the client.
+func (c *Client) dial() (*net.UDPConn, error) {
+	addr, err := net.ResolveUDPAddr(c.Network(), net.JoinHostPort(c.ip, strconv.Itoa(c.port)))
+	if err != nil {
+		return nil, err
+	}
+	return net.DialUDP(c.Network(), c.laddr, addr)
 }
 
 ////
@@ -533,7 +689,7 @@ func (s *Server) ListenAndServe() error {
 		s.Dispatcher = NewStandardDispatcher()
 	}
 
-	ln, err := net.ListenPacket("udp", s.Addr)
+	ln, _, err := s.ListenConfig.Listen(s.Addr)
 	if err != nil {
 		return err
 	}
@@ -542,14 +698,133 @@ func (s *Server) ListenAndServe() error {
 	return s.Serve(ln)
 }
 
+// Start listens on Addr and serves in a background goroutine. It returns
+// after the connection was bound, which makes it suitable for applications
+// that can't block, e.g. GUIs. The server can be stopped with Stop, Err
+// returns the error that stopped it.
+func (s *Server) Start() error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if s.done != nil {
+		select {
+		case <-s.done: // Stopped because of an error
+		default:
+			return errors.New("osc: server already started")
+		}
+	}
+	if s.Dispatcher == nil {
+		s.Dispatcher = NewStandardDispatcher()
+	}
+
+	ln, _, err := s.ListenConfig.Listen(s.Addr)
+	if err != nil {
+		return err
+	}
+	done := make(chan struct{})
+	s.listener, s.done, s.stopped, s.err = ln, done, false, nil
+	s.conn = ln // Serve sets it as well, LocalAddr works right away
+	s.log(LogInfo, "osc: server started", "addr", ln.LocalAddr())
+	go func() {
+		defer close(done)
+		err := s.Serve(ln)
+		ln.Close()
+		s.mu.Lock()
+		if !s.stopped {
+			s.err = err
+		}
+		s.mu.Unlock()
+	}()
+	return nil
+}
+
+// Stop stops the server that was started by Start and waits until the
+// running handlers returned or ctx is done, in which case the error of ctx is
+// returned. Bundles that are scheduled for the future aren't waited for. The
+// server can be started again afterwards.
+func (s *Server) Stop(ctx context.Context) error {
+	s.mu.Lock()
+	ln, done := s.listener, s.done
+	if done == nil {
+		s.mu.Unlock()
+		return nil
+	}
+	s.stopped = true
+	s.mu.Unlock()
+
+	ln.Close()
+	select {
+	case <-done:
+	case <-ctx.Done():
+		return ctx.Err()
+	}
+
+	handled := make(chan struct{})
+	go func() {
+		s.inflight.Wait()
+		close(handled)
+	}()
+	select {
+	case <-handled:
+	case <-ctx.Done():
+		return ctx.Err()
+	}
+
+	s.mu.Lock()
+	if s.done == done {
+		s.listener, s.done = nil, nil
+	}
+	s.mu.Unlock()
+	return nil
+}
+
+// Err returns the error that stopped the server that was started by Start. It
+// returns nil while the server is running and if it was stopped by Stop.
+func (s *Server) Err() error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return s.err
+}
+
+// LocalAddr returns the local address of the connection that the server is
+// serving, or nil if the server isn't serving.
+func (s *Server) LocalAddr() net.Addr {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if s.conn == nil {
+		return nil
+	}
+	return s.conn.LocalAddr()
+}
+
 // Serve retrieves incoming OSC packets from the given connection and dispatches
-// retrieved OSC packets. If something goes wrong an error is returned.
+// retrieved OSC packets. The connection can be bound by the caller, e.g. to
+// use socket activation.
+//
+// Packets that can't be decoded are skipped and reported to the
+// OnDecodeError hook of Trace. If ReadTimeout is set, the deadline is re-armed
+// for every read and reads that time out are retried. Temporary errors are
+// retried with an increasing delay. Serve returns the first error that can't
+// be retried, e.g. because the connection was closed.
 func (s *Server) Serve(c net.PacketConn) error {
+	s.mu.Lock()
+	s.conn = c
+	s.mu.Unlock()
+	defer func() {
+		s.mu.Lock()
+		s.conn = nil
+		s.mu.Unlock()
+	}()
+
 	var tempDelay time.Duration
 	for {
-		msg, err := s.readFromConnection(c)
+		msg, info, decodeErr, err := s.receive(c)
 		if err != nil {
-			if ne, ok := err.(net.Error); ok && ne.Temporary() {
+			ne, ok := err.(net.Error)
+			if ok && ne.Timeout() {
+				tempDelay = 0
+				continue
+			}
+			if ok && ne.Temporary() {
 				if tempDelay == 0 {
 					tempDelay = 5 * time.Millisecond
 				} else {
@@ -558,514 +833,221 @@ func (s *Server) Serve(c net.PacketConn) error {
 				if max := 1 * time.Second; tempDelay > max {
 					tempDelay = max
 				}
+				s.log(LogWarn, "osc: read error, retrying", "error", err, "delay", tempDelay)
 				time.Sleep(tempDelay)
 				continue
 			}
+			s.mu.Lock()
+			stopped := s.stopped
+			s.mu.Unlock()
+			if stopped || err == io.EOF { // See ServeReader
+				s.log(LogInfo, "osc: server stopped")
+			} else {
+				s.log(LogError, "osc: server failed", "error", err)
+			}
 			return err
 		}
 		tempDelay = 0
-		go s.Dispatcher.Dispatch(msg)
-	}
-}
-
-// ReceivePacket listens for incoming OSC packets and returns the packet if one is received.
-func (s *Server) ReceivePacket(c net.PacketConn) (Packet, error) {
-	return s.readFromConnection(c)
-}
-
-// readFromConnection retrieves OSC packets.
-func (s *Server) readFromConnection(c net.PacketConn) (Packet, error) {
-	if s.ReadTimeout != 0 {
-		if err := c.SetReadDeadline(time.Now().Add(s.ReadTimeout)); err != nil {
-			return nil, err
+		if decodeErr != nil {
+			continue
+		}
+		if ch := s.packetsChan(); ch != nil {
+			s.deliver(ch, msg, info)
+			continue
 		}
+		s.inflight.Add(1)
+		go func() {
+			defer s.inflight.Done()
+			s.dispatch(msg)
+		}()
 	}
+}
 
-	data := make([]byte, 65535)
-	n, _, err := c.ReadFrom(data)
-	if err != nil {
-		return nil, err
+// dispatch passes the packet through the receive pipeline to the dispatcher of
+// the server.
+func (s *Server) dispatch(packet Packet) {
+	if packet = s.prepare(packet); packet == nil {
+		return
 	}
-
-	var start int
-	p, err := readPacket(bufio.NewReader(bytes.NewBuffer(data)), &start, n)
-	if err != nil {
-		return nil, err
+	if d, ok := s.Dispatcher.(*StandardDispatcher); ok {
+		d.dispatch(packet, s.dispatchTrace(), s.unmatchedFunc(), s.ReuseMessages)
+		return
 	}
-	return p, nil
-}
-
-// ParsePacket parses the given msg string and returns a Packet
-func ParsePacket(msg string) (Packet, error) {
-	var start int
-	p, err := readPacket(bufio.NewReader(bytes.NewBufferString(msg)), &start, len(msg))
-	if err != nil {
-		return nil, err
+	s.Dispatcher.Dispatch(packet)
+	if s.ReuseMessages {
+		codec.ReleasePacket(packet)
 	}
-	return p, nil
 }
 
-// receivePacket receives an OSC packet from the given reader.
-func readPacket(reader *bufio.Reader, start *int, end int) (Packet, error) {
-	//var buf []byte
-	buf, err := reader.Peek(1)
-	if err != nil {
-		return nil, err
-	}
-
-	// An OSC Message starts with a '/'
-	if buf[0] == '/' {
-		packet, err := readMessage(reader, start)
-		if err != nil {
-			return nil, err
+// prepare passes the packet through the receive pipeline and records it in
+// the State of the server. Returns nil if the pipeline dropped the packet.
+func (s *Server) prepare(packet Packet) Packet {
+	if packet = s.runStages(packet); packet == nil {
+		if len(s.stages) > 0 {
+			s.log(LogDebug, "osc: message dropped by the receive pipeline")
 		}
-		return packet, nil
+		return nil
 	}
-	if buf[0] == '#' { // An OSC bundle starts with a '#'
-		packet, err := readBundle(reader, start, end)
-		if err != nil {
-			return nil, err
-		}
-		return packet, nil
+	if s.State != nil {
+		s.State.record(packet)
 	}
-
-	var p Packet
-	return p, nil
+	return packet
 }
 
-// readBundle reads an Bundle from reader.
-func readBundle(reader *bufio.Reader, start *int, end int) (*Bundle, error) {
-	// Read the '#bundle' OSC string
-	startTag, n, err := readPaddedString(reader)
-	if err != nil {
-		return nil, err
-	}
-	*start += n
-
-	if startTag != bundleTagString {
-		return nil, fmt.Errorf("Invalid bundle start tag: %s", startTag)
-	}
-
-	// Read the timetag
-	var timeTag uint64
-	if err := binary.Read(reader, binary.BigEndian, &timeTag); err != nil {
-		return nil, err
-	}
-	*start += 8
-
-	// Create a new bundle
-	bundle := NewBundle(timetagToTime(timeTag))
-
-	// Read until the end of the buffer
-	for *start < end {
-		// Read the size of the bundle element
-		var length int32
-		if err := binary.Read(reader, binary.BigEndian, &length); err != nil {
-			return nil, err
-		}
-		*start += 4
-
-		p, err := readPacket(reader, start, end)
-		if err != nil {
-			return nil, err
-		}
-		if err = bundle.Append(p); err != nil {
-			return nil, err
+// DefaultUnmatchedBuffer is the capacity of the Unmatched channel if
+// Server.UnmatchedBuffer is zero.
+const DefaultUnmatchedBuffer = 64
+
+// Unmatched returns a channel that receives copies of the messages that
+// matched no handler, e.g. to display unexpected traffic in a monitor. The
+// default handler is still called for these messages. If the channel is full,
+// the oldest message is dropped. Messages are only reported if the Dispatcher
+// is a StandardDispatcher and only after Unmatched was called the first time.
+func (s *Server) Unmatched() <-chan *Message {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if s.unmatched == nil {
+		n := s.UnmatchedBuffer
+		if n <= 0 {
+			n = DefaultUnmatchedBuffer
 		}
+		s.unmatched = make(chan *Message, n)
 	}
-
-	return bundle, nil
-}
-
-// readMessage from `reader`.
-func readMessage(reader *bufio.Reader, start *int) (*Message, error) {
-	// First, read the OSC address
-	addr, n, err := readPaddedString(reader)
-	if err != nil {
-		return nil, err
-	}
-	*start += n
-
-	// Read all arguments
-	msg := NewMessage(addr)
-	if err = readArguments(msg, reader, start); err != nil {
-		return nil, err
-	}
-
-	return msg, nil
+	return s.unmatched
 }
 
-// readArguments from `reader` and add them to the OSC message `msg`.
-func readArguments(msg *Message, reader *bufio.Reader, start *int) error {
-	// Read the type tag string
-	var n int
-	typetags, n, err := readPaddedString(reader)
-	if err != nil {
-		return err
-	}
-	*start += n
-
-	// If the typetag doesn't start with ',', it's not valid
-	if typetags[0] != ',' {
-		return errors.New("unsupported type tag string")
+// unmatchedFunc returns the function that passes unmatched messages to the
+// Unmatched channel, or nil if the channel wasn't requested.
+func (s *Server) unmatchedFunc() func(*Message) {
+	s.mu.Lock()
+	ch := s.unmatched
+	s.mu.Unlock()
+	if ch == nil {
+		return nil
 	}
-
-	// Remove ',' from the type tag
-	typetags = typetags[1:]
-
-	for _, c := range typetags {
-		switch c {
-		default:
-			return fmt.Errorf("unsupported type tag: %c", c)
-
-		case 'i': // int32
-			var i int32
-			if err = binary.Read(reader, binary.BigEndian, &i); err != nil {
-				return err
-			}
-			*start += 4
-			msg.Append(i)
-
-		case 'h': // int64
-			var i int64
-			if err = binary.Read(reader, binary.BigEndian, &i); err != nil {
-				return err
+	return func(msg *Message) {
+		msg = msg.Clone()
+		s.unmatchedMu.Lock()
+		defer s.unmatchedMu.Unlock()
+		for {
+			select {
+			case ch <- msg:
+				return
+			default:
 			}
-			*start += 8
-			msg.Append(i)
-
-		case 'f': // float32
-			var f float32
-			if err = binary.Read(reader, binary.BigEndian, &f); err != nil {
-				return err
-			}
-			*start += 4
-			msg.Append(f)
-
-		case 'd': // float64/double
-			var d float64
-			if err = binary.Read(reader, binary.BigEndian, &d); err != nil {
-				return err
+			// Drop the oldest message to make room
+			select {
+			case <-ch:
+			default:
 			}
-			*start += 8
-			msg.Append(d)
-
-		case 's': // string
-			// TODO: fix reading string value
-			var s string
-			if s, _, err = readPaddedString(reader); err != nil {
-				return err
-			}
-			*start += len(s) + padBytesNeeded(len(s))
-			msg.Append(s)
-
-		case 'b': // blob
-			var buf []byte
-			var n int
-			if buf, n, err = readBlob(reader); err != nil {
-				return err
-			}
-			*start += n
-			msg.Append(buf)
-
-		case 't': // OSC time tag
-			var tt uint64
-			if err = binary.Read(reader, binary.BigEndian, &tt); err != nil {
-				return nil
-			}
-			*start += 8
-			msg.Append(NewTimetagFromTimetag(tt))
-
-		case 'N': // nil
-			msg.Append(nil)
-
-		case 'T': // true
-			msg.Append(true)
-
-		case 'F': // false
-			msg.Append(false)
 		}
 	}
-
-	return nil
 }
 
-////
-// Timetag
-////
-
-// NewTimetag returns a new OSC time tag object.
-func NewTimetag(timeStamp time.Time) *Timetag {
-	return &Timetag{
-		time:     timeStamp,
-		timeTag:  timeToTimetag(timeStamp),
-		MinValue: uint64(1)}
-}
-
-// NewTimetagFromTimetag creates a new Timetag from the given `timetag`.
-func NewTimetagFromTimetag(timetag uint64) *Timetag {
-	time := timetagToTime(timetag)
-	return NewTimetag(time)
-}
-
-// Time returns the time.
-func (t *Timetag) Time() time.Time {
-	return t.time
-}
-
-// FractionalSecond returns the last 32 bits of the OSC time tag. Specifies the
-// fractional part of a second.
-func (t *Timetag) FractionalSecond() uint32 {
-	return uint32(t.timeTag << 32)
-}
-
-// SecondsSinceEpoch returns the first 32 bits (the number of seconds since the
-// midnight 1900) from the OSC time tag.
-func (t *Timetag) SecondsSinceEpoch() uint32 {
-	return uint32(t.timeTag >> 32)
-}
-
-// TimeTag returns the time tag value
-func (t *Timetag) TimeTag() uint64 {
-	return t.timeTag
-}
-
-// MarshalBinary converts the OSC time tag to a byte array.
-func (t *Timetag) MarshalBinary() ([]byte, error) {
-	data := new(bytes.Buffer)
-	if err := binary.Write(data, binary.BigEndian, t.timeTag); err != nil {
-		return []byte{}, err
-	}
-	return data.Bytes(), nil
-}
-
-// SetTime sets the value of the OSC time tag.
-func (t *Timetag) SetTime(time time.Time) {
-	t.time = time
-	t.timeTag = timeToTimetag(time)
+// ReceivePacket listens for incoming OSC packets and returns the packet if one is received.
+func (s *Server) ReceivePacket(c net.PacketConn) (Packet, error) {
+	return s.readFromConnection(c)
 }
 
-// ExpiresIn calculates the number of seconds until the current time is the
-// same as the value of the time tag. It returns zero if the value of the
-// time tag is in the past.
-func (t *Timetag) ExpiresIn() time.Duration {
-	if t.timeTag <= 1 {
-		return 0
+// readFromConnection retrieves OSC packets.
+func (s *Server) readFromConnection(c net.PacketConn) (Packet, error) {
+	p, _, decodeErr, err := s.receive(c)
+	if err != nil {
+		return nil, err
 	}
-
-	tt := timetagToTime(t.timeTag)
-	seconds := tt.Sub(time.Now())
-
-	if seconds <= 0 {
-		return 0
+	if decodeErr != nil {
+		return nil, decodeErr
 	}
-
-	return seconds
-}
-
-// timeToTimetag converts the given time to an OSC time tag.
-//
-// An OSC time tag is defined as follows:
-// Time tags are represented by a 64 bit fixed point number. The first 32 bits
-// specify the number of seconds since midnight on January 1, 1900, and the
-// last 32 bits specify fractional parts of a second to a precision of about
-// 200 picoseconds. This is the representation used by Internet NTP timestamps.
-//
-// The time tag value consisting of 63 zero bits followed by a one in the least
-// significant bit is a special case meaning "immediately."
-func timeToTimetag(time time.Time) (timetag uint64) {
-	timetag = uint64((secondsFrom1900To1970 + time.Unix()) << 32)
-	return (timetag + uint64(uint32(time.Nanosecond())))
-}
-
-// timetagToTime converts the given timetag to a time object.
-func timetagToTime(timetag uint64) (t time.Time) {
-	return time.Unix(int64((timetag>>32)-secondsFrom1900To1970), int64(timetag&0xffffffff))
+	return p, nil
 }
 
-////
-// De/Encoding functions
-////
-
-// readBlob reads an OSC blob from the blob byte array. Padding bytes are
-// removed from the reader and not returned.
-func readBlob(reader *bufio.Reader) ([]byte, int, error) {
-	// First, get the length
-	var blobLen int32
-	if err := binary.Read(reader, binary.BigEndian, &blobLen); err != nil {
-		return nil, 0, err
-	}
-	n := 4 + int(blobLen)
-
-	// Read the data
-	blob := make([]byte, blobLen)
-	if _, err := reader.Read(blob); err != nil {
-		return nil, 0, err
-	}
-
-	// Remove the padding bytes
-	numPadBytes := padBytesNeeded(int(blobLen))
-	if numPadBytes > 0 {
-		n += numPadBytes
-		dummy := make([]byte, numPadBytes)
-		if _, err := reader.Read(dummy); err != nil {
-			return nil, 0, err
+// receive reads one OSC packet from the connection and returns it with the
+// info that its messages share. err is the error of reading from the
+// connection and decodeErr the error of decoding the received data, which
+// only affects this packet.
+func (s *Server) receive(c net.PacketConn) (p Packet, info *MessageInfo, decodeErr, err error) {
+	if s.ReadTimeout != 0 {
+		if err := c.SetReadDeadline(time.Now().Add(s.ReadTimeout)); err != nil {
+			return nil, nil, nil, err
 		}
 	}
 
-	return blob, n, nil
-}
-
-// writeBlob writes the data byte array as an OSC blob into buff. If the length
-// of data isn't 32-bit aligned, padding bytes will be added.
-func writeBlob(data []byte, buf *bytes.Buffer) (int, error) {
-	// Add the size of the blob
-	dlen := int32(len(data))
-	if err := binary.Write(buf, binary.BigEndian, dlen); err != nil {
-		return 0, err
+	var data []byte
+	if s.ReuseMessages {
+		buf := bufferPool.Get().(*[]byte)
+		defer bufferPool.Put(buf)
+		data = *buf
+	} else {
+		data = make([]byte, receiveBufferSize)
 	}
-
-	// Write the data
-	if _, err := buf.Write(data); err != nil {
-		return 0, nil
+	n, addr, err := c.ReadFrom(data)
+	if err != nil {
+		return nil, nil, nil, err
 	}
+	received := time.Now()
 
-	// Add padding bytes if necessary
-	numPadBytes := padBytesNeeded(len(data))
-	if numPadBytes > 0 {
-		padBytes := make([]byte, numPadBytes)
-		n, err := buf.Write(padBytes)
-		if err != nil {
-			return 0, err
+	d := Decoder{Options: s.DecodeOptions, ReuseMessages: s.ReuseMessages}
+	if s.ArgumentByteOrder != nil {
+		if order := s.ArgumentByteOrder(addr); order != nil {
+			d.Options.ArgumentByteOrder = order
 		}
-		numPadBytes = n
 	}
-
-	return 4 + len(data) + numPadBytes, nil
-}
-
-// readPaddedString reads a padded string from the given reader. The padding
-// bytes are removed from the reader.
-func readPaddedString(reader *bufio.Reader) (string, int, error) {
-	// Read the string from the reader
-	str, err := reader.ReadString(0)
-	if err != nil {
-		return "", 0, err
-	}
-	n := len(str)
-
-	// Remove the string delimiter, in order to calculate the right amount
-	// of padding bytes
-	str = str[:len(str)-1]
-
-	// Remove the padding bytes
-	padLen := padBytesNeeded(len(str)) - 1
-	if padLen > 0 {
-		n += padLen
-		padBytes := make([]byte, padLen)
-		if _, err = reader.Read(padBytes); err != nil {
-			return "", 0, err
+	p, err = d.DecodeBytes(data[:n])
+	if err == nil && s.AuthKey != nil {
+		if key := s.AuthKey(addr); key == nil || Verify(p, key) != nil {
+			if s.ReuseMessages {
+				codec.ReleasePacket(p)
+			}
+			p, err = nil, ErrUnauthenticated
 		}
 	}
-
-	return str, n, nil
-}
-
-// writePaddedString writes a string with padding bytes to the a buffer.
-// Returns, the number of written bytes and an error if any.
-func writePaddedString(str string, buf *bytes.Buffer) (int, error) {
-	// Write the string to the buffer
-	n, err := buf.WriteString(str)
 	if err != nil {
-		return 0, err
+		if s.Trace != nil && s.Trace.OnDecodeError != nil {
+			s.Trace.OnDecodeError(data[:n], addr, err)
+		}
+		s.log(LogWarn, "osc: invalid packet", "from", addr, "size", n, "error", err)
+		return nil, nil, err, nil
 	}
-
-	// Calculate the padding bytes needed and create a buffer for the padding bytes
-	numPadBytes := padBytesNeeded(len(str))
-	if numPadBytes > 0 {
-		padBytes := make([]byte, numPadBytes)
-		// Add the padding bytes to the buffer
-		n, err := buf.Write(padBytes)
-		if err != nil {
-			return 0, err
+	if s.Acknowledge && s.Sequence != nil {
+		if seq, ok := firstSequence(p); ok {
+			s.acknowledge(c, addr, seq)
 		}
-		numPadBytes = n
 	}
-
-	return n + numPadBytes, nil
-}
-
-// padBytesNeeded determines how many bytes are needed to fill up to the next 4
-// byte length.
-func padBytesNeeded(elementLen int) int {
-	return 4*(elementLen/4+1) - elementLen
-}
-
-////
-// Utility and helper functions
-////
-
-// PrintMessage pretty prints an OSC message to the standard output.
-func PrintMessage(msg *Message) {
-	fmt.Println(msg)
-}
-
-// addressExists returns true if the OSC address `addr` is found in `handlers`.
-func addressExists(addr string, handlers map[string]Handler) bool {
-	for h := range handlers {
-		if h == addr {
-			return true
+	if s.Sequence != nil && !s.Sequence.Check(addr, p) {
+		s.log(LogDebug, "osc: duplicate packet discarded", "from", addr)
+		return nil, nil, ErrDuplicatePacket, nil
+	}
+	if s.MaxBundleAge > 0 {
+		var stale int
+		if p, stale = s.dropStale(p); stale > 0 {
+			s.log(LogDebug, "osc: stale messages dropped", "from", addr, "count", stale)
+		}
+		if p == nil {
+			return nil, nil, ErrStaleBundle, nil
 		}
 	}
-	return false
-}
-
-// getRegEx compiles and returns a regular expression object for the given
-// address `pattern`.
-func getRegEx(pattern string) *regexp.Regexp {
-	for _, trs := range []struct {
-		old, new string
-	}{
-		{".", `\.`}, // Escape all '.' in the pattern
-		{"(", `\(`}, // Escape all '(' in the pattern
-		{")", `\)`}, // Escape all ')' in the pattern
-		{"*", ".*"}, // Replace a '*' with '.*' that matches zero or more chars
-		{"{", "("},  // Change a '{' to '('
-		{",", "|"},  // Change a ',' to '|'
-		{"}", ")"},  // Change a '}' to ')'
-		{"?", "."},  // Change a '?' to '.'
-	} {
-		pattern = strings.Replace(pattern, trs.old, trs.new, -1)
-	}
-
-	return regexp.MustCompile(pattern)
+	info = &MessageInfo{Source: addr, Received: received, Transport: c.LocalAddr().Network()}
+	codec.SetPacketInfo(p, info)
+	s.logPacket(p, addr, n)
+	if s.Trace != nil && s.Trace.OnPacketReceived != nil {
+		s.Trace.OnPacketReceived(p, addr)
+	}
+	if s.intercept != nil && s.intercept(p, addr) {
+		s.log(LogDebug, "osc: packet consumed by the server", "from", addr)
+		return nil, nil, errIntercepted, nil
+	}
+	if s.builtins && s.serveBuiltin(c, p, addr, received) {
+		s.log(LogDebug, "osc: built-in request answered", "from", addr)
+		return nil, nil, errIntercepted, nil
+	}
+	return p, info, nil, nil
 }
 
-// getTypeTag returns the OSC type tag for the given argument.
-func getTypeTag(arg interface{}) (string, error) {
-	switch t := arg.(type) {
-	case bool:
-		if arg.(bool) {
-			return "T", nil
-		}
-		return "F", nil
-	case nil:
-		return "N", nil
-	case int32:
-		return "i", nil
-	case float32:
-		return "f", nil
-	case string:
-		return "s", nil
-	case []byte:
-		return "b", nil
-	case int64:
-		return "h", nil
-	case float64:
-		return "d", nil
-	case Timetag:
-		return "t", nil
-	default:
-		return "", fmt.Errorf("Unsupported type: %T", t)
+// acknowledge sends the acknowledgment of the packet with the sequence
+// number seq to addr. Errors are ignored, the sender retransmits the packet.
+func (s *Server) acknowledge(c net.PacketConn, addr net.Addr, seq uint32) {
+	data, err := NewMessage(AckAddress, int32(seq)).MarshalBinary()
+	if err == nil {
+		c.WriteTo(data, addr)
 	}
 }
@@ -1,113 +1,304 @@
 package osc
 
 import (
-	"bufio"
 	"bytes"
+	"context"
+	"encoding/binary"
+	"errors"
+	"fmt"
 	"net"
 	"reflect"
+	"sort"
 	"sync"
 	"testing"
 	"time"
 )
 
-func TestMessage_Append(t *testing.T) {
-	oscAddress := "/address"
-	message := NewMessage(oscAddress)
-	if message.Address != oscAddress {
-		t.Errorf("OSC address should be \"%s\" and is \"%s\"", oscAddress, message.Address)
+func TestAddMsgHandler(t *testing.T) {
+	d := NewStandardDispatcher()
+	err := d.AddMsgHandler("/address/test", func(msg *Message) {})
+	if err != nil {
+		t.Error("Expected that OSC address '/address/test' is valid")
 	}
+}
 
-	message.Append("string argument")
-	message.Append(123456789)
-	message.Append(true)
+func TestAddMsgHandlerWithInvalidAddress(t *testing.T) {
+	d := NewStandardDispatcher()
+	err := d.AddMsgHandler("/address*/test", func(msg *Message) {})
+	if err == nil {
+		t.Error("Expected error with '/address*/test'")
+	}
+}
 
-	if message.CountArguments() != 3 {
-		t.Errorf("Number of arguments should be %d and is %d", 3, message.CountArguments())
+func TestStandardDispatcher_Dispatch(t *testing.T) {
+	d := NewStandardDispatcher()
+	var got []string
+	for _, addr := range []string{"/synth/1/freq", "/synth/2/freq", "/synth/1/gain", "/fx/reverb"} {
+		addr := addr
+		if err := d.AddMsgHandler(addr, func(msg *Message) { got = append(got, addr) }); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	for _, tt := range []struct {
+		pattern string
+		want    []string
+	}{
+		{"/synth/1/freq", []string{"/synth/1/freq"}},
+		{"/synth/*/freq", []string{"/synth/1/freq", "/synth/2/freq"}},
+		{"/synth/1/{freq,gain}", []string{"/synth/1/freq", "/synth/1/gain"}},
+		{"/synth/[2-9]/freq", []string{"/synth/2/freq"}},
+		{"/synth/?/gain", []string{"/synth/1/gain"}},
+		{"/synth/*", nil},
+		{"/synth", nil},
+		{"/fx/reverb/mix", nil},
+	} {
+		got = nil
+		d.Dispatch(NewMessage(tt.pattern))
+		sort.Strings(got)
+		if !reflect.DeepEqual(got, tt.want) {
+			t.Errorf("%s: dispatched to %v, want = %v", tt.pattern, got, tt.want)
+		}
 	}
 }
 
-func TestMessage_Equals(t *testing.T) {
-	msg1 := NewMessage("/address")
-	msg2 := NewMessage("/address")
-	msg1.Append(1234)
-	msg2.Append(1234)
-	msg1.Append("test string")
-	msg2.Append("test string")
+func TestStandardDispatcher_SetDefaultHandler(t *testing.T) {
+	d := NewStandardDispatcher()
+	var handled, unmatched, all []string
+	if err := d.AddMsgHandler("/known", func(msg *Message) { handled = append(handled, msg.Address) }); err != nil {
+		t.Fatal(err)
+	}
+	if err := d.AddMsgHandler("*", func(msg *Message) { all = append(all, msg.Address) }); err != nil {
+		t.Fatal(err)
+	}
+	d.SetDefaultHandler(HandlerFunc(func(msg *Message) { unmatched = append(unmatched, msg.Address) }))
+
+	d.Dispatch(NewMessage("/known"))
+	d.Dispatch(NewMessage("/unknown"))
 
-	if !msg1.Equals(msg2) {
-		t.Error("Messages should be equal")
+	if want := []string{"/known"}; !reflect.DeepEqual(handled, want) {
+		t.Errorf("handled = %v, want = %v", handled, want)
+	}
+	if want := []string{"/unknown"}; !reflect.DeepEqual(unmatched, want) {
+		t.Errorf("default handler received %v, want = %v", unmatched, want)
+	}
+	if want := []string{"/known", "/unknown"}; !reflect.DeepEqual(all, want) {
+		t.Errorf("catch-all handler received %v, want = %v", all, want)
 	}
 }
 
-func TestMessage_TypeTags(t *testing.T) {
+func TestStandardDispatcher_Invoke(t *testing.T) {
+	var got []string
+	d := NewStandardDispatcher()
+	for _, addr := range []string{"/cue/1/go", "/cue/2/go"} {
+		if err := d.AddMsgHandler(addr, func(msg *Message) {
+			got = append(got, fmt.Sprint(msg.Address, msg.Arguments))
+		}); err != nil {
+			t.Fatal(err)
+		}
+	}
+	defaults := 0
+	d.SetDefaultHandler(HandlerFunc(func(msg *Message) { defaults++ }))
+
+	if n := d.Invoke("/cue/1/go", int32(5)); n != 1 {
+		t.Errorf("Invoke(/cue/1/go) = %d, want = 1", n)
+	}
+	if n := d.Invoke("/cue/*/go"); n != 2 {
+		t.Errorf("Invoke(/cue/*/go) = %d, want = 2", n)
+	}
+	if want := []string{"/cue/1/go[5]", "/cue/*/go[]", "/cue/*/go[]"}; !reflect.DeepEqual(got, want) {
+		t.Errorf("handlers received %v, want = %v", got, want)
+	}
+
+	if n := d.Invoke("/cue/3/go"); n != 0 || defaults != 1 {
+		t.Errorf("Invoke(/cue/3/go) = %d, default handler called %d times, want = 0, 1", n, defaults)
+	}
+}
+
+func TestStandardDispatcher_SetMatchMode(t *testing.T) {
+	var got []string
+	handler := func(name string) HandlerFunc {
+		return func(msg *Message) { got = append(got, name) }
+	}
+
 	for _, tt := range []struct {
-		desc string
-		msg  *Message
-		tags string
-		ok   bool
+		mode    MatchMode
+		address string
+		want    []string
 	}{
-		{"addr_only", NewMessage("/"), ",", true},
-		{"nil", NewMessage("/", nil), ",N", true},
-		{"bool_true", NewMessage("/", true), ",T", true},
-		{"bool_false", NewMessage("/", false), ",F", true},
-		{"int32", NewMessage("/", int32(1)), ",i", true},
-		{"int64", NewMessage("/", int64(2)), ",h", true},
-		{"float32", NewMessage("/", float32(3.0)), ",f", true},
-		{"float64", NewMessage("/", float64(4.0)), ",d", true},
-		{"string", NewMessage("/", "5"), ",s", true},
-		{"[]byte", NewMessage("/", []byte{'6'}), ",b", true},
-		{"two_args", NewMessage("/", "123", int32(456)), ",si", true},
-		{"invalid_msg", nil, "", false},
-		{"invalid_arg", NewMessage("/foo/bar", 789), "", false},
+		{MatchMessagePattern, "/mixer/*/mute", []string{"1"}},
+		{MatchMessagePattern, "/mixer/1/mute", []string{"1"}},
+		{MatchHandlerPattern, "/mixer/*/mute", nil},
+		{MatchHandlerPattern, "/mixer/1/mute", []string{"1", "pattern"}},
+		{MatchHandlerPattern, "/mixer/2/mute", []string{"pattern"}},
+		{MatchBoth, "/mixer/*/mute", []string{"1"}},
+		{MatchBoth, "/mixer/1/mute", []string{"1", "pattern"}},
 	} {
-		tags, err := tt.msg.TypeTags()
-		if err != nil && tt.ok {
-			t.Errorf("%s: TypeTags() unexpected error: %s", tt.desc, err)
-			continue
+		d := NewStandardDispatcher()
+		d.SetMatchMode(tt.mode)
+		if err := d.AddMsgHandler("/mixer/1/mute", handler("1")); err != nil {
+			t.Fatal(err)
 		}
-		if err == nil && !tt.ok {
-			t.Errorf("%s: TypeTags() expected an error", tt.desc)
-			continue
+		err := d.AddMsgHandler("/mixer/[0-9]/mute", handler("pattern"))
+		if tt.mode&MatchHandlerPattern == 0 && err == nil {
+			t.Errorf("mode %d: AddMsgHandler() expected an error for a pattern", tt.mode)
 		}
-		if !tt.ok {
-			continue
+		if tt.mode&MatchHandlerPattern != 0 && err != nil {
+			t.Errorf("mode %d: AddMsgHandler() unexpected error: %s", tt.mode, err)
 		}
-		if got, want := tags, tt.tags; got != want {
-			t.Errorf("%s: TypeTags() = '%s', want = '%s'", tt.desc, got, want)
+
+		got = nil
+		d.Dispatch(NewMessage(tt.address))
+		if !reflect.DeepEqual(got, tt.want) {
+			t.Errorf("mode %d: %s dispatched to %v, want = %v", tt.mode, tt.address, got, tt.want)
 		}
 	}
+
+	d := NewStandardDispatcher()
+	d.SetMatchMode(MatchHandlerPattern)
+	if err := d.AddMsgHandler("/a/[b", handler("invalid")); err == nil {
+		t.Error("expected error for invalid pattern")
+	}
+	if err := d.AddMsgHandler("/a/*", handler("1")); err != nil {
+		t.Fatal(err)
+	}
+	if err := d.AddMsgHandler("/a/*", handler("2")); err == nil {
+		t.Error("expected error for duplicate pattern")
+	}
 }
 
-func TestMessage_String(t *testing.T) {
+func TestStandardDispatcher_AnyDepthPattern(t *testing.T) {
+	var got []string
+	d := NewStandardDispatcher()
+	for _, addr := range []string{"/volume", "/mixer/volume", "/mixer/1/volume", "/mixer/1/mute"} {
+		addr := addr
+		if err := d.AddMsgHandler(addr, func(msg *Message) { got = append(got, addr) }); err != nil {
+			t.Fatal(err)
+		}
+	}
+
 	for _, tt := range []struct {
-		desc string
-		msg  *Message
-		str  string
+		strict  bool
+		address string
+		want    []string
 	}{
-		{"nil", nil, ""},
-		{"addr_only", NewMessage("/foo/bar"), "/foo/bar ,"},
-		{"one_addr", NewMessage("/foo/bar", "123"), "/foo/bar ,s 123"},
-		{"two_args", NewMessage("/foo/bar", "123", int32(456)), "/foo/bar ,si 123 456"},
+		{false, "//volume", []string{"/mixer/1/volume", "/mixer/volume", "/volume"}},
+		{false, "/mixer//volume", []string{"/mixer/1/volume", "/mixer/volume"}},
+		{false, "//1//*", []string{"/mixer/1/mute", "/mixer/1/volume"}},
+		{true, "//volume", nil},
 	} {
-		if got, want := tt.msg.String(), tt.str; got != want {
-			t.Errorf("%s: String() = '%s', want = '%s'", tt.desc, got, want)
+		got = nil
+		d.SetStrictPatterns(tt.strict)
+		d.Dispatch(NewMessage(tt.address))
+		sort.Strings(got)
+		if !reflect.DeepEqual(got, tt.want) {
+			t.Errorf("strict %t: %s dispatched to %v, want = %v", tt.strict, tt.address, got, tt.want)
 		}
 	}
+
+	d = NewStandardDispatcher()
+	d.SetMatchMode(MatchHandlerPattern)
+	if err := d.AddMsgHandler("//mute", func(msg *Message) { got = append(got, "//mute") }); err != nil {
+		t.Fatal(err)
+	}
+	got = nil
+	d.Dispatch(NewMessage("/mixer/3/mute"))
+	if !reflect.DeepEqual(got, []string{"//mute"}) {
+		t.Errorf("/mixer/3/mute dispatched to %v, want = [//mute]", got)
+	}
 }
 
-func TestAddMsgHandler(t *testing.T) {
+func TestStandardDispatcher_AddTypedHandler(t *testing.T) {
+	var got []*Message
+	var rejected []string
 	d := NewStandardDispatcher()
-	err := d.AddMsgHandler("/address/test", func(msg *Message) {})
-	if err != nil {
-		t.Error("Expected that OSC address '/address/test' is valid")
+	if err := d.AddTypedHandler("/synth/freq", "f", func(msg *Message) {
+		got = append(got, msg)
+	}); err != nil {
+		t.Fatal(err)
+	}
+	if err := d.AddTypedHandler("/synth/note", ",is", func(msg *Message) {
+		got = append(got, msg)
+	}); err != nil {
+		t.Fatal(err)
+	}
+	if err := d.AddTypedHandler("/synth/freq", "f", func(msg *Message) {}); err == nil {
+		t.Error("expected error for duplicate address")
+	}
+	d.SetTypeMismatchHandler(func(msg *Message, want string) {
+		rejected = append(rejected, msg.Address+" "+want)
+	})
+
+	d.Dispatch(NewMessage("/synth/freq", float32(440)))
+	d.Dispatch(NewMessage("/synth/freq", int32(440)))
+	d.Dispatch(NewMessage("/synth/freq"))
+	d.Dispatch(NewMessage("/synth/note", int32(60), "on"))
+	d.Dispatch(NewMessage("/synth/*", "on"))
+
+	if len(got) != 2 || got[0].Address != "/synth/freq" || got[1].Address != "/synth/note" {
+		t.Errorf("handlers received %v, want the valid /synth/freq and /synth/note messages", got)
+	}
+	want := []string{"/synth/* ,f", "/synth/* ,is", "/synth/freq ,f", "/synth/freq ,f"}
+	sort.Strings(rejected)
+	if !reflect.DeepEqual(rejected, want) {
+		t.Errorf("rejected %v, want = %v", rejected, want)
 	}
 }
 
-func TestAddMsgHandlerWithInvalidAddress(t *testing.T) {
+func TestStandardDispatcher_Route(t *testing.T) {
+	var got []string
+	synth := NewStandardDispatcher()
+	for _, addr := range []string{"/freq", "/env/attack"} {
+		addr := addr
+		if err := synth.AddMsgHandler(addr, func(msg *Message) { got = append(got, addr+" "+msg.Address) }); err != nil {
+			t.Fatal(err)
+		}
+	}
+
 	d := NewStandardDispatcher()
-	err := d.AddMsgHandler("/address*/test", func(msg *Message) {})
-	if err == nil {
-		t.Error("Expected error with '/address*/test'")
+	if err := d.Route("/synth/1", synth); err != nil {
+		t.Fatal(err)
+	}
+	if err := d.Route("/synth/2/", synth); err != nil {
+		t.Fatal(err)
+	}
+	if err := d.Route("synth", synth); err == nil {
+		t.Error("expected error for prefix without leading '/'")
+	}
+	if err := d.Route("/synth/*", synth); err == nil {
+		t.Error("expected error for prefix with wildcard")
+	}
+
+	for _, tt := range []struct {
+		pattern string
+		want    []string
+	}{
+		{"/synth/1/freq", []string{"/freq /synth/1/freq"}},
+		{"/synth/2/env/attack", []string{"/env/attack /synth/2/env/attack"}},
+		{"/synth/*/freq", []string{"/freq /synth/*/freq", "/freq /synth/*/freq"}},
+		{"/synth/1", nil},
+		{"/freq", nil},
+	} {
+		got = nil
+		d.Dispatch(NewMessage(tt.pattern))
+		if !reflect.DeepEqual(got, tt.want) {
+			t.Errorf("%s: dispatched to %v, want = %v", tt.pattern, got, tt.want)
+		}
+	}
+}
+
+func BenchmarkStandardDispatcher_Dispatch(b *testing.B) {
+	d := NewStandardDispatcher()
+	for i := 0; i < 10000; i++ {
+		if err := d.AddMsgHandler(fmt.Sprintf("/rig/%d/dimmer", i), func(msg *Message) {}); err != nil {
+			b.Fatal(err)
+		}
+	}
+	msg := NewMessage("/rig/5000/dimmer", float32(0.5))
+
+	b.ResetTimer()
+	for i := 0; i < b.N; i++ {
+		d.Dispatch(msg)
 	}
 }
 
@@ -172,6 +363,85 @@ func TestServerMessageDispatching(t *testing.T) {
 	done.Wait()
 }
 
+func TestServerBundleDispatching(t *testing.T) {
+	conn, err := net.ListenPacket("udp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer conn.Close()
+
+	received := make(chan string, 4)
+	d := NewStandardDispatcher()
+	if err := d.AddMsgHandler("*", func(msg *Message) { received <- msg.Address }); err != nil {
+		t.Fatal(err)
+	}
+	server := &Server{Dispatcher: d}
+	go server.Serve(conn)
+
+	nested := NewBundle(time.Now())
+	for _, p := range []Packet{NewMessage("/nested/1"), NewMessage("/nested/2")} {
+		if err := nested.Append(p); err != nil {
+			t.Fatal(err)
+		}
+	}
+	bundle := NewBundle(time.Now())
+	for _, p := range []Packet{NewMessage("/first"), NewMessage("/second"), nested} {
+		if err := bundle.Append(p); err != nil {
+			t.Fatal(err)
+		}
+	}
+	client := NewClient("127.0.0.1", conn.LocalAddr().(*net.UDPAddr).Port)
+	if _, err := client.Send(bundle); err != nil {
+		t.Fatal(err)
+	}
+
+	var got []string
+	for len(got) < 4 {
+		select {
+		case addr := <-received:
+			got = append(got, addr)
+		case <-time.After(5 * time.Second):
+			t.Fatalf("timed out, received %v", got)
+		}
+	}
+	sort.Strings(got)
+	if want := []string{"/first", "/nested/1", "/nested/2", "/second"}; !reflect.DeepEqual(got, want) {
+		t.Errorf("dispatched %v, want = %v", got, want)
+	}
+}
+
+func TestServerLocalAddr(t *testing.T) {
+	server := &Server{Addr: "127.0.0.1:0", Dispatcher: NewStandardDispatcher()}
+	if addr := server.LocalAddr(); addr != nil {
+		t.Errorf("LocalAddr() = %v before serving, want = nil", addr)
+	}
+
+	conn, addr, err := Listen(server.Addr)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if addr.Port == 0 {
+		t.Error("Listen() returned port 0")
+	}
+
+	done := make(chan error)
+	go func() { done <- server.Serve(conn) }()
+
+	deadline := time.Now().Add(5 * time.Second)
+	for server.LocalAddr() == nil && time.Now().Before(deadline) {
+		time.Sleep(time.Millisecond)
+	}
+	if got, want := server.LocalAddr(), net.Addr(addr); got == nil || got.String() != want.String() {
+		t.Errorf("LocalAddr() = %v, want = %v", got, want)
+	}
+
+	conn.Close()
+	<-done
+	if addr := server.LocalAddr(); addr != nil {
+		t.Errorf("LocalAddr() = %v after serving, want = nil", addr)
+	}
+}
+
 func TestServerMessageReceiving(t *testing.T) {
 	finish := make(chan bool)
 	start := make(chan bool)
@@ -240,6 +510,179 @@ func TestServerMessageReceiving(t *testing.T) {
 	done.Wait()
 }
 
+func TestServer_Unmatched(t *testing.T) {
+	d := NewStandardDispatcher()
+	defaultCalls := 0
+	d.SetDefaultHandler(HandlerFunc(func(msg *Message) { defaultCalls++ }))
+	if err := d.AddMsgHandler("/known", func(msg *Message) {}); err != nil {
+		t.Fatal(err)
+	}
+	s := &Server{Dispatcher: d, UnmatchedBuffer: 2}
+
+	// Messages are only reported after the channel was requested
+	s.dispatch(NewMessage("/early"))
+	unmatched := s.Unmatched()
+	if cap(unmatched) != 2 {
+		t.Errorf("cap(Unmatched()) = %d, want = 2", cap(unmatched))
+	}
+	for _, addr := range []string{"/known", "/a", "/b", "/c"} {
+		s.dispatch(NewMessage(addr, int32(1)))
+	}
+	if defaultCalls != 4 {
+		t.Errorf("default handler was called %d times, want = 4", defaultCalls)
+	}
+
+	// The oldest message was dropped
+	for _, addr := range []string{"/b", "/c"} {
+		select {
+		case msg := <-unmatched:
+			if want := NewMessage(addr, int32(1)); !msg.Equals(want) {
+				t.Errorf("unmatched message = %v, want = %v", msg, want)
+			}
+		default:
+			t.Fatalf("missing unmatched message %s", addr)
+		}
+	}
+	select {
+	case msg := <-unmatched:
+		t.Errorf("unexpected unmatched message %v", msg)
+	default:
+	}
+}
+
+func TestServer_StartStop(t *testing.T) {
+	started := make(chan struct{})
+	release := make(chan struct{})
+	d := NewStandardDispatcher()
+	if err := d.AddMsgHandler("/slow", func(msg *Message) {
+		close(started)
+		<-release
+	}); err != nil {
+		t.Fatal(err)
+	}
+	server := &Server{Addr: "127.0.0.1:0", Dispatcher: d}
+	if err := server.Start(); err != nil {
+		t.Fatal(err)
+	}
+	if err := server.Start(); err == nil {
+		t.Error("second Start() expected error")
+	}
+
+	addr := server.LocalAddr().(*net.UDPAddr)
+	if _, err := NewClient(addr.IP.String(), addr.Port).Send(NewMessage("/slow")); err != nil {
+		t.Fatal(err)
+	}
+	select {
+	case <-started:
+	case <-time.After(5 * time.Second):
+		t.Fatal("handler wasn't called")
+	}
+
+	// Stop waits for the running handler
+	ctx, cancel := context.WithTimeout(context.Background(), 50*time.Millisecond)
+	defer cancel()
+	if err := server.Stop(ctx); err != context.DeadlineExceeded {
+		t.Errorf("Stop() = %v while the handler runs, want = %v", err, context.DeadlineExceeded)
+	}
+	close(release)
+	if err := server.Stop(context.Background()); err != nil {
+		t.Errorf("Stop() unexpected error: %s", err)
+	}
+	if err := server.Err(); err != nil {
+		t.Errorf("Err() = %v after Stop, want = nil", err)
+	}
+	if addr := server.LocalAddr(); addr != nil {
+		t.Errorf("LocalAddr() = %v after Stop, want = nil", addr)
+	}
+
+	// The server can be started again
+	if err := server.Start(); err != nil {
+		t.Fatal(err)
+	}
+	if err := server.Stop(context.Background()); err != nil {
+		t.Errorf("Stop() unexpected error: %s", err)
+	}
+	if err := server.Stop(context.Background()); err != nil {
+		t.Errorf("Stop() of a stopped server unexpected error: %s", err)
+	}
+
+	if err := (&Server{Addr: "256.0.0.1:0"}).Start(); err == nil {
+		t.Error("Start() with invalid address expected error")
+	}
+}
+
+func TestServer_Serve_Resilience(t *testing.T) {
+	conn, err := net.ListenPacket("udp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatal(err)
+	}
+	decodeErrors := make(chan error, 1)
+	received := make(chan *Message, 1)
+	d := NewStandardDispatcher()
+	if err := d.AddMsgHandler("/test", func(msg *Message) { received <- msg }); err != nil {
+		t.Fatal(err)
+	}
+	server := &Server{
+		Dispatcher:  d,
+		ReadTimeout: 20 * time.Millisecond,
+		Trace: &ServerTrace{
+			OnDecodeError: func(data []byte, addr net.Addr, err error) { decodeErrors <- err },
+		},
+	}
+	served := make(chan error, 1)
+	go func() { served <- server.Serve(conn) }()
+
+	client, err := net.Dial("udp", conn.LocalAddr().String())
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer client.Close()
+
+	// A malformed packet is skipped
+	if _, err := client.Write([]byte("/test\x00\x00\x00,x\x00\x00")); err != nil {
+		t.Fatal(err)
+	}
+	select {
+	case err := <-decodeErrors:
+		if err == nil {
+			t.Error("OnDecodeError called with nil error")
+		}
+	case <-time.After(5 * time.Second):
+		t.Fatal("OnDecodeError wasn't called")
+	}
+
+	// Several read deadlines expire before the next packet arrives
+	time.Sleep(100 * time.Millisecond)
+	data, err := NewMessage("/test", int32(1)).MarshalBinary()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, err := client.Write(data); err != nil {
+		t.Fatal(err)
+	}
+	select {
+	case msg := <-received:
+		if !reflect.DeepEqual(msg.Arguments, []interface{}{int32(1)}) {
+			t.Errorf("received %v, want = /test 1", msg)
+		}
+	case err := <-served:
+		t.Fatalf("Serve() returned %v", err)
+	case <-time.After(5 * time.Second):
+		t.Fatal("message wasn't dispatched")
+	}
+
+	// Closing the connection is fatal
+	conn.Close()
+	select {
+	case err := <-served:
+		if err == nil {
+			t.Error("Serve() = nil after the connection was closed, want an error")
+		}
+	case <-time.After(5 * time.Second):
+		t.Fatal("Serve didn't return after the connection was closed")
+	}
+}
+
 func TestReadTimeout(t *testing.T) {
 	start := make(chan bool)
 	wg := sync.WaitGroup{}
@@ -254,13 +697,13 @@ func TestReadTimeout(t *testing.T) {
 		case <-start:
 			client := NewClient("localhost", 6677)
 			msg := NewMessage("/address/test1")
-			err := client.Send(msg)
+			_, err := client.Send(msg)
 			if err != nil {
 				t.Fatal(err)
 			}
 			time.Sleep(150 * time.Millisecond)
 			msg = NewMessage("/address/test2")
-			err = client.Send(msg)
+			_, err = client.Send(msg)
 			if err != nil {
 				t.Fatal(err)
 			}
@@ -309,215 +752,365 @@ func TestReadTimeout(t *testing.T) {
 	wg.Wait()
 }
 
-func TestReadPaddedString(t *testing.T) {
-	for _, tt := range []struct {
-		buf []byte // buffer
-		n   int    // bytes needed
-		s   string // resulting string
-	}{
-		{[]byte{'t', 'e', 's', 't', 's', 't', 'r', 'i', 'n', 'g', 0, 0}, 12, "teststring"},
-		{[]byte{'t', 'e', 's', 't', 0, 0, 0, 0}, 8, "test"},
-	} {
-		buf := bytes.NewBuffer(tt.buf)
-		s, n, err := readPaddedString(bufio.NewReader(buf))
-		if err != nil {
-			t.Errorf("%s: Error reading padded string: %s", s, err)
-		}
-		if got, want := n, tt.n; got != want {
-			t.Errorf("%s: Bytes needed don't match; got = %d, want = %d", tt.s, got, want)
-		}
-		if got, want := s, tt.s; got != want {
-			t.Errorf("%s: Strings don't match; got = %s, want = %s", tt.s, got, want)
-		}
+func TestClientSetLocalAddr(t *testing.T) {
+	client := NewClient("localhost", 8967)
+	err := client.SetLocalAddr("localhost", 41789)
+	if err != nil {
+		t.Error(err.Error())
+	}
+	expectedAddr := "127.0.0.1:41789"
+	if client.laddr.String() != expectedAddr {
+		t.Errorf("Expected laddr to be %s but was %s", expectedAddr, client.laddr.String())
 	}
 }
 
-func TestWritePaddedString(t *testing.T) {
-	buf := []byte{}
-	bytesBuffer := bytes.NewBuffer(buf)
-	testString := "testString"
-	expectedNumberOfWrittenBytes := len(testString) + padBytesNeeded(len(testString))
-
-	n, err := writePaddedString(testString, bytesBuffer)
+func TestClientSend(t *testing.T) {
+	conn, err := net.ListenPacket("udp", "127.0.0.1:0")
 	if err != nil {
-		t.Errorf(err.Error())
+		t.Fatal(err)
 	}
+	defer conn.Close()
 
-	if n != expectedNumberOfWrittenBytes {
-		t.Errorf("Expected number of written bytes should be \"%d\" and is \"%d\"", expectedNumberOfWrittenBytes, n)
+	client := NewClient("127.0.0.1", conn.LocalAddr().(*net.UDPAddr).Port)
+	client.SetWriteTimeout(time.Second)
+	if got, want := client.WriteTimeout(), time.Second; got != want {
+		t.Errorf("WriteTimeout() = %s, want = %s", got, want)
 	}
-}
 
-func TestPadBytesNeeded(t *testing.T) {
-	var n int
-	n = padBytesNeeded(4)
-	if n != 4 {
-		t.Errorf("Number of pad bytes should be 4 and is: %d", n)
+	msg := NewMessage("/address/test", int32(1))
+	data, err := msg.MarshalBinary()
+	if err != nil {
+		t.Fatal(err)
+	}
+	n, err := client.Send(msg)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got, want := n, len(data); got != want {
+		t.Errorf("Send() = %d, want = %d", got, want)
 	}
+}
 
-	n = padBytesNeeded(3)
-	if n != 1 {
-		t.Errorf("Number of pad bytes should be 1 and is: %d", n)
+func TestClient_AddSendHook(t *testing.T) {
+	conn, err := net.ListenPacket("udp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatal(err)
 	}
+	defer conn.Close()
+	addr := conn.LocalAddr().(*net.UDPAddr)
+	client := NewClient(addr.IP.String(), addr.Port)
+
+	var backup []Packet
+	client.AddSendHook(func(p Packet) (Packet, error) {
+		msg, ok := p.(*Message)
+		if !ok {
+			return p, nil
+		}
+		switch {
+		case msg.Address == "/private":
+			return nil, nil
+		case msg.Address == "/invalid":
+			return nil, errors.New("invalid")
+		}
+		msg = msg.Clone()
+		msg.Address = "/desk" + msg.Address
+		return msg, nil
+	})
+	client.AddSendHook(func(p Packet) (Packet, error) {
+		backup = append(backup, p)
+		return p, nil
+	})
 
-	n = padBytesNeeded(1)
-	if n != 3 {
-		t.Errorf("Number of pad bytes should be 3 and is: %d", n)
+	original := NewMessage("/fader", float32(0.5))
+	if _, err := client.Send(original); err != nil {
+		t.Fatal(err)
+	}
+	if original.Address != "/fader" {
+		t.Errorf("the hook modified the original message: %v", original)
+	}
+	if n, err := client.Send(NewMessage("/private")); n != 0 || err != nil {
+		t.Errorf("Send() of dropped packet = %d, %v, want = 0, nil", n, err)
+	}
+	if _, err := client.Send(NewMessage("/invalid")); err == nil {
+		t.Error("Send() expected error of hook")
 	}
 
-	n = padBytesNeeded(0)
-	if n != 4 {
-		t.Errorf("Number of pad bytes should be 4 and is: %d", n)
+	server := &Server{ReadTimeout: 5 * time.Second}
+	p, err := server.ReceivePacket(conn)
+	if err != nil {
+		t.Fatal(err)
 	}
+	if want := NewMessage("/desk/fader", float32(0.5)); !p.(*Message).Equals(want) {
+		t.Errorf("received %v, want = %v", p, want)
+	}
+	if len(backup) != 1 || backup[0].(*Message).Address != "/desk/fader" {
+		t.Errorf("second hook received %v, want the rewritten message", backup)
+	}
+}
 
-	n = padBytesNeeded(32)
-	if n != 4 {
-		t.Errorf("Number of pad bytes should be 4 and is: %d", n)
+func TestClient_Emit(t *testing.T) {
+	conn, err := net.ListenPacket("udp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatal(err)
 	}
+	defer conn.Close()
+	addr := conn.LocalAddr().(*net.UDPAddr)
+	client := NewClient(addr.IP.String(), addr.Port)
 
-	n = padBytesNeeded(63)
-	if n != 1 {
-		t.Errorf("Number of pad bytes should be 1 and is: %d", n)
+	if err := client.Emit("/synth/note", 60, 440.0, "sine", true); err != nil {
+		t.Fatal(err)
+	}
+	if err := client.Emit("/invalid", struct{}{}); err == nil {
+		t.Error("Emit() expected error for unsupported argument")
 	}
 
-	n = padBytesNeeded(10)
-	if n != 2 {
-		t.Errorf("Number of pad bytes should be 2 and is: %d", n)
+	server := &Server{ReadTimeout: 5 * time.Second}
+	p, err := server.ReceivePacket(conn)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if want := NewMessage("/synth/note", int32(60), float32(440), "sine", true); !p.(*Message).Equals(want) {
+		t.Errorf("received %v, want = %v", p, want)
 	}
 }
 
-func TestTypeTagsString(t *testing.T) {
-	msg := NewMessage("/some/address")
-	msg.Append(int32(100))
-	msg.Append(true)
-	msg.Append(false)
+func TestClient_Stats(t *testing.T) {
+	conn, err := net.ListenPacket("udp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer conn.Close()
+	addr := conn.LocalAddr().(*net.UDPAddr)
+	client := NewClient(addr.IP.String(), addr.Port)
+	client.AddSendHook(func(p Packet) (Packet, error) {
+		if msg, ok := p.(*Message); ok && msg.Address == "/private" {
+			return nil, nil
+		}
+		return p, nil
+	})
+	var failed []Packet
+	client.OnSendError(func(p Packet, err error) {
+		failed = append(failed, p)
+	})
 
-	typeTags, err := msg.TypeTags()
+	start := time.Now()
+	n, err := client.Send(NewMessage("/fader", float32(0.5)))
 	if err != nil {
-		t.Error(err.Error())
+		t.Fatal(err)
+	}
+	client.Send(NewMessage("/private"))
+	invalid := NewMessage("/invalid", struct{}{})
+	if _, err := client.Send(invalid); err == nil {
+		t.Fatal("Send() expected error for unsupported argument")
 	}
 
-	if typeTags != ",iTF" {
-		t.Errorf("Type tag string should be ',iTF' and is: %s", typeTags)
+	stats := client.Stats()
+	if stats.Packets != 1 || stats.Bytes != uint64(n) || stats.Dropped != 1 || stats.Errors != 1 {
+		t.Errorf("Stats() = %+v, want 1 packet with %d bytes, 1 dropped and 1 error", stats, n)
+	}
+	if stats.LastError == nil || stats.LastErrorTime.Before(start) || stats.LastSend.Before(start) {
+		t.Errorf("Stats() = %+v, want last error and times", stats)
+	}
+	if len(failed) != 1 || failed[0] != invalid {
+		t.Errorf("OnSendError() got %v, want = [%v]", failed, invalid)
 	}
 }
 
-func TestClientSetLocalAddr(t *testing.T) {
-	client := NewClient("localhost", 8967)
-	err := client.SetLocalAddr("localhost", 41789)
+func TestNewClientFromConn(t *testing.T) {
+	conn, err := net.ListenPacket("udp", "127.0.0.1:0")
 	if err != nil {
-		t.Error(err.Error())
+		t.Fatal(err)
 	}
-	expectedAddr := "127.0.0.1:41789"
-	if client.laddr.String() != expectedAddr {
-		t.Errorf("Expected laddr to be %s but was %s", expectedAddr, client.laddr.String())
+	defer conn.Close()
+
+	// The receiver of the replies shares the socket with the client
+	shared, err := net.DialUDP("udp", nil, conn.LocalAddr().(*net.UDPAddr))
+	if err != nil {
+		t.Fatal(err)
 	}
-}
+	defer shared.Close()
 
-func TestParsePacket(t *testing.T) {
-	for _, tt := range []struct {
-		desc string
-		msg  string
-		pkt  Packet
-		ok   bool
-	}{
-		{"no_args",
-			"/a/b/c" + nulls(2) + "," + nulls(3),
-			makePacket("/a/b/c", nil),
-			true},
-		{"string_arg",
-			"/d/e/f" + nulls(2) + ",s" + nulls(2) + "foo" + nulls(1),
-			makePacket("/d/e/f", []string{"foo"}),
-			true},
-		{"empty", "", nil, false},
-	} {
-		pkt, err := ParsePacket(tt.msg)
-		if err != nil && tt.ok {
-			t.Errorf("%s: ParsePacket() returned unexpected error; %s", tt.desc, err)
-		}
-		if err == nil && !tt.ok {
-			t.Errorf("%s: ParsePacket() expected error", tt.desc)
-		}
-		if !tt.ok {
-			continue
-		}
+	client := NewClientFromConn(shared)
+	if got, want := client.Port(), conn.LocalAddr().(*net.UDPAddr).Port; got != want {
+		t.Errorf("Port() = %d, want = %d", got, want)
+	}
 
-		pktBytes, err := pkt.MarshalBinary()
-		if err != nil {
-			t.Errorf("%s: failure converting pkt to byte array; %s", tt.desc, err)
-			continue
+	server := &Server{ReadTimeout: 5 * time.Second}
+	for i := 0; i < 2; i++ {
+		msg := NewMessage("/ping", int32(i))
+		if _, err := client.Send(msg); err != nil {
+			t.Fatal(err)
 		}
-		ttpktBytes, err := tt.pkt.MarshalBinary()
+		p, err := server.ReceivePacket(conn)
 		if err != nil {
-			t.Errorf("%s: failure converting tt.pkt to byte array; %s", tt.desc, err)
-			continue
+			t.Fatal(err)
 		}
-		if got, want := pktBytes, ttpktBytes; !reflect.DeepEqual(got, want) {
-			t.Errorf("%s: ParsePacket() as bytes = '%s', want = '%s'", tt.desc, got, want)
-			continue
+		if !p.(*Message).Equals(msg) {
+			t.Errorf("received %v, want = %v", p, msg)
 		}
 	}
+
+	// Replies to the source address of the packets arrive at the shared socket
+	if _, err := conn.WriteTo([]byte("pong"), shared.LocalAddr()); err != nil {
+		t.Fatal(err)
+	}
+	shared.SetReadDeadline(time.Now().Add(5 * time.Second))
+	buf := make([]byte, 16)
+	if n, err := shared.Read(buf); err != nil || string(buf[:n]) != "pong" {
+		t.Errorf("shared socket read %q, %v, want = pong", buf[:n], err)
+	}
 }
 
-func TestOscMessageMatch(t *testing.T) {
-	tc := []struct {
-		desc        string
-		addr        string
-		addrPattern string
-		want        bool
-	}{
-		{
-			"match everything",
-			"*",
-			"/a/b",
-			true,
-		},
-		{
-			"don't match",
-			"/a/b",
-			"/a",
-			false,
-		},
-		{
-			"match alternatives",
-			"/a/{foo,bar}",
-			"/a/foo",
-			true,
-		},
-		{
-			"don't match if address is not part of the alternatives",
-			"/a/{foo,bar}",
-			"/a/bob",
-			false,
+func TestServer_ArgumentByteOrder(t *testing.T) {
+	conn, err := net.ListenPacket("udp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer conn.Close()
+
+	lenient, err := net.Dial("udp", conn.LocalAddr().String())
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer lenient.Close()
+	strict, err := net.Dial("udp", conn.LocalAddr().String())
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer strict.Close()
+
+	server := &Server{
+		ReadTimeout: 5 * time.Second,
+		ArgumentByteOrder: func(addr net.Addr) binary.ByteOrder {
+			if addr.String() == lenient.LocalAddr().String() {
+				return binary.LittleEndian
+			}
+			return nil
 		},
 	}
+	want := NewMessage("/le", int32(1), float32(2), int64(3), float64(4))
+
+	if _, err := lenient.Write(littleEndianMessage()); err != nil {
+		t.Fatal(err)
+	}
+	p, err := server.ReceivePacket(conn)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !p.(*Message).Equals(want) {
+		t.Errorf("lenient source: got %v, want = %v", p, want)
+	}
 
-	for _, tt := range tc {
-		msg := NewMessage(tt.addr)
+	data, err := want.MarshalBinary()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, err := strict.Write(data); err != nil {
+		t.Fatal(err)
+	}
+	if p, err = server.ReceivePacket(conn); err != nil {
+		t.Fatal(err)
+	}
+	if !p.(*Message).Equals(want) {
+		t.Errorf("strict source: got %v, want = %v", p, want)
+	}
+}
 
-		got := msg.Match(tt.addrPattern)
-		if got != tt.want {
-			t.Errorf("%s: msg.Match('%s') = '%t', want = '%t'", tt.desc, tt.addrPattern, got, tt.want)
-		}
+func TestServer_RawPackets(t *testing.T) {
+	conn, err := net.ListenPacket("udp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer conn.Close()
+
+	// A message with non-canonical padding is passed through byte-exact
+	data := []byte("/raw\x00\x00\x00\x00,i\x00\x00\x00\x00\x00\x07")
+	data[6] = 'x'
+	sender, err := net.Dial("udp", conn.LocalAddr().String())
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer sender.Close()
+	if _, err := sender.Write(data); err != nil {
+		t.Fatal(err)
+	}
+
+	server := &Server{ReadTimeout: 5 * time.Second, ReuseMessages: true, DecodeOptions: DecodeOptions{Raw: true}}
+	p, err := server.ReceivePacket(conn)
+	if err != nil {
+		t.Fatal(err)
+	}
+	msg := p.(*Message)
+	if !bytes.Equal(msg.RawPacket(), data) || !bytes.Equal(msg.Raw(), data) {
+		t.Errorf("RawPacket() = % x, want = % x", msg.RawPacket(), data)
 	}
 }
 
-const zero = string(byte(0))
+// littleEndianMessage returns the message "/le" with the arguments int32(1),
+// float32(2), int64(3) and float64(4) encoded as little-endian.
+func littleEndianMessage() []byte {
+	buf := bytes.NewBufferString("/le\x00,ifhd\x00\x00\x00")
+	for _, v := range []interface{}{int32(1), float32(2), int64(3), float64(4)} {
+		binary.Write(buf, binary.LittleEndian, v)
+	}
+	return buf.Bytes()
+}
+
+func TestIPv6(t *testing.T) {
+	lc := &ListenConfig{Network: "udp6"}
+	conn, addr, err := lc.Listen("[::1]:0")
+	if err != nil {
+		t.Skip("IPv6 loopback isn't available:", err)
+	}
+	defer conn.Close()
 
-// nulls returns a string of `i` nulls.
-func nulls(i int) string {
-	s := ""
-	for j := 0; j < i; j++ {
-		s += zero
+	client := NewClient("::1", addr.Port)
+	if err := client.SetNetwork("udp6"); err != nil {
+		t.Fatal(err)
+	}
+	if err := client.SetLocalAddr("::1", 0); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := client.Send(NewMessage("/ipv6")); err != nil {
+		t.Fatal(err)
+	}
+	server := &Server{ReadTimeout: 5 * time.Second}
+	p, err := server.ReceivePacket(conn)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got := p.(*Message).Address; got != "/ipv6" {
+		t.Errorf("received %s, want = /ipv6", got)
+	}
+
+	if err := client.SetNetwork("udp4"); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := client.Send(NewMessage("/ipv6")); err == nil {
+		t.Error("Send() to an IPv6 address with network udp4 expected error")
 	}
-	return s
 }
 
-// makePacket creates a fake Message Packet.
-func makePacket(addr string, args []string) Packet {
-	msg := NewMessage(addr)
-	for _, arg := range args {
-		msg.Append(arg)
+func TestClient_SetNetwork(t *testing.T) {
+	client := NewClient("localhost", 9000)
+	if got := client.Network(); got != "udp" {
+		t.Errorf("Network() = %s, want = udp", got)
 	}
-	return msg
+	if err := client.SetNetwork("tcp"); err == nil {
+		t.Error("SetNetwork(tcp) expected error")
+	}
+
+	// The zone of a link-local address is kept
+	conn := &fakeConn{remote: &net.UDPAddr{IP: net.ParseIP("fe80::1"), Port: 9000, Zone: "eth0"}}
+	if got := NewClientFromConn(conn).IP(); got != "fe80::1%eth0" {
+		t.Errorf("IP() = %s, want = fe80::1%%eth0", got)
+	}
+}
+
+// fakeConn is a net.Conn with a fixed remote address.
+type fakeConn struct {
+	net.Conn
+	remote net.Addr
 }
+
+func (c *fakeConn) RemoteAddr() net.Addr { return c.remote }
@@ -0,0 +1,18 @@
+// Package oscquic is an experimental transport that sends OSC packets as
+// unreliable QUIC datagrams (RFC 9221), which adds TLS and congestion control
+// for links over the public internet while keeping the packet semantics of
+// UDP: packets may be lost, but are never delayed by retransmissions of
+// earlier packets.
+//
+// The package depends on github.com/quic-go/quic-go v0.63.0, which requires
+// Go 1.26, and is only built with the build tag quic:
+//
+//	go get github.com/quic-go/quic-go@v0.63.0
+//	go build -tags quic
+//
+// Older releases of quic-go, before the Connection interface was replaced by
+// the Conn type, aren't supported.
+//
+// A packet must fit into a single datagram, whose maximum size depends on
+// the path MTU and is usually about 1200 bytes.
+package oscquic
@@ -0,0 +1,153 @@
+//go:build quic
+// +build quic
+
+package oscquic
+
+import (
+	"context"
+	"crypto/tls"
+	"errors"
+	"net"
+
+	"github.com/hypebeast/go-osc/osc"
+	"github.com/quic-go/quic-go"
+)
+
+// ALPN is the application protocol that is negotiated by Dial and Listen if
+// the TLS configuration doesn't set NextProtos.
+const ALPN = "osc"
+
+// config returns the QUIC configuration with datagrams enabled.
+func config() *quic.Config {
+	return &quic.Config{EnableDatagrams: true}
+}
+
+// withALPN returns a copy of tlsConf with the OSC protocol, if tlsConf
+// doesn't set NextProtos.
+func withALPN(tlsConf *tls.Config) *tls.Config {
+	if tlsConf == nil {
+		tlsConf = &tls.Config{}
+	}
+	if len(tlsConf.NextProtos) != 0 {
+		return tlsConf
+	}
+	tlsConf = tlsConf.Clone()
+	tlsConf.NextProtos = []string{ALPN}
+	return tlsConf
+}
+
+// Conn is a QUIC connection that carries OSC packets as datagrams. It is
+// safe to call Send and Receive concurrently.
+type Conn struct {
+	conn *quic.Conn
+
+	// DecodeOptions control how received packets are decoded.
+	DecodeOptions osc.DecodeOptions
+}
+
+// Dial opens a QUIC connection to addr ("host:port").
+func Dial(ctx context.Context, addr string, tlsConf *tls.Config) (*Conn, error) {
+	conn, err := quic.DialAddr(ctx, addr, withALPN(tlsConf), config())
+	if err != nil {
+		return nil, err
+	}
+	if !conn.ConnectionState().SupportsDatagrams.Remote {
+		conn.CloseWithError(0, "datagrams not supported")
+		return nil, errors.New("oscquic: peer doesn't support datagrams")
+	}
+	return &Conn{conn: conn}, nil
+}
+
+// Send sends the packet as a single datagram.
+func (c *Conn) Send(packet osc.Packet) error {
+	data, err := packet.MarshalBinary()
+	if err != nil {
+		return err
+	}
+	return c.conn.SendDatagram(data)
+}
+
+// Receive waits for the next datagram and decodes it. Malformed packets are
+// reported as *osc.DecodeError, after which Receive can be called again.
+func (c *Conn) Receive(ctx context.Context) (osc.Packet, error) {
+	data, err := c.conn.ReceiveDatagram(ctx)
+	if err != nil {
+		return nil, err
+	}
+	dec := osc.Decoder{Options: c.DecodeOptions}
+	return dec.DecodeBytes(data)
+}
+
+// LocalAddr returns the local address of the connection.
+func (c *Conn) LocalAddr() net.Addr {
+	return c.conn.LocalAddr()
+}
+
+// RemoteAddr returns the address of the peer.
+func (c *Conn) RemoteAddr() net.Addr {
+	return c.conn.RemoteAddr()
+}
+
+// Close closes the connection.
+func (c *Conn) Close() error {
+	return c.conn.CloseWithError(0, "")
+}
+
+// Listener accepts QUIC connections that carry OSC packets.
+type Listener struct {
+	ln *quic.Listener
+}
+
+// Listen listens for QUIC connections on addr. tlsConf must contain a
+// certificate.
+func Listen(addr string, tlsConf *tls.Config) (*Listener, error) {
+	ln, err := quic.ListenAddr(addr, withALPN(tlsConf), config())
+	if err != nil {
+		return nil, err
+	}
+	return &Listener{ln: ln}, nil
+}
+
+// Accept waits for the next connection.
+func (l *Listener) Accept(ctx context.Context) (*Conn, error) {
+	conn, err := l.ln.Accept(ctx)
+	if err != nil {
+		return nil, err
+	}
+	return &Conn{conn: conn}, nil
+}
+
+// Addr returns the address the listener is listening on.
+func (l *Listener) Addr() net.Addr {
+	return l.ln.Addr()
+}
+
+// Close stops listening. Accepted connections stay open.
+func (l *Listener) Close() error {
+	return l.ln.Close()
+}
+
+// Serve accepts connections on l and passes the packets received on every
+// connection to d until ctx is done or the listener fails. Malformed packets
+// are skipped.
+func Serve(ctx context.Context, l *Listener, d osc.Dispatcher) error {
+	for {
+		conn, err := l.Accept(ctx)
+		if err != nil {
+			return err
+		}
+		go func() {
+			defer conn.Close()
+			for {
+				p, err := conn.Receive(ctx)
+				if _, ok := err.(*osc.DecodeError); ok {
+					continue
+				}
+				if err != nil {
+					return
+				}
+				d.Dispatch(p)
+			}
+		}()
+	}
+}
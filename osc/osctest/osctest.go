@@ -0,0 +1,194 @@
+// Package osctest provides utilities for testing code that sends and receives
+// OSC packets.
+package osctest
+
+import (
+	"bytes"
+	"fmt"
+	"io/ioutil"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/hypebeast/go-osc/osc"
+)
+
+// UpdateGolden makes AssertGolden write the golden files instead of comparing
+// them, e.g. set from a test flag to update the files after the wire format
+// changed on purpose.
+var UpdateGolden bool
+
+// MustMessage returns a new message with the given address and arguments. It
+// panics if the message can't be encoded, e.g. because of an invalid address
+// or an argument of an unsupported type.
+func MustMessage(addr string, args ...interface{}) *osc.Message {
+	msg := osc.NewMessage(addr, args...)
+	if _, err := msg.MarshalBinary(); err != nil {
+		panic(fmt.Sprintf("osctest: invalid message %s: %s", addr, err))
+	}
+	return msg
+}
+
+// Recorder is a Handler that records copies of the received messages. It is
+// safe for concurrent use, so it can be used as handler of a running server.
+type Recorder struct {
+	mu       sync.Mutex
+	messages []*osc.Message
+	received chan struct{} // Closed and replaced for every message
+}
+
+// Verify that Recorder implements the Handler interface.
+var _ osc.Handler = (*Recorder)(nil)
+
+// NewRecorder returns a new Recorder.
+func NewRecorder() *Recorder {
+	return &Recorder{received: make(chan struct{})}
+}
+
+// HandleMessage records a copy of msg. Implements the Handler interface.
+func (r *Recorder) HandleMessage(msg *osc.Message) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.messages = append(r.messages, msg.Clone())
+	close(r.received)
+	r.received = make(chan struct{})
+}
+
+// Messages returns the recorded messages in the order they were received.
+func (r *Recorder) Messages() []*osc.Message {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	return append([]*osc.Message(nil), r.messages...)
+}
+
+// Len returns the number of recorded messages.
+func (r *Recorder) Len() int {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	return len(r.messages)
+}
+
+// Reset discards the recorded messages.
+func (r *Recorder) Reset() {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.messages = nil
+}
+
+// Wait waits until at least n messages were recorded or the timeout expired.
+// It returns the recorded messages.
+func (r *Recorder) Wait(n int, timeout time.Duration) []*osc.Message {
+	deadline := time.After(timeout)
+	for {
+		r.mu.Lock()
+		messages, received := r.messages, r.received
+		r.mu.Unlock()
+		if len(messages) >= n {
+			return append([]*osc.Message(nil), messages...)
+		}
+		select {
+		case <-received:
+		case <-deadline:
+			return r.Messages()
+		}
+	}
+}
+
+// AssertReceived reports an error if none of the recorded messages is equal
+// to want.
+func (r *Recorder) AssertReceived(t testing.TB, want *osc.Message) {
+	t.Helper()
+	messages := r.Messages()
+	for _, msg := range messages {
+		if msg.Equals(want) {
+			return
+		}
+	}
+	t.Errorf("osctest: message %v not received, got:\n%s", want, formatMessages(messages))
+}
+
+// AssertMessages reports an error if the recorded messages aren't equal to
+// want.
+func (r *Recorder) AssertMessages(t testing.TB, want ...*osc.Message) {
+	t.Helper()
+	messages := r.Messages()
+	equal := len(messages) == len(want)
+	for i := 0; equal && i < len(want); i++ {
+		equal = messages[i].Equals(want[i])
+	}
+	if !equal {
+		t.Errorf("osctest: received messages:\n%swant:\n%s", formatMessages(messages), formatMessages(want))
+	}
+}
+
+// formatMessages returns one line per message.
+func formatMessages(messages []*osc.Message) string {
+	if len(messages) == 0 {
+		return "\t(none)\n"
+	}
+	var b bytes.Buffer
+	for _, msg := range messages {
+		fmt.Fprintf(&b, "\t%v\n", msg)
+	}
+	return b.String()
+}
+
+// AssertWireFormat reports an error if the encoded packet isn't equal to want.
+// The difference is shown as hex dump.
+func AssertWireFormat(t testing.TB, packet osc.Packet, want []byte) {
+	t.Helper()
+	data, err := packet.MarshalBinary()
+	if err != nil {
+		t.Errorf("osctest: MarshalBinary() unexpected error: %s", err)
+		return
+	}
+	if !bytes.Equal(data, want) {
+		t.Errorf("osctest: wire format of %v:\n%swant:\n%s", packet, Dump(data), Dump(want))
+	}
+}
+
+// AssertGolden reports an error if the encoded packet isn't equal to the
+// contents of the golden file at path. If UpdateGolden is set, the file is
+// written instead.
+func AssertGolden(t testing.TB, path string, packet osc.Packet) {
+	t.Helper()
+	if UpdateGolden {
+		data, err := packet.MarshalBinary()
+		if err != nil {
+			t.Errorf("osctest: MarshalBinary() unexpected error: %s", err)
+			return
+		}
+		if err := ioutil.WriteFile(path, data, 0644); err != nil {
+			t.Errorf("osctest: %s", err)
+		}
+		return
+	}
+
+	want, err := ioutil.ReadFile(path)
+	if err != nil {
+		t.Errorf("osctest: %s", err)
+		return
+	}
+	AssertWireFormat(t, packet, want)
+}
+
+// Dump returns a hex dump of OSC data with one row per 4 bytes, which matches
+// the alignment of OSC data.
+func Dump(data []byte) string {
+	var b bytes.Buffer
+	for i := 0; i < len(data); i += 4 {
+		end := i + 4
+		if end > len(data) {
+			end = len(data)
+		}
+		fmt.Fprintf(&b, "\t%04x  % -11x  ", i, data[i:end])
+		for _, c := range data[i:end] {
+			if c < 0x20 || c > 0x7E {
+				c = '.'
+			}
+			b.WriteByte(c)
+		}
+		b.WriteByte('\n')
+	}
+	return b.String()
+}
@@ -0,0 +1,125 @@
+package osctest
+
+import (
+	"fmt"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/hypebeast/go-osc/osc"
+)
+
+// recordingT records the errors reported by the assertions.
+type recordingT struct {
+	testing.TB
+	errors []string
+}
+
+func (t *recordingT) Helper() {}
+
+func (t *recordingT) Errorf(format string, args ...interface{}) {
+	t.errors = append(t.errors, fmt.Sprintf(format, args...))
+}
+
+func TestMustMessage(t *testing.T) {
+	msg := MustMessage("/test", int32(1), "a")
+	if want := osc.NewMessage("/test", int32(1), "a"); !msg.Equals(want) {
+		t.Errorf("MustMessage() = %v, want = %v", msg, want)
+	}
+
+	for _, tt := range []struct {
+		addr string
+		args []interface{}
+	}{
+		{"test", nil},
+		{"/test", []interface{}{struct{}{}}},
+	} {
+		func() {
+			defer func() {
+				if recover() == nil {
+					t.Errorf("MustMessage(%s, %v) didn't panic", tt.addr, tt.args)
+				}
+			}()
+			MustMessage(tt.addr, tt.args...)
+		}()
+	}
+}
+
+func TestRecorder(t *testing.T) {
+	r := NewRecorder()
+	d := osc.NewStandardDispatcher()
+	if err := d.AddMsgHandler("*", r.HandleMessage); err != nil {
+		t.Fatal(err)
+	}
+	go func() {
+		d.Dispatch(MustMessage("/a", int32(1)))
+		d.Dispatch(MustMessage("/b"))
+	}()
+	if got := r.Wait(2, 5*time.Second); len(got) != 2 {
+		t.Fatalf("Wait() = %v, want 2 messages", got)
+	}
+
+	r.AssertReceived(t, MustMessage("/b"))
+	r.AssertMessages(t, MustMessage("/a", int32(1)), MustMessage("/b"))
+
+	rt := &recordingT{TB: t}
+	r.AssertReceived(rt, MustMessage("/c"))
+	r.AssertMessages(rt, MustMessage("/a", int32(1)))
+	if len(rt.errors) != 2 {
+		t.Errorf("failed assertions reported %d errors, want = 2", len(rt.errors))
+	}
+
+	r.Reset()
+	if r.Len() != 0 {
+		t.Errorf("Len() = %d after Reset, want = 0", r.Len())
+	}
+	if got := r.Wait(1, 10*time.Millisecond); len(got) != 0 {
+		t.Errorf("Wait() = %v, want no messages", got)
+	}
+}
+
+func TestAssertWireFormat(t *testing.T) {
+	msg := MustMessage("/a", int32(1))
+	AssertWireFormat(t, msg, []byte("/a\x00\x00,i\x00\x00\x00\x00\x00\x01"))
+
+	rt := &recordingT{TB: t}
+	AssertWireFormat(rt, msg, []byte("/a\x00\x00,i\x00\x00\x00\x00\x00\x02"))
+	if len(rt.errors) != 1 || !strings.Contains(rt.errors[0], "0008  00 00 00 02") {
+		t.Errorf("errors = %q, want a hex dump of the difference", rt.errors)
+	}
+}
+
+func TestAssertGolden(t *testing.T) {
+	dir, err := ioutil.TempDir("", "osctest")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+	path := filepath.Join(dir, "msg.golden")
+	msg := MustMessage("/golden", "x")
+
+	UpdateGolden = true
+	AssertGolden(t, path, msg)
+	UpdateGolden = false
+	AssertGolden(t, path, msg)
+
+	rt := &recordingT{TB: t}
+	AssertGolden(rt, path, MustMessage("/golden", "y"))
+	AssertGolden(rt, filepath.Join(dir, "missing.golden"), msg)
+	if len(rt.errors) != 2 {
+		t.Errorf("failed assertions reported %d errors, want = 2", len(rt.errors))
+	}
+}
+
+func TestDump(t *testing.T) {
+	got := Dump([]byte("/ab\x00,i\x00\x00\x00"))
+	want := "\t0000  2f 61 62 00  /ab.\n" +
+		"\t0004  2c 69 00 00  ,i..\n" +
+		"\t0008  00           .\n"
+	if got != want {
+		t.Errorf("Dump() =\n%s\nwant =\n%s", got, want)
+	}
+}
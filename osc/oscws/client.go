@@ -0,0 +1,108 @@
+//go:build js && wasm
+// +build js,wasm
+
+package oscws
+
+import (
+	"context"
+	"sync"
+	"time"
+
+	"github.com/hypebeast/go-osc/osc/codec"
+)
+
+// DefaultDialTimeout is the time a Client waits for its WebSocket to open.
+const DefaultDialTimeout = 5 * time.Second
+
+// Client sends OSC packets over a WebSocket, e.g. from a browser user
+// interface to a WebSocket-to-UDP bridge. It is the browser counterpart of
+// osc.Client and implements the Sender interface of package osc.
+//
+// The WebSocket is opened on the first Send, and opened again by the next
+// Send after it was closed, so a restarted bridge doesn't break the client.
+// Send waits for the WebSocket to open and must not be called from a
+// JavaScript callback, which would block the event loop of the browser.
+type Client struct {
+	url string
+
+	mu     sync.Mutex
+	conn   *Conn
+	closed bool
+
+	// DialTimeout limits the time Send waits for the WebSocket to open. If
+	// it is 0, DefaultDialTimeout is used.
+	DialTimeout time.Duration
+}
+
+// NewClient creates a client that sends packets to the WebSocket at url,
+// e.g. "ws://localhost:8080/osc".
+func NewClient(url string) *Client {
+	return &Client{url: url}
+}
+
+// URL returns the URL of the WebSocket.
+func (c *Client) URL() string { return c.url }
+
+// Send sends the packet as a single binary message and returns the number of
+// bytes that were sent.
+func (c *Client) Send(packet codec.Packet) (int, error) {
+	conn, err := c.connect()
+	if err != nil {
+		return 0, err
+	}
+	return conn.Send(packet)
+}
+
+// Emit builds a message with the given address and arguments and sends it,
+// e.g. client.Emit("/synth/freq", 440.0). The arguments are converted with
+// the CoerceNative32 policy like in osc.Client.Emit.
+func (c *Client) Emit(addr string, args ...interface{}) error {
+	msg := codec.NewMessage(addr)
+	msg.SetCoercion(codec.CoerceNative32)
+	msg.Append(args...)
+	_, err := c.Send(msg)
+	return err
+}
+
+// Close closes the WebSocket. Send returns ErrClosed afterwards.
+func (c *Client) Close() error {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.closed = true
+	if c.conn == nil {
+		return nil
+	}
+	err := c.conn.Close()
+	c.conn = nil
+	return err
+}
+
+// connect returns the open connection of the client and opens a new one if
+// there is none or the last one was closed.
+func (c *Client) connect() (*Conn, error) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	if c.closed {
+		return nil, ErrClosed
+	}
+	if c.conn != nil && !c.conn.isClosed() {
+		return c.conn, nil
+	}
+	if c.conn != nil {
+		c.conn.Close()
+		c.conn = nil
+	}
+
+	timeout := c.DialTimeout
+	if timeout == 0 {
+		timeout = DefaultDialTimeout
+	}
+	ctx, cancel := context.WithTimeout(context.Background(), timeout)
+	defer cancel()
+	conn, err := Dial(ctx, c.url)
+	if err != nil {
+		return nil, err
+	}
+	c.conn = conn
+	return conn, nil
+}
@@ -0,0 +1,24 @@
+// Package oscws sends and receives OSC packets over a WebSocket in the
+// browser, so Go user interfaces that are compiled to WebAssembly can talk
+// OSC directly. Every packet is sent as one binary WebSocket message, the
+// convention used by osc.js and most WebSocket-to-UDP bridges.
+//
+// Client sends packets like osc.Client and opens the WebSocket when it is
+// needed:
+//
+//	client := oscws.NewClient("ws://localhost:8080/osc")
+//	client.Emit("/synth/freq", 440.0)
+//
+// Conn is a single WebSocket connection that sends packets and receives them
+// for a Dispatcher, e.g. an osc.Mux.
+//
+// The package uses the WebSocket API of the browser through syscall/js and
+// is only built for GOOS=js and GOARCH=wasm:
+//
+//	GOOS=js GOARCH=wasm go build
+//
+// It only depends on the packages codec and dispatch of go-osc, which don't
+// use package net. Client and Conn implement the Sender interface of package
+// osc, but package osc itself uses UDP and its Client, Server and Peer can't
+// be used in the browser.
+package oscws
@@ -0,0 +1,167 @@
+//go:build js && wasm
+// +build js,wasm
+
+package oscws
+
+import (
+	"context"
+	"errors"
+	"sync"
+	"syscall/js"
+
+	"github.com/hypebeast/go-osc/osc/codec"
+	"github.com/hypebeast/go-osc/osc/dispatch"
+)
+
+// ErrClosed is returned by Send and Receive after the WebSocket was closed.
+var ErrClosed = errors.New("oscws: connection closed")
+
+// receiveBuffer is the number of received packets that are buffered until
+// Receive is called. Further packets are dropped.
+const receiveBuffer = 256
+
+// Conn is a WebSocket connection that carries OSC packets. It is safe to call
+// Send and Receive concurrently. Its Send method implements the Sender
+// interface of package osc.
+type Conn struct {
+	ws       js.Value
+	received chan []byte
+	closed   chan struct{}
+	handlers []listener
+
+	// DecodeOptions control how received packets are decoded.
+	DecodeOptions codec.DecodeOptions
+
+	closedOnce sync.Once // Closes closed
+	closeOnce  sync.Once // Closes the WebSocket
+}
+
+// listener is an event listener that was added to the WebSocket.
+type listener struct {
+	event string
+	fn    js.Func
+}
+
+// Dial opens a WebSocket connection to url, e.g. "ws://localhost:8080/osc",
+// and waits until it is open or ctx is done.
+func Dial(ctx context.Context, url string) (*Conn, error) {
+	ws := js.Global().Get("WebSocket").New(url)
+	ws.Set("binaryType", "arraybuffer")
+
+	c := &Conn{
+		ws:       ws,
+		received: make(chan []byte, receiveBuffer),
+		closed:   make(chan struct{}),
+	}
+	opened := make(chan struct{})
+	failed := make(chan struct{})
+	var once sync.Once
+	c.on("open", func(js.Value) { once.Do(func() { close(opened) }) })
+	c.on("error", func(js.Value) { once.Do(func() { close(failed) }) })
+	c.on("close", func(js.Value) {
+		once.Do(func() { close(failed) })
+		c.markClosed()
+	})
+	c.on("message", func(event js.Value) {
+		buf := js.Global().Get("Uint8Array").New(event.Get("data"))
+		data := make([]byte, buf.Get("length").Int())
+		js.CopyBytesToGo(data, buf)
+		select {
+		case c.received <- data:
+		default:
+		}
+	})
+
+	select {
+	case <-opened:
+		return c, nil
+	case <-failed:
+		c.Close()
+		return nil, errors.New("oscws: connection to " + url + " failed")
+	case <-ctx.Done():
+		c.Close()
+		return nil, ctx.Err()
+	}
+}
+
+// on adds an event listener to the WebSocket.
+func (c *Conn) on(event string, fn func(event js.Value)) {
+	f := js.FuncOf(func(this js.Value, args []js.Value) interface{} {
+		fn(args[0])
+		return nil
+	})
+	c.handlers = append(c.handlers, listener{event, f})
+	c.ws.Call("addEventListener", event, f)
+}
+
+// Send sends the packet as a single binary message.
+func (c *Conn) Send(packet codec.Packet) (int, error) {
+	if c.isClosed() {
+		return 0, ErrClosed
+	}
+	data, err := packet.MarshalBinary()
+	if err != nil {
+		return 0, err
+	}
+	buf := js.Global().Get("Uint8Array").New(len(data))
+	js.CopyBytesToJS(buf, data)
+	c.ws.Call("send", buf)
+	return len(data), nil
+}
+
+// Receive waits for the next message and decodes it. Malformed packets are
+// reported as *codec.DecodeError, after which Receive can be called again.
+func (c *Conn) Receive(ctx context.Context) (codec.Packet, error) {
+	select {
+	case data := <-c.received:
+		dec := codec.Decoder{Options: c.DecodeOptions}
+		return dec.DecodeBytes(data)
+	case <-c.closed:
+		return nil, ErrClosed
+	case <-ctx.Done():
+		return nil, ctx.Err()
+	}
+}
+
+// Serve passes the received packets to d until ctx is done or the connection
+// is closed. Malformed packets are skipped.
+func (c *Conn) Serve(ctx context.Context, d dispatch.Dispatcher) error {
+	for {
+		p, err := c.Receive(ctx)
+		if _, ok := err.(*codec.DecodeError); ok {
+			continue
+		}
+		if err != nil {
+			return err
+		}
+		d.Dispatch(p)
+	}
+}
+
+// Close closes the WebSocket.
+func (c *Conn) Close() error {
+	c.markClosed()
+	c.closeOnce.Do(func() {
+		c.ws.Call("close")
+		for _, l := range c.handlers {
+			c.ws.Call("removeEventListener", l.event, l.fn)
+			l.fn.Release()
+		}
+	})
+	return nil
+}
+
+// isClosed reports whether the WebSocket was closed.
+func (c *Conn) isClosed() bool {
+	select {
+	case <-c.closed:
+		return true
+	default:
+		return false
+	}
+}
+
+// markClosed makes Send and Receive return ErrClosed.
+func (c *Conn) markClosed() {
+	c.closedOnce.Do(func() { close(c.closed) })
+}
@@ -0,0 +1,167 @@
+package osc
+
+import (
+	"net"
+	"time"
+)
+
+// DefaultPacketsBuffer is the capacity of the Packets channel if
+// Server.PacketsBuffer is zero.
+const DefaultPacketsBuffer = 64
+
+// ReceivedPacket is a packet that was received by a Server, see Packets.
+type ReceivedPacket struct {
+	Packet   Packet
+	Source   net.Addr  // Address of the sender
+	Received time.Time // Local time the packet was received at
+}
+
+// Packets returns a channel that receives the packets that are received by
+// Serve, as an alternative to dispatching them. This allows applications
+// with a main loop, e.g. games or audio threads, to drain the received
+// packets at their own pace without locking in handlers.
+//
+// After Packets was called the first time, received packets are sent to the
+// channel after they passed the receive pipeline, instead of being passed to
+// the Dispatcher. Bundles are sent when they are received, their timetags
+// must be honored by the receiver. PacketsOverflow selects what happens if
+// the channel is full. The channel isn't closed when Serve returns,
+// since the server can be served again. Messages aren't recycled, even if
+// ReuseMessages is set.
+func (s *Server) Packets() <-chan ReceivedPacket {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if s.packets == nil {
+		n := s.PacketsBuffer
+		if n <= 0 {
+			n = DefaultPacketsBuffer
+		}
+		s.packets = make(chan ReceivedPacket, n)
+	}
+	return s.packets
+}
+
+// OverflowPolicy selects what happens to a received packet if the channel
+// returned by Server.Packets is full.
+type OverflowPolicy int
+
+const (
+	// OverflowBlock makes Serve wait until the packet can be sent, which
+	// makes the operating system drop packets once the socket buffer is
+	// full. Nothing is lost as long as the receiver catches up.
+	OverflowBlock OverflowPolicy = iota
+
+	// OverflowDropNewest drops the received packet, which keeps the queued
+	// packets, e.g. for a complete but delayed log.
+	OverflowDropNewest
+
+	// OverflowDropOldest drops the oldest queued packet to make room for
+	// the received packet, which keeps the latency low.
+	OverflowDropOldest
+
+	// OverflowCoalesce replaces the queued messages with the address of the
+	// received message, so that only the latest value of an address is
+	// queued, e.g. for faders and telemetry. If no queued message has the
+	// address, or if the received packet is a bundle, the oldest packet is
+	// dropped.
+	OverflowCoalesce
+)
+
+// PacketStats are the counters of the packets that were sent to the Packets
+// channel of a Server.
+type PacketStats struct {
+	Delivered uint64 // Number of packets sent to the channel
+	Dropped   uint64 // Number of packets dropped because the channel was full
+	Coalesced uint64 // Number of queued messages replaced by newer ones
+}
+
+// PacketStats returns the counters of the Packets channel, e.g. to detect a
+// receiver that can't keep up.
+func (s *Server) PacketStats() PacketStats {
+	s.packetsMu.Lock()
+	defer s.packetsMu.Unlock()
+	return s.packetStats
+}
+
+// packetsChan returns the Packets channel, or nil if it wasn't requested.
+func (s *Server) packetsChan() chan ReceivedPacket {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return s.packets
+}
+
+// deliver passes the packet through the receive pipeline and sends it to ch
+// according to PacketsOverflow.
+func (s *Server) deliver(ch chan ReceivedPacket, packet Packet, info *MessageInfo) {
+	if packet = s.prepare(packet); packet == nil {
+		return
+	}
+	rp := ReceivedPacket{Packet: packet, Source: info.Source, Received: info.Received}
+	if s.PacketsOverflow == OverflowBlock {
+		ch <- rp
+		s.packetsMu.Lock()
+		s.packetStats.Delivered++
+		s.packetsMu.Unlock()
+		return
+	}
+
+	s.packetsMu.Lock()
+	defer s.packetsMu.Unlock()
+	select {
+	case ch <- rp:
+		s.packetStats.Delivered++
+		return
+	default:
+	}
+
+	switch s.PacketsOverflow {
+	case OverflowDropNewest:
+		s.packetStats.Dropped++
+		s.log(LogDebug, "osc: packet dropped, the packets channel is full", "from", rp.Source)
+		return
+
+	case OverflowCoalesce:
+		if msg, ok := packet.(*Message); ok && s.coalesce(ch, msg.Address) {
+			break
+		}
+		fallthrough
+
+	default:
+		// Drop the oldest packet, unless the receiver took one meanwhile
+		select {
+		case <-ch:
+			s.packetStats.Dropped++
+			s.log(LogDebug, "osc: oldest packet dropped, the packets channel is full")
+		default:
+		}
+	}
+	// There is room now, only deliver sends to ch
+	ch <- rp
+	s.packetStats.Delivered++
+}
+
+// coalesce removes the queued messages with the given address from ch and
+// returns true if there were any. The order of the other packets is kept.
+func (s *Server) coalesce(ch chan ReceivedPacket, addr string) bool {
+	var queued []ReceivedPacket
+drain:
+	for {
+		select {
+		case rp := <-ch:
+			queued = append(queued, rp)
+		default:
+			break drain
+		}
+	}
+
+	removed := 0
+	for _, rp := range queued {
+		if msg, ok := rp.Packet.(*Message); ok && msg.Address == addr {
+			removed++
+			continue
+		}
+		ch <- rp // Doesn't block, only deliver sends to ch
+	}
+	s.packetStats.Coalesced += uint64(removed)
+	return removed > 0
+}
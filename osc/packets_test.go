@@ -0,0 +1,130 @@
+package osc
+
+import (
+	"net"
+	"reflect"
+	"testing"
+	"time"
+)
+
+func TestServer_Packets(t *testing.T) {
+	dispatched := make(chan *Message, 1)
+	d := NewStandardDispatcher()
+	d.AddMsgHandler("*", func(msg *Message) {
+		dispatched <- msg
+	})
+	server := &Server{Dispatcher: d, PacketsBuffer: 4}
+	server.Use(Filter(func(msg *Message) bool { return msg.Address != "/drop" }))
+	packets := server.Packets()
+	if cap(packets) != 4 {
+		t.Errorf("cap(Packets()) = %d, want = 4", cap(packets))
+	}
+	conn, addr, err := Listen("127.0.0.1:0")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer conn.Close()
+	go server.Serve(conn)
+
+	sender, err := net.ListenPacket("udp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer sender.Close()
+	bundle := NewBundle(time.Now().Add(time.Hour))
+	bundle.Append(NewMessage("/b"))
+	want := []Packet{NewMessage("/a", int32(1)), bundle, NewMessage("/c")}
+	for _, p := range []Packet{want[0], NewMessage("/drop"), want[1], want[2]} {
+		data, _ := p.MarshalBinary()
+		if _, err := sender.WriteTo(data, addr); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	for i, p := range want {
+		select {
+		case got := <-packets:
+			if got.Source.String() != sender.LocalAddr().String() || got.Received.IsZero() {
+				t.Errorf("packet %d from %v at %v, want from %v", i, got.Source, got.Received, sender.LocalAddr())
+			}
+			switch p := p.(type) {
+			case *Message:
+				if msg, ok := got.Packet.(*Message); !ok || !msg.Equals(p) {
+					t.Errorf("packet %d = %v, want = %v", i, got.Packet, p)
+				}
+			case *Bundle:
+				if b, ok := got.Packet.(*Bundle); !ok || !b.Equals(p) {
+					t.Errorf("packet %d = %v, want = %v", i, got.Packet, p)
+				}
+			}
+		case <-time.After(time.Second):
+			t.Fatalf("packet %d not received", i)
+		}
+	}
+	select {
+	case msg := <-dispatched:
+		t.Errorf("message %v dispatched, want packets only on the channel", msg)
+	case <-time.After(10 * time.Millisecond):
+	}
+}
+
+func TestServer_PacketsOverflow(t *testing.T) {
+	addresses := func(ch <-chan ReceivedPacket) []string {
+		var addrs []string
+		for len(ch) > 0 {
+			switch p := (<-ch).Packet.(type) {
+			case *Message:
+				addrs = append(addrs, p.Address)
+			case *Bundle:
+				addrs = append(addrs, "#bundle")
+			}
+		}
+		return addrs
+	}
+	bundle := NewBundle(time.Now())
+	info := &MessageInfo{}
+
+	for _, tt := range []struct {
+		policy  OverflowPolicy
+		packets []Packet
+		want    []string
+		stats   PacketStats
+	}{
+		{
+			OverflowDropNewest,
+			[]Packet{NewMessage("/a"), NewMessage("/b"), NewMessage("/c"), NewMessage("/d")},
+			[]string{"/a", "/b", "/c"},
+			PacketStats{Delivered: 3, Dropped: 1},
+		},
+		{
+			OverflowDropOldest,
+			[]Packet{NewMessage("/a"), NewMessage("/b"), NewMessage("/c"), NewMessage("/d"), NewMessage("/e")},
+			[]string{"/c", "/d", "/e"},
+			PacketStats{Delivered: 5, Dropped: 2},
+		},
+		{
+			OverflowCoalesce,
+			[]Packet{NewMessage("/a"), NewMessage("/b"), NewMessage("/a"), NewMessage("/a"), NewMessage("/c")},
+			[]string{"/b", "/a", "/c"},
+			PacketStats{Delivered: 5, Coalesced: 2},
+		},
+		{
+			OverflowCoalesce,
+			[]Packet{NewMessage("/a"), NewMessage("/b"), NewMessage("/c"), bundle},
+			[]string{"/b", "/c", "#bundle"},
+			PacketStats{Delivered: 4, Dropped: 1},
+		},
+	} {
+		server := &Server{PacketsBuffer: 3, PacketsOverflow: tt.policy}
+		ch := server.Packets()
+		for _, p := range tt.packets {
+			server.deliver(server.packetsChan(), p, info)
+		}
+		if got := addresses(ch); !reflect.DeepEqual(got, tt.want) {
+			t.Errorf("policy %d: queued %v, want = %v", tt.policy, got, tt.want)
+		}
+		if stats := server.PacketStats(); stats != tt.stats {
+			t.Errorf("policy %d: PacketStats() = %+v, want = %+v", tt.policy, stats, tt.stats)
+		}
+	}
+}
@@ -0,0 +1,155 @@
+package osc
+
+import (
+	"errors"
+	"fmt"
+	"strconv"
+	"strings"
+
+	"github.com/hypebeast/go-osc/osc/internal/pattern"
+)
+
+// Params holds the address parts that were captured by the parameters of an
+// address template, keyed by the parameter names.
+type Params map[string]string
+
+// Int returns the value of the parameter name as integer, e.g. the channel
+// number captured by "/track/{n}/volume".
+func (p Params) Int(name string) (int, error) {
+	v, ok := p[name]
+	if !ok {
+		return 0, fmt.Errorf("osc: no parameter %q", name)
+	}
+	return strconv.Atoi(v)
+}
+
+// ParamHandlerFunc is a message handler that receives the parameters that
+// were captured from the address of the message.
+type ParamHandlerFunc func(msg *Message, params Params)
+
+// paramHandler is a handler that is registered for an address template.
+type paramHandler struct {
+	template *addressTemplate
+	handler  ParamHandlerFunc
+	priority int
+	seq      uint64
+}
+
+// addressTemplate is an OSC address whose parts may be parameters, e.g.
+// "/track/{n}/volume".
+type addressTemplate struct {
+	template string
+	parts    []templatePart
+}
+
+// templatePart is either a literal address part or a parameter.
+type templatePart struct {
+	literal string
+	param   string // Name of the parameter, empty for literal parts
+}
+
+// AddParamHandler adds a new message handler for the given address template.
+// Address parts of the form "{name}" are parameters that match any non-empty
+// address part, e.g. the template "/track/{n}/volume" matches
+// "/track/1/volume" and passes the parameter n = "1" to the handler. Other
+// parts must not contain address pattern characters.
+//
+// If the address of a message is a pattern, the literal parts of the template
+// are matched against it and the parameters capture the parts of the pattern,
+// e.g. "/track/*/volume" passes n = "*".
+func (s *StandardDispatcher) AddParamHandler(addr string, handler ParamHandlerFunc) error {
+	if s.normalize {
+		addr = normalizeAddress(addr)
+	}
+	t, err := parseAddressTemplate(addr)
+	if err != nil {
+		return err
+	}
+	if s.caseInsensitive {
+		// The parameter names keep their case
+		for i := range t.parts {
+			t.parts[i].literal = strings.ToLower(t.parts[i].literal)
+		}
+	}
+	for _, ph := range s.paramHandlers {
+		if ph.template.template == addr {
+			return errors.New("OSC address exists already")
+		}
+	}
+	s.paramHandlers = append(s.paramHandlers, paramHandler{t, handler, 0, nextHandlerSeq()})
+	return nil
+}
+
+// parseAddressTemplate parses an address template.
+func parseAddressTemplate(template string) (*addressTemplate, error) {
+	if !strings.HasPrefix(template, "/") {
+		return nil, fmt.Errorf("osc: address template %q must start with '/'", template)
+	}
+
+	t := &addressTemplate{template: template}
+	names := make(map[string]bool)
+	for _, part := range strings.Split(template, "/") {
+		if strings.HasPrefix(part, "{") && strings.HasSuffix(part, "}") && isParamName(part[1:len(part)-1]) {
+			name := part[1 : len(part)-1]
+			if names[name] {
+				return nil, fmt.Errorf("osc: duplicate parameter %q in address template %q", name, template)
+			}
+			names[name] = true
+			t.parts = append(t.parts, templatePart{param: name})
+			continue
+		}
+		if err := pattern.ValidateAddress(part); err != nil {
+			return nil, err
+		}
+		t.parts = append(t.parts, templatePart{literal: part})
+	}
+	return t, nil
+}
+
+// isParamName returns true if name is a valid parameter name, i.e. a letter
+// or '_' followed by letters, digits and '_'.
+func isParamName(name string) bool {
+	if name == "" {
+		return false
+	}
+	for i, c := range name {
+		switch {
+		case c == '_', 'a' <= c && c <= 'z', 'A' <= c && c <= 'Z':
+		case '0' <= c && c <= '9' && i > 0:
+		default:
+			return false
+		}
+	}
+	return true
+}
+
+// match matches the template against the address pattern p and returns the
+// captured parameters.
+func (t *addressTemplate) match(p *Pattern) (Params, bool) {
+	parts := pattern.Parts(p)
+	if pattern.AnyDepth(p) || len(parts) != len(t.parts) {
+		return nil, false
+	}
+	var params Params
+	var sources []string
+	for i, part := range t.parts {
+		if part.param == "" {
+			if !parts[i].Match(part.literal) {
+				return nil, false
+			}
+			continue
+		}
+
+		if sources == nil {
+			sources = strings.Split(p.String(), "/")
+		}
+		if sources[i] == "" {
+			return nil, false
+		}
+		if params == nil {
+			params = make(Params)
+		}
+		params[part.param] = sources[i]
+	}
+	return params, true
+}
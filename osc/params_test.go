@@ -0,0 +1,74 @@
+package osc
+
+import (
+	"reflect"
+	"testing"
+)
+
+func TestStandardDispatcher_AddParamHandler(t *testing.T) {
+	type call struct {
+		handler string
+		params  Params
+	}
+	var calls []call
+	d := NewStandardDispatcher()
+	for _, addr := range []string{"/track/{n}/volume", "/track/{n}/send/{bus}", "/track/{n}/mute"} {
+		addr := addr
+		err := d.AddParamHandler(addr, func(msg *Message, params Params) {
+			calls = append(calls, call{addr, params})
+		})
+		if err != nil {
+			t.Fatalf("AddParamHandler(%s) unexpected error: %s", addr, err)
+		}
+	}
+	if err := d.AddMsgHandler("/track/master/volume", func(msg *Message) {
+		calls = append(calls, call{msg.Address, nil})
+	}); err != nil {
+		t.Fatal(err)
+	}
+
+	for _, tt := range []struct {
+		addr string
+		want []call
+	}{
+		{"/track/3/volume", []call{{"/track/{n}/volume", Params{"n": "3"}}}},
+		{"/track/12/send/a", []call{{"/track/{n}/send/{bus}", Params{"n": "12", "bus": "a"}}}},
+		{"/track/master/volume", []call{
+			{"/track/master/volume", nil},
+			{"/track/{n}/volume", Params{"n": "master"}},
+		}},
+		{"/track/*/mute", []call{{"/track/{n}/mute", Params{"n": "*"}}}},
+		{"/track/1/{mute,volume}", []call{
+			{"/track/{n}/volume", Params{"n": "1"}},
+			{"/track/{n}/mute", Params{"n": "1"}},
+		}},
+		{"/track//volume", []call{{"/track//volume", nil}}},
+		{"/track/1/pan", nil},
+		{"/track/1/volume/fine", nil},
+	} {
+		calls = nil
+		d.Dispatch(NewMessage(tt.addr))
+		if !reflect.DeepEqual(calls, tt.want) {
+			t.Errorf("%s: calls = %v, want = %v", tt.addr, calls, tt.want)
+		}
+	}
+
+	for _, addr := range []string{"track/{n}", "/track/{n}/{n}", "/track/{n}/volume", "/track/*/{n}"} {
+		if err := d.AddParamHandler(addr, func(*Message, Params) {}); err == nil {
+			t.Errorf("AddParamHandler(%s) expected error", addr)
+		}
+	}
+}
+
+func TestParams_Int(t *testing.T) {
+	params := Params{"n": "12", "name": "bass"}
+	if n, err := params.Int("n"); err != nil || n != 12 {
+		t.Errorf("Int(n) = %d, %v, want = 12", n, err)
+	}
+	if _, err := params.Int("name"); err == nil {
+		t.Error("Int(name) expected error")
+	}
+	if _, err := params.Int("missing"); err == nil {
+		t.Error("Int(missing) expected error")
+	}
+}
@@ -0,0 +1,52 @@
+package osc
+
+import (
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestPattern_MatchBacktracking(t *testing.T) {
+	// Backtracking on every '*' takes exponential time for these patterns
+	pattern := "/" + strings.Repeat("*a", 16) + "b"
+	addr := "/" + strings.Repeat("a", 40)
+
+	d := NewStandardDispatcher()
+	if err := d.AddMsgHandler(addr, func(*Message) {}); err != nil {
+		t.Fatal(err)
+	}
+	start := time.Now()
+	if MustCompilePattern(pattern).Match(addr) {
+		t.Errorf("%q matches %q", pattern, addr)
+	}
+	if n := d.Invoke(pattern); n != 0 {
+		t.Errorf("Invoke(%q) matched %d handlers, want = 0", pattern, n)
+	}
+	if elapsed := time.Since(start); elapsed > time.Second {
+		t.Errorf("matching %q took %s", pattern, elapsed)
+	}
+}
+
+func TestPattern_MatchAnyDepth(t *testing.T) {
+	// Trying every suffix for every "//" takes exponential time
+	pattern := strings.Repeat("//x", MaxAnyDepthOperators) + "/y"
+	addr := strings.Repeat("/x", 40)
+
+	d := NewStandardDispatcher()
+	if err := d.AddMsgHandler(addr, func(*Message) {}); err != nil {
+		t.Fatal(err)
+	}
+	start := time.Now()
+	if MustCompilePattern(pattern).Match(addr) {
+		t.Errorf("%q matches %q", pattern, addr)
+	}
+	if n := d.Invoke(pattern); n != 0 {
+		t.Errorf("Invoke(%q) matched %d handlers, want = 0", pattern, n)
+	}
+	if n := d.Invoke(strings.Repeat("//x", MaxAnyDepthOperators)); n != 1 {
+		t.Errorf("Invoke(%q) matched %d handlers, want = 1", pattern, n)
+	}
+	if elapsed := time.Since(start); elapsed > time.Second {
+		t.Errorf("matching %q took %s", pattern, elapsed)
+	}
+}
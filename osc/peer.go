@@ -0,0 +1,135 @@
+package osc
+
+import (
+	"net"
+	"sync"
+	"time"
+)
+
+// Peer sends and receives OSC packets on one UDP socket. Many devices, e.g.
+// Behringer X32 consoles, send their replies to the port that a request was
+// sent from, which requires sending from the port that is listened on.
+type Peer struct {
+	// Server dispatches the incoming packets. Its fields can be changed
+	// before Serve is called, Addr is not used.
+	Server *Server
+
+	conn net.PacketConn
+
+	mu        sync.Mutex
+	closed    bool
+	handshake handshakeState
+	sync      syncState
+	timeSync  timeSyncState
+}
+
+// NewPeer binds a UDP socket to addr and returns a Peer that dispatches the
+// incoming packets to dispatcher. If dispatcher is nil, a new
+// StandardDispatcher is used.
+func NewPeer(addr string, dispatcher Dispatcher) (*Peer, error) {
+	if dispatcher == nil {
+		dispatcher = NewStandardDispatcher()
+	}
+	conn, _, err := Listen(addr)
+	if err != nil {
+		return nil, err
+	}
+	p := &Peer{Server: &Server{Dispatcher: dispatcher}, conn: conn}
+	p.handshake = handshakeState{
+		local:   DefaultCapabilities(),
+		remote:  make(map[string]remoteCapabilities),
+		seen:    make(map[string]time.Time),
+		waiters: make(map[string][]chan Capabilities),
+	}
+	p.timeSync = timeSyncState{
+		estimates: make(map[string]ClockEstimate),
+		waiters:   make(map[uint64]chan [3]time.Time),
+	}
+	p.Server.intercept = p.intercept
+	return p, nil
+}
+
+// intercept consumes the handshake, time synchronization and state sync
+// messages received by the peer.
+func (p *Peer) intercept(packet Packet, addr net.Addr) bool {
+	if p.interceptHandshake(packet, addr) || p.interceptTimeSync(packet, addr) {
+		return true
+	}
+	return p.interceptSync(packet, addr)
+}
+
+// LocalAddr returns the address the socket of the peer is bound to.
+func (p *Peer) LocalAddr() net.Addr {
+	return p.conn.LocalAddr()
+}
+
+// Serve receives and dispatches incoming packets until the peer is closed. It
+// returns nil if the peer was closed, otherwise the error that stopped it.
+func (p *Peer) Serve() error {
+	err := p.Server.Serve(p.conn)
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	if p.closed {
+		return nil
+	}
+	return err
+}
+
+// SendTo sends an OSC Bundle or an OSC Message from the socket of the peer to
+// addr, which has the form "host:port". It returns the number of bytes that
+// were sent.
+func (p *Peer) SendTo(addr string, packet Packet) (int, error) {
+	raddr, err := net.ResolveUDPAddr("udp", addr)
+	if err != nil {
+		return 0, err
+	}
+	return p.SendToAddr(raddr, packet)
+}
+
+// SendToAddr sends an OSC Bundle or an OSC Message from the socket of the peer
+// to addr. It returns the number of bytes that were sent.
+func (p *Peer) SendToAddr(addr net.Addr, packet Packet) (int, error) {
+	data, err := packet.MarshalBinary()
+	if err != nil {
+		return 0, err
+	}
+	return p.conn.WriteTo(data, addr)
+}
+
+// Target returns a Sender that sends packets from the socket of the peer to
+// addr, which has the form "host:port". The address is resolved once.
+func (p *Peer) Target(addr string) (*PeerTarget, error) {
+	raddr, err := net.ResolveUDPAddr("udp", addr)
+	if err != nil {
+		return nil, err
+	}
+	return &PeerTarget{peer: p, addr: raddr}, nil
+}
+
+// Close closes the socket of the peer, which stops Serve.
+func (p *Peer) Close() error {
+	p.mu.Lock()
+	p.closed = true
+	p.mu.Unlock()
+	return p.conn.Close()
+}
+
+// PeerTarget sends packets from the socket of a Peer to a fixed address. It
+// implements the Sender interface.
+type PeerTarget struct {
+	peer *Peer
+	addr net.Addr
+}
+
+// Verify that PeerTarget implements the Sender interface.
+var _ Sender = (*PeerTarget)(nil)
+
+// Addr returns the address that packets are sent to.
+func (t *PeerTarget) Addr() net.Addr {
+	return t.addr
+}
+
+// Send sends an OSC Bundle or an OSC Message to the address of the target.
+func (t *PeerTarget) Send(packet Packet) (int, error) {
+	return t.peer.SendToAddr(t.addr, packet)
+}
@@ -0,0 +1,86 @@
+package osc
+
+import (
+	"net"
+	"testing"
+	"time"
+)
+
+func TestPeer(t *testing.T) {
+	// The remote device replies to the source port of every request
+	remote, err := net.ListenPacket("udp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer remote.Close()
+	go func() {
+		buf := make([]byte, 1024)
+		for {
+			n, addr, err := remote.ReadFrom(buf)
+			if err != nil {
+				return
+			}
+			p, err := ParsePacket(string(buf[:n]))
+			if err != nil {
+				continue
+			}
+			reply := NewMessage(p.(*Message).Address+"/reply", p.(*Message).Arguments...)
+			data, _ := reply.MarshalBinary()
+			remote.WriteTo(data, addr)
+		}
+	}()
+
+	replies := make(chan *Message, 2)
+	d := NewStandardDispatcher()
+	if err := d.AddMsgHandler("/info/reply", func(msg *Message) { replies <- msg }); err != nil {
+		t.Fatal(err)
+	}
+	peer, err := NewPeer("127.0.0.1:0", d)
+	if err != nil {
+		t.Fatal(err)
+	}
+	served := make(chan error, 1)
+	go func() { served <- peer.Serve() }()
+
+	if _, err := peer.SendTo(remote.LocalAddr().String(), NewMessage("/info", int32(1))); err != nil {
+		t.Fatal(err)
+	}
+	target, err := peer.Target(remote.LocalAddr().String())
+	if err != nil {
+		t.Fatal(err)
+	}
+	if target.Addr().String() != remote.LocalAddr().String() {
+		t.Errorf("Addr() = %s, want = %s", target.Addr(), remote.LocalAddr())
+	}
+	if _, err := target.Send(NewMessage("/info", int32(2))); err != nil {
+		t.Fatal(err)
+	}
+
+	// Packets are dispatched concurrently, the replies may arrive in any order
+	got := make(map[int32]bool)
+	for i := 0; i < 2; i++ {
+		select {
+		case msg := <-replies:
+			if v, ok := msg.Arguments[0].(int32); ok {
+				got[v] = true
+			}
+		case <-time.After(5 * time.Second):
+			t.Fatal("timeout waiting for reply")
+		}
+	}
+	if !got[1] || !got[2] {
+		t.Errorf("received replies %v, want replies for 1 and 2", got)
+	}
+
+	if err := peer.Close(); err != nil {
+		t.Fatal(err)
+	}
+	select {
+	case err := <-served:
+		if err != nil {
+			t.Errorf("Serve() = %v after Close, want = nil", err)
+		}
+	case <-time.After(5 * time.Second):
+		t.Fatal("Serve didn't return after Close")
+	}
+}
@@ -0,0 +1,147 @@
+package osc
+
+import (
+	"sync"
+	"time"
+
+	"github.com/hypebeast/go-osc/osc/codec"
+)
+
+// Stage is a step of the receive pipeline of a Server, see Server.Use. It is
+// called with every received message before it is dispatched and returns the
+// message to dispatch instead, or nil to drop the message. The messages
+// belong to the server, so a stage may modify them in place. Stages may be
+// called concurrently.
+type Stage func(msg *Message) *Message
+
+// Use adds stages to the receive pipeline of the server. Every received
+// message passes the stages in the order they were added before it is
+// dispatched, which allows to filter, rewrite and rate limit the incoming
+// messages without a custom Dispatcher, e.g.
+//
+//	server.Use(osc.Filter(isFader), osc.MapAddress(strings.ToLower), osc.Throttle(30))
+//
+// The messages of bundles pass the pipeline when the bundle is received, not
+// when it is dispatched. Stages must be added before the server is started.
+func (s *Server) Use(stages ...Stage) {
+	s.stages = append(s.stages, stages...)
+}
+
+// runStages passes the messages of the packet through the receive pipeline.
+// Dropped messages are removed from bundles. Returns nil if the packet is a
+// message that was dropped.
+func (s *Server) runStages(packet Packet) Packet {
+	if len(s.stages) == 0 {
+		return packet
+	}
+
+	switch p := packet.(type) {
+	case *Message:
+		if msg := s.runMessageStages(p); msg != nil {
+			return msg
+		}
+		return nil
+
+	case *Bundle:
+		kept := p.Messages[:0]
+		for _, msg := range p.Messages {
+			if msg = s.runMessageStages(msg); msg != nil {
+				kept = append(kept, msg)
+			}
+		}
+		p.Messages = kept
+		for _, b := range p.Bundles {
+			s.runStages(b)
+		}
+	}
+	return packet
+}
+
+// runMessageStages passes msg through all stages. Returns nil if a stage
+// dropped it, the dropped message is recycled if ReuseMessages is set.
+func (s *Server) runMessageStages(msg *Message) *Message {
+	for _, stage := range s.stages {
+		out := stage(msg)
+		if out == nil {
+			if s.ReuseMessages {
+				codec.ReleasePacket(msg)
+			}
+			return nil
+		}
+		msg = out
+	}
+	return msg
+}
+
+// Filter returns a Stage that drops all messages for which keep returns
+// false.
+func Filter(keep func(msg *Message) bool) Stage {
+	return func(msg *Message) *Message {
+		if !keep(msg) {
+			return nil
+		}
+		return msg
+	}
+}
+
+// MapAddress returns a Stage that replaces the address of every message with
+// the result of rewrite, e.g. to translate the addresses of a controller to
+// the addresses of the handlers.
+func MapAddress(rewrite func(addr string) string) Stage {
+	return func(msg *Message) *Message {
+		msg.Address = rewrite(msg.Address)
+		return msg
+	}
+}
+
+// MapArguments returns a Stage that replaces the arguments of every message
+// with the result of convert, e.g. to scale or clamp values.
+func MapArguments(convert func(addr string, args []interface{}) []interface{}) Stage {
+	return func(msg *Message) *Message {
+		msg.Arguments = convert(msg.Address, msg.Arguments)
+		return msg
+	}
+}
+
+// maxThrottleAddresses is the maximum number of addresses that a Throttle
+// keeps the time of the last message of.
+const maxThrottleAddresses = 1024
+
+// Throttle returns a Stage that passes at most rate messages per second for
+// each address and drops the others, e.g. to protect slow handlers from
+// sensors with high update rates. A rate that isn't positive drops nothing.
+// The addresses are chosen by the senders, so at most 1024 addresses are
+// throttled at a time, messages with further addresses pass until the
+// addresses of earlier messages expired.
+func Throttle(rate float64) Stage {
+	if rate <= 0 {
+		return func(msg *Message) *Message { return msg }
+	}
+	interval := time.Duration(float64(time.Second) / rate)
+	var mu sync.Mutex
+	last := make(map[string]time.Time)
+
+	return func(msg *Message) *Message {
+		now := time.Now()
+
+		mu.Lock()
+		defer mu.Unlock()
+		prev, ok := last[msg.Address]
+		if ok && now.Sub(prev) < interval {
+			return nil
+		}
+		if !ok && len(last) >= maxThrottleAddresses {
+			// Addresses whose last message is older than interval pass anyway
+			for addr, t := range last {
+				if now.Sub(t) >= interval {
+					delete(last, addr)
+				}
+			}
+			if len(last) >= maxThrottleAddresses {
+				return msg
+			}
+		}
+		last[msg.Address] = now
+		return msg
+	}
+}
@@ -0,0 +1,88 @@
+package osc
+
+import (
+	"fmt"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestServer_Use(t *testing.T) {
+	d := NewStandardDispatcher()
+	var received []string
+	if err := d.AddMsgHandler("*", func(msg *Message) {
+		received = append(received, msg.Address)
+	}); err != nil {
+		t.Fatal(err)
+	}
+
+	s := &Server{Dispatcher: d}
+	s.Use(
+		Filter(func(msg *Message) bool { return !strings.HasPrefix(msg.Address, "/ignored") }),
+		MapAddress(strings.ToLower),
+	)
+	for _, addr := range []string{"/Fader/1", "/ignored/x", "/KNOB"} {
+		s.dispatch(NewMessage(addr))
+	}
+	if got, want := strings.Join(received, " "), "/fader/1 /knob"; got != want {
+		t.Errorf("received %q, want = %q", got, want)
+	}
+
+	// Dropped messages are removed from bundles
+	b := &Bundle{Messages: []*Message{NewMessage("/ignored"), NewMessage("/A")}}
+	if p := s.runStages(b); len(p.(*Bundle).Messages) != 1 || b.Messages[0].Address != "/a" {
+		t.Errorf("bundle messages = %v, want = [/a]", b.Messages)
+	}
+}
+
+func TestThrottle(t *testing.T) {
+	throttle := Throttle(20)
+	pass := func(addr string) bool { return throttle(NewMessage(addr)) != nil }
+
+	for _, tt := range []struct {
+		addr string
+		pass bool
+	}{
+		{"/a", true},
+		{"/a", false},
+		{"/b", true},
+		{"/a", false},
+	} {
+		if got := pass(tt.addr); got != tt.pass {
+			t.Errorf("Throttle(%s) passed = %v, want = %v", tt.addr, got, tt.pass)
+		}
+	}
+
+	time.Sleep(60 * time.Millisecond)
+	if !pass("/a") {
+		t.Error("message wasn't passed after the interval")
+	}
+
+	unlimited := Throttle(0)
+	for i := 0; i < 3; i++ {
+		if unlimited(NewMessage("/a")) == nil {
+			t.Fatal("Throttle(0) dropped a message")
+		}
+	}
+}
+
+func TestThrottle_Limit(t *testing.T) {
+	throttle := Throttle(20)
+	pass := func(addr string) bool { return throttle(NewMessage(addr)) != nil }
+	for i := 0; i < maxThrottleAddresses; i++ {
+		pass(fmt.Sprintf("/spam/%d", i))
+	}
+
+	// Further addresses aren't throttled until the others expired
+	if !pass("/new") || !pass("/new") {
+		t.Error("message with an untracked address was dropped")
+	}
+	if pass("/spam/0") {
+		t.Error("message with a tracked address was passed")
+	}
+
+	time.Sleep(60 * time.Millisecond)
+	if !pass("/new") || pass("/new") {
+		t.Error("address wasn't throttled after the others expired")
+	}
+}
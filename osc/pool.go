@@ -0,0 +1,14 @@
+package osc
+
+import "sync"
+
+// receiveBufferSize is the size of the buffer that a datagram is read into.
+const receiveBufferSize = 65535
+
+// bufferPool recycles receive buffers.
+var bufferPool = sync.Pool{
+	New: func() interface{} {
+		b := make([]byte, receiveBufferSize)
+		return &b
+	},
+}
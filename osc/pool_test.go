@@ -0,0 +1,82 @@
+package osc
+
+import (
+	"testing"
+	"time"
+)
+
+func TestServer_ReuseMessages(t *testing.T) {
+	var retained, released *Message
+	d := NewStandardDispatcher()
+	if err := d.AddMsgHandler("/retain", func(msg *Message) {
+		msg.Retain()
+		retained = msg
+	}); err != nil {
+		t.Fatal(err)
+	}
+	if err := d.AddMsgHandler("/release", func(msg *Message) {
+		released = msg
+	}); err != nil {
+		t.Fatal(err)
+	}
+	s := &Server{Dispatcher: d, ReuseMessages: true}
+	dec := Decoder{ReuseMessages: true}
+
+	for _, msg := range []*Message{
+		NewMessage("/retain", int32(1), "a"),
+		NewMessage("/release", int32(2), "b"),
+	} {
+		data, err := msg.MarshalBinary()
+		if err != nil {
+			t.Fatal(err)
+		}
+		p, err := dec.DecodeBytes(data)
+		if err != nil {
+			t.Fatal(err)
+		}
+		s.dispatch(p)
+	}
+
+	if retained == nil || released == nil {
+		t.Fatal("handlers were not called")
+	}
+	if want := NewMessage("/retain", int32(1), "a"); !retained.Equals(want) {
+		t.Errorf("retained message = %v, want = %v", retained, want)
+	}
+	if released.Address != "" || len(released.Arguments) != 0 {
+		t.Errorf("released message wasn't reset: %v", released)
+	}
+}
+
+func TestMux_DispatchReuseMessages(t *testing.T) {
+	received := make(chan *Message, 1)
+	m := NewMux()
+	if err := m.HandleFunc("/a", func(msg *Message) { received <- msg }); err != nil {
+		t.Fatal(err)
+	}
+	s := &Server{Dispatcher: m, ReuseMessages: true}
+	dec := Decoder{ReuseMessages: true}
+
+	bundle := NewBundleIn(20 * time.Millisecond)
+	if err := bundle.Append(NewMessage("/a", int32(1), "x")); err != nil {
+		t.Fatal(err)
+	}
+	data, err := bundle.MarshalBinary()
+	if err != nil {
+		t.Fatal(err)
+	}
+	p, err := dec.DecodeBytes(data)
+	if err != nil {
+		t.Fatal(err)
+	}
+	s.dispatch(p)
+
+	select {
+	case msg := <-received:
+		if want := NewMessage("/a", int32(1), "x"); !msg.Equals(want) {
+			t.Errorf("dispatched message = %v, want = %v", msg, want)
+		}
+	case <-time.After(5 * time.Second):
+		t.Fatal("bundle wasn't dispatched")
+	}
+}
@@ -0,0 +1,102 @@
+package osc
+
+import (
+	"errors"
+	"sort"
+	"sync/atomic"
+)
+
+// ErrNoHandler is returned by StandardDispatcher.SetPriority if no handler is
+// registered for the address.
+var ErrNoHandler = errors.New("osc: no handler registered for the address")
+
+// handlerSeq numbers the registered handlers of all dispatchers, so that
+// handlers with equal priorities are called in the order of their
+// registration, including the handlers of routed dispatchers.
+var handlerSeq uint64
+
+// nextHandlerSeq returns the sequence number of a new handler.
+func nextHandlerSeq() uint64 {
+	return atomic.AddUint64(&handlerSeq, 1)
+}
+
+// SetPriority sets the priority of the handler that was registered for addr,
+// which is an address, pattern or template as passed to AddMsgHandler,
+// AddParamHandler and the like. Returns ErrNoHandler if there is no such
+// handler.
+//
+// The handlers that match a message are called in order of decreasing
+// priority, e.g. a validation handler with priority 10 runs before the
+// handlers with the default priority 0, and a logging handler with priority
+// -10 runs after them. Handlers with equal priorities are called in the
+// order handlers for addresses, for templates and for patterns, and in the
+// order in which they were registered within each group. The default handler
+// and the catch-all handler aren't affected, the catch-all handler is always
+// called last.
+func (s *StandardDispatcher) SetPriority(addr string, priority int) error {
+	template := addr
+	if s.normalize {
+		template = normalizeAddress(addr)
+	}
+	addr = s.routeAddress(addr)
+	for i := range s.patternHandlers {
+		if s.patternHandlers[i].pattern.String() == addr {
+			s.patternHandlers[i].priority = priority
+			return nil
+		}
+	}
+	for i := range s.paramHandlers {
+		if s.paramHandlers[i].template.template == template {
+			s.paramHandlers[i].priority = priority
+			return nil
+		}
+	}
+	if node := s.handlers.find(addr); node != nil && node.handler != nil {
+		node.priority = priority
+		return nil
+	}
+	return ErrNoHandler
+}
+
+// Kinds of handlers, in the order in which handlers with equal priorities are
+// called.
+const (
+	callAddress = iota
+	callTemplate
+	callPattern
+)
+
+// handlerCall is a handler that matched a message.
+type handlerCall struct {
+	priority int
+	kind     int
+	seq      uint64
+	handler  Handler
+	param    ParamHandlerFunc // Set instead of handler for templates
+	params   Params
+}
+
+// call calls the handler with msg.
+func (c *handlerCall) call(msg *Message) {
+	if c.param != nil {
+		c.param(msg, c.params)
+		return
+	}
+	c.handler.HandleMessage(msg)
+}
+
+// sortCalls sorts calls by decreasing priority, kind and registration order.
+func sortCalls(calls []handlerCall) {
+	if len(calls) < 2 {
+		return
+	}
+	sort.Slice(calls, func(i, j int) bool {
+		if calls[i].priority != calls[j].priority {
+			return calls[i].priority > calls[j].priority
+		}
+		if calls[i].kind != calls[j].kind {
+			return calls[i].kind < calls[j].kind
+		}
+		return calls[i].seq < calls[j].seq
+	})
+}
@@ -0,0 +1,63 @@
+package osc
+
+import (
+	"reflect"
+	"testing"
+)
+
+func TestStandardDispatcher_SetPriority(t *testing.T) {
+	var got []string
+	handler := func(name string) HandlerFunc {
+		return func(msg *Message) { got = append(got, name) }
+	}
+
+	d := NewStandardDispatcher()
+	d.SetMatchMode(MatchBoth)
+	for _, addr := range []string{"/mixer/3/mute", "/mixer/1/mute", "/mixer/2/mute", "/mixer/*/mute", "/mixer/?/mute"} {
+		if err := d.AddMsgHandler(addr, handler(addr)); err != nil {
+			t.Fatal(err)
+		}
+	}
+	if err := d.AddParamHandler("/mixer/{n}/mute", func(*Message, Params) { got = append(got, "template") }); err != nil {
+		t.Fatal(err)
+	}
+	if err := d.AddMsgHandler("*", handler("*")); err != nil {
+		t.Fatal(err)
+	}
+
+	// Without priorities, handlers are called in the order of their groups
+	// and registration
+	want := []string{"/mixer/3/mute", "/mixer/1/mute", "/mixer/2/mute", "template", "/mixer/*/mute", "/mixer/?/mute", "*"}
+	for i := 0; i < 10; i++ {
+		got = nil
+		d.Dispatch(NewMessage("/mixer/*/mute"))
+		if !reflect.DeepEqual(got, want) {
+			t.Fatalf("dispatched to %v, want = %v", got, want)
+		}
+	}
+
+	for _, tt := range []struct {
+		addr     string
+		priority int
+	}{
+		{"/mixer/?/mute", 10},
+		{"/mixer/1/mute", -10},
+		{"/mixer/{n}/mute", 5},
+	} {
+		if err := d.SetPriority(tt.addr, tt.priority); err != nil {
+			t.Fatalf("SetPriority(%s) unexpected error: %s", tt.addr, err)
+		}
+	}
+	want = []string{"/mixer/?/mute", "template", "/mixer/3/mute", "/mixer/2/mute", "/mixer/*/mute", "/mixer/1/mute", "*"}
+	got = nil
+	d.Dispatch(NewMessage("/mixer/*/mute"))
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("dispatched to %v, want = %v", got, want)
+	}
+
+	for _, addr := range []string{"/mixer/4/mute", "/mixer", "/mixer/[0-9]/mute"} {
+		if err := d.SetPriority(addr, 1); err != ErrNoHandler {
+			t.Errorf("SetPriority(%s) = %v, want = %v", addr, err, ErrNoHandler)
+		}
+	}
+}
@@ -0,0 +1,107 @@
+package osc
+
+import (
+	"strings"
+	"time"
+)
+
+// Proxy forwards OSC packets that are received on Addr to one or more
+// downstream targets. The address of every forwarded message can optionally
+// be rewritten, see Rewrite.
+//
+// Proxy implements the Dispatcher interface, so it can also be used as the
+// dispatcher of a Server.
+type Proxy struct {
+	Addr        string
+	Targets     []*Client
+	Rewrites    []Rewrite
+	ReadTimeout time.Duration
+
+	// OnError is called if a packet can't be forwarded to a target. Errors
+	// are ignored if OnError is nil.
+	OnError func(target *Client, err error)
+}
+
+// Verify that Proxy implements the Dispatcher interface.
+var _ Dispatcher = (*Proxy)(nil)
+
+// Rewrite replaces the address prefix Prefix with Replacement. The prefix
+// only matches whole address parts, e.g. the prefix "/x32" matches the
+// addresses "/x32" and "/x32/ch/01" but not "/x320".
+type Rewrite struct {
+	Prefix      string
+	Replacement string
+}
+
+// NewProxy returns a Proxy that listens on addr and forwards all received
+// packets to the given targets.
+func NewProxy(addr string, targets ...*Client) *Proxy {
+	return &Proxy{Addr: addr, Targets: targets}
+}
+
+// AddRewrite adds a rule that replaces the address prefix `prefix` with
+// `replacement`. Rules are applied in the order they were added, the first
+// matching rule wins.
+func (p *Proxy) AddRewrite(prefix, replacement string) {
+	p.Rewrites = append(p.Rewrites, Rewrite{Prefix: prefix, Replacement: replacement})
+}
+
+// ListenAndServe retrieves incoming OSC packets and forwards them to all
+// targets.
+func (p *Proxy) ListenAndServe() error {
+	server := &Server{Addr: p.Addr, Dispatcher: p, ReadTimeout: p.ReadTimeout}
+	return server.ListenAndServe()
+}
+
+// Dispatch rewrites the given packet and sends it to all targets. Implements
+// the Dispatcher interface.
+func (p *Proxy) Dispatch(packet Packet) {
+	p.rewrite(packet)
+
+	for _, target := range p.Targets {
+		if _, err := target.Send(packet); err != nil && p.OnError != nil {
+			p.OnError(target, err)
+		}
+	}
+}
+
+// rewrite applies the rewrite rules to all messages of the given packet.
+func (p *Proxy) rewrite(packet Packet) {
+	rewritePacket(p.Rewrites, packet)
+}
+
+// rewritePacket applies the rules to all messages of the given packet.
+func rewritePacket(rules []Rewrite, packet Packet) {
+	switch t := packet.(type) {
+	case *Message:
+		t.Address = rewriteAddress(rules, t.Address)
+
+	case *Bundle:
+		for _, m := range t.Messages {
+			rewritePacket(rules, m)
+		}
+		for _, b := range t.Bundles {
+			rewritePacket(rules, b)
+		}
+	}
+}
+
+// rewriteAddress returns addr with the first matching rule of rules applied.
+func rewriteAddress(rules []Rewrite, addr string) string {
+	for _, r := range rules {
+		if !strings.HasPrefix(addr, r.Prefix) {
+			continue
+		}
+		rest := addr[len(r.Prefix):]
+		if rest != "" && rest[0] != '/' && !strings.HasSuffix(r.Prefix, "/") {
+			continue
+		}
+
+		addr = r.Replacement + rest
+		if addr == "" {
+			addr = "/"
+		}
+		return addr
+	}
+	return addr
+}
@@ -0,0 +1,61 @@
+package osc
+
+import (
+	"net"
+	"testing"
+	"time"
+)
+
+func TestRewriteAddress(t *testing.T) {
+	rules := []Rewrite{{Prefix: "/x32", Replacement: ""}, {Prefix: "/a/b", Replacement: "/c"}}
+
+	for _, tt := range []struct {
+		addr string
+		want string
+	}{
+		{"/x32/ch/01/mix", "/ch/01/mix"},
+		{"/x32", "/"},
+		{"/x320/ch", "/x320/ch"},
+		{"/a/b/c", "/c/c"},
+		{"/a/bc", "/a/bc"},
+		{"/other", "/other"},
+	} {
+		if got := rewriteAddress(rules, tt.addr); got != tt.want {
+			t.Errorf("rewriteAddress(%q) = %q, want = %q", tt.addr, got, tt.want)
+		}
+	}
+}
+
+func TestProxy_Dispatch(t *testing.T) {
+	conn, err := net.ListenPacket("udp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer conn.Close()
+	port := conn.LocalAddr().(*net.UDPAddr).Port
+
+	p := NewProxy("", NewClient("127.0.0.1", port))
+	p.AddRewrite("/x32", "")
+	p.OnError = func(target *Client, err error) {
+		t.Errorf("forwarding to %s:%d failed: %s", target.IP(), target.Port(), err)
+	}
+
+	bundle := NewBundle(time.Now())
+	if err := bundle.Append(NewMessage("/x32/ch/01/mix", float32(0.5))); err != nil {
+		t.Fatal(err)
+	}
+	p.Dispatch(bundle)
+
+	server := &Server{ReadTimeout: 5 * time.Second}
+	packet, err := server.ReceivePacket(conn)
+	if err != nil {
+		t.Fatal(err)
+	}
+	b, ok := packet.(*Bundle)
+	if !ok || len(b.Messages) != 1 {
+		t.Fatalf("expected a bundle with one message, got %#v", packet)
+	}
+	if got, want := b.Messages[0].Address, "/ch/01/mix"; got != want {
+		t.Errorf("forwarded address = %s, want = %s", got, want)
+	}
+}
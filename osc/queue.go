@@ -0,0 +1,148 @@
+package osc
+
+import (
+	"sync"
+	"time"
+)
+
+// SendQueue sends messages asynchronously at a bounded rate. Messages can be
+// enqueued from many goroutines. If a message is enqueued for an address that
+// still has a pending message, the pending message is replaced by the new one,
+// i.e. only the latest value is sent. This is useful to stream fader or
+// encoder values to devices that can't keep up with high update rates.
+type SendQueue struct {
+	sender   Sender
+	interval time.Duration
+
+	// OnError is called if a message couldn't be sent. Errors are ignored if
+	// OnError is nil. It must be set before the first message is enqueued.
+	OnError func(msg *Message, err error)
+
+	mu      sync.Mutex
+	pending map[string]*Message
+	order   []string
+	wake    chan struct{}
+	closed  chan struct{}
+	done    chan struct{}
+}
+
+// NewSendQueue returns a new SendQueue that sends messages with sender. At most
+// one message is sent per interval, a zero interval disables the rate limit.
+func NewSendQueue(sender Sender, interval time.Duration) *SendQueue {
+	q := &SendQueue{
+		sender:   sender,
+		interval: interval,
+		pending:  make(map[string]*Message),
+		wake:     make(chan struct{}, 1),
+		closed:   make(chan struct{}),
+		done:     make(chan struct{}),
+	}
+	go q.run()
+	return q
+}
+
+// Enqueue adds the message to the queue. A pending message with the same
+// address is replaced. Returns ErrClientClosed if the queue was closed.
+func (q *SendQueue) Enqueue(msg *Message) error {
+	q.mu.Lock()
+	select {
+	case <-q.closed:
+		q.mu.Unlock()
+		return ErrClientClosed
+	default:
+	}
+	if _, ok := q.pending[msg.Address]; !ok {
+		q.order = append(q.order, msg.Address)
+	}
+	q.pending[msg.Address] = msg
+	q.mu.Unlock()
+
+	select {
+	case q.wake <- struct{}{}:
+	default:
+	}
+	return nil
+}
+
+// Len returns the number of pending messages.
+func (q *SendQueue) Len() int {
+	q.mu.Lock()
+	defer q.mu.Unlock()
+	return len(q.order)
+}
+
+// Close sends all pending messages without rate limit and stops the queue.
+func (q *SendQueue) Close() error {
+	q.mu.Lock()
+	select {
+	case <-q.closed:
+	default:
+		close(q.closed)
+	}
+	q.mu.Unlock()
+
+	<-q.done
+	return nil
+}
+
+// run sends the pending messages until the queue is closed.
+func (q *SendQueue) run() {
+	defer close(q.done)
+
+	var limit <-chan time.Time
+	if q.interval > 0 {
+		ticker := time.NewTicker(q.interval)
+		defer ticker.Stop()
+		limit = ticker.C
+	}
+
+	for {
+		select {
+		case <-q.wake:
+		case <-q.closed:
+			q.flush()
+			return
+		}
+
+		for msg := q.next(); msg != nil; msg = q.next() {
+			q.send(msg)
+			if limit == nil {
+				continue
+			}
+			select {
+			case <-limit:
+			case <-q.closed:
+				q.flush()
+				return
+			}
+		}
+	}
+}
+
+// next removes the oldest pending message from the queue and returns it.
+// Returns nil if there are no pending messages.
+func (q *SendQueue) next() *Message {
+	q.mu.Lock()
+	defer q.mu.Unlock()
+	if len(q.order) == 0 {
+		return nil
+	}
+	addr := q.order[0]
+	q.order = q.order[1:]
+	msg := q.pending[addr]
+	delete(q.pending, addr)
+	return msg
+}
+
+// flush sends all pending messages.
+func (q *SendQueue) flush() {
+	for msg := q.next(); msg != nil; msg = q.next() {
+		q.send(msg)
+	}
+}
+
+func (q *SendQueue) send(msg *Message) {
+	if _, err := q.sender.Send(msg); err != nil && q.OnError != nil {
+		q.OnError(msg, err)
+	}
+}
@@ -0,0 +1,103 @@
+package osc
+
+import (
+	"sync"
+	"testing"
+	"time"
+)
+
+// recordingSender records all sent packets.
+type recordingSender struct {
+	mu      sync.Mutex
+	packets []Packet
+}
+
+func (r *recordingSender) Send(packet Packet) (int, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.packets = append(r.packets, packet)
+	return 0, nil
+}
+
+func (r *recordingSender) messages() []*Message {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	var msgs []*Message
+	for _, p := range r.packets {
+		msgs = append(msgs, p.(*Message))
+	}
+	return msgs
+}
+
+func TestSendQueue_Coalesce(t *testing.T) {
+	sender := &recordingSender{}
+	q := NewSendQueue(sender, 50*time.Millisecond)
+
+	var wg sync.WaitGroup
+	for g := 0; g < 4; g++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for i := int32(0); i < 100; i++ {
+				if err := q.Enqueue(NewMessage("/fader", i)); err != nil {
+					t.Error(err)
+				}
+			}
+		}()
+	}
+	wg.Wait()
+	if err := q.Enqueue(NewMessage("/button", true)); err != nil {
+		t.Fatal(err)
+	}
+	if err := q.Close(); err != nil {
+		t.Fatal(err)
+	}
+
+	var faders, buttons int
+	var last *Message
+	for _, msg := range sender.messages() {
+		switch msg.Address {
+		case "/fader":
+			faders++
+			last = msg
+		case "/button":
+			buttons++
+		}
+	}
+	if faders == 0 || faders > 3 {
+		t.Errorf("sent %d fader messages, want between 1 and 3", faders)
+	}
+	if got := last.Arguments[0].(int32); got != 99 {
+		t.Errorf("last fader value = %d, want = 99", got)
+	}
+	if buttons != 1 {
+		t.Errorf("sent %d button messages, want = 1", buttons)
+	}
+
+	if err := q.Enqueue(NewMessage("/fader")); err != ErrClientClosed {
+		t.Errorf("Enqueue() after Close() = %v, want = %v", err, ErrClientClosed)
+	}
+}
+
+func TestSendQueue_RateLimit(t *testing.T) {
+	sender := &recordingSender{}
+	interval := 20 * time.Millisecond
+	q := NewSendQueue(sender, interval)
+	defer q.Close()
+
+	start := time.Now()
+	for _, addr := range []string{"/a", "/b", "/c", "/d"} {
+		if err := q.Enqueue(NewMessage(addr)); err != nil {
+			t.Fatal(err)
+		}
+	}
+	for len(sender.messages()) < 4 {
+		if time.Since(start) > 5*time.Second {
+			t.Fatal("timed out")
+		}
+		time.Sleep(time.Millisecond)
+	}
+	if elapsed := time.Since(start); elapsed < 3*interval {
+		t.Errorf("4 messages were sent within %s, want at least %s", elapsed, 3*interval)
+	}
+}
@@ -0,0 +1,97 @@
+package osc
+
+import (
+	"context"
+	"math"
+	"time"
+)
+
+// DefaultRampRate is the number of messages per second that a Ramp sends if
+// its Rate is zero.
+const DefaultRampRate = 50
+
+// Ramp sends interpolated values of a continuous control, e.g. to fade a
+// fader, a lighting intensity or a synth parameter smoothly from one value
+// to another. Every message has the address of the ramp and the value as
+// float32 argument.
+type Ramp struct {
+	Address  string
+	From, To float64
+	Duration time.Duration
+
+	// Rate is the number of messages per second. If it is zero,
+	// DefaultRampRate is used.
+	Rate float64
+
+	// Curve maps the elapsed fraction of the duration, from 0 to 1, to the
+	// fraction of the value change, e.g. func(t float64) float64 { return t * t }
+	// for a fade that starts slowly. The ramp is linear if Curve is nil.
+	Curve func(t float64) float64
+}
+
+// NewRamp returns a linear Ramp from the value from to the value to over
+// duration at the DefaultRampRate.
+func NewRamp(addr string, from, to float64, duration time.Duration) *Ramp {
+	return &Ramp{Address: addr, From: from, To: to, Duration: duration}
+}
+
+// Steps returns the number of messages that the ramp sends.
+func (r *Ramp) Steps() int {
+	rate := r.Rate
+	if rate <= 0 {
+		rate = DefaultRampRate
+	}
+	if r.Duration <= 0 {
+		return 1
+	}
+	return int(math.Ceil(r.Duration.Seconds()*rate)) + 1
+}
+
+// Value returns the value of the ramp at elapsed time after its start. The
+// value is clamped to the start and end values.
+func (r *Ramp) Value(elapsed time.Duration) float64 {
+	if r.Duration <= 0 || elapsed >= r.Duration {
+		return r.To
+	}
+	if elapsed <= 0 {
+		return r.From
+	}
+	t := float64(elapsed) / float64(r.Duration)
+	if r.Curve != nil {
+		t = r.Curve(t)
+	}
+	return r.From + (r.To-r.From)*t
+}
+
+// Run sends the values of the ramp with sender, starting with the start
+// value right away and ending with the exact end value after Duration. It
+// blocks until the ramp is done and returns the first error of sender, or
+// the error of ctx if it is done before, e.g. to abort a fade that is
+// superseded by another one.
+func (r *Ramp) Run(ctx context.Context, sender Sender) error {
+	steps := r.Steps()
+	if steps == 1 {
+		_, err := sender.Send(NewMessage(r.Address, float32(r.To)))
+		return err
+	}
+
+	interval := r.Duration / time.Duration(steps-1)
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+	for i := 0; i < steps; i++ {
+		if i > 0 {
+			select {
+			case <-ticker.C:
+			case <-ctx.Done():
+				return ctx.Err()
+			}
+		}
+		// The steps are computed from their index and not from the clock, so
+		// that late ticks don't skip the start or end value
+		value := r.Value(r.Duration * time.Duration(i) / time.Duration(steps-1))
+		if _, err := sender.Send(NewMessage(r.Address, float32(value))); err != nil {
+			return err
+		}
+	}
+	return nil
+}
@@ -0,0 +1,73 @@
+package osc
+
+import (
+	"context"
+	"testing"
+	"time"
+)
+
+func TestRamp_Run(t *testing.T) {
+	sender := &recordingSender{}
+	r := NewRamp("/fader/1", 0, 1, 40*time.Millisecond)
+	r.Rate = 100
+	if got := r.Steps(); got != 5 {
+		t.Fatalf("Steps() = %d, want = 5", got)
+	}
+	start := time.Now()
+	if err := r.Run(context.Background(), sender); err != nil {
+		t.Fatal(err)
+	}
+	if elapsed := time.Since(start); elapsed < 35*time.Millisecond {
+		t.Errorf("Run() returned after %s, want at least the duration", elapsed)
+	}
+
+	want := []float32{0, 0.25, 0.5, 0.75, 1}
+	msgs := sender.messages()
+	if len(msgs) != len(want) {
+		t.Fatalf("sent %d messages, want = %d", len(msgs), len(want))
+	}
+	for i, msg := range msgs {
+		if msg.Address != "/fader/1" || msg.Arguments[0] != want[i] {
+			t.Errorf("message %d = %v, want = /fader/1 %v", i, msg, want[i])
+		}
+	}
+}
+
+func TestRamp_Value(t *testing.T) {
+	r := &Ramp{From: 10, To: 0, Duration: time.Second, Curve: func(t float64) float64 { return t * t }}
+	for _, tt := range []struct {
+		elapsed time.Duration
+		want    float64
+	}{
+		{-time.Second, 10},
+		{0, 10},
+		{500 * time.Millisecond, 7.5},
+		{time.Second, 0},
+		{2 * time.Second, 0},
+	} {
+		if got := r.Value(tt.elapsed); got != tt.want {
+			t.Errorf("Value(%s) = %v, want = %v", tt.elapsed, got, tt.want)
+		}
+	}
+}
+
+func TestRamp_Cancel(t *testing.T) {
+	sender := &recordingSender{}
+	r := NewRamp("/fader/1", 0, 1, time.Minute)
+	ctx, cancel := context.WithTimeout(context.Background(), 50*time.Millisecond)
+	defer cancel()
+	if err := r.Run(ctx, sender); err != context.DeadlineExceeded {
+		t.Errorf("Run() = %v, want = %v", err, context.DeadlineExceeded)
+	}
+	if n := len(sender.messages()); n == 0 || n > 5 {
+		t.Errorf("sent %d messages before the cancellation", n)
+	}
+
+	sender = &recordingSender{}
+	if err := NewRamp("/fader/1", 0, 1, 0).Run(context.Background(), sender); err != nil {
+		t.Fatal(err)
+	}
+	if msgs := sender.messages(); len(msgs) != 1 || msgs[0].Arguments[0] != float32(1) {
+		t.Errorf("ramp without duration sent %v, want = [/fader/1 1]", msgs)
+	}
+}
@@ -0,0 +1,196 @@
+package osc
+
+import (
+	"errors"
+	"net"
+	"sync"
+	"time"
+)
+
+// AckAddress is the address of the messages that acknowledge reliably sent
+// packets. Its single int32 argument is the sequence number of the first
+// message of the acknowledged packet.
+const AckAddress = "/osc/ack"
+
+// ErrNotAcknowledged is returned by ReliableClient.Send if the receiver didn't
+// acknowledge the packet after all retransmissions.
+var ErrNotAcknowledged = errors.New("osc: packet was not acknowledged")
+
+const (
+	defaultReliableRetries = 3
+	defaultReliableTimeout = 100 * time.Millisecond
+)
+
+// ReliableClient sends OSC packets over UDP and retransmits them until the
+// receiver acknowledged them, e.g. for scene changes that must not be lost.
+// The messages are stamped with sequence numbers, see SequenceHook. The
+// receiver must be a Server with a SequenceChecker and Acknowledge set,
+// which discards the duplicates caused by retransmissions.
+type ReliableClient struct {
+	// Retries is the number of retransmissions of a packet that wasn't
+	// acknowledged. Defaults to 3.
+	Retries int
+
+	// Timeout is the time to wait for the acknowledgment of a packet before
+	// it is retransmitted. Defaults to 100ms.
+	Timeout time.Duration
+
+	conn  net.Conn
+	stamp SendHook
+	done  chan struct{} // Closed when the client is closed
+
+	mu      sync.Mutex
+	pending map[uint32]chan struct{} // Closed when the packet was acknowledged
+	closed  bool
+}
+
+// NewReliableClient returns a ReliableClient that sends packets to addr,
+// which has the form "host:port".
+func NewReliableClient(addr string) (*ReliableClient, error) {
+	conn, err := net.Dial("udp", addr)
+	if err != nil {
+		return nil, err
+	}
+	c := &ReliableClient{
+		conn:    conn,
+		stamp:   SequenceHook(),
+		done:    make(chan struct{}),
+		pending: make(map[uint32]chan struct{}),
+	}
+	go c.readAcks()
+	return c, nil
+}
+
+// Send sends the packet and blocks until it was acknowledged. Returns
+// ErrNotAcknowledged if it wasn't acknowledged after Retries retransmissions.
+// Packets without messages are sent once without waiting.
+func (c *ReliableClient) Send(packet Packet) error {
+	stamped, err := c.stamp(packet)
+	if err != nil {
+		return err
+	}
+	data, err := stamped.MarshalBinary()
+	if err != nil {
+		return err
+	}
+	seq, ok := firstSequence(stamped)
+	if !ok {
+		_, err = c.conn.Write(data)
+		return err
+	}
+
+	acked := make(chan struct{})
+	c.mu.Lock()
+	if c.closed {
+		c.mu.Unlock()
+		return ErrClientClosed
+	}
+	c.pending[seq] = acked
+	c.mu.Unlock()
+	defer func() {
+		c.mu.Lock()
+		delete(c.pending, seq)
+		c.mu.Unlock()
+	}()
+
+	retries, timeout := c.Retries, c.Timeout
+	if retries <= 0 {
+		retries = defaultReliableRetries
+	}
+	if timeout <= 0 {
+		timeout = defaultReliableTimeout
+	}
+	for attempt := 0; attempt <= retries; attempt++ {
+		if _, err = c.conn.Write(data); err != nil {
+			return err
+		}
+		timer := time.NewTimer(timeout)
+		select {
+		case <-acked:
+			timer.Stop()
+			return nil
+		case <-c.done:
+			timer.Stop()
+			return ErrClientClosed
+		case <-timer.C:
+		}
+	}
+	return ErrNotAcknowledged
+}
+
+// Close closes the connection. Pending calls of Send return ErrClientClosed.
+func (c *ReliableClient) Close() error {
+	c.mu.Lock()
+	if c.closed {
+		c.mu.Unlock()
+		return nil
+	}
+	c.closed = true
+	close(c.done)
+	c.mu.Unlock()
+	return c.conn.Close()
+}
+
+// readAcks receives the acknowledgments until the client is closed.
+func (c *ReliableClient) readAcks() {
+	buf := make([]byte, receiveBufferSize)
+	var d Decoder
+	var tempDelay time.Duration
+	for {
+		n, err := c.conn.Read(buf)
+		if err != nil {
+			// E.g. an ICMP port unreachable of a previous packet. Errors
+			// may persist, so the reads are retried with a backoff.
+			if tempDelay == 0 {
+				tempDelay = 5 * time.Millisecond
+			} else {
+				tempDelay *= 2
+			}
+			if max := 1 * time.Second; tempDelay > max {
+				tempDelay = max
+			}
+			select {
+			case <-c.done:
+				return
+			case <-time.After(tempDelay):
+				continue
+			}
+		}
+		tempDelay = 0
+		p, err := d.DecodeBytes(buf[:n])
+		msg, ok := p.(*Message)
+		if err != nil || !ok || msg.Address != AckAddress {
+			continue
+		}
+		c.mu.Lock()
+		for _, arg := range msg.Arguments {
+			if seq, ok := arg.(int32); ok {
+				if acked, ok := c.pending[uint32(seq)]; ok {
+					close(acked)
+					delete(c.pending, uint32(seq))
+				}
+			}
+		}
+		c.mu.Unlock()
+	}
+}
+
+// firstSequence returns the sequence number of the first message of a packet
+// that was stamped by SequenceHook.
+func firstSequence(packet Packet) (uint32, bool) {
+	switch p := packet.(type) {
+	case *Message:
+		_, _, seq, ok := splitSequence(p.Address)
+		return seq, ok
+	case *Bundle:
+		if len(p.Messages) > 0 {
+			return firstSequence(p.Messages[0])
+		}
+		for _, b := range p.Bundles {
+			if seq, ok := firstSequence(b); ok {
+				return seq, true
+			}
+		}
+	}
+	return 0, false
+}
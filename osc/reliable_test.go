@@ -0,0 +1,104 @@
+package osc
+
+import (
+	"net"
+	"testing"
+	"time"
+)
+
+func TestReliableClient(t *testing.T) {
+	conn, err := net.ListenPacket("udp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer conn.Close()
+
+	received := make(chan Packet, 10)
+	go func() {
+		// Lose the first transmission
+		buf := make([]byte, receiveBufferSize)
+		if _, _, err := conn.ReadFrom(buf); err != nil {
+			return
+		}
+		server := &Server{Sequence: &SequenceChecker{}, Acknowledge: true}
+		for {
+			p, err := server.ReceivePacket(conn)
+			if err == ErrDuplicatePacket {
+				continue
+			}
+			if err != nil {
+				return
+			}
+			received <- p
+		}
+	}()
+
+	client, err := NewReliableClient(conn.LocalAddr().String())
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer client.Close()
+	client.Timeout = 20 * time.Millisecond
+
+	scene := NewBundle(time.Time{})
+	scene.Messages = []*Message{NewMessage("/scene", int32(3)), NewMessage("/go")}
+	if err := client.Send(scene); err != nil {
+		t.Fatalf("Send() = %v", err)
+	}
+	if err := client.Send(NewMessage("/stop")); err != nil {
+		t.Fatalf("Send() = %v", err)
+	}
+
+	for _, want := range []string{"/scene", "/stop"} {
+		select {
+		case p := <-received:
+			var addr string
+			switch p := p.(type) {
+			case *Message:
+				addr = p.Address
+			case *Bundle:
+				addr = p.Messages[0].Address
+				if len(p.Messages[0].Arguments) != 1 {
+					t.Errorf("sequence number wasn't removed: %v", p.Messages[0])
+				}
+			}
+			if addr != want {
+				t.Errorf("received %s, want = %s", addr, want)
+			}
+		case <-time.After(5 * time.Second):
+			t.Fatalf("%s wasn't received", want)
+		}
+	}
+}
+
+func TestReliableClient_NotAcknowledged(t *testing.T) {
+	conn, err := net.ListenPacket("udp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer conn.Close()
+
+	client, err := NewReliableClient(conn.LocalAddr().String())
+	if err != nil {
+		t.Fatal(err)
+	}
+	client.Retries = 2
+	client.Timeout = 10 * time.Millisecond
+	if err := client.Send(NewMessage("/cue")); err != ErrNotAcknowledged {
+		t.Errorf("Send() = %v, want = %v", err, ErrNotAcknowledged)
+	}
+
+	// The packet was sent three times
+	buf := make([]byte, receiveBufferSize)
+	conn.SetReadDeadline(time.Now().Add(time.Second))
+	for i := 0; i < 3; i++ {
+		if _, _, err := conn.ReadFrom(buf); err != nil {
+			t.Fatalf("transmission %d: %v", i, err)
+		}
+	}
+
+	client.Close()
+	if err := client.Send(NewMessage("/cue")); err != ErrClientClosed {
+		t.Errorf("Send() after Close() = %v, want = %v", err, ErrClientClosed)
+	}
+}
@@ -0,0 +1,122 @@
+package osc
+
+import (
+	"context"
+	"sync"
+	"time"
+)
+
+// ReplayFullSpeed is the Replayer speed that sends the packets as fast as
+// possible, without waiting between them.
+const ReplayFullSpeed = -1
+
+// Replayer sends the packets of a session to a target with the timing they
+// were recorded with, e.g. to reproduce a bug with the traffic of a
+// rehearsal. The zero value replays a whole session in real time. Bundles
+// keep their recorded timetags.
+type Replayer struct {
+	// Speed scales the timing of the session, e.g. 2 replays it twice as
+	// fast and 0.5 at half speed. If it is zero, the session is replayed in
+	// real time, if it is negative, see ReplayFullSpeed, as fast as
+	// possible.
+	Speed float64
+
+	// Start skips the packets that were recorded in the first Start of the
+	// session, i.e. the replay jumps to the packets that were recorded Start
+	// after the first one. The first replayed packet is sent after the time
+	// between Start and its position.
+	Start time.Duration
+
+	// OnPacket is called with every replayed record after it was sent and
+	// the time the packet was sent behind its schedule, if it isn't nil.
+	OnPacket func(rec SessionRecord, lag time.Duration)
+
+	mu    sync.Mutex
+	stats ReplayStats
+}
+
+// ReplayStats are the statistics of a running or finished replay.
+type ReplayStats struct {
+	Packets  uint64        // Number of sent packets
+	Skipped  uint64        // Number of packets before Start
+	Position time.Duration // Position of the last sent packet in the session
+	MaxLag   time.Duration // Longest time a packet was sent behind its schedule
+}
+
+// Replay reads the records of sr and sends their packets to target until the
+// end of the session. It returns the error of reading the session or of
+// sending a packet, or the error of ctx if it is done before. sr isn't
+// closed.
+func (r *Replayer) Replay(ctx context.Context, sr *SessionReader, target Sender) error {
+	speed := r.Speed
+	if speed == 0 {
+		speed = 1
+	}
+	r.mu.Lock()
+	r.stats = ReplayStats{}
+	r.mu.Unlock()
+
+	var first, started time.Time
+	timer := time.NewTimer(0)
+	defer timer.Stop()
+	<-timer.C
+	for sr.Next() {
+		rec := sr.Record()
+		if first.IsZero() {
+			first = rec.Time
+		}
+		pos := rec.Time.Sub(first)
+		if pos < r.Start {
+			r.mu.Lock()
+			r.stats.Skipped++
+			r.mu.Unlock()
+			continue
+		}
+		if started.IsZero() {
+			started = time.Now()
+		}
+
+		due := started
+		if speed > 0 {
+			due = started.Add(time.Duration(float64(pos-r.Start) / speed))
+			if wait := time.Until(due); wait > 0 {
+				timer.Reset(wait)
+				select {
+				case <-timer.C:
+				case <-ctx.Done():
+					return ctx.Err()
+				}
+			}
+		}
+		if err := ctx.Err(); err != nil {
+			return err
+		}
+		lag := time.Since(due)
+		if speed < 0 {
+			lag = 0
+		}
+		if _, err := target.Send(rec.Packet); err != nil {
+			return err
+		}
+
+		r.mu.Lock()
+		r.stats.Packets++
+		r.stats.Position = pos
+		if lag > r.stats.MaxLag {
+			r.stats.MaxLag = lag
+		}
+		r.mu.Unlock()
+		if r.OnPacket != nil {
+			r.OnPacket(rec, lag)
+		}
+	}
+	return sr.Err()
+}
+
+// Stats returns the statistics of the current or last replay, e.g. to show
+// the progress of a long session.
+func (r *Replayer) Stats() ReplayStats {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	return r.stats
+}
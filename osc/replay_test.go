@@ -0,0 +1,97 @@
+package osc
+
+import (
+	"bytes"
+	"context"
+	"testing"
+	"time"
+)
+
+// replaySession returns a session with a message every 100 ms, whose single
+// argument is its index.
+func replaySession(t *testing.T, n int) []byte {
+	var buf bytes.Buffer
+	sw, err := NewSessionWriter(&buf, false)
+	if err != nil {
+		t.Fatal(err)
+	}
+	start := time.Unix(1500000000, 0)
+	for i := 0; i < n; i++ {
+		if err := sw.Write(start.Add(time.Duration(i)*100*time.Millisecond), NewMessage("/step", int32(i))); err != nil {
+			t.Fatal(err)
+		}
+	}
+	if err := sw.Close(); err != nil {
+		t.Fatal(err)
+	}
+	return buf.Bytes()
+}
+
+func TestReplayer_Replay(t *testing.T) {
+	session := replaySession(t, 5)
+	for _, tt := range []struct {
+		name     string
+		speed    float64
+		start    time.Duration
+		want     []int32
+		min, max time.Duration
+		wantSkip uint64
+	}{
+		{"real time", 0, 0, []int32{0, 1, 2, 3, 4}, 400 * time.Millisecond, 2 * time.Second, 0},
+		{"double speed", 2, 0, []int32{0, 1, 2, 3, 4}, 200 * time.Millisecond, 380 * time.Millisecond, 0},
+		{"full speed", ReplayFullSpeed, 0, []int32{0, 1, 2, 3, 4}, 0, 100 * time.Millisecond, 0},
+		{"jump", 0, 250 * time.Millisecond, []int32{3, 4}, 150 * time.Millisecond, 2 * time.Second, 3},
+	} {
+		sr, err := NewSessionReader(bytes.NewReader(session))
+		if err != nil {
+			t.Fatal(err)
+		}
+		sender := &recordingSender{}
+		var hooked int
+		r := &Replayer{Speed: tt.speed, Start: tt.start}
+		r.OnPacket = func(SessionRecord, time.Duration) { hooked++ }
+
+		start := time.Now()
+		if err := r.Replay(context.Background(), sr, sender); err != nil {
+			t.Fatalf("%s: Replay() unexpected error: %s", tt.name, err)
+		}
+		if d := time.Since(start); d < tt.min || d > tt.max {
+			t.Errorf("%s: Replay() took %s, want between %s and %s", tt.name, d, tt.min, tt.max)
+		}
+
+		var got []int32
+		for _, msg := range sender.messages() {
+			got = append(got, msg.Arguments[0].(int32))
+		}
+		if len(got) != len(tt.want) || hooked != len(tt.want) {
+			t.Fatalf("%s: replayed %v with %d hook calls, want = %v", tt.name, got, hooked, tt.want)
+		}
+		for i := range got {
+			if got[i] != tt.want[i] {
+				t.Errorf("%s: replayed %v, want = %v", tt.name, got, tt.want)
+				break
+			}
+		}
+		stats := r.Stats()
+		if stats.Packets != uint64(len(tt.want)) || stats.Skipped != tt.wantSkip || stats.Position != 400*time.Millisecond {
+			t.Errorf("%s: Stats() = %+v, want %d packets, %d skipped at 400ms", tt.name, stats, len(tt.want), tt.wantSkip)
+		}
+	}
+}
+
+func TestReplayer_ReplayCanceled(t *testing.T) {
+	sr, err := NewSessionReader(bytes.NewReader(replaySession(t, 50)))
+	if err != nil {
+		t.Fatal(err)
+	}
+	ctx, cancel := context.WithTimeout(context.Background(), 150*time.Millisecond)
+	defer cancel()
+	sender := &recordingSender{}
+	var r Replayer
+	if err := r.Replay(ctx, sr, sender); err != context.DeadlineExceeded {
+		t.Errorf("Replay() error = %v, want = %v", err, context.DeadlineExceeded)
+	}
+	if n := len(sender.messages()); n < 1 || n > 3 {
+		t.Errorf("replayed %d packets before the deadline, want 2", n)
+	}
+}
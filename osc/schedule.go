@@ -0,0 +1,36 @@
+package osc
+
+import "time"
+
+// ScheduleLead returns how long before their time packets are sent by
+// Schedule. Zero means that they are sent immediately.
+func (c *Client) ScheduleLead() time.Duration { return c.scheduleLead }
+
+// SetScheduleLead makes Schedule hold packets until lead before their time,
+// for targets that execute bundles immediately instead of honoring future
+// timetags. The lead should cover the network latency. A zero lead, the
+// default, sends packets immediately and leaves the scheduling to the target.
+func (c *Client) SetScheduleLead(lead time.Duration) { c.scheduleLead = lead }
+
+// Schedule sends the packet in a bundle with the timetag at, so that the
+// target executes it at that time. The packet is sent immediately, or lead
+// before at if a lead was set with SetScheduleLead. Held packets are sent in
+// the background, errors are reported to the function set with OnSendError.
+// Bundles are nested in the scheduled bundle, their timetags must not be
+// before at.
+func (c *Client) Schedule(packet Packet, at time.Time) error {
+	bundle := NewBundle(at)
+	if err := bundle.Append(packet); err != nil {
+		return err
+	}
+
+	delay := time.Until(at.Add(-c.scheduleLead))
+	if c.scheduleLead == 0 || delay <= 0 {
+		_, err := c.Send(bundle)
+		return err
+	}
+	time.AfterFunc(delay, func() {
+		c.Send(bundle)
+	})
+	return nil
+}
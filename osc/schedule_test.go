@@ -0,0 +1,46 @@
+package osc
+
+import (
+	"net"
+	"testing"
+	"time"
+)
+
+func TestClient_Schedule(t *testing.T) {
+	conn, err := net.ListenPacket("udp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer conn.Close()
+	addr := conn.LocalAddr().(*net.UDPAddr)
+	client := NewClient(addr.IP.String(), addr.Port)
+	server := &Server{ReadTimeout: 5 * time.Second}
+
+	for _, lead := range []time.Duration{0, 50 * time.Millisecond} {
+		client.SetScheduleLead(lead)
+		start := time.Now()
+		at := start.Add(200 * time.Millisecond)
+		if err := client.Schedule(NewMessage("/cue", int32(1)), at); err != nil {
+			t.Fatal(err)
+		}
+
+		p, err := server.ReceivePacket(conn)
+		if err != nil {
+			t.Fatal(err)
+		}
+		elapsed := time.Since(start)
+		b, ok := p.(*Bundle)
+		if !ok || len(b.Messages) != 1 || b.Messages[0].Address != "/cue" {
+			t.Fatalf("lead %s: received %#v, want a bundle with /cue", lead, p)
+		}
+		if b.Timetag.TimeTag() != NewTimetag(at).TimeTag() {
+			t.Errorf("lead %s: timetag = %s, want = %s", lead, b.Timetag.Time(), at)
+		}
+		if lead == 0 && elapsed > 100*time.Millisecond {
+			t.Errorf("lead %s: packet was held for %s", lead, elapsed)
+		}
+		if lead > 0 && elapsed < 140*time.Millisecond {
+			t.Errorf("lead %s: packet was sent after %s, want about 150ms", lead, elapsed)
+		}
+	}
+}
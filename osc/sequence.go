@@ -0,0 +1,282 @@
+package osc
+
+import (
+	"crypto/rand"
+	"encoding/binary"
+	"errors"
+	"net"
+	"strconv"
+	"strings"
+	"sync"
+	"sync/atomic"
+	"time"
+)
+
+// ErrDuplicatePacket is returned by Server.ReceivePacket for packets that
+// were discarded by the SequenceChecker of the server as duplicates.
+var ErrDuplicatePacket = errors.New("osc: duplicate packet")
+
+// sequenceWindow is the number of sequence numbers before the highest
+// received one that are checked for duplicates.
+const sequenceWindow = 64
+
+// maxSequenceSenders is the number of senders whose state a SequenceChecker
+// remembers before it forgets the ones that didn't send for
+// sequenceExpiry. Messages of further senders aren't checked until senders
+// expired.
+const maxSequenceSenders = 1024
+
+// sequenceExpiry is the time after which the state of a sender that didn't
+// send may be forgotten.
+const sequenceExpiry = time.Minute
+
+// SequenceHook returns a SendHook that stamps every message with a sequence
+// number, starting with 0. The messages of a bundle are numbered in order.
+// Every hook has a random stream ID that identifies the sender independently
+// of its source port, since a Client without a connection sends every packet
+// from a new port. The stream ID and the sequence number are appended to the
+// address after a '#', e.g. "/fader#3f09a2c1.12", which can't collide with
+// the arguments or with a valid address, since OSC reserves '#'. The receiver
+// uses a SequenceChecker to detect lost, reordered and duplicate messages and
+// to remove the sequence numbers. The stamped addresses don't match the
+// handlers of receivers without a SequenceChecker.
+//
+// The messages are validated before they are stamped, unless SkipValidation
+// is set, and the stamped messages skip the validation of MarshalBinary.
+//
+// If packets are also signed, the SequenceHook must be added before the
+// SignHook.
+func SequenceHook() SendHook {
+	var next uint32
+	prefix := string(sequenceSeparator) + strconv.FormatUint(uint64(newStreamID()), 16) + string(streamSeparator)
+	var stamp func(packet Packet) (Packet, error)
+	stamp = func(packet Packet) (Packet, error) {
+		switch p := packet.(type) {
+		case *Message:
+			if !p.SkipValidation {
+				if err := p.Validate(); err != nil {
+					return nil, err
+				}
+			}
+			msg := p.Clone()
+			seq := atomic.AddUint32(&next, 1) - 1
+			msg.Address += prefix + strconv.FormatUint(uint64(seq), 10)
+			msg.SkipValidation = true
+			return msg, nil
+
+		case *Bundle:
+			stamped := &Bundle{Timetag: p.Timetag}
+			for _, msg := range p.Messages {
+				s, err := stamp(msg)
+				if err != nil {
+					return nil, err
+				}
+				stamped.Messages = append(stamped.Messages, s.(*Message))
+			}
+			for _, b := range p.Bundles {
+				s, err := stamp(b)
+				if err != nil {
+					return nil, err
+				}
+				stamped.Bundles = append(stamped.Bundles, s.(*Bundle))
+			}
+			return stamped, nil
+		}
+		return packet, nil
+	}
+	return stamp
+}
+
+// newStreamID returns a random stream ID for SequenceHook.
+func newStreamID() uint32 {
+	var b [4]byte
+	if _, err := rand.Read(b[:]); err != nil {
+		return uint32(time.Now().UnixNano())
+	}
+	return binary.BigEndian.Uint32(b[:])
+}
+
+// sequenceSeparator separates the sequence number from the address of a
+// stamped message, streamSeparator separates the stream ID from the sequence
+// number.
+const (
+	sequenceSeparator = '#'
+	streamSeparator   = '.'
+)
+
+// splitSequence splits the address of a message that was stamped by
+// SequenceHook into the original address, the stream ID and the sequence
+// number. The stream ID is empty if the address only has a sequence number.
+func splitSequence(addr string) (string, string, uint32, bool) {
+	i := strings.LastIndexByte(addr, sequenceSeparator)
+	if i < 0 {
+		return addr, "", 0, false
+	}
+	stream, number := "", addr[i+1:]
+	if j := strings.IndexByte(number, streamSeparator); j >= 0 {
+		stream, number = number[:j], number[j+1:]
+		if stream == "" {
+			return addr, "", 0, false
+		}
+	}
+	seq, err := strconv.ParseUint(number, 10, 32)
+	if err != nil {
+		return addr, "", 0, false
+	}
+	return addr[:i], stream, uint32(seq), true
+}
+
+// SequenceChecker removes the sequence numbers added by SequenceHook from
+// received messages and detects gaps, reordering and duplicates per sender.
+// A sender is identified by its IP address and the stream ID of its
+// SequenceHook, messages without a stream ID by their source address, i.e.
+// they must be sent from a fixed port. The state of at most 1024 senders is
+// kept, senders that didn't send for a minute are forgotten first. Set it as
+// Server.Sequence to check all received packets. The callbacks may be nil. A
+// SequenceChecker is safe for concurrent use.
+type SequenceChecker struct {
+	// OnGap is called if messages were skipped, i.e. lost or delayed.
+	// missing is the number of skipped messages, starting with first.
+	OnGap func(addr net.Addr, first, missing uint32)
+
+	// OnReorder is called for a message that arrived after a message with
+	// a higher sequence number.
+	OnReorder func(addr net.Addr, seq uint32)
+
+	// OnDuplicate is called for a message whose sequence number was already
+	// received. Duplicates are discarded.
+	OnDuplicate func(addr net.Addr, seq uint32)
+
+	mu      sync.Mutex
+	senders map[string]*sequenceState
+}
+
+// sequenceState is the state of a single sender.
+type sequenceState struct {
+	last    uint32    // Highest received sequence number
+	seen    uint64    // Bit i is set if last-i was received
+	updated time.Time // Time of the last message
+}
+
+// Check removes the sequence numbers from the messages of packet, which was
+// received from addr, and reports gaps, reordering and duplicates. Duplicate
+// messages are removed from bundles. Returns false if the packet contains no
+// message that isn't a duplicate. Messages without a sequence number are
+// passed unchanged.
+//
+// A message that is more than 64 sequence numbers older than the highest
+// received one is treated as a restart of the sender.
+func (c *SequenceChecker) Check(addr net.Addr, packet Packet) bool {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	if c.senders == nil {
+		c.senders = make(map[string]*sequenceState)
+	}
+	return c.check(addr, time.Now(), packet)
+}
+
+func (c *SequenceChecker) check(addr net.Addr, now time.Time, packet Packet) bool {
+	switch p := packet.(type) {
+	case *Message:
+		return c.checkMessage(addr, now, p)
+
+	case *Bundle:
+		messages := p.Messages[:0]
+		for _, msg := range p.Messages {
+			if c.checkMessage(addr, now, msg) {
+				messages = append(messages, msg)
+			}
+		}
+		p.Messages = messages
+		bundles := p.Bundles[:0]
+		for _, b := range p.Bundles {
+			if c.check(addr, now, b) {
+				bundles = append(bundles, b)
+			}
+		}
+		p.Bundles = bundles
+		return len(p.Messages) != 0 || len(p.Bundles) != 0
+	}
+	return false
+}
+
+// checkMessage removes the sequence number of msg and updates the state of
+// the sender. Returns false if msg is a duplicate.
+func (c *SequenceChecker) checkMessage(addr net.Addr, now time.Time, msg *Message) bool {
+	base, stream, seq, ok := splitSequence(msg.Address)
+	if !ok {
+		return true
+	}
+	msg.Address = base
+
+	key := senderKey(addr, stream)
+	s, ok := c.senders[key]
+	if !ok {
+		if len(c.senders) >= maxSequenceSenders {
+			c.forget(now)
+			if len(c.senders) >= maxSequenceSenders {
+				return true
+			}
+		}
+		c.senders[key] = &sequenceState{last: seq, seen: 1, updated: now}
+		return true
+	}
+	s.updated = now
+
+	switch diff := int32(seq - s.last); {
+	case diff > 0:
+		if diff > 1 && c.OnGap != nil {
+			c.OnGap(addr, s.last+1, uint32(diff-1))
+		}
+		if diff < sequenceWindow {
+			s.seen = s.seen<<uint(diff) | 1
+		} else {
+			s.seen = 1
+		}
+		s.last = seq
+
+	case diff > -sequenceWindow:
+		bit := uint64(1) << uint(-diff)
+		if s.seen&bit != 0 {
+			if c.OnDuplicate != nil {
+				c.OnDuplicate(addr, seq)
+			}
+			return false
+		}
+		s.seen |= bit
+		if c.OnReorder != nil {
+			c.OnReorder(addr, seq)
+		}
+
+	default:
+		// The sender restarted
+		*s = sequenceState{last: seq, seen: 1, updated: now}
+	}
+	return true
+}
+
+// forget removes the senders that didn't send for sequenceExpiry.
+func (c *SequenceChecker) forget(now time.Time) {
+	for key, s := range c.senders {
+		if now.Sub(s.updated) >= sequenceExpiry {
+			delete(c.senders, key)
+		}
+	}
+}
+
+// senderKey returns the key of the sender state for messages from addr with
+// the given stream ID. Streams are identified by the IP address only, since
+// the source port may change with every packet.
+func senderKey(addr net.Addr, stream string) string {
+	if addr == nil {
+		return string(sequenceSeparator) + stream
+	}
+	if stream == "" {
+		return addr.String()
+	}
+	host, _, err := net.SplitHostPort(addr.String())
+	if err != nil {
+		host = addr.String()
+	}
+	return host + string(sequenceSeparator) + stream
+}
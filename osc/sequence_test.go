@@ -0,0 +1,218 @@
+package osc
+
+import (
+	"fmt"
+	"net"
+	"reflect"
+	"testing"
+	"time"
+)
+
+func TestSequenceHook(t *testing.T) {
+	hook := SequenceHook()
+	msg := NewMessage("/a", "x")
+	p, err := hook(msg)
+	if err != nil {
+		t.Fatal(err)
+	}
+	stamped := p.(*Message)
+	if base, stream, seq, ok := splitSequence(stamped.Address); !ok || base != "/a" || stream == "" || seq != 0 {
+		t.Errorf("stamped address = %q, want = /a#<stream>.0", stamped.Address)
+	}
+	if want := NewMessage(stamped.Address, "x"); !stamped.Equals(want) {
+		t.Errorf("stamped message = %v, want = %v", p, want)
+	}
+	if len(msg.Arguments) != 1 {
+		t.Errorf("hook modified the original message: %v", msg)
+	}
+	if _, err := stamped.MarshalBinary(); err != nil {
+		t.Errorf("MarshalBinary() of stamped message: %s", err)
+	}
+	if _, err := hook(NewMessage("/a#b")); err == nil {
+		t.Error("hook expected error for an invalid address")
+	}
+	if _, err := NewMessage("/a#1").MarshalBinary(); err == nil {
+		t.Error("MarshalBinary() expected error for an address with '#'")
+	}
+
+	bundle := &Bundle{
+		Messages: []*Message{NewMessage("/b"), NewMessage("/c")},
+		Bundles:  []*Bundle{{Messages: []*Message{NewMessage("/d")}}},
+	}
+	p, err = hook(bundle)
+	if err != nil {
+		t.Fatal(err)
+	}
+	b := p.(*Bundle)
+	for i, msg := range []*Message{b.Messages[0], b.Messages[1], b.Bundles[0].Messages[0]} {
+		if _, _, seq, ok := splitSequence(msg.Address); !ok || seq != uint32(i+1) {
+			t.Errorf("sequence number of %s = %v, want = %d", msg.Address, seq, i+1)
+		}
+	}
+}
+
+func TestSequenceChecker(t *testing.T) {
+	var events []string
+	c := &SequenceChecker{
+		OnGap: func(addr net.Addr, first, missing uint32) {
+			events = append(events, fmt.Sprintf("gap %s %d+%d", addr, first, missing))
+		},
+		OnReorder: func(addr net.Addr, seq uint32) {
+			events = append(events, fmt.Sprintf("reorder %s %d", addr, seq))
+		},
+		OnDuplicate: func(addr net.Addr, seq uint32) {
+			events = append(events, fmt.Sprintf("duplicate %s %d", addr, seq))
+		},
+	}
+	a := &net.UDPAddr{IP: net.IPv4(10, 0, 0, 1), Port: 1}
+	b := &net.UDPAddr{IP: net.IPv4(10, 0, 0, 2), Port: 1}
+
+	for _, tt := range []struct {
+		addr net.Addr
+		seq  int32
+		ok   bool
+	}{
+		{a, 0, true},
+		{a, 1, true},
+		{b, 100, true},
+		{a, 4, true},
+		{a, 2, true},
+		{a, 2, false},
+		{a, 4, false},
+		{b, 101, true},
+		{a, 200, true},
+		{a, 5, true}, // restart
+		{a, 6, true},
+	} {
+		msg := NewMessage(fmt.Sprintf("/x#%d", tt.seq), "arg")
+		if ok := c.Check(tt.addr, msg); ok != tt.ok {
+			t.Errorf("Check(%s, %d) = %v, want = %v", tt.addr, tt.seq, ok, tt.ok)
+		}
+		if want := NewMessage("/x", "arg"); !msg.Equals(want) {
+			t.Errorf("checked message = %v, want = %v", msg, want)
+		}
+	}
+
+	want := []string{
+		"gap 10.0.0.1:1 2+2",
+		"reorder 10.0.0.1:1 2",
+		"duplicate 10.0.0.1:1 2",
+		"duplicate 10.0.0.1:1 4",
+		"gap 10.0.0.1:1 5+195",
+	}
+	if !reflect.DeepEqual(events, want) {
+		t.Errorf("events = %q, want = %q", events, want)
+	}
+
+	bundle := &Bundle{Messages: []*Message{NewMessage("/y#6"), NewMessage("/y#7")}}
+	if !c.Check(a, bundle) || len(bundle.Messages) != 1 || bundle.Messages[0].Address != "/y" {
+		t.Errorf("Check() didn't remove the duplicate from bundle: %v", bundle.Messages)
+	}
+	if !c.Check(a, NewMessage("/unstamped", "z")) {
+		t.Error("Check() rejected a message without sequence number")
+	}
+
+	// A trailing int32 argument isn't a sequence number
+	for i := 0; i < 2; i++ {
+		msg := NewMessage("/fader", int32(5))
+		if !c.Check(a, msg) {
+			t.Errorf("Check() rejected unstamped message %d as duplicate", i)
+		}
+		if want := NewMessage("/fader", int32(5)); !msg.Equals(want) {
+			t.Errorf("checked message = %v, want = %v", msg, want)
+		}
+	}
+}
+
+func TestServer_Sequence(t *testing.T) {
+	conn, err := net.ListenPacket("udp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer conn.Close()
+	addr := conn.LocalAddr().(*net.UDPAddr)
+
+	shared, err := net.DialUDP("udp", nil, addr)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer shared.Close()
+	client := NewClientFromConn(shared)
+	client.AddSendHook(SequenceHook())
+	var sent []byte
+	client.AddSendHook(func(p Packet) (Packet, error) {
+		sent, _ = p.MarshalBinary()
+		return p, nil
+	})
+	if _, err := client.Send(NewMessage("/a", int32(1))); err != nil {
+		t.Fatal(err)
+	}
+	// Send the same datagram again
+	if _, err := shared.Write(sent); err != nil {
+		t.Fatal(err)
+	}
+
+	server := &Server{ReadTimeout: 5 * time.Second, Sequence: &SequenceChecker{}}
+	p, err := server.ReceivePacket(conn)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if want := NewMessage("/a", int32(1)); !p.(*Message).Equals(want) {
+		t.Errorf("received %v, want = %v", p, want)
+	}
+	if _, err := server.ReceivePacket(conn); err != ErrDuplicatePacket {
+		t.Errorf("ReceivePacket() of duplicate = %v, want = %v", err, ErrDuplicatePacket)
+	}
+}
+
+func TestServer_SequenceEphemeralPorts(t *testing.T) {
+	conn, err := net.ListenPacket("udp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer conn.Close()
+	addr := conn.LocalAddr().(*net.UDPAddr)
+
+	// The client sends every packet from a new port
+	client := NewClient("127.0.0.1", addr.Port)
+	client.AddSendHook(SequenceHook())
+	var drop bool
+	client.AddSendHook(func(p Packet) (Packet, error) {
+		if drop {
+			return nil, fmt.Errorf("dropped")
+		}
+		return p, nil
+	})
+
+	var gaps []uint32
+	server := &Server{ReadTimeout: 5 * time.Second, Sequence: &SequenceChecker{
+		OnGap: func(addr net.Addr, first, missing uint32) {
+			gaps = append(gaps, first, missing)
+		},
+	}}
+	for i := 0; i < 3; i++ {
+		drop = i == 1
+		if _, err := client.Send(NewMessage("/a", int32(i))); drop != (err != nil) {
+			t.Fatalf("Send() of message %d error = %v", i, err)
+		}
+		if drop {
+			continue
+		}
+		if _, err := server.ReceivePacket(conn); err != nil {
+			t.Fatal(err)
+		}
+	}
+	if want := []uint32{1, 1}; !reflect.DeepEqual(gaps, want) {
+		t.Errorf("gaps = %v, want = %v", gaps, want)
+	}
+}
+
+func TestSequenceChecker_Senders(t *testing.T) {
+	c := &SequenceChecker{}
+	for port := 1; port <= maxSequenceSenders+10; port++ {
+		c.Check(&net.UDPAddr{IP: net.IPv4(10, 0, 0, 1), Port: port}, NewMessage("/x#0"))
+	}
+	if n := len(c.senders); n != maxSequenceSenders {
+		t.Errorf("%d senders were tracked, want = %d", n, maxSequenceSenders)
+	}
+}
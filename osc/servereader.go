@@ -0,0 +1,62 @@
+package osc
+
+import (
+	"bufio"
+	"io"
+	"net"
+	"time"
+)
+
+// ServeReader dispatches the packets read from r, which are delimited by
+// framing, e.g. a TCP stream that was extracted from a capture or written by
+// an Encoder. This allows to reproduce issues offline with the dispatcher and
+// the receive options of a live server. The packets are handled like packets
+// received by Serve, with a source address whose network is "reader".
+// Acknowledgments are discarded.
+//
+// ServeReader returns after r ended and the running handlers returned, but
+// doesn't wait for bundles, which a StandardDispatcher dispatches at their
+// time in the background. It returns nil
+// if r ended at a frame boundary, otherwise the error of reading r. Frames
+// that are larger than the receive buffer of a server are truncated.
+func (s *Server) ServeReader(r io.Reader, framing Framing) error {
+	if s.Dispatcher == nil {
+		s.Dispatcher = NewStandardDispatcher()
+	}
+	err := s.Serve(&readerConn{r: bufio.NewReader(r), framing: framing})
+	s.inflight.Wait()
+	if err == io.EOF {
+		return nil
+	}
+	return err
+}
+
+// readerAddr is the source address of the packets served by ServeReader.
+type readerAddr struct{}
+
+func (readerAddr) Network() string { return "reader" }
+func (readerAddr) String() string  { return "reader" }
+
+// readerConn is a net.PacketConn that reads packets from a stream.
+type readerConn struct {
+	r       *bufio.Reader
+	framing Framing
+}
+
+// Verify that readerConn implements the net.PacketConn interface.
+var _ net.PacketConn = (*readerConn)(nil)
+
+func (c *readerConn) ReadFrom(p []byte) (int, net.Addr, error) {
+	frame, err := c.framing.ReadFrame(c.r)
+	if err != nil {
+		return 0, nil, err
+	}
+	return copy(p, frame), readerAddr{}, nil
+}
+
+func (c *readerConn) WriteTo(p []byte, addr net.Addr) (int, error) { return len(p), nil }
+func (c *readerConn) Close() error                                 { return nil }
+func (c *readerConn) LocalAddr() net.Addr                          { return readerAddr{} }
+func (c *readerConn) SetDeadline(t time.Time) error                { return nil }
+func (c *readerConn) SetReadDeadline(t time.Time) error            { return nil }
+func (c *readerConn) SetWriteDeadline(t time.Time) error           { return nil }
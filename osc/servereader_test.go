@@ -0,0 +1,69 @@
+package osc
+
+import (
+	"bytes"
+	"io"
+	"net"
+	"reflect"
+	"sort"
+	"sync"
+	"testing"
+)
+
+func TestServer_ServeReader(t *testing.T) {
+	for _, framing := range []Framing{SizePrefixFraming, SLIPFraming} {
+		var stream bytes.Buffer
+		e := NewEncoder(&stream)
+		e.Framing = framing
+		bundle := &Bundle{Timetag: *NewTimetagFromTimetag(1)}
+		bundle.Append(NewMessage("/b", int32(2)))
+		for _, p := range []Packet{NewMessage("/a", int32(1)), bundle, NewMessage("/c", "x")} {
+			if err := e.Encode(p); err != nil {
+				t.Fatal(err)
+			}
+		}
+		// Skipped like an invalid datagram
+		framing.WriteFrame(&stream, []byte("/garbage"))
+
+		var mu sync.Mutex
+		var wg sync.WaitGroup
+		var received, sources []string
+		wg.Add(3)
+		d := NewStandardDispatcher()
+		d.AddMsgHandler("*", func(msg *Message) {
+			mu.Lock()
+			defer mu.Unlock()
+			received = append(received, msg.Address)
+			wg.Done()
+		})
+		server := &Server{Dispatcher: d, Trace: &ServerTrace{
+			OnPacketReceived: func(p Packet, addr net.Addr) {
+				sources = append(sources, addr.String())
+			},
+		}}
+		if err := server.ServeReader(&stream, framing); err != nil {
+			t.Errorf("ServeReader() = %v, want = nil", err)
+		}
+
+		// The messages of bundles are dispatched in the background
+		wg.Wait()
+		sort.Strings(received)
+		if want := []string{"/a", "/b", "/c"}; !reflect.DeepEqual(received, want) {
+			t.Errorf("received %v, want = %v", received, want)
+		}
+		if want := []string{"reader", "reader", "reader"}; !reflect.DeepEqual(sources, want) {
+			t.Errorf("sources = %v, want = %v", sources, want)
+		}
+	}
+}
+
+func TestServer_ServeReader_truncated(t *testing.T) {
+	var stream bytes.Buffer
+	SizePrefixFraming.WriteFrame(&stream, []byte("/a\x00\x00,\x00\x00\x00"))
+	stream.Truncate(stream.Len() - 2)
+
+	server := &Server{}
+	if err := server.ServeReader(&stream, SizePrefixFraming); err != io.ErrUnexpectedEOF {
+		t.Errorf("ServeReader() = %v, want = %v", err, io.ErrUnexpectedEOF)
+	}
+}
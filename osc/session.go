@@ -0,0 +1,245 @@
+package osc
+
+import (
+	"bufio"
+	"bytes"
+	"compress/gzip"
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"time"
+)
+
+// Session files store a sequence of timestamped OSC packets, e.g. to capture a
+// rehearsal and analyze or replay it later. A session file starts with the
+// 8 byte header "OSCSESS" followed by the version byte, then every packet is
+// stored as a record of:
+//   - the receive time as int64 nanoseconds since the Unix epoch
+//   - the packet size as int32
+//   - the packet data
+//
+// All numbers are big-endian. The whole file may be gzip compressed.
+const (
+	sessionMagic   = "OSCSESS"
+	sessionVersion = 1
+)
+
+// ErrInvalidSession is returned if a session file has an invalid header.
+var ErrInvalidSession = errors.New("osc: invalid session file")
+
+// SessionRecord is a packet of a session file and the time it was recorded.
+type SessionRecord struct {
+	Time   time.Time
+	Packet Packet
+}
+
+// SessionWriter writes packets to a session file.
+type SessionWriter struct {
+	bw     *bufio.Writer
+	gz     *gzip.Writer
+	closer io.Closer // Closed by Close, if not nil
+}
+
+// NewSessionWriter returns a new SessionWriter that writes a session to w.
+// The session is gzip compressed if compress is set. Close must be called to
+// flush the session, it doesn't close w.
+func NewSessionWriter(w io.Writer, compress bool) (*SessionWriter, error) {
+	sw := &SessionWriter{}
+	if compress {
+		sw.gz = gzip.NewWriter(w)
+		w = sw.gz
+	}
+	sw.bw = bufio.NewWriter(w)
+
+	if _, err := sw.bw.WriteString(sessionMagic); err != nil {
+		return nil, err
+	}
+	if err := sw.bw.WriteByte(sessionVersion); err != nil {
+		return nil, err
+	}
+	return sw, nil
+}
+
+// CreateSession creates the session file with the given name. If the file
+// already exists, it is truncated.
+func CreateSession(name string, compress bool) (*SessionWriter, error) {
+	f, err := os.Create(name)
+	if err != nil {
+		return nil, err
+	}
+	sw, err := NewSessionWriter(f, compress)
+	if err != nil {
+		f.Close()
+		return nil, err
+	}
+	sw.closer = f
+	return sw, nil
+}
+
+// Write adds the packet with the time t to the session.
+func (sw *SessionWriter) Write(t time.Time, packet Packet) error {
+	data, err := packet.MarshalBinary()
+	if err != nil {
+		return err
+	}
+
+	var header [12]byte
+	binary.BigEndian.PutUint64(header[:], uint64(t.UnixNano()))
+	binary.BigEndian.PutUint32(header[8:], uint32(len(data)))
+	if _, err = sw.bw.Write(header[:]); err != nil {
+		return err
+	}
+	_, err = sw.bw.Write(data)
+	return err
+}
+
+// Close flushes the session. If the session was created by CreateSession, the
+// file is closed.
+func (sw *SessionWriter) Close() error {
+	err := sw.bw.Flush()
+	if sw.gz != nil {
+		if gerr := sw.gz.Close(); err == nil {
+			err = gerr
+		}
+	}
+	if sw.closer != nil {
+		if cerr := sw.closer.Close(); err == nil {
+			err = cerr
+		}
+	}
+	return err
+}
+
+// SessionReader reads the packets of a session file. Its usage is similar to
+// bufio.Scanner:
+//
+//	for sr.Next() {
+//	    rec := sr.Record()
+//	    ...
+//	}
+//	if err := sr.Err(); err != nil {
+//	    ...
+//	}
+type SessionReader struct {
+	// Options control how the packets are decoded.
+	Options DecodeOptions
+
+	br     *bufio.Reader
+	gz     *gzip.Reader
+	closer io.Closer
+	record SessionRecord
+	err    error
+}
+
+// NewSessionReader returns a new SessionReader that reads a session from r.
+// Compressed sessions are detected automatically. Returns ErrInvalidSession
+// if r doesn't start with a session header.
+func NewSessionReader(r io.Reader) (*SessionReader, error) {
+	sr := &SessionReader{br: bufio.NewReader(r)}
+
+	// gzip streams start with 0x1F 0x8B
+	if magic, err := sr.br.Peek(2); err == nil && magic[0] == 0x1F && magic[1] == 0x8B {
+		if sr.gz, err = gzip.NewReader(sr.br); err != nil {
+			return nil, err
+		}
+		sr.br = bufio.NewReader(sr.gz)
+	}
+
+	header := make([]byte, len(sessionMagic)+1)
+	if _, err := io.ReadFull(sr.br, header); err != nil {
+		if err == io.EOF || err == io.ErrUnexpectedEOF {
+			return nil, ErrInvalidSession
+		}
+		return nil, err
+	}
+	if !bytes.Equal(header[:len(sessionMagic)], []byte(sessionMagic)) {
+		return nil, ErrInvalidSession
+	}
+	if v := header[len(sessionMagic)]; v != sessionVersion {
+		return nil, fmt.Errorf("osc: unsupported session file version %d", v)
+	}
+	return sr, nil
+}
+
+// OpenSession opens the session file with the given name.
+func OpenSession(name string) (*SessionReader, error) {
+	f, err := os.Open(name)
+	if err != nil {
+		return nil, err
+	}
+	sr, err := NewSessionReader(f)
+	if err != nil {
+		f.Close()
+		return nil, err
+	}
+	sr.closer = f
+	return sr, nil
+}
+
+// Next reads the next record, which is then available through Record. Returns
+// false at the end of the session or if an error occurred.
+func (sr *SessionReader) Next() bool {
+	if sr.err != nil {
+		return false
+	}
+
+	var header [12]byte
+	if _, err := io.ReadFull(sr.br, header[:]); err != nil {
+		if err != io.EOF {
+			sr.err = err
+		}
+		return false
+	}
+	t := int64(binary.BigEndian.Uint64(header[:8]))
+	size := int32(binary.BigEndian.Uint32(header[8:]))
+	if size < 0 {
+		sr.err = errors.New("osc: negative packet size in session file")
+		return false
+	}
+
+	var data bytes.Buffer
+	if n, err := data.ReadFrom(io.LimitReader(sr.br, int64(size))); err != nil {
+		sr.err = err
+		return false
+	} else if n < int64(size) {
+		sr.err = io.ErrUnexpectedEOF
+		return false
+	}
+
+	d := Decoder{Options: sr.Options}
+	p, err := d.DecodeBytes(data.Bytes())
+	if err != nil {
+		sr.err = err
+		return false
+	}
+	sr.record = SessionRecord{Time: time.Unix(0, t), Packet: p}
+	return true
+}
+
+// Record returns the record that was read by the last call to Next.
+func (sr *SessionReader) Record() SessionRecord {
+	return sr.record
+}
+
+// Err returns the first error that occurred while reading the session. It
+// returns nil at the end of the session.
+func (sr *SessionReader) Err() error {
+	return sr.err
+}
+
+// Close closes the session. If the session was opened by OpenSession, the file
+// is closed.
+func (sr *SessionReader) Close() error {
+	var err error
+	if sr.gz != nil {
+		err = sr.gz.Close()
+	}
+	if sr.closer != nil {
+		if cerr := sr.closer.Close(); err == nil {
+			err = cerr
+		}
+	}
+	return err
+}
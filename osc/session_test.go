@@ -0,0 +1,108 @@
+package osc
+
+import (
+	"bytes"
+	"io"
+	"path/filepath"
+	"testing"
+	"time"
+)
+
+func TestSession_RoundTrip(t *testing.T) {
+	start := time.Unix(1500000000, 123)
+	bundle := NewBundle(start)
+	bundle.Append(NewMessage("/b", "x"))
+	records := []SessionRecord{
+		{start, NewMessage("/a", int32(1))},
+		{start.Add(time.Millisecond), bundle},
+		{start.Add(time.Second), NewMessage("/c", []byte{1, 2, 3}, float32(0.5))},
+	}
+
+	for _, compress := range []bool{false, true} {
+		name := filepath.Join(t.TempDir(), "session.osc")
+		sw, err := CreateSession(name, compress)
+		if err != nil {
+			t.Fatal(err)
+		}
+		for _, rec := range records {
+			if err := sw.Write(rec.Time, rec.Packet); err != nil {
+				t.Fatal(err)
+			}
+		}
+		if err := sw.Close(); err != nil {
+			t.Fatal(err)
+		}
+
+		sr, err := OpenSession(name)
+		if err != nil {
+			t.Fatal(err)
+		}
+		var got []SessionRecord
+		for sr.Next() {
+			got = append(got, sr.Record())
+		}
+		if err := sr.Err(); err != nil {
+			t.Errorf("compress %v: Err() = %s", compress, err)
+		}
+		if err := sr.Close(); err != nil {
+			t.Fatal(err)
+		}
+
+		if len(got) != len(records) {
+			t.Fatalf("compress %v: read %d records, want = %d", compress, len(got), len(records))
+		}
+		for i, rec := range records {
+			if !got[i].Time.Equal(rec.Time) || !packetsEqual(got[i].Packet, rec.Packet) {
+				t.Errorf("compress %v: record %d = %v, want = %v", compress, i, got[i], rec)
+			}
+		}
+	}
+}
+
+func TestSessionReader_Errors(t *testing.T) {
+	if _, err := NewSessionReader(bytes.NewReader([]byte("not a session"))); err != ErrInvalidSession {
+		t.Errorf("NewSessionReader() error = %v, want = %v", err, ErrInvalidSession)
+	}
+	if _, err := NewSessionReader(bytes.NewReader(nil)); err != ErrInvalidSession {
+		t.Errorf("NewSessionReader() error = %v, want = %v", err, ErrInvalidSession)
+	}
+	if _, err := NewSessionReader(bytes.NewReader([]byte("OSCSESS\x09"))); err == nil {
+		t.Error("expected error for unsupported version")
+	}
+
+	// Truncated record
+	var buf bytes.Buffer
+	sw, err := NewSessionWriter(&buf, false)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := sw.Write(time.Now(), NewMessage("/a", int32(1))); err != nil {
+		t.Fatal(err)
+	}
+	if err := sw.Close(); err != nil {
+		t.Fatal(err)
+	}
+	sr, err := NewSessionReader(bytes.NewReader(buf.Bytes()[:buf.Len()-2]))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if sr.Next() {
+		t.Error("Next() = true for truncated record")
+	}
+	if sr.Err() != io.ErrUnexpectedEOF {
+		t.Errorf("Err() = %v, want = %v", sr.Err(), io.ErrUnexpectedEOF)
+	}
+}
+
+// packetsEqual returns true if both packets are equal messages or bundles.
+func packetsEqual(a, b Packet) bool {
+	switch x := a.(type) {
+	case *Message:
+		y, ok := b.(*Message)
+		return ok && x.Equals(y)
+	case *Bundle:
+		y, ok := b.(*Bundle)
+		return ok && x.Equals(y)
+	}
+	return false
+}
@@ -0,0 +1,123 @@
+package osc
+
+// DefaultMaxPacketSize is the largest UDP payload that fits into an Ethernet
+// frame with an IPv4 header. Larger datagrams are fragmented, and some
+// receivers drop fragmented datagrams.
+const DefaultMaxPacketSize = 1472
+
+// MaxPacketSize returns the size above which Send splits bundles. Zero means
+// that bundles aren't split.
+func (c *Client) MaxPacketSize() int {
+	switch {
+	case c.maxSize == 0:
+		return DefaultMaxPacketSize
+	case c.maxSize < 0:
+		return 0
+	}
+	return c.maxSize
+}
+
+// SetMaxPacketSize makes Send split bundles whose encoded size is larger than
+// size into several datagrams, see SplitBundle. The default is
+// DefaultMaxPacketSize, which avoids fragmentation on Ethernet networks. A
+// split bundle isn't executed atomically by the receiver anymore, so
+// receivers that rely on it must disable splitting by passing zero or a
+// negative size.
+func (c *Client) SetMaxPacketSize(size int) {
+	if size <= 0 {
+		size = -1
+	}
+	c.maxSize = size
+}
+
+// sendSplit sends the parts of the bundle b, which is the encoded packet.
+// Sending stops at the first error.
+func (c *Client) sendSplit(packet Packet, b *Bundle) (int, error) {
+	parts, err := SplitBundle(b, c.MaxPacketSize())
+	if err != nil {
+		c.sendFailed(packet, err)
+		return 0, err
+	}
+	total := 0
+	for _, part := range parts {
+		data, err := part.MarshalBinary()
+		if err != nil {
+			c.sendFailed(packet, err)
+			return total, err
+		}
+		n, err := c.sendData(packet, data)
+		total += n
+		if err != nil {
+			return total, err
+		}
+	}
+	return total, nil
+}
+
+// SplitBundle splits b into bundles whose encoded size is at most maxSize
+// bytes. The bundles have the timetag of b and contain its messages and
+// nested bundles in their order. Nested bundles that are too large are split
+// as well and keep their timetags. Messages that are larger than maxSize on
+// their own are put into a bundle of their own, which is larger than
+// maxSize. If b fits into maxSize, it is returned unchanged.
+func SplitBundle(b *Bundle, maxSize int) ([]*Bundle, error) {
+	data, err := b.MarshalBinary()
+	if err != nil {
+		return nil, err
+	}
+	if len(data) <= maxSize {
+		return []*Bundle{b}, nil
+	}
+	return splitBundle(b, maxSize)
+}
+
+// splitBundle distributes the elements of b to bundles of at most maxSize
+// bytes.
+func splitBundle(b *Bundle, maxSize int) ([]*Bundle, error) {
+	var bundles []*Bundle
+	var current *Bundle
+	size := 0
+	add := func(p Packet, n int) {
+		n += 4 // Size prefix of the bundle element
+		if current == nil || size+n > maxSize && size > bundleHeaderSize {
+			current = &Bundle{Timetag: b.Timetag}
+			bundles = append(bundles, current)
+			size = bundleHeaderSize
+		}
+		current.Append(p)
+		size += n
+	}
+
+	for _, msg := range b.Messages {
+		data, err := msg.MarshalBinary()
+		if err != nil {
+			return nil, err
+		}
+		add(msg, len(data))
+	}
+	for _, nested := range b.Bundles {
+		data, err := nested.MarshalBinary()
+		if err != nil {
+			return nil, err
+		}
+		if bundleHeaderSize+4+len(data) <= maxSize {
+			add(nested, len(data))
+			continue
+		}
+		parts, err := splitBundle(nested, maxSize-bundleHeaderSize-4)
+		if err != nil {
+			return nil, err
+		}
+		for _, part := range parts {
+			data, err := part.MarshalBinary()
+			if err != nil {
+				return nil, err
+			}
+			add(part, len(data))
+		}
+	}
+	if bundles == nil {
+		bundles = []*Bundle{{Timetag: b.Timetag}}
+	}
+	return bundles, nil
+}
@@ -0,0 +1,116 @@
+package osc
+
+import (
+	"fmt"
+	"reflect"
+	"strings"
+	"testing"
+	"time"
+)
+
+// flatten returns the addresses of the messages of b with the timetags of
+// their bundles in encoding order.
+func flatten(b *Bundle) []string {
+	var out []string
+	for _, msg := range b.Messages {
+		out = append(out, fmt.Sprintf("%s@%d", msg.Address, b.Timetag.TimeTag()))
+	}
+	for _, nested := range b.Bundles {
+		out = append(out, flatten(nested)...)
+	}
+	return out
+}
+
+func TestSplitBundle(t *testing.T) {
+	at := time.Now()
+	b := NewBundle(at)
+	for i := 0; i < 10; i++ {
+		b.Append(NewMessage("/m/"+string(rune('a'+i)), strings.Repeat("x", 60)))
+	}
+	nested := NewBundle(at.Add(time.Second))
+	for i := 0; i < 5; i++ {
+		nested.Append(NewMessage("/n/"+string(rune('a'+i)), strings.Repeat("y", 60)))
+	}
+	b.Append(nested)
+	b.Append(NewMessage("/large", strings.Repeat("z", 400)))
+
+	parts, err := SplitBundle(b, 300)
+	if err != nil {
+		t.Fatal(err)
+	}
+	var got []string
+	for i, part := range parts {
+		data, _ := part.MarshalBinary()
+		large := len(part.Messages) == 1 && part.Messages[0].Address == "/large"
+		if len(data) > 300 && !large {
+			t.Errorf("part %d has %d bytes, want <= 300", i, len(data))
+		}
+		if part.Timetag.TimeTag() != b.Timetag.TimeTag() {
+			t.Errorf("part %d has timetag %d, want = %d", i, part.Timetag.TimeTag(), b.Timetag.TimeTag())
+		}
+		got = append(got, flatten(part)...)
+	}
+	if want := flatten(b); !reflect.DeepEqual(got, want) {
+		t.Errorf("parts contain %v, want = %v", got, want)
+	}
+
+	// Small bundles are returned unchanged
+	small := NewBundle(at)
+	small.Append(NewMessage("/a"))
+	if parts, _ := SplitBundle(small, DefaultMaxPacketSize); len(parts) != 1 || parts[0] != small {
+		t.Errorf("SplitBundle() = %v, want the bundle itself", parts)
+	}
+}
+
+func TestClient_SetMaxPacketSize(t *testing.T) {
+	conn, addr, err := Listen("127.0.0.1:0")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer conn.Close()
+
+	client := NewClient("127.0.0.1", addr.Port)
+	if size := client.MaxPacketSize(); size != DefaultMaxPacketSize {
+		t.Errorf("MaxPacketSize() = %d, want = %d", size, DefaultMaxPacketSize)
+	}
+	for _, size := range []int{0, -1} {
+		client.SetMaxPacketSize(size)
+		if got := client.MaxPacketSize(); got != 0 {
+			t.Errorf("MaxPacketSize() after SetMaxPacketSize(%d) = %d, want = 0", size, got)
+		}
+	}
+	client.SetMaxPacketSize(200)
+	b := NewBundle(time.Now())
+	for i := 0; i < 4; i++ {
+		b.Append(NewMessage("/m", strings.Repeat("x", 80)))
+	}
+	n, err := client.Send(b)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	total, messages := 0, 0
+	buf := make([]byte, 65535)
+	conn.SetReadDeadline(time.Now().Add(time.Second))
+	for total < n {
+		size, _, err := conn.ReadFrom(buf)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if size > 200 {
+			t.Errorf("received %d bytes, want <= 200", size)
+		}
+		p, err := ParsePacket(string(buf[:size]))
+		if err != nil {
+			t.Fatal(err)
+		}
+		messages += len(p.(*Bundle).Messages)
+		total += size
+	}
+	if messages != 4 {
+		t.Errorf("received %d messages, want = 4", messages)
+	}
+	if stats := client.Stats(); stats.Packets != 4 {
+		t.Errorf("Stats().Packets = %d, want = 4", stats.Packets)
+	}
+}
@@ -0,0 +1,75 @@
+package osc
+
+import (
+	"errors"
+	"time"
+
+	"github.com/hypebeast/go-osc/osc/codec"
+)
+
+// ErrStaleBundle is returned by Server.ReceivePacket for bundles that were
+// discarded because they were older than Server.MaxBundleAge.
+var ErrStaleBundle = errors.New("osc: stale bundle")
+
+// StaleMessages returns the number of messages that were dropped because
+// their bundle was older than MaxBundleAge.
+func (s *Server) StaleMessages() uint64 {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return s.staleMessages
+}
+
+// dropStale removes the messages of the bundles in p whose timetag is more
+// than MaxBundleAge in the past and returns their number. The returned packet
+// is nil if no message is left.
+func (s *Server) dropStale(p Packet) (Packet, int) {
+	b, ok := p.(*Bundle)
+	if !ok || s.MaxBundleAge <= 0 {
+		return p, 0
+	}
+	dropped := s.dropStaleBundle(b, time.Now().Add(-s.MaxBundleAge))
+	if dropped == 0 {
+		return p, 0
+	}
+	s.mu.Lock()
+	s.staleMessages += uint64(dropped)
+	s.mu.Unlock()
+	if len(b.Messages) == 0 && len(b.Bundles) == 0 {
+		return nil, dropped
+	}
+	return p, dropped
+}
+
+// dropStaleBundle drops the messages of b and its nested bundles whose
+// timetag is before oldest and returns their number. Bundles with the
+// timetag "immediately" are never stale.
+func (s *Server) dropStaleBundle(b *Bundle, oldest time.Time) int {
+	if tt := b.Timetag.TimeTag(); tt > 1 && b.Timetag.Time().Before(oldest) {
+		n := countMessages(b)
+		if s.ReuseMessages {
+			codec.ReleasePacket(b)
+		}
+		b.Messages, b.Bundles = nil, nil
+		return n
+	}
+
+	dropped := 0
+	kept := b.Bundles[:0]
+	for _, nested := range b.Bundles {
+		dropped += s.dropStaleBundle(nested, oldest)
+		if len(nested.Messages) > 0 || len(nested.Bundles) > 0 {
+			kept = append(kept, nested)
+		}
+	}
+	b.Bundles = kept
+	return dropped
+}
+
+// countMessages returns the number of messages in b and its nested bundles.
+func countMessages(b *Bundle) int {
+	n := len(b.Messages)
+	for _, nested := range b.Bundles {
+		n += countMessages(nested)
+	}
+	return n
+}
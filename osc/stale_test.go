@@ -0,0 +1,57 @@
+package osc
+
+import (
+	"net"
+	"testing"
+	"time"
+)
+
+func TestServer_MaxBundleAge(t *testing.T) {
+	conn, err := net.ListenPacket("udp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer conn.Close()
+	addr := conn.LocalAddr().(*net.UDPAddr)
+	client := NewClient(addr.IP.String(), addr.Port)
+	server := &Server{ReadTimeout: 5 * time.Second, MaxBundleAge: time.Second}
+
+	stale := NewBundle(time.Now().Add(-10 * time.Second))
+	stale.Append(NewMessage("/fader/1", float32(0.1)))
+	stale.Append(NewMessage("/fader/2", float32(0.2)))
+
+	late := NewBundle(time.Now().Add(-500 * time.Millisecond))
+	late.Append(NewMessage("/late"))
+
+	fresh := &Bundle{Timetag: *NewTimetagFromTimetag(1)}
+	fresh.Append(NewMessage("/fader/3", float32(0.3)))
+	fresh.Append(stale)
+
+	for _, tt := range []struct {
+		packet   Packet
+		err      error
+		messages int
+		stale    uint64
+	}{
+		{stale, ErrStaleBundle, 0, 2},
+		{late, nil, 1, 2},
+		{fresh, nil, 1, 4},
+		{NewMessage("/message"), nil, 0, 4},
+	} {
+		if _, err := client.Send(tt.packet); err != nil {
+			t.Fatal(err)
+		}
+		p, err := server.ReceivePacket(conn)
+		if err != tt.err {
+			t.Errorf("ReceivePacket() error = %v, want = %v", err, tt.err)
+		}
+		if b, ok := p.(*Bundle); ok {
+			if len(b.Messages) != tt.messages || len(b.Bundles) != 0 {
+				t.Errorf("received bundle with %d messages and %d bundles, want = %d, 0", len(b.Messages), len(b.Bundles), tt.messages)
+			}
+		}
+		if got := server.StaleMessages(); got != tt.stale {
+			t.Errorf("StaleMessages() = %d, want = %d", got, tt.stale)
+		}
+	}
+}
@@ -0,0 +1,103 @@
+package osc
+
+import (
+	"sort"
+	"sync"
+	"time"
+
+	"github.com/hypebeast/go-osc/osc/internal/pattern"
+)
+
+// StateEntry is the last message that a StateStore received for an address.
+type StateEntry struct {
+	Message  *Message
+	Received time.Time
+}
+
+// StateStore records the last received message of every address, e.g. to
+// let a user interface that joins late show the current state without
+// querying the device. Set it as Server.State to record all received
+// messages, or register it as handler. Messages whose address is a pattern
+// aren't recorded. A StateStore is safe for concurrent use.
+type StateStore struct {
+	mu      sync.RWMutex
+	entries map[string]StateEntry
+}
+
+// Verify that StateStore implements the Handler interface.
+var _ Handler = (*StateStore)(nil)
+
+// NewStateStore returns an empty StateStore.
+func NewStateStore() *StateStore {
+	return &StateStore{entries: make(map[string]StateEntry)}
+}
+
+// Set records a copy of msg as the current state of its address.
+func (s *StateStore) Set(msg *Message) {
+	if pattern.IsPattern(msg.Address) {
+		return
+	}
+	entry := StateEntry{Message: msg.Clone(), Received: time.Now()}
+	s.mu.Lock()
+	s.entries[msg.Address] = entry
+	s.mu.Unlock()
+}
+
+// HandleMessage calls Set. Implements the Handler interface.
+func (s *StateStore) HandleMessage(msg *Message) {
+	s.Set(msg)
+}
+
+// Get returns the last message that was recorded for addr. The message must
+// not be modified.
+func (s *StateStore) Get(addr string) (StateEntry, bool) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+	entry, ok := s.entries[addr]
+	return entry, ok
+}
+
+// Delete removes the state of addr.
+func (s *StateStore) Delete(addr string) {
+	s.mu.Lock()
+	delete(s.entries, addr)
+	s.mu.Unlock()
+}
+
+// Len returns the number of recorded addresses.
+func (s *StateStore) Len() int {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+	return len(s.entries)
+}
+
+// Snapshot returns the state of all addresses, sorted by address. The
+// messages must not be modified.
+func (s *StateStore) Snapshot() []StateEntry {
+	s.mu.RLock()
+	snapshot := make([]StateEntry, 0, len(s.entries))
+	for _, entry := range s.entries {
+		snapshot = append(snapshot, entry)
+	}
+	s.mu.RUnlock()
+
+	sort.Slice(snapshot, func(i, j int) bool {
+		return snapshot[i].Message.Address < snapshot[j].Message.Address
+	})
+	return snapshot
+}
+
+// record records the messages of a received packet.
+func (s *StateStore) record(packet Packet) {
+	switch p := packet.(type) {
+	case *Message:
+		s.Set(p)
+	case *Bundle:
+		for _, msg := range p.Messages {
+			s.Set(msg)
+		}
+		for _, b := range p.Bundles {
+			s.record(b)
+		}
+	}
+}
@@ -0,0 +1,66 @@
+package osc
+
+import (
+	"context"
+	"net"
+	"testing"
+	"time"
+)
+
+func TestServer_State(t *testing.T) {
+	state := NewStateStore()
+	received := make(chan struct{}, 3)
+	d := NewStandardDispatcher()
+	if err := d.AddMsgHandler("*", func(msg *Message) { received <- struct{}{} }); err != nil {
+		t.Fatal(err)
+	}
+	server := &Server{Addr: "127.0.0.1:0", Dispatcher: d, State: state, ReuseMessages: true}
+	if err := server.Start(); err != nil {
+		t.Fatal(err)
+	}
+	defer server.Stop(context.Background())
+
+	addr := server.LocalAddr().(*net.UDPAddr)
+	client := NewClient(addr.IP.String(), addr.Port)
+	bundle := NewBundle(time.Now())
+	bundle.Append(NewMessage("/fader/2", float32(0.2)))
+	bundle.Append(NewMessage("/fader/*", float32(0)))
+	for _, p := range []Packet{NewMessage("/fader/1", float32(0.1)), bundle, NewMessage("/fader/1", float32(0.5))} {
+		before := time.Now()
+		if _, err := client.Send(p); err != nil {
+			t.Fatal(err)
+		}
+		n := 1
+		if _, ok := p.(*Bundle); ok {
+			n = 2
+		}
+		for i := 0; i < n; i++ {
+			select {
+			case <-received:
+			case <-time.After(5 * time.Second):
+				t.Fatal("packet wasn't dispatched")
+			}
+		}
+		if msg, ok := p.(*Message); ok {
+			entry, ok := state.Get(msg.Address)
+			if !ok || !entry.Message.Equals(msg) || entry.Received.Before(before) {
+				t.Errorf("Get(%s) = %v, %v, want = %v", msg.Address, entry, ok, msg)
+			}
+		}
+	}
+
+	snapshot := state.Snapshot()
+	if len(snapshot) != 2 || state.Len() != 2 {
+		t.Fatalf("Snapshot() has %d entries, want = 2", len(snapshot))
+	}
+	for i, want := range []*Message{NewMessage("/fader/1", float32(0.5)), NewMessage("/fader/2", float32(0.2))} {
+		if !snapshot[i].Message.Equals(want) {
+			t.Errorf("Snapshot()[%d] = %v, want = %v", i, snapshot[i].Message, want)
+		}
+	}
+
+	state.Delete("/fader/1")
+	if _, ok := state.Get("/fader/1"); ok {
+		t.Error("Get() returned a deleted address")
+	}
+}
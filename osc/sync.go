@@ -0,0 +1,157 @@
+package osc
+
+import (
+	"net"
+	"time"
+)
+
+// SyncAddress is the address of the requests for the complete state of a
+// Peer, see EnableSync. The request has no arguments.
+const SyncAddress = "/sync"
+
+// SyncPacketSize is the maximum size of the bundles that a Peer sends the
+// state in, which keeps them below the MTU of Ethernet networks.
+const SyncPacketSize = DefaultMaxPacketSize
+
+// SyncInterval is the minimum time between two transfers of the state to the
+// same address, further requests within SyncInterval are ignored.
+const SyncInterval = time.Second
+
+// maxSyncSources is the number of addresses whose last transfer a Peer
+// remembers before it forgets the transfers older than SyncInterval.
+const maxSyncSources = 1024
+
+// bundleHeaderSize is the size of the "#bundle" string and the timetag.
+const bundleHeaderSize = 16
+
+// Bundles returns the state of all addresses as bundles with the timetag
+// "immediately" whose encoded size is at most maxSize bytes. Messages that
+// are larger than maxSize are put into a bundle of their own. If maxSize is
+// zero, a single bundle is returned. Returns no bundles if the store is
+// empty.
+func (s *StateStore) Bundles(maxSize int) ([]*Bundle, error) {
+	var bundles []*Bundle
+	var current *Bundle
+	size := 0
+	for _, entry := range s.Snapshot() {
+		data, err := entry.Message.MarshalBinary()
+		if err != nil {
+			return nil, err
+		}
+		n := 4 + len(data) // Size prefix of the bundle element
+		if current == nil || (maxSize > 0 && size+n > maxSize) {
+			current = &Bundle{Timetag: *NewTimetagFromTimetag(1)}
+			bundles = append(bundles, current)
+			size = bundleHeaderSize
+		}
+		current.Messages = append(current.Messages, entry.Message)
+		size += n
+	}
+	return bundles, nil
+}
+
+// syncState is the state resync configuration of a Peer.
+type syncState struct {
+	state *StateStore
+	push  bool                 // Push the state to every new source address
+	allow func(net.Addr) bool  // See SetSyncFilter
+	sent  map[string]time.Time // Last transfer of the state per address
+}
+
+// EnableSync makes the peer record the received messages in state and send
+// the complete state as bundles to every address that sends a message to
+// SyncAddress, e.g. to synchronize a control surface that joins late. The
+// requests are consumed, i.e. they aren't dispatched. If push is set, the
+// state is also sent to every address the peer receives a packet from for
+// the first time. The state is sent to an address at most once per
+// SyncInterval. It must be called before Serve.
+//
+// The source address of UDP packets can be forged, so a single small
+// request makes the peer send its whole state to any address, e.g. to flood
+// a victim with reflected traffic. Peers that are reachable from untrusted
+// networks should restrict the addresses with SetSyncFilter.
+func (p *Peer) EnableSync(state *StateStore, push bool) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	p.Server.State = state
+	p.sync = syncState{state: state, push: push, allow: p.sync.allow, sent: make(map[string]time.Time)}
+}
+
+// SetSyncFilter restricts the addresses that the state is sent to by
+// EnableSync to those for which allow returns true, e.g. the addresses of
+// known control surfaces. Requests of other addresses are consumed but not
+// answered. Passing nil allows all addresses.
+func (p *Peer) SetSyncFilter(allow func(addr net.Addr) bool) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	p.sync.allow = allow
+}
+
+// PushState sends the complete state that was passed to EnableSync to addr.
+// It does nothing if sync isn't enabled.
+func (p *Peer) PushState(addr net.Addr) error {
+	p.mu.Lock()
+	state := p.sync.state
+	p.mu.Unlock()
+	if state == nil {
+		return nil
+	}
+
+	bundles, err := state.Bundles(SyncPacketSize)
+	if err != nil {
+		return err
+	}
+	for _, b := range bundles {
+		if _, err := p.SendToAddr(addr, b); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// interceptSync handles the sync requests received by the peer. Returns true
+// if the packet was consumed.
+func (p *Peer) interceptSync(packet Packet, addr net.Addr) bool {
+	p.mu.Lock()
+	if p.sync.state == nil {
+		p.mu.Unlock()
+		return false
+	}
+	msg, _ := packet.(*Message)
+	request := msg != nil && msg.Address == SyncAddress
+	if !request && !p.sync.push {
+		p.mu.Unlock()
+		return false
+	}
+	if p.sync.allow != nil && !p.sync.allow(addr) {
+		p.mu.Unlock()
+		return request
+	}
+
+	key, now := addr.String(), time.Now()
+	last, seen := p.sync.sent[key]
+	send := (request || !seen) && (!seen || now.Sub(last) >= SyncInterval)
+	if send {
+		if len(p.sync.sent) >= maxSyncSources {
+			p.sync.forget(now)
+		}
+		p.sync.sent[key] = now
+	}
+	p.mu.Unlock()
+
+	if send {
+		// Errors are ignored, the remote side may request the state again
+		p.PushState(addr)
+	}
+	return request
+}
+
+// forget removes the addresses whose last transfer is older than
+// SyncInterval, which bounds the memory if many addresses send packets.
+func (s *syncState) forget(now time.Time) {
+	for key, t := range s.sent {
+		if now.Sub(t) >= SyncInterval {
+			delete(s.sent, key)
+		}
+	}
+}
@@ -0,0 +1,134 @@
+package osc
+
+import (
+	"fmt"
+	"net"
+	"testing"
+	"time"
+)
+
+func TestStateStore_Bundles(t *testing.T) {
+	state := NewStateStore()
+	if bundles, err := state.Bundles(0); err != nil || len(bundles) != 0 {
+		t.Errorf("Bundles() of empty store = %v, %v, want = [], nil", bundles, err)
+	}
+	for i := 0; i < 100; i++ {
+		state.Set(NewMessage(fmt.Sprintf("/fader/%02d", i), float32(i)))
+	}
+
+	bundles, err := state.Bundles(SyncPacketSize)
+	if err != nil {
+		t.Fatal(err)
+	}
+	total := 0
+	for _, b := range bundles {
+		data, err := b.MarshalBinary()
+		if err != nil {
+			t.Fatal(err)
+		}
+		if len(data) > SyncPacketSize {
+			t.Errorf("bundle of %d bytes exceeds %d", len(data), SyncPacketSize)
+		}
+		if b.Timetag.TimeTag() != 1 {
+			t.Errorf("bundle has timetag %d, want = 1", b.Timetag.TimeTag())
+		}
+		total += len(b.Messages)
+	}
+	if total != 100 || len(bundles) < 2 {
+		t.Errorf("Bundles() returned %d messages in %d bundles, want = 100 in several", total, len(bundles))
+	}
+	if bundles, _ := state.Bundles(0); len(bundles) != 1 {
+		t.Errorf("Bundles(0) returned %d bundles, want = 1", len(bundles))
+	}
+}
+
+func TestPeer_EnableSync(t *testing.T) {
+	newPeer := func() (*Peer, chan *Message) {
+		received := make(chan *Message, 16)
+		d := NewStandardDispatcher()
+		if err := d.AddMsgHandler("*", func(msg *Message) { received <- msg }); err != nil {
+			t.Fatal(err)
+		}
+		p, err := NewPeer("127.0.0.1:0", d)
+		if err != nil {
+			t.Fatal(err)
+		}
+		return p, received
+	}
+	wait := func(received chan *Message, addr string) {
+		t.Helper()
+		select {
+		case msg := <-received:
+			if msg.Address != addr {
+				t.Errorf("received %v, want = %s", msg, addr)
+			}
+		case <-time.After(5 * time.Second):
+			t.Fatalf("%s wasn't received", addr)
+		}
+	}
+
+	console, consoleReceived := newPeer()
+	defer console.Close()
+	state := NewStateStore()
+	console.EnableSync(state, false)
+	go console.Serve()
+
+	operator, operatorReceived := newPeer()
+	defer operator.Close()
+	go operator.Serve()
+	if _, err := operator.SendTo(console.LocalAddr().String(), NewMessage("/fader/1", float32(0.7))); err != nil {
+		t.Fatal(err)
+	}
+	wait(consoleReceived, "/fader/1")
+
+	// A late operator requests the state
+	late, lateReceived := newPeer()
+	defer late.Close()
+	go late.Serve()
+	if _, err := late.SendTo(console.LocalAddr().String(), NewMessage(SyncAddress)); err != nil {
+		t.Fatal(err)
+	}
+	wait(lateReceived, "/fader/1")
+	select {
+	case msg := <-consoleReceived:
+		t.Errorf("sync request was dispatched: %v", msg)
+	case <-time.After(20 * time.Millisecond):
+	}
+
+	// With push, new sources receive the state right away
+	mirror, mirrorReceived := newPeer()
+	defer mirror.Close()
+	mirror.EnableSync(state, true)
+	go mirror.Serve()
+	if _, err := operator.SendTo(mirror.LocalAddr().String(), NewMessage("/fader/2", float32(0.1))); err != nil {
+		t.Fatal(err)
+	}
+	wait(mirrorReceived, "/fader/2")
+	wait(operatorReceived, "/fader/1")
+
+	none := func(received chan *Message) {
+		t.Helper()
+		select {
+		case msg := <-received:
+			t.Errorf("received %v, want no state", msg)
+		case <-time.After(50 * time.Millisecond):
+		}
+	}
+
+	// Requests within SyncInterval aren't answered
+	if _, err := late.SendTo(console.LocalAddr().String(), NewMessage(SyncAddress)); err != nil {
+		t.Fatal(err)
+	}
+	none(lateReceived)
+
+	// Filtered addresses don't receive the state
+	console.SetSyncFilter(func(addr net.Addr) bool { return false })
+	other, otherReceived := newPeer()
+	defer other.Close()
+	go other.Serve()
+	if _, err := other.SendTo(console.LocalAddr().String(), NewMessage(SyncAddress)); err != nil {
+		t.Fatal(err)
+	}
+	none(otherReceived)
+	none(consoleReceived)
+}
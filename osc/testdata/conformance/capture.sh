@@ -0,0 +1,41 @@
+#!/bin/sh
+# Records the packets that liblo's oscsend and python-osc send for the
+# messages of this corpus into DIR, default ./captures. Needs oscsend
+# (liblo-tools) and python3 with the python-osc package.
+set -e
+
+DIR=${1:-captures}
+ADDR=127.0.0.1
+PORT=${PORT:-57120}
+mkdir -p "$DIR"
+DIR=$(cd "$DIR" && pwd)
+cd "$(dirname "$0")/../.."
+
+record() {
+	go test -count=1 -run 'ConformanceRecord$' . \
+		-osc.record="$ADDR:$PORT" -osc.record.prefix="$1" -osc.corpus="$DIR" &
+	sleep 3
+}
+
+record liblo
+oscsend $ADDR $PORT /foo iisff 1000 -1 hello 1.234 5.678
+oscsend $ADDR $PORT /s sss "" abc abcd
+oscsend $ADDR $PORT /hd hd 4294967296 0.5
+oscsend $ADDR $PORT /tfni TFNI
+oscsend $ADDR $PORT /ping
+wait
+
+record python-osc
+python3 - $ADDR $PORT <<'PY'
+import sys
+from pythonosc.udp_client import SimpleUDPClient
+c = SimpleUDPClient(sys.argv[1], int(sys.argv[2]))
+c.send_message("/foo", [1000, -1, "hello", 1.234, 5.678])
+c.send_message("/s", ["", "abc", "abcd"])
+c.send_message("/b", [b"", b"\x01", b"\x01\x02\x03\x04"])
+c.send_message("/tfn", [True, False, None])
+c.send_message("/ping", [])
+PY
+wait
+
+go test -count=1 -run 'Conformance$' . -osc.corpus="$DIR"
@@ -0,0 +1,202 @@
+package osc
+
+import (
+	"context"
+	"errors"
+	"net"
+	"time"
+)
+
+// Addresses of the time synchronization messages. The request has the send
+// time of the requester as timetag argument, the reply has the timetags of
+// the request, the time the request was received, and the time the reply
+// was sent.
+const (
+	TimeSyncAddress      = "/sys/time"
+	TimeSyncReplyAddress = "/sys/time/reply"
+)
+
+// ErrNoTimeSyncReply is returned by Peer.SyncClock if none of its requests
+// was answered.
+var ErrNoTimeSyncReply = errors.New("osc: no reply to time synchronization request")
+
+// DefaultTimeSyncSamples is the number of requests that SyncClock sends if
+// the number of samples isn't positive.
+const DefaultTimeSyncSamples = 8
+
+// ClockEstimate is the estimated difference between the local clock and the
+// clock of a remote peer, see Peer.SyncClock.
+type ClockEstimate struct {
+	Offset  time.Duration // Remote clock minus local clock
+	RTT     time.Duration // Round-trip time of the sample the offset is from
+	Samples int           // Number of samples the estimate is based on
+}
+
+// RemoteTime converts the local time t to the clock of the remote peer, e.g.
+// to compute the timetag of a bundle that the remote peer should execute at
+// the local time t.
+func (e ClockEstimate) RemoteTime(t time.Time) time.Time {
+	return t.Add(e.Offset)
+}
+
+// LocalTime converts the time t of the remote clock to the local clock.
+func (e ClockEstimate) LocalTime(t time.Time) time.Time {
+	return t.Add(-e.Offset)
+}
+
+// timeSyncState is the time synchronization state of a Peer.
+type timeSyncState struct {
+	answer    bool
+	estimates map[string]ClockEstimate
+	waiters   map[uint64]chan [3]time.Time // Keyed by the request timetag
+}
+
+// EnableTimeSync makes the peer reply to the time synchronization requests
+// of other peers and consume them, i.e. they aren't dispatched. It must be
+// called before Serve.
+func (p *Peer) EnableTimeSync() {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	p.timeSync.answer = true
+}
+
+// SyncClock estimates the offset of the clock of the peer at addr, which has
+// the form "host:port" and must have called EnableTimeSync. Like NTP, it
+// sends the given number of timetagged requests one after another and uses
+// the reply with the smallest round-trip time, whose offset is the most
+// accurate. Requests without reply within a second are skipped. Serve must
+// be running to receive the replies. Returns ErrNoTimeSyncReply if no request
+// was answered. The estimate is also available through ClockEstimate
+// afterwards.
+func (p *Peer) SyncClock(ctx context.Context, addr string, samples int) (ClockEstimate, error) {
+	raddr, err := net.ResolveUDPAddr("udp", addr)
+	if err != nil {
+		return ClockEstimate{}, err
+	}
+	if samples <= 0 {
+		samples = DefaultTimeSyncSamples
+	}
+
+	var best ClockEstimate
+	for i := 0; i < samples; i++ {
+		sample, err := p.timeSample(ctx, raddr)
+		if err == ErrNoTimeSyncReply {
+			continue
+		}
+		if err != nil {
+			return ClockEstimate{}, err
+		}
+		if best.Samples == 0 || sample.RTT < best.RTT {
+			best.Offset, best.RTT = sample.Offset, sample.RTT
+		}
+		best.Samples++
+	}
+	if best.Samples == 0 {
+		return ClockEstimate{}, ErrNoTimeSyncReply
+	}
+
+	p.mu.Lock()
+	p.timeSync.estimates[raddr.String()] = best
+	p.mu.Unlock()
+	return best, nil
+}
+
+// ClockEstimate returns the last estimate of SyncClock for addr. Returns
+// false if the clock of addr wasn't synchronized yet.
+func (p *Peer) ClockEstimate(addr net.Addr) (ClockEstimate, bool) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	e, ok := p.timeSync.estimates[addr.String()]
+	return e, ok
+}
+
+// timeSample sends a single request to addr and computes the offset and the
+// round-trip time from its reply.
+func (p *Peer) timeSample(ctx context.Context, addr net.Addr) (ClockEstimate, error) {
+	reply := make(chan [3]time.Time, 1)
+	sent := time.Now()
+	key := NewTimetag(sent).TimeTag()
+	p.mu.Lock()
+	p.timeSync.waiters[key] = reply
+	p.mu.Unlock()
+	defer func() {
+		p.mu.Lock()
+		delete(p.timeSync.waiters, key)
+		p.mu.Unlock()
+	}()
+
+	if _, err := p.SendToAddr(addr, NewMessage(TimeSyncAddress, sent)); err != nil {
+		return ClockEstimate{}, err
+	}
+	timer := time.NewTimer(time.Second)
+	defer timer.Stop()
+	select {
+	case times := <-reply:
+		// times are the send time, remote receive time and remote reply
+		// time, the local receive time is the fourth timestamp
+		received := time.Now()
+		return ClockEstimate{
+			Offset: (times[1].Sub(times[0]) + times[2].Sub(received)) / 2,
+			RTT:    received.Sub(times[0]) - times[2].Sub(times[1]),
+		}, nil
+	case <-timer.C:
+		return ClockEstimate{}, ErrNoTimeSyncReply
+	case <-ctx.Done():
+		return ClockEstimate{}, ctx.Err()
+	}
+}
+
+// interceptTimeSync handles the time synchronization messages received by
+// the peer. Returns true if the packet was consumed.
+func (p *Peer) interceptTimeSync(packet Packet, addr net.Addr) bool {
+	msg, ok := packet.(*Message)
+	if !ok {
+		return false
+	}
+	switch msg.Address {
+	case TimeSyncAddress:
+		received := time.Now()
+		p.mu.Lock()
+		answer := p.timeSync.answer
+		p.mu.Unlock()
+		if !answer || len(msg.Arguments) < 1 {
+			return false
+		}
+		// Errors are ignored, the requester skips the sample
+		p.SendToAddr(addr, NewMessage(TimeSyncReplyAddress, msg.Arguments[0], received, time.Now()))
+		return true
+
+	case TimeSyncReplyAddress:
+		var times [3]time.Time
+		if len(msg.Arguments) < 3 {
+			return true
+		}
+		for i := range times {
+			t, ok := timeArgument(msg.Arguments[i])
+			if !ok {
+				return true
+			}
+			times[i] = t
+		}
+		p.mu.Lock()
+		key := NewTimetag(times[0]).TimeTag()
+		if reply, ok := p.timeSync.waiters[key]; ok {
+			reply <- times
+			delete(p.timeSync.waiters, key)
+		}
+		p.mu.Unlock()
+		return true
+	}
+	return false
+}
+
+// timeArgument returns the time of a 't' argument.
+func timeArgument(arg interface{}) (time.Time, bool) {
+	switch t := arg.(type) {
+	case Timetag:
+		return t.Time(), true
+	case time.Time:
+		return t, true
+	}
+	return time.Time{}, false
+}
@@ -0,0 +1,56 @@
+package osc
+
+import (
+	"context"
+	"testing"
+	"time"
+)
+
+func TestPeer_SyncClock(t *testing.T) {
+	a, err := NewPeer("127.0.0.1:0", nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer a.Close()
+	b, err := NewPeer("127.0.0.1:0", nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer b.Close()
+	b.EnableTimeSync()
+	go a.Serve()
+	go b.Serve()
+
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+	e, err := a.SyncClock(ctx, b.LocalAddr().String(), 4)
+	if err != nil {
+		t.Fatal(err)
+	}
+	// Both peers use the same clock
+	if e.Samples != 4 || e.RTT < 0 || e.RTT > time.Second || e.Offset < -50*time.Millisecond || e.Offset > 50*time.Millisecond {
+		t.Errorf("SyncClock() = %+v, want 4 samples with an offset near 0", e)
+	}
+	if got, ok := a.ClockEstimate(b.LocalAddr()); !ok || got != e {
+		t.Errorf("ClockEstimate() = %+v, %v, want = %+v", got, ok, e)
+	}
+	if _, ok := b.ClockEstimate(a.LocalAddr()); ok {
+		t.Error("ClockEstimate() of a peer that didn't sync returned an estimate")
+	}
+
+	// Peers without EnableTimeSync don't reply
+	if _, err := b.SyncClock(ctx, a.LocalAddr().String(), 1); err != ErrNoTimeSyncReply {
+		t.Errorf("SyncClock() error = %v, want = %v", err, ErrNoTimeSyncReply)
+	}
+}
+
+func TestClockEstimate(t *testing.T) {
+	e := ClockEstimate{Offset: 2 * time.Second}
+	now := time.Now()
+	if got := e.RemoteTime(now); !got.Equal(now.Add(2 * time.Second)) {
+		t.Errorf("RemoteTime() = %s, want = %s", got, now.Add(2*time.Second))
+	}
+	if got := e.LocalTime(e.RemoteTime(now)); !got.Equal(now) {
+		t.Errorf("LocalTime() = %s, want = %s", got, now)
+	}
+}
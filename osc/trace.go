@@ -0,0 +1,25 @@
+package osc
+
+import (
+	"net"
+	"time"
+)
+
+// ServerTrace is a set of hooks to run at various stages of receiving and
+// dispatching OSC packets. Any particular hook may be nil. Hooks may be called
+// concurrently from different goroutines.
+type ServerTrace struct {
+	// OnPacketReceived is called for every packet that was received and
+	// decoded successfully.
+	OnPacketReceived func(packet Packet, addr net.Addr)
+
+	// OnDecodeError is called if the received data couldn't be decoded or
+	// was rejected because it wasn't authenticated, see Server.AuthKey.
+	OnDecodeError func(data []byte, addr net.Addr, err error)
+
+	// OnMessageDispatched is called after a message was passed to all
+	// matching handlers. handlerCount is the number of matching handlers,
+	// not counting the default and catch-all handlers. It is only called if
+	// the dispatcher of the server is a StandardDispatcher.
+	OnMessageDispatched func(address string, handlerCount int, duration time.Duration)
+}
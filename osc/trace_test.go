@@ -0,0 +1,74 @@
+package osc
+
+import (
+	"net"
+	"testing"
+	"time"
+)
+
+func TestServerTrace(t *testing.T) {
+	conn, err := net.ListenPacket("udp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer conn.Close()
+
+	received := make(chan Packet, 1)
+	decodeErrors := make(chan error, 1)
+	type dispatchInfo struct {
+		address string
+		count   int
+	}
+	dispatched := make(chan dispatchInfo, 1)
+
+	d := NewStandardDispatcher()
+	if err := d.AddMsgHandler("/a/1", func(msg *Message) {}); err != nil {
+		t.Fatal(err)
+	}
+	if err := d.AddMsgHandler("/a/2", func(msg *Message) {}); err != nil {
+		t.Fatal(err)
+	}
+	server := &Server{
+		Dispatcher:  d,
+		ReadTimeout: 5 * time.Second,
+		Trace: &ServerTrace{
+			OnPacketReceived: func(packet Packet, addr net.Addr) { received <- packet },
+			OnDecodeError:    func(data []byte, addr net.Addr, err error) { decodeErrors <- err },
+			OnMessageDispatched: func(address string, handlerCount int, duration time.Duration) {
+				dispatched <- dispatchInfo{address, handlerCount}
+			},
+		},
+	}
+
+	client := NewClient("127.0.0.1", conn.LocalAddr().(*net.UDPAddr).Port)
+	if _, err := client.Send(NewMessage("/a/*")); err != nil {
+		t.Fatal(err)
+	}
+	packet, err := server.ReceivePacket(conn)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got := <-received; got != packet {
+		t.Errorf("OnPacketReceived got %v, want = %v", got, packet)
+	}
+
+	server.dispatch(packet)
+	if got, want := <-dispatched, (dispatchInfo{"/a/*", 2}); got != want {
+		t.Errorf("OnMessageDispatched got %v, want = %v", got, want)
+	}
+
+	c, err := net.Dial("udp", conn.LocalAddr().String())
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer c.Close()
+	if _, err := c.Write([]byte("/a\x00\x00x")); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := server.ReceivePacket(conn); err == nil {
+		t.Fatal("expected decode error")
+	}
+	if err := <-decodeErrors; err == nil {
+		t.Error("OnDecodeError got nil error")
+	}
+}
@@ -0,0 +1,9 @@
+// Package transport contains the network code that isn't specific to the UDP
+// client and server of package osc: StreamClient, which sends packets over
+// TCP or Unix sockets, the Sender interface that all clients implement, and
+// the socket options of ListenConfig.
+//
+// Package osc aliases the types of this package. The UDP Client, Server and
+// Peer stay in package osc, because they are built into the dispatcher,
+// scheduler and request/reply machinery of that package.
+package transport
@@ -0,0 +1,69 @@
+package transport
+
+import "net"
+
+// ListenConfig contains options for binding the UDP socket of a server. The
+// zero value binds with the defaults of the operating system.
+type ListenConfig struct {
+	// Network is "udp4" or "udp6" to listen only on IPv4 or IPv6. The default
+	// "udp" listens on both if the address has no host or an unspecified
+	// host, e.g. ":9000" or "[::]:9000".
+	Network string
+
+	// ReuseAddr sets SO_REUSEADDR, which allows to bind a port that is still
+	// in use by closed connections, and to bind multicast groups from several
+	// processes.
+	ReuseAddr bool
+
+	// ReusePort sets SO_REUSEPORT, which allows several processes to listen
+	// on the same port. It is only supported on Linux and BSD systems,
+	// including macOS, and requires Go 1.11 or newer.
+	ReusePort bool
+
+	// ReadBuffer is the size of the receive buffer of the socket in bytes.
+	// Larger buffers avoid dropped packets during bursts, e.g. of bundles
+	// from lighting desks. Zero keeps the default of the operating system.
+	ReadBuffer int
+
+	// WriteBuffer is the size of the send buffer of the socket in bytes. Zero
+	// keeps the default of the operating system.
+	WriteBuffer int
+}
+
+// Listen announces on the UDP address addr with the options of lc and returns
+// the connection together with the address it is bound to. IPv6 addresses
+// must be enclosed in brackets and may have a zone, e.g.
+// "[fe80::1%eth0]:9000".
+func (lc *ListenConfig) Listen(addr string) (net.PacketConn, *net.UDPAddr, error) {
+	network := lc.Network
+	if network == "" {
+		network = "udp"
+	}
+	conn, err := lc.listenPacket(network, addr)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	udpConn := conn.(*net.UDPConn)
+	if lc.ReadBuffer > 0 {
+		if err := udpConn.SetReadBuffer(lc.ReadBuffer); err != nil {
+			conn.Close()
+			return nil, nil, err
+		}
+	}
+	if lc.WriteBuffer > 0 {
+		if err := udpConn.SetWriteBuffer(lc.WriteBuffer); err != nil {
+			conn.Close()
+			return nil, nil, err
+		}
+	}
+	return conn, conn.LocalAddr().(*net.UDPAddr), nil
+}
+
+// Listen announces on the UDP address addr and returns the connection together
+// with the address it is bound to. This is useful if addr has the port 0 and
+// the operating system chooses a free port. The connection can be passed to
+// osc.Server.Serve.
+func Listen(addr string) (net.PacketConn, *net.UDPAddr, error) {
+	return (&ListenConfig{}).Listen(addr)
+}
@@ -0,0 +1,18 @@
+//go:build !go1.11
+// +build !go1.11
+
+package transport
+
+import (
+	"errors"
+	"net"
+)
+
+// listenPacket binds a packet connection. Socket options can't be set before
+// binding before Go 1.11.
+func (lc *ListenConfig) listenPacket(network, addr string) (net.PacketConn, error) {
+	if lc.ReuseAddr || lc.ReusePort {
+		return nil, errors.New("osc: ReuseAddr and ReusePort require Go 1.11")
+	}
+	return net.ListenPacket(network, addr)
+}
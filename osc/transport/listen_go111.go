@@ -0,0 +1,31 @@
+//go:build go1.11
+// +build go1.11
+
+package transport
+
+import (
+	"context"
+	"net"
+	"syscall"
+)
+
+// listenPacket binds a packet connection and sets the socket options of lc
+// before binding.
+func (lc *ListenConfig) listenPacket(network, addr string) (net.PacketConn, error) {
+	if !lc.ReuseAddr && !lc.ReusePort {
+		return net.ListenPacket(network, addr)
+	}
+
+	config := net.ListenConfig{
+		Control: func(network, address string, c syscall.RawConn) error {
+			var err error
+			if cerr := c.Control(func(fd uintptr) {
+				err = setReuse(fd, lc.ReuseAddr, lc.ReusePort)
+			}); cerr != nil {
+				return cerr
+			}
+			return err
+		},
+	}
+	return config.ListenPacket(context.Background(), network, addr)
+}
@@ -0,0 +1,46 @@
+package transport
+
+import (
+	"runtime"
+	"testing"
+)
+
+func TestListenConfig(t *testing.T) {
+	lc := &ListenConfig{ReadBuffer: 1 << 20, WriteBuffer: 1 << 16}
+	conn, addr, err := lc.Listen("127.0.0.1:0")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer conn.Close()
+	if addr.Port == 0 {
+		t.Error("Listen() returned port 0")
+	}
+}
+
+func TestListenConfig_ReusePort(t *testing.T) {
+	switch runtime.GOOS {
+	case "linux", "darwin", "dragonfly", "freebsd", "netbsd", "openbsd":
+	default:
+		t.Skip("SO_REUSEPORT isn't supported on", runtime.GOOS)
+	}
+
+	lc := &ListenConfig{ReuseAddr: true, ReusePort: true}
+	first, addr, err := lc.Listen("127.0.0.1:0")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer first.Close()
+
+	// A second socket can bind the same port
+	second, _, err := lc.Listen(addr.String())
+	if err != nil {
+		t.Fatalf("second Listen(%s) unexpected error: %s", addr, err)
+	}
+	second.Close()
+
+	// Without the option the port is in use
+	if conn, _, err := (&ListenConfig{}).Listen(addr.String()); err == nil {
+		conn.Close()
+		t.Errorf("Listen(%s) without ReusePort expected error", addr)
+	}
+}
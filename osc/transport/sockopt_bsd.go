@@ -0,0 +1,11 @@
+//go:build darwin || dragonfly || freebsd || netbsd || openbsd
+// +build darwin dragonfly freebsd netbsd openbsd
+
+package transport
+
+import "syscall"
+
+// soReusePort returns the value of SO_REUSEPORT.
+func soReusePort() int {
+	return syscall.SO_REUSEPORT
+}
@@ -0,0 +1,13 @@
+package transport
+
+import "runtime"
+
+// soReusePort returns the value of SO_REUSEPORT, which the syscall package
+// doesn't define for all architectures.
+func soReusePort() int {
+	switch runtime.GOARCH {
+	case "mips", "mipsle", "mips64", "mips64le":
+		return 0x200
+	}
+	return 0xf
+}
@@ -0,0 +1,12 @@
+//go:build !linux && !darwin && !dragonfly && !freebsd && !netbsd && !openbsd
+// +build !linux,!darwin,!dragonfly,!freebsd,!netbsd,!openbsd
+
+package transport
+
+import "errors"
+
+// setReuse returns an error, the socket options aren't supported on this
+// platform.
+func setReuse(fd uintptr, reuseAddr, reusePort bool) error {
+	return errors.New("osc: ReuseAddr and ReusePort aren't supported on this platform")
+}
@@ -0,0 +1,21 @@
+//go:build linux || darwin || dragonfly || freebsd || netbsd || openbsd
+// +build linux darwin dragonfly freebsd netbsd openbsd
+
+package transport
+
+import "syscall"
+
+// setReuse sets SO_REUSEADDR and SO_REUSEPORT on the socket fd.
+func setReuse(fd uintptr, reuseAddr, reusePort bool) error {
+	if reuseAddr {
+		if err := syscall.SetsockoptInt(int(fd), syscall.SOL_SOCKET, syscall.SO_REUSEADDR, 1); err != nil {
+			return err
+		}
+	}
+	if reusePort {
+		if err := syscall.SetsockoptInt(int(fd), syscall.SOL_SOCKET, soReusePort(), 1); err != nil {
+			return err
+		}
+	}
+	return nil
+}
@@ -0,0 +1,188 @@
+package transport
+
+import (
+	"bytes"
+	"errors"
+	"net"
+	"sync"
+	"time"
+
+	"github.com/hypebeast/go-osc/osc/codec"
+)
+
+const (
+	defaultMaxRetries = 5
+	defaultMinBackoff = 50 * time.Millisecond
+	defaultMaxBackoff = 5 * time.Second
+)
+
+// ErrClientClosed is returned by StreamClient.Send if the client was closed.
+var ErrClientClosed = errors.New("osc: client closed")
+
+// Sender is the interface that wraps the Send method. It is implemented by
+// StreamClient and by the clients of package osc.
+type Sender interface {
+	Send(packet codec.Packet) (int, error)
+}
+
+// Verify that StreamClient implements the Sender interface.
+var _ Sender = (*StreamClient)(nil)
+
+// StreamClient sends OSC packets over a stream oriented connection, e.g. TCP.
+// Every packet is prefixed with its size as int32, as defined by the OSC 1.0
+// specification for stream based transports.
+//
+// If Reconnect is set, a broken connection is re-established with an
+// exponential backoff and the failed packet is sent again.
+type StreamClient struct {
+	network string
+	addr    string
+
+	// Reconnect enables the automatic reconnection of broken connections.
+	Reconnect bool
+	// MaxRetries is the number of connection attempts before Send gives up.
+	// Defaults to 5.
+	MaxRetries int
+	// MaxBackoff is the maximum delay between two connection attempts.
+	// Defaults to 5 seconds.
+	MaxBackoff time.Duration
+	// WriteTimeout is the maximum duration that sending a packet may take.
+	// A zero value disables the timeout.
+	WriteTimeout time.Duration
+
+	// OnDisconnect is called with the error that broke the connection.
+	OnDisconnect func(err error)
+	// OnReconnect is called after a broken connection was re-established.
+	OnReconnect func()
+
+	mu        sync.Mutex
+	conn      net.Conn
+	closed    chan struct{}
+	closeOnce sync.Once // Closes closed
+}
+
+// NewStreamClient returns a new StreamClient that sends OSC packets to addr
+// on the given network, e.g. "tcp" or "unix". The connection is established
+// with the first call to Send.
+func NewStreamClient(network, addr string) *StreamClient {
+	return &StreamClient{network: network, addr: addr, closed: make(chan struct{})}
+}
+
+// Send sends an OSC Bundle or an OSC Message. It returns the number of bytes
+// that were written to the connection, including the size prefix. A nil error
+// means that the packet was handed to the operating system, it doesn't
+// guarantee that the receiver processed it.
+func (c *StreamClient) Send(packet codec.Packet) (int, error) {
+	data, err := packet.MarshalBinary()
+	if err != nil {
+		return 0, err
+	}
+
+	// The frame is written at once, so that it can be sent again after a
+	// reconnect
+	var buf bytes.Buffer
+	if err := codec.SizePrefixFraming.WriteFrame(&buf, data); err != nil {
+		return 0, err
+	}
+	frame := buf.Bytes()
+
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	if c.conn == nil {
+		if err := c.connect(false); err != nil {
+			return 0, err
+		}
+	}
+
+	n, err := c.write(frame)
+	if err == nil || !c.Reconnect {
+		return n, err
+	}
+
+	if err := c.connect(true); err != nil {
+		return 0, err
+	}
+	return c.write(frame)
+}
+
+// Close closes the connection. Pending reconnection attempts are aborted.
+func (c *StreamClient) Close() error {
+	c.closeOnce.Do(func() { close(c.closed) })
+
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	if c.conn == nil {
+		return nil
+	}
+	err := c.conn.Close()
+	c.conn = nil
+	return err
+}
+
+// write writes the frame to the connection. A broken connection is closed
+// and reported to OnDisconnect.
+func (c *StreamClient) write(frame []byte) (int, error) {
+	if c.WriteTimeout != 0 {
+		if err := c.conn.SetWriteDeadline(time.Now().Add(c.WriteTimeout)); err != nil {
+			return 0, err
+		}
+	}
+
+	n, err := c.conn.Write(frame)
+	if err != nil {
+		c.conn.Close()
+		c.conn = nil
+		if c.OnDisconnect != nil {
+			c.OnDisconnect(err)
+		}
+	}
+	return n, err
+}
+
+// connect dials the remote address. If Reconnect is set, failed attempts are
+// retried with an exponential backoff.
+func (c *StreamClient) connect(reconnect bool) error {
+	retries := 1
+	if c.Reconnect {
+		retries = c.MaxRetries
+		if retries <= 0 {
+			retries = defaultMaxRetries
+		}
+	}
+	maxBackoff := c.MaxBackoff
+	if maxBackoff <= 0 {
+		maxBackoff = defaultMaxBackoff
+	}
+
+	var err error
+	backoff := defaultMinBackoff
+	for i := 0; i < retries; i++ {
+		if i > 0 {
+			select {
+			case <-c.closed:
+				return ErrClientClosed
+			case <-time.After(backoff):
+			}
+			if backoff *= 2; backoff > maxBackoff {
+				backoff = maxBackoff
+			}
+		}
+
+		select {
+		case <-c.closed:
+			return ErrClientClosed
+		default:
+		}
+
+		var conn net.Conn
+		if conn, err = net.Dial(c.network, c.addr); err == nil {
+			c.conn = conn
+			if reconnect && c.OnReconnect != nil {
+				c.OnReconnect()
+			}
+			return nil
+		}
+	}
+	return err
+}
@@ -0,0 +1,117 @@
+package transport
+
+import (
+	"encoding/binary"
+	"io"
+	"net"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/hypebeast/go-osc/osc/codec"
+)
+
+func TestStreamClient_Reconnect(t *testing.T) {
+	ln, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer ln.Close()
+
+	// The server closes every connection after the first received packet.
+	received := make(chan string, 10)
+	go func() {
+		for {
+			conn, err := ln.Accept()
+			if err != nil {
+				return
+			}
+			var size int32
+			if err := binary.Read(conn, binary.BigEndian, &size); err == nil {
+				data := make([]byte, size)
+				if _, err := io.ReadFull(conn, data); err == nil {
+					if p, err := codec.ParsePacket(string(data)); err == nil {
+						received <- p.(*codec.Message).Address
+					}
+				}
+			}
+			conn.Close()
+		}
+	}()
+
+	disconnected, reconnected := make(chan error, 10), make(chan struct{}, 10)
+	client := NewStreamClient("tcp", ln.Addr().String())
+	client.Reconnect = true
+	client.OnDisconnect = func(err error) { disconnected <- err }
+	client.OnReconnect = func() { reconnected <- struct{}{} }
+	defer client.Close()
+
+	msg := codec.NewMessage("/first")
+	data, err := msg.MarshalBinary()
+	if err != nil {
+		t.Fatal(err)
+	}
+	n, err := client.Send(msg)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got, want := n, len(data)+4; got != want {
+		t.Errorf("Send() = %d, want = %d", got, want)
+	}
+	if got := <-received; got != "/first" {
+		t.Errorf("received %s, want = /first", got)
+	}
+
+	// Writes to the closed connection eventually fail and trigger a reconnect.
+	timeout := time.After(5 * time.Second)
+	for done := false; !done; {
+		if _, err := client.Send(codec.NewMessage("/second")); err != nil {
+			t.Fatal(err)
+		}
+		select {
+		case <-reconnected:
+			done = true
+		case <-timeout:
+			t.Fatal("timed out waiting for reconnect")
+		case <-time.After(10 * time.Millisecond):
+		}
+	}
+	if len(disconnected) == 0 {
+		t.Error("OnDisconnect wasn't called")
+	}
+
+	select {
+	case got := <-received:
+		if got != "/second" {
+			t.Errorf("received %s, want = /second", got)
+		}
+	case <-time.After(5 * time.Second):
+		t.Fatal("timed out waiting for packet")
+	}
+}
+
+func TestStreamClient_Close(t *testing.T) {
+	client := NewStreamClient("tcp", "127.0.0.1:1")
+	client.Reconnect = true
+	if err := client.Close(); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := client.Send(codec.NewMessage("/address")); err != ErrClientClosed {
+		t.Errorf("Send() error = %v, want = %v", err, ErrClientClosed)
+	}
+}
+
+func TestStreamClient_CloseConcurrent(t *testing.T) {
+	client := NewStreamClient("tcp", "127.0.0.1:1")
+	var wg sync.WaitGroup
+	for i := 0; i < 8; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			if err := client.Close(); err != nil {
+				t.Errorf("Close() unexpected error: %s", err)
+			}
+		}()
+	}
+	wg.Wait()
+}
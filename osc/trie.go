@@ -0,0 +1,170 @@
+package osc
+
+import (
+	"strings"
+
+	"github.com/hypebeast/go-osc/osc/internal/pattern"
+)
+
+// addressNode is a node of the tree that stores the handlers of a
+// dispatcher. Every node represents one part of an OSC address, e.g. the
+// address "/synth/1/freq" is stored as the path "", "synth", "1", "freq".
+type addressNode struct {
+	children map[string]*addressNode
+	handler  Handler
+	priority int            // Priority of handler, see SetPriority
+	seq      uint64         // Registration order of handler
+	mounts   []*addressNode // Trees that are routed below this node
+}
+
+// newAddressNode returns an empty address tree.
+func newAddressNode() *addressNode {
+	return &addressNode{children: make(map[string]*addressNode)}
+}
+
+// insert stores handler for the given address. An existing handler is
+// replaced.
+func (n *addressNode) insert(addr string, handler Handler) {
+	node := n.node(addr)
+	node.handler, node.seq = handler, nextHandlerSeq()
+}
+
+// mount routes all addresses below addr to the given tree.
+func (n *addressNode) mount(addr string, tree *addressNode) {
+	node := n.node(addr)
+	node.mounts = append(node.mounts, tree)
+}
+
+// node returns the node for the given address. Missing nodes are created.
+func (n *addressNode) node(addr string) *addressNode {
+	node := n
+	for _, part := range strings.Split(addr, "/") {
+		child, ok := node.children[part]
+		if !ok {
+			child = newAddressNode()
+			node.children[part] = child
+		}
+		node = child
+	}
+	return node
+}
+
+// lookup returns the handler that is registered for exactly the given
+// address, or nil if there is none.
+func (n *addressNode) lookup(addr string) Handler {
+	if node := n.find(addr); node != nil {
+		return node.handler
+	}
+	return nil
+}
+
+// find returns the node for the given address, or nil if it doesn't exist.
+func (n *addressNode) find(addr string) *addressNode {
+	node := n
+	for _, part := range strings.Split(addr, "/") {
+		child, ok := node.children[part]
+		if !ok {
+			return nil
+		}
+		node = child
+	}
+	return node
+}
+
+// match calls fn for every node with a handler whose address is matched by
+// the given OSC address pattern.
+func (n *addressNode) match(p *Pattern, fn func(*addressNode)) {
+	parts := pattern.Parts(p)
+	if !pattern.AnyDepth(p) {
+		n.matchParts(parts, 0, nil, fn)
+		return
+	}
+
+	// "//" can reach the same node with the same remaining parts on several
+	// paths, every such state is only visited once.
+	seen := make(map[*addressNode]bool)
+	visited := make(map[matchState]bool)
+	n.matchParts(parts, 0, visited, func(node *addressNode) {
+		if !seen[node] {
+			seen[node] = true
+			fn(node)
+		}
+	})
+}
+
+// matchState is a node that is reached with the pattern parts starting at
+// index part.
+type matchState struct {
+	node *addressNode
+	part int
+}
+
+// matchParts calls fn for every node with a handler that is matched by
+// parts[i:]. States that are already in visited are skipped, visited may be
+// nil if parts doesn't contain "//".
+func (n *addressNode) matchParts(parts []pattern.Part, i int, visited map[matchState]bool, fn func(*addressNode)) {
+	if visited != nil {
+		state := matchState{n, i}
+		if visited[state] {
+			return
+		}
+		visited[state] = true
+	}
+
+	if i < len(parts) {
+		// Mounted trees store their addresses relative to this node, i.e.
+		// their root part "" stands for this node.
+		for _, tree := range n.mounts {
+			if root, ok := tree.children[""]; ok {
+				root.matchParts(parts, i, visited, fn)
+			}
+		}
+	}
+
+	if i == len(parts) {
+		if n.handler != nil {
+			fn(n)
+		}
+		return
+	}
+
+	part := &parts[i]
+	if part.AnyDepth {
+		// Match no part at this node or one more part in every child
+		n.matchParts(parts, i+1, visited, fn)
+		for _, child := range n.children {
+			child.matchParts(parts, i, visited, fn)
+		}
+		return
+	}
+	if part.IsLiteral() {
+		if child, ok := n.children[part.Literal]; ok {
+			child.matchParts(parts, i+1, visited, fn)
+		}
+		return
+	}
+
+	for name, child := range n.children {
+		if part.Match(name) {
+			child.matchParts(parts, i+1, visited, fn)
+		}
+	}
+}
+
+// addresses calls fn with the address of every node below n that has a
+// handler, including the nodes of mounted trees. parts are the address parts
+// of n.
+func (n *addressNode) addresses(parts []string, fn func(addr string)) {
+	if n.handler != nil {
+		fn(strings.Join(parts, "/"))
+	}
+	for _, tree := range n.mounts {
+		// The root of a mounted tree stands for this node
+		if root, ok := tree.children[""]; ok {
+			root.addresses(parts, fn)
+		}
+	}
+	for part, child := range n.children {
+		child.addresses(append(parts[:len(parts):len(parts)], part), fn)
+	}
+}
@@ -0,0 +1,283 @@
+package osc
+
+import (
+	"image/color"
+	"io"
+	"net"
+	"time"
+
+	"github.com/hypebeast/go-osc/osc/codec"
+	"github.com/hypebeast/go-osc/osc/dispatch"
+	"github.com/hypebeast/go-osc/osc/transport"
+)
+
+// The packet types, the encoder and the decoder are implemented by package
+// codec, which doesn't depend on package net. They are aliased here, so that
+// code using this package doesn't need to import codec.
+type (
+	// Packet is the interface for Message and Bundle, see codec.Packet.
+	Packet = codec.Packet
+	// Message is an OSC message, see codec.Message.
+	Message = codec.Message
+	// Bundle is an OSC bundle, see codec.Bundle.
+	Bundle = codec.Bundle
+	// Timetag is an OSC time tag, see codec.Timetag.
+	Timetag = codec.Timetag
+	// Impulse is the OSC 'I' argument type, see codec.Impulse.
+	Impulse = codec.Impulse
+	// Nil is the OSC 'N' argument type, see codec.Nil.
+	Nil = codec.Nil
+	// Char is the OSC 'c' argument type, see codec.Char.
+	Char = codec.Char
+	// MIDI is the OSC 'm' argument type, see codec.MIDI.
+	MIDI = codec.MIDI
+	// MIDIEvent is a MIDI channel event, see codec.MIDIEvent.
+	MIDIEvent = codec.MIDIEvent
+	// NoteOn is a MIDI note on event, see codec.NoteOn.
+	NoteOn = codec.NoteOn
+	// NoteOff is a MIDI note off event, see codec.NoteOff.
+	NoteOff = codec.NoteOff
+	// ControlChange is a MIDI control change event, see codec.ControlChange.
+	ControlChange = codec.ControlChange
+	// RGBA is the OSC 'r' argument type, see codec.RGBA.
+	RGBA = codec.RGBA
+	// MessageInfo describes how a message was received, see
+	// codec.MessageInfo.
+	MessageInfo = codec.MessageInfo
+	// Coercion converts appended arguments, see codec.Coercion.
+	Coercion = codec.Coercion
+	// Profile selects how strictly packets are decoded, see codec.Profile.
+	Profile = codec.Profile
+	// StringPolicy restricts the characters of strings, see
+	// codec.StringPolicy.
+	StringPolicy = codec.StringPolicy
+	// DecodeOptions control how packets are decoded, see
+	// codec.DecodeOptions.
+	DecodeOptions = codec.DecodeOptions
+	// DecodeError describes a malformed packet, see codec.DecodeError.
+	DecodeError = codec.DecodeError
+	// ScanError is returned by Message.Scan, see codec.ScanError.
+	ScanError = codec.ScanError
+	// Decoder decodes packets, see codec.Decoder.
+	Decoder = codec.Decoder
+	// Encoder writes packets to a stream, see codec.Encoder.
+	Encoder = codec.Encoder
+	// Framing delimits packets in a stream, see codec.Framing.
+	Framing = codec.Framing
+	// Writer writes custom argument types, see codec.Writer.
+	Writer = codec.Writer
+	// Reader reads custom argument types, see codec.Reader.
+	Reader = codec.Reader
+	// Pattern is a compiled OSC address pattern, see codec.Pattern.
+	Pattern = codec.Pattern
+	// PatternSyntaxError describes an invalid address pattern, see
+	// codec.PatternSyntaxError.
+	PatternSyntaxError = codec.PatternSyntaxError
+)
+
+// The dispatcher interfaces and Mux are implemented by package dispatch,
+// which only depends on codec.
+type (
+	// Dispatcher dispatches received packets, see dispatch.Dispatcher.
+	Dispatcher = dispatch.Dispatcher
+	// Handler handles received messages, see dispatch.Handler.
+	Handler = dispatch.Handler
+	// HandlerFunc is a function that implements Handler, see
+	// dispatch.HandlerFunc.
+	HandlerFunc = dispatch.HandlerFunc
+	// Mux is a dispatcher with net/http.ServeMux like semantics, see
+	// dispatch.Mux.
+	Mux = dispatch.Mux
+)
+
+// The stream client and the socket options are implemented by package
+// transport. They are aliased here as well.
+type (
+	// Sender is the interface that wraps the Send method. It is implemented
+	// by Client and StreamClient, see transport.Sender.
+	Sender = transport.Sender
+	// StreamClient sends packets over a stream oriented connection, see
+	// transport.StreamClient.
+	StreamClient = transport.StreamClient
+	// ListenConfig contains options for binding the UDP socket of a server,
+	// see transport.ListenConfig.
+	ListenConfig = transport.ListenConfig
+)
+
+// Argument coercion policies, see codec.Coercion.
+const (
+	CoerceStrict   = codec.CoerceStrict
+	CoerceNative   = codec.CoerceNative
+	CoerceNative32 = codec.CoerceNative32
+)
+
+// Decoding profiles, see codec.Profile.
+const (
+	ProfileDefault = codec.ProfileDefault
+	ProfileOSC10   = codec.ProfileOSC10
+	ProfileOSC11   = codec.ProfileOSC11
+	ProfileLoose   = codec.ProfileLoose
+)
+
+// String policies, see codec.StringPolicy.
+const (
+	StringsAny   = codec.StringsAny
+	StringsUTF8  = codec.StringsUTF8
+	StringsASCII = codec.StringsASCII
+)
+
+// Sizes of variable-length arguments for DecodeOptions.TagSizes.
+const (
+	TagSizeString = codec.TagSizeString
+	TagSizeBlob   = codec.TagSizeBlob
+)
+
+// Limits of address patterns, see codec.MaxPatternLength and
+// codec.MaxAnyDepthOperators.
+const (
+	MaxPatternLength     = codec.MaxPatternLength
+	MaxAnyDepthOperators = codec.MaxAnyDepthOperators
+)
+
+// Errors of the codec, see package codec.
+var (
+	ErrUnexpectedEOF        = codec.ErrUnexpectedEOF
+	ErrInvalidTypeTag       = codec.ErrInvalidTypeTag
+	ErrInvalidBundleTag     = codec.ErrInvalidBundleTag
+	ErrInvalidBundleElement = codec.ErrInvalidBundleElement
+	ErrInvalidPacket        = codec.ErrInvalidPacket
+	ErrTooManyArguments     = codec.ErrTooManyArguments
+	ErrBundleTooDeep        = codec.ErrBundleTooDeep
+	ErrInvalidTimetag       = codec.ErrInvalidTimetag
+	ErrInvalidString        = codec.ErrInvalidString
+	ErrUnsupportedType      = codec.ErrUnsupportedType
+)
+
+// Framings of packets in streams, see codec.SizePrefixFraming and
+// codec.SLIPFraming.
+var (
+	SizePrefixFraming = codec.SizePrefixFraming
+	SLIPFraming       = codec.SLIPFraming
+)
+
+// ErrClientClosed is returned by StreamClient.Send if the client was closed.
+var ErrClientClosed = transport.ErrClientClosed
+
+// NewMessage returns a new Message. The address parameter is the OSC address.
+func NewMessage(addr string, args ...interface{}) *Message {
+	return codec.NewMessage(addr, args...)
+}
+
+// NewMIDIMessage returns a message with one 'm' argument per event, see
+// codec.NewMIDIMessage.
+func NewMIDIMessage(addr string, port uint8, events ...MIDIEvent) *Message {
+	return codec.NewMIDIMessage(addr, port, events...)
+}
+
+// NewBundle returns an OSC Bundle. Use this function to create a new OSC
+// Bundle.
+func NewBundle(time time.Time) *Bundle {
+	return codec.NewBundle(time)
+}
+
+// NewBundleIn returns an OSC bundle whose time tag is delay from now.
+func NewBundleIn(delay time.Duration) *Bundle {
+	return codec.NewBundleIn(delay)
+}
+
+// NewTimetag returns a new OSC time tag object.
+func NewTimetag(timeStamp time.Time) *Timetag {
+	return codec.NewTimetag(timeStamp)
+}
+
+// NewTimetagFromTimetag creates a new Timetag from the given `timetag`.
+func NewTimetagFromTimetag(timetag uint64) *Timetag {
+	return codec.NewTimetagFromTimetag(timetag)
+}
+
+// NewRGBA converts c to an 'r' argument.
+func NewRGBA(c color.Color) RGBA {
+	return codec.NewRGBA(c)
+}
+
+// NewDecoder returns a new Decoder that reads packets from r, see
+// codec.NewDecoder.
+func NewDecoder(r io.Reader) *Decoder {
+	return codec.NewDecoder(r)
+}
+
+// NewEncoder returns a new Encoder that writes packets to w.
+func NewEncoder(w io.Writer) *Encoder {
+	return codec.NewEncoder(w)
+}
+
+// ParsePacket parses the given msg string and returns a Packet
+func ParsePacket(msg string) (Packet, error) {
+	return codec.ParsePacket(msg)
+}
+
+// ParsePacketWithOptions parses the given msg string according to opts and
+// returns a Packet.
+func ParsePacketWithOptions(msg string, opts DecodeOptions) (Packet, error) {
+	return codec.ParsePacketWithOptions(msg, opts)
+}
+
+// PrintMessage pretty prints an OSC message to the standard output.
+func PrintMessage(msg *Message) {
+	codec.PrintMessage(msg)
+}
+
+// RegisterType registers a custom argument type with the given type tag, see
+// codec.RegisterType.
+func RegisterType(tag byte, enc func(v interface{}, w *Writer) error, dec func(r *Reader) (interface{}, error)) error {
+	return codec.RegisterType(tag, enc, dec)
+}
+
+// UnregisterType removes the custom type with the given type tag.
+func UnregisterType(tag byte) {
+	codec.UnregisterType(tag)
+}
+
+// CompilePattern parses an OSC address pattern and returns a Pattern that can
+// be used to match it against OSC addresses. The pattern must start with '/'.
+func CompilePattern(pattern string) (*Pattern, error) {
+	return codec.CompilePattern(pattern)
+}
+
+// CompilePatternOSC10 is like CompilePattern but follows the OSC 1.0
+// specification strictly, i.e. "//" matches an empty address part and not
+// any number of parts.
+func CompilePatternOSC10(pattern string) (*Pattern, error) {
+	return codec.CompilePatternOSC10(pattern)
+}
+
+// MustCompilePattern is like CompilePattern but panics if the pattern can't be
+// parsed.
+func MustCompilePattern(pattern string) *Pattern {
+	return codec.MustCompilePattern(pattern)
+}
+
+// Quote returns a pattern that matches exactly the given address, see
+// codec.Quote.
+func Quote(addr string) string {
+	return codec.Quote(addr)
+}
+
+// NewMux returns a new Mux.
+func NewMux() *Mux {
+	return dispatch.NewMux()
+}
+
+// NewStreamClient returns a new StreamClient that sends OSC packets to addr
+// on the given network, e.g. "tcp" or "unix", see transport.NewStreamClient.
+func NewStreamClient(network, addr string) *StreamClient {
+	return transport.NewStreamClient(network, addr)
+}
+
+// Listen announces on the UDP address addr and returns the connection together
+// with the address it is bound to. This is useful if addr has the port 0 and
+// the operating system chooses a free port. The connection can be passed to
+// Server.Serve.
+func Listen(addr string) (net.PacketConn, *net.UDPAddr, error) {
+	return transport.Listen(addr)
+}
@@ -0,0 +1,537 @@
+package osc
+
+import (
+	"encoding/binary"
+	"errors"
+	"net"
+	"os"
+	"strings"
+	"sync"
+	"time"
+)
+
+// DNS-SD service types for OSC services.
+const (
+	ServiceOSC      = "_osc._udp"     // OSC over UDP
+	ServiceOSCQuery = "_oscjson._tcp" // OSCQuery over HTTP
+)
+
+const (
+	mdnsDomain = "local"
+	mdnsTTL    = 120
+
+	dnsTypeA    = 1
+	dnsTypePTR  = 12
+	dnsTypeTXT  = 16
+	dnsTypeAAAA = 28
+	dnsTypeSRV  = 33
+	dnsTypeANY  = 255
+
+	dnsClassIN         = 1
+	dnsClassCacheFlush = 0x8000
+	dnsFlagResponse    = 0x8400 // Response, authoritative answer
+)
+
+var (
+	mdnsAddr         = &net.UDPAddr{IP: net.IPv4(224, 0, 0, 251), Port: 5353}
+	errInvalidDNSMsg = errors.New("osc: invalid DNS message")
+)
+
+// Service describes an OSC service that is announced with multicast DNS
+// service discovery (mDNS/DNS-SD, also known as Zeroconf or Bonjour).
+type Service struct {
+	Instance string   // Name of the service instance, e.g. "Synth"
+	Service  string   // Service type, e.g. ServiceOSC
+	Host     string   // Host name without domain, defaults to os.Hostname()
+	Port     int      // Port of the service
+	IPs      []net.IP // Addresses of the host, defaults to all interface addresses
+	Text     []string // Key value pairs of the TXT record of up to 255 bytes each, e.g. "version=1"
+}
+
+// Advertiser announces a Service on the local network and answers mDNS
+// queries for it.
+type Advertiser struct {
+	svc  Service
+	conn *net.UDPConn
+	done chan struct{}
+	once sync.Once
+}
+
+// Advertise announces the given service on the local network until Close is
+// called on the returned Advertiser.
+func Advertise(svc Service) (*Advertiser, error) {
+	if svc.Instance == "" || svc.Service == "" || svc.Port == 0 {
+		return nil, errors.New("osc: service instance, type and port are required")
+	}
+	for _, text := range svc.Text {
+		if len(text) > 255 {
+			return nil, errors.New("osc: TXT record entries must not be longer than 255 bytes")
+		}
+	}
+	if svc.Host == "" {
+		host, err := os.Hostname()
+		if err != nil {
+			return nil, err
+		}
+		svc.Host = strings.SplitN(host, ".", 2)[0]
+	}
+	if len(svc.IPs) == 0 {
+		addrs, err := net.InterfaceAddrs()
+		if err != nil {
+			return nil, err
+		}
+		for _, addr := range addrs {
+			if ipnet, ok := addr.(*net.IPNet); ok && !ipnet.IP.IsLoopback() {
+				svc.IPs = append(svc.IPs, ipnet.IP)
+			}
+		}
+	}
+
+	conn, err := net.ListenMulticastUDP("udp4", nil, mdnsAddr)
+	if err != nil {
+		return nil, err
+	}
+
+	a := &Advertiser{svc: svc, conn: conn, done: make(chan struct{})}
+	if err := a.announce(mdnsTTL); err != nil {
+		conn.Close()
+		return nil, err
+	}
+	go a.serve()
+	return a, nil
+}
+
+// Advertise announces the server as an OSC service with the given instance
+// name on the local network. The server must be serving.
+func (s *Server) Advertise(instance string) (*Advertiser, error) {
+	addr, ok := s.LocalAddr().(*net.UDPAddr)
+	if !ok {
+		return nil, errors.New("osc: server is not serving UDP")
+	}
+	svc := Service{Instance: instance, Service: ServiceOSC, Port: addr.Port}
+	if !addr.IP.IsUnspecified() {
+		svc.IPs = []net.IP{addr.IP}
+	}
+	return Advertise(svc)
+}
+
+// Close withdraws the announcement of the service.
+func (a *Advertiser) Close() error {
+	var err error
+	a.once.Do(func() {
+		close(a.done)
+		// A TTL of zero tells the other hosts that the service is gone.
+		a.announce(0)
+		err = a.conn.Close()
+	})
+	return err
+}
+
+// announce sends the records of the service to the multicast group.
+func (a *Advertiser) announce(ttl uint32) error {
+	msg := dnsMessage{flags: dnsFlagResponse, answers: a.svc.records(ttl)}
+	_, err := a.conn.WriteToUDP(msg.pack(), mdnsAddr)
+	return err
+}
+
+// serve answers queries until the advertiser is closed.
+func (a *Advertiser) serve() {
+	buf := make([]byte, 9000)
+	var tempDelay time.Duration
+	for {
+		n, from, err := a.conn.ReadFromUDP(buf)
+		if err != nil {
+			select {
+			case <-a.done:
+				return
+			default:
+			}
+			if ne, ok := err.(net.Error); !ok || !ne.Temporary() {
+				return
+			}
+			if tempDelay == 0 {
+				tempDelay = 5 * time.Millisecond
+			} else {
+				tempDelay *= 2
+			}
+			if max := 1 * time.Second; tempDelay > max {
+				tempDelay = max
+			}
+			time.Sleep(tempDelay)
+			continue
+		}
+		tempDelay = 0
+
+		query, err := parseDNSMessage(buf[:n])
+		if err != nil || query.flags&0x8000 != 0 || !a.svc.answers(query.questions) {
+			continue
+		}
+
+		resp := dnsMessage{flags: dnsFlagResponse, answers: a.svc.records(mdnsTTL)}
+		to := mdnsAddr
+		if from.Port != mdnsAddr.Port {
+			// Legacy unicast query, reply directly to the sender
+			resp.id = query.id
+			resp.questions = query.questions
+			to = from
+		}
+		a.conn.WriteToUDP(resp.pack(), to)
+	}
+}
+
+// Discover browses the local network for services of the given type, e.g.
+// ServiceOSC, and returns all services that responded within timeout.
+func Discover(service string, timeout time.Duration) ([]Service, error) {
+	conn, err := net.ListenUDP("udp4", &net.UDPAddr{IP: net.IPv4zero})
+	if err != nil {
+		return nil, err
+	}
+	defer conn.Close()
+
+	query := dnsMessage{questions: []dnsQuestion{{
+		name:  serviceName(service),
+		qtype: dnsTypePTR,
+		class: dnsClassIN,
+	}}}
+	if _, err := conn.WriteToUDP(query.pack(), mdnsAddr); err != nil {
+		return nil, err
+	}
+	if err := conn.SetReadDeadline(time.Now().Add(timeout)); err != nil {
+		return nil, err
+	}
+
+	var records []dnsRecord
+	buf := make([]byte, 9000)
+	for {
+		n, _, err := conn.ReadFromUDP(buf)
+		if err != nil {
+			if ne, ok := err.(net.Error); ok && ne.Timeout() {
+				break
+			}
+			return nil, err
+		}
+		if resp, err := parseDNSMessage(buf[:n]); err == nil {
+			records = append(records, resp.answers...)
+		}
+	}
+
+	return servicesFromRecords(service, records), nil
+}
+
+// records returns the PTR, SRV, TXT and address records of the service.
+func (svc *Service) records(ttl uint32) []dnsRecord {
+	instance := svc.instanceName()
+	host := []string{svc.Host, mdnsDomain}
+
+	text := svc.Text
+	if len(text) == 0 {
+		text = []string{""}
+	}
+	records := []dnsRecord{
+		{name: serviceName(svc.Service), rtype: dnsTypePTR, class: dnsClassIN, ttl: ttl, target: instance},
+		{name: instance, rtype: dnsTypeSRV, class: dnsClassIN | dnsClassCacheFlush, ttl: ttl, target: host, port: uint16(svc.Port)},
+		{name: instance, rtype: dnsTypeTXT, class: dnsClassIN | dnsClassCacheFlush, ttl: ttl, text: text},
+	}
+	for _, ip := range svc.IPs {
+		rtype := uint16(dnsTypeAAAA)
+		if ip4 := ip.To4(); ip4 != nil {
+			rtype, ip = dnsTypeA, ip4
+		}
+		records = append(records, dnsRecord{name: host, rtype: rtype, class: dnsClassIN | dnsClassCacheFlush, ttl: ttl, ip: ip})
+	}
+	return records
+}
+
+// answers returns true if any of the questions asks for the service.
+func (svc *Service) answers(questions []dnsQuestion) bool {
+	names := []string{
+		nameKey(serviceName(svc.Service)),
+		nameKey(svc.instanceName()),
+		nameKey([]string{svc.Host, mdnsDomain}),
+	}
+	for _, q := range questions {
+		key := nameKey(q.name)
+		for _, name := range names {
+			if key == name {
+				return true
+			}
+		}
+	}
+	return false
+}
+
+func (svc *Service) instanceName() []string {
+	return append([]string{svc.Instance}, serviceName(svc.Service)...)
+}
+
+// servicesFromRecords assembles the services of the given type from the
+// received resource records.
+func servicesFromRecords(service string, records []dnsRecord) []Service {
+	type recordKey struct {
+		name  string
+		rtype uint16
+	}
+	byName := make(map[recordKey]dnsRecord)
+	var addrs []dnsRecord
+	for _, r := range records {
+		switch r.rtype {
+		case dnsTypeSRV, dnsTypeTXT:
+			byName[recordKey{nameKey(r.name), r.rtype}] = r
+		case dnsTypeA, dnsTypeAAAA:
+			addrs = append(addrs, r)
+		}
+	}
+
+	var services []Service
+	seen := make(map[string]bool)
+	for _, r := range records {
+		if r.rtype != dnsTypePTR || nameKey(r.name) != nameKey(serviceName(service)) || r.ttl == 0 {
+			continue
+		}
+		key := nameKey(r.target)
+		srv, ok := byName[recordKey{key, dnsTypeSRV}]
+		if !ok || seen[key] || len(r.target) == 0 {
+			continue
+		}
+		seen[key] = true
+
+		svc := Service{Instance: r.target[0], Service: service, Port: int(srv.port)}
+		if len(srv.target) > 0 {
+			svc.Host = srv.target[0]
+		}
+		if txt, ok := byName[recordKey{key, dnsTypeTXT}]; ok {
+			for _, t := range txt.text {
+				if t != "" {
+					svc.Text = append(svc.Text, t)
+				}
+			}
+		}
+		for _, a := range addrs {
+			if nameKey(a.name) == nameKey(srv.target) {
+				svc.IPs = append(svc.IPs, a.ip)
+			}
+		}
+		services = append(services, svc)
+	}
+	return services
+}
+
+// serviceName returns the labels of the DNS name of the service type.
+func serviceName(service string) []string {
+	return append(strings.Split(service, "."), mdnsDomain)
+}
+
+// nameKey returns a normalized representation of a DNS name for comparison.
+func nameKey(labels []string) string {
+	return strings.ToLower(strings.Join(labels, "."))
+}
+
+// dnsMessage is a minimal DNS message as used by mDNS. Additional records are
+// treated as answers.
+type dnsMessage struct {
+	id        uint16
+	flags     uint16
+	questions []dnsQuestion
+	answers   []dnsRecord
+}
+
+type dnsQuestion struct {
+	name  []string
+	qtype uint16
+	class uint16
+}
+
+type dnsRecord struct {
+	name  []string
+	rtype uint16
+	class uint16
+	ttl   uint32
+
+	target []string // PTR and SRV
+	port   uint16   // SRV
+	text   []string // TXT
+	ip     net.IP   // A and AAAA
+}
+
+// pack encodes the message without name compression.
+func (m *dnsMessage) pack() []byte {
+	buf := make([]byte, 12)
+	binary.BigEndian.PutUint16(buf[0:], m.id)
+	binary.BigEndian.PutUint16(buf[2:], m.flags)
+	binary.BigEndian.PutUint16(buf[4:], uint16(len(m.questions)))
+	binary.BigEndian.PutUint16(buf[6:], uint16(len(m.answers)))
+
+	for _, q := range m.questions {
+		buf = appendDNSName(buf, q.name)
+		buf = appendUint16(buf, q.qtype)
+		buf = appendUint16(buf, q.class)
+	}
+
+	for _, r := range m.answers {
+		buf = appendDNSName(buf, r.name)
+		buf = appendUint16(buf, r.rtype)
+		buf = appendUint16(buf, r.class)
+		buf = append(buf, byte(r.ttl>>24), byte(r.ttl>>16), byte(r.ttl>>8), byte(r.ttl))
+
+		var rdata []byte
+		switch r.rtype {
+		case dnsTypePTR:
+			rdata = appendDNSName(nil, r.target)
+		case dnsTypeSRV:
+			rdata = []byte{0, 0, 0, 0} // Priority and weight
+			rdata = appendUint16(rdata, r.port)
+			rdata = appendDNSName(rdata, r.target)
+		case dnsTypeTXT:
+			for _, t := range r.text {
+				rdata = append(rdata, byte(len(t)))
+				rdata = append(rdata, t...)
+			}
+		case dnsTypeA, dnsTypeAAAA:
+			rdata = r.ip
+		}
+		buf = appendUint16(buf, uint16(len(rdata)))
+		buf = append(buf, rdata...)
+	}
+
+	return buf
+}
+
+// parseDNSMessage decodes a DNS message. Unknown record types are skipped.
+func parseDNSMessage(data []byte) (*dnsMessage, error) {
+	if len(data) < 12 {
+		return nil, errInvalidDNSMsg
+	}
+	m := &dnsMessage{
+		id:    binary.BigEndian.Uint16(data[0:]),
+		flags: binary.BigEndian.Uint16(data[2:]),
+	}
+	qdcount := int(binary.BigEndian.Uint16(data[4:]))
+	rrcount := int(binary.BigEndian.Uint16(data[6:])) +
+		int(binary.BigEndian.Uint16(data[8:])) +
+		int(binary.BigEndian.Uint16(data[10:]))
+
+	off := 12
+	for i := 0; i < qdcount; i++ {
+		name, n, err := readDNSName(data, off)
+		if err != nil {
+			return nil, err
+		}
+		off = n
+		if off+4 > len(data) {
+			return nil, errInvalidDNSMsg
+		}
+		m.questions = append(m.questions, dnsQuestion{
+			name:  name,
+			qtype: binary.BigEndian.Uint16(data[off:]),
+			class: binary.BigEndian.Uint16(data[off+2:]),
+		})
+		off += 4
+	}
+
+	for i := 0; i < rrcount; i++ {
+		name, n, err := readDNSName(data, off)
+		if err != nil {
+			return nil, err
+		}
+		off = n
+		if off+10 > len(data) {
+			return nil, errInvalidDNSMsg
+		}
+		r := dnsRecord{
+			name:  name,
+			rtype: binary.BigEndian.Uint16(data[off:]),
+			class: binary.BigEndian.Uint16(data[off+2:]),
+			ttl:   binary.BigEndian.Uint32(data[off+4:]),
+		}
+		rdlen := int(binary.BigEndian.Uint16(data[off+8:]))
+		off += 10
+		if off+rdlen > len(data) {
+			return nil, errInvalidDNSMsg
+		}
+		rdata := data[off : off+rdlen]
+
+		switch r.rtype {
+		case dnsTypePTR:
+			if r.target, _, err = readDNSName(data, off); err != nil {
+				return nil, err
+			}
+		case dnsTypeSRV:
+			if rdlen < 7 {
+				return nil, errInvalidDNSMsg
+			}
+			r.port = binary.BigEndian.Uint16(rdata[4:])
+			if r.target, _, err = readDNSName(data, off+6); err != nil {
+				return nil, err
+			}
+		case dnsTypeTXT:
+			for i := 0; i < len(rdata); {
+				l := int(rdata[i])
+				if i+1+l > len(rdata) {
+					return nil, errInvalidDNSMsg
+				}
+				r.text = append(r.text, string(rdata[i+1:i+1+l]))
+				i += 1 + l
+			}
+		case dnsTypeA, dnsTypeAAAA:
+			r.ip = append(net.IP{}, rdata...)
+		}
+		off += rdlen
+
+		m.answers = append(m.answers, r)
+	}
+
+	return m, nil
+}
+
+// appendDNSName appends the DNS wire format of the given labels to buf.
+func appendDNSName(buf []byte, labels []string) []byte {
+	for _, l := range labels {
+		if len(l) > 63 {
+			l = l[:63]
+		}
+		buf = append(buf, byte(len(l)))
+		buf = append(buf, l...)
+	}
+	return append(buf, 0)
+}
+
+// readDNSName reads a possibly compressed DNS name starting at off. It returns
+// the labels and the offset after the name.
+func readDNSName(data []byte, off int) ([]string, int, error) {
+	var labels []string
+	end := -1
+	for jumps := 0; ; {
+		if off >= len(data) {
+			return nil, 0, errInvalidDNSMsg
+		}
+		l := int(data[off])
+		switch {
+		case l == 0:
+			if end < 0 {
+				end = off + 1
+			}
+			return labels, end, nil
+
+		case l&0xc0 == 0xc0:
+			if off+1 >= len(data) || jumps > 10 {
+				return nil, 0, errInvalidDNSMsg
+			}
+			if end < 0 {
+				end = off + 2
+			}
+			off = int(binary.BigEndian.Uint16(data[off:]) & 0x3fff)
+			jumps++
+
+		default:
+			if off+1+l > len(data) {
+				return nil, 0, errInvalidDNSMsg
+			}
+			labels = append(labels, string(data[off+1:off+1+l]))
+			off += 1 + l
+		}
+	}
+}
+
+func appendUint16(buf []byte, v uint16) []byte {
+	return append(buf, byte(v>>8), byte(v))
+}
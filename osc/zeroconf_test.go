@@ -0,0 +1,106 @@
+package osc
+
+import (
+	"net"
+	"reflect"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestDNSMessage_PackParse(t *testing.T) {
+	svc := Service{
+		Instance: "My Synth",
+		Service:  ServiceOSC,
+		Host:     "studio",
+		Port:     8000,
+		IPs:      []net.IP{net.IPv4(192, 168, 1, 10).To4()},
+		Text:     []string{"version=1"},
+	}
+	msg := dnsMessage{flags: dnsFlagResponse, answers: svc.records(mdnsTTL)}
+
+	parsed, err := parseDNSMessage(msg.pack())
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !reflect.DeepEqual(parsed.answers, msg.answers) {
+		t.Errorf("parsed records = %+v, want = %+v", parsed.answers, msg.answers)
+	}
+
+	services := servicesFromRecords(ServiceOSC, parsed.answers)
+	if len(services) != 1 {
+		t.Fatalf("found %d services, want = 1", len(services))
+	}
+	if got := services[0]; !reflect.DeepEqual(got, svc) {
+		t.Errorf("service = %+v, want = %+v", got, svc)
+	}
+}
+
+func TestDNSMessage_CompressedNames(t *testing.T) {
+	// A PTR response for "_osc._udp.local" whose target points back into
+	// the question name.
+	data := []byte{
+		0, 0, 0x84, 0, 0, 1, 0, 1, 0, 0, 0, 0,
+		4, '_', 'o', 's', 'c', 4, '_', 'u', 'd', 'p', 5, 'l', 'o', 'c', 'a', 'l', 0,
+		0, dnsTypePTR, 0, dnsClassIN,
+		0xc0, 12, 0, dnsTypePTR, 0, dnsClassIN, 0, 0, 0, 120, 0, 4,
+		1, 'x', 0xc0, 12,
+	}
+	msg, err := parseDNSMessage(data)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got, want := msg.answers[0].target, []string{"x", "_osc", "_udp", "local"}; !reflect.DeepEqual(got, want) {
+		t.Errorf("target = %v, want = %v", got, want)
+	}
+
+	// A pointer loop must not hang.
+	if _, _, err := readDNSName([]byte{0xc0, 0}, 0); err == nil {
+		t.Error("expected error for pointer loop")
+	}
+}
+
+func TestService_Answers(t *testing.T) {
+	svc := Service{Instance: "Synth", Service: ServiceOSC, Host: "studio", Port: 8000}
+	for _, tt := range []struct {
+		name []string
+		want bool
+	}{
+		{[]string{"_osc", "_udp", "local"}, true},
+		{[]string{"Synth", "_OSC", "_udp", "local"}, true},
+		{[]string{"studio", "local"}, true},
+		{[]string{"_http", "_tcp", "local"}, false},
+	} {
+		if got := svc.answers([]dnsQuestion{{name: tt.name}}); got != tt.want {
+			t.Errorf("answers(%v) = %t, want = %t", tt.name, got, tt.want)
+		}
+	}
+}
+
+func TestAdvertise_LongText(t *testing.T) {
+	svc := Service{Instance: "Synth", Service: ServiceOSC, Port: 8000, Text: []string{strings.Repeat("x", 256)}}
+	if _, err := Advertise(svc); err == nil {
+		t.Error("Advertise() expected error for a TXT entry longer than 255 bytes")
+	}
+}
+
+func TestAdvertiser_ServeReadError(t *testing.T) {
+	conn, err := net.ListenUDP("udp4", &net.UDPAddr{IP: net.IPv4(127, 0, 0, 1)})
+	if err != nil {
+		t.Fatal(err)
+	}
+	a := &Advertiser{conn: conn, done: make(chan struct{})}
+	conn.Close()
+
+	// The read fails permanently, serve must not spin
+	done := make(chan struct{})
+	go func() {
+		a.serve()
+		close(done)
+	}()
+	select {
+	case <-done:
+	case <-time.After(5 * time.Second):
+		t.Fatal("serve() didn't return after a permanent read error")
+	}
+}